@@ -0,0 +1,489 @@
+//go:build apidiff
+
+package goproxyclient
+
+import (
+	"archive/zip"
+	"context"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/bobg/errors"
+)
+
+// APIDiff describes the differences between the exported APIs of two versions of a Go module.
+// Create one with [Client.APIDiff].
+//
+// This is available only when building with the apidiff build tag,
+// since type-checking a module's source is costly
+// and most users of this package don't need it.
+type APIDiff struct {
+	Module     string
+	Old, New   string // versions
+	Changes    []APIChange
+	Incomplete bool // see [Client.APIDiff]
+}
+
+// APIChange is a single difference in an [APIDiff].
+type APIChange struct {
+	// Package is the import path of the package.
+	Package string
+
+	// Name is the name of the changed package-level identifier,
+	// or of a field or method in the form TYPE.NAME.
+	// It is empty if the whole package was added or removed.
+	Name string
+
+	// Kind is "added," "removed," or "changed."
+	Kind string
+
+	// Old and New are the declarations before and after the change,
+	// empty where there is none.
+	Old, New string
+
+	// Breaking tells whether the change could break code using the old version.
+	Breaking bool
+}
+
+// Breaking tells whether any of the changes in d is a breaking change.
+func (d *APIDiff) Breaking() bool {
+	return slices.ContainsFunc(d.Changes, func(c APIChange) bool { return c.Breaking })
+}
+
+// Bump suggests which part of the old version's number the new version should increase,
+// under the rules of semantic versioning:
+// "major" if there are breaking changes,
+// "minor" if there are other changes,
+// and "patch" if there are none.
+//
+// Note that a new major version of a Go module (after v1) needs a new module path,
+// and that modules at major version 0 make no compatibility promises,
+// so for them a breaking change conventionally bumps the minor version.
+func (d *APIDiff) Bump() string {
+	switch {
+	case d.Breaking():
+		return "major"
+	case len(d.Changes) > 0:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// APIDiff compares the exported APIs of two versions of a Go module,
+// like the apidiff tool,
+// to help decide whether upgrading from one to the other is risky.
+// It type-checks the source in each version's zip file
+// (for the current platform, as determined by [build.Default])
+// and reports the exported identifiers in the module's public packages
+// that were added, removed, or changed.
+// Packages under an internal directory, main packages, and test files are ignored.
+//
+// Imports from the standard library are type-checked from the Go installation's source.
+// Imports from other modules are not resolved,
+// so types from them all compare alike,
+// and the result is marked Incomplete
+// if the module has any such imports.
+//
+// Removing or changing an identifier is a breaking change,
+// except that adding fields to a struct type
+// or methods to a non-interface type
+// is not.
+// Adding a method to an interface type is breaking
+// (it breaks implementations of the interface outside the module)
+// unless the interface has unexported methods.
+func (cl Client) APIDiff(ctx context.Context, mod, oldVer, newVer string) (*APIDiff, error) {
+	fset := token.NewFileSet()
+	std := importer.ForCompiler(fset, "source", nil)
+
+	oldAPI, err := cl.loadAPI(ctx, fset, std, mod, oldVer)
+	if err != nil {
+		return nil, err
+	}
+	newAPI, err := cl.loadAPI(ctx, fset, std, mod, newVer)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &APIDiff{
+		Module:     mod,
+		Old:        oldVer,
+		New:        newVer,
+		Incomplete: oldAPI.incomplete || newAPI.incomplete,
+	}
+
+	for _, pkgPath := range slices.Sorted(maps.Keys(oldAPI.pkgs)) {
+		newPkg, ok := newAPI.pkgs[pkgPath]
+		if !ok {
+			d.Changes = append(d.Changes, APIChange{Package: pkgPath, Kind: "removed", Old: "package " + pkgPath, Breaking: true})
+			continue
+		}
+		d.Changes = append(d.Changes, diffPackages(oldAPI.pkgs[pkgPath], newPkg)...)
+	}
+	for _, pkgPath := range slices.Sorted(maps.Keys(newAPI.pkgs)) {
+		if _, ok := oldAPI.pkgs[pkgPath]; !ok {
+			d.Changes = append(d.Changes, APIChange{Package: pkgPath, Kind: "added", New: "package " + pkgPath})
+		}
+	}
+
+	return d, nil
+}
+
+// moduleAPI is the type-checked public packages of a module version.
+type moduleAPI struct {
+	pkgs       map[string]*types.Package // import path -> package
+	incomplete bool
+}
+
+// LoadAPI parses and type-checks the public packages in the zip file for mod@ver.
+func (cl Client) loadAPI(ctx context.Context, fset *token.FileSet, std types.Importer, mod, ver string) (*moduleAPI, error) {
+	zr, err := cl.zipReader(ctx, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := mod + "@" + ver + "/"
+
+	bc := build.Default
+	bc.JoinPath = path.Join
+	bc.OpenFile = func(name string) (io.ReadCloser, error) {
+		return zr.Open(prefix + name)
+	}
+
+	imp := &apiImporter{
+		fset:  fset,
+		std:   std,
+		files: make(map[string][]*ast.File),
+		pkgs:  make(map[string]*types.Package),
+	}
+
+	for _, f := range zr.File {
+		name, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok {
+			return nil, errors.Newf("file %s in zip for %s@%s lacks the expected prefix", f.Name, mod, ver)
+		}
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		dir, file := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+		if ignoredDir(dir) || strings.HasPrefix(file, ".") || strings.HasPrefix(file, "_") {
+			continue
+		}
+		match, err := bc.MatchFile(dir, file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "evaluating build constraints for %s", f.Name)
+		}
+		if !match {
+			continue
+		}
+
+		parsed, err := parseZipFile(fset, f)
+		if err != nil {
+			return nil, err
+		}
+
+		pkgPath := mod
+		if dir != "" {
+			pkgPath = mod + "/" + dir
+		}
+		imp.files[pkgPath] = append(imp.files[pkgPath], parsed)
+	}
+
+	result := &moduleAPI{pkgs: make(map[string]*types.Package)}
+	for pkgPath, files := range imp.files {
+		if files[0].Name.Name == "main" || slices.Contains(strings.Split(pkgPath, "/"), "internal") {
+			continue
+		}
+		pkg, err := imp.Import(pkgPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "type-checking %s@%s", pkgPath, ver)
+		}
+		result.pkgs[pkgPath] = pkg
+	}
+	result.incomplete = imp.incomplete
+
+	return result, nil
+}
+
+func parseZipFile(fset *token.FileSet, f *zip.File) (*ast.File, error) {
+	src, err := readZipFile(f)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parser.ParseFile(fset, f.Name, src, parser.SkipObjectResolution)
+	return parsed, errors.Wrapf(err, "parsing %s", f.Name)
+}
+
+// apiImporter is a [types.Importer] for type-checking the packages of one module version.
+// Packages in the module are type-checked from their parsed files,
+// standard-library packages are imported with std,
+// and other packages are replaced with empty stand-ins.
+type apiImporter struct {
+	fset       *token.FileSet
+	std        types.Importer
+	files      map[string][]*ast.File // import path -> files, for packages in the module
+	pkgs       map[string]*types.Package
+	incomplete bool
+}
+
+func (imp *apiImporter) Import(pkgPath string) (*types.Package, error) {
+	if pkg, ok := imp.pkgs[pkgPath]; ok {
+		return pkg, nil
+	}
+
+	var pkg *types.Package
+	if files, ok := imp.files[pkgPath]; ok {
+		conf := types.Config{
+			Importer:    imp,
+			Error:       func(error) {}, // type errors come from unresolved imports; report what can be determined
+			FakeImportC: true,
+		}
+		pkg, _ = conf.Check(pkgPath, imp.fset, files, nil)
+	} else if isStdPkg(pkgPath) {
+		var err error
+		if pkg, err = imp.std.Import(pkgPath); err != nil {
+			return nil, err
+		}
+	} else {
+		imp.incomplete = true
+		pkg = types.NewPackage(pkgPath, path.Base(pkgPath))
+		pkg.MarkComplete()
+	}
+
+	imp.pkgs[pkgPath] = pkg
+	return pkg, nil
+}
+
+// IsStdPkg tells whether an import path outside the module being checked
+// is for a standard-library package,
+// whose paths have no dot in their first element.
+func isStdPkg(pkgPath string) bool {
+	first, _, _ := strings.Cut(pkgPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// DiffPackages compares the exported identifiers in two versions of a package.
+func diffPackages(oldPkg, newPkg *types.Package) []APIChange {
+	var (
+		changes  []APIChange
+		pkgPath  = oldPkg.Path()
+		oldScope = oldPkg.Scope()
+		newScope = newPkg.Scope()
+	)
+	for _, name := range oldScope.Names() {
+		oldObj := oldScope.Lookup(name)
+		if !oldObj.Exported() {
+			continue
+		}
+		newObj := newScope.Lookup(name)
+		if newObj == nil || !newObj.Exported() {
+			changes = append(changes, APIChange{Package: pkgPath, Name: name, Kind: "removed", Old: objectString(oldObj), Breaking: true})
+			continue
+		}
+		changes = append(changes, diffObjects(pkgPath, oldObj, newObj)...)
+	}
+	for _, name := range newScope.Names() {
+		newObj := newScope.Lookup(name)
+		if newObj.Exported() && oldScope.Lookup(name) == nil {
+			changes = append(changes, APIChange{Package: pkgPath, Name: name, Kind: "added", New: objectString(newObj)})
+		}
+	}
+	return changes
+}
+
+func diffObjects(pkgPath string, oldObj, newObj types.Object) []APIChange {
+	oldStr, newStr := objectString(oldObj), objectString(newObj)
+
+	oldTN, ok1 := oldObj.(*types.TypeName)
+	newTN, ok2 := newObj.(*types.TypeName)
+	if !ok1 || !ok2 || oldTN.IsAlias() != newTN.IsAlias() {
+		if oldStr == newStr {
+			return nil
+		}
+		return []APIChange{{Package: pkgPath, Name: oldObj.Name(), Kind: "changed", Old: oldStr, New: newStr, Breaking: true}}
+	}
+
+	name := oldObj.Name()
+	oldU, newU := oldTN.Type().Underlying(), newTN.Type().Underlying()
+
+	var changes []APIChange
+	switch {
+	case isStruct(oldU) && isStruct(newU) && typeParamsString(oldTN) == typeParamsString(newTN):
+		changes = diffMembers(pkgPath, name, structFields(oldU.(*types.Struct)), structFields(newU.(*types.Struct)), false)
+
+	case isInterface(oldU) && isInterface(newU) && typeParamsString(oldTN) == typeParamsString(newTN):
+		oldI := oldU.(*types.Interface)
+		addBreaks := true
+		for i := 0; i < oldI.NumMethods(); i++ {
+			if !oldI.Method(i).Exported() {
+				addBreaks = false
+			}
+		}
+		return diffMembers(pkgPath, name, interfaceMethods(oldI), interfaceMethods(newU.(*types.Interface)), addBreaks)
+
+	default:
+		if oldStr != newStr {
+			changes = []APIChange{{Package: pkgPath, Name: name, Kind: "changed", Old: oldStr, New: newStr, Breaking: true}}
+		}
+	}
+
+	if !oldTN.IsAlias() {
+		changes = append(changes, diffMembers(pkgPath, name, methodSet(oldTN.Type()), methodSet(newTN.Type()), false)...)
+	}
+	return changes
+}
+
+// DiffMembers compares the fields or methods of two versions of a type,
+// given as maps from names to descriptions.
+// Removals and changes are breaking;
+// additions are breaking if addBreaks is true.
+func diffMembers(pkgPath, typeName string, oldMembers, newMembers map[string]string, addBreaks bool) []APIChange {
+	var changes []APIChange
+	for _, name := range slices.Sorted(maps.Keys(oldMembers)) {
+		oldStr := oldMembers[name]
+		newStr, ok := newMembers[name]
+		switch {
+		case !ok:
+			changes = append(changes, APIChange{Package: pkgPath, Name: typeName + "." + name, Kind: "removed", Old: oldStr, Breaking: true})
+		case oldStr != newStr:
+			changes = append(changes, APIChange{Package: pkgPath, Name: typeName + "." + name, Kind: "changed", Old: oldStr, New: newStr, Breaking: true})
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(newMembers)) {
+		if _, ok := oldMembers[name]; !ok {
+			changes = append(changes, APIChange{Package: pkgPath, Name: typeName + "." + name, Kind: "added", New: newMembers[name], Breaking: addBreaks})
+		}
+	}
+	return changes
+}
+
+func isStruct(t types.Type) bool {
+	_, ok := t.(*types.Struct)
+	return ok
+}
+
+func isInterface(t types.Type) bool {
+	_, ok := t.(*types.Interface)
+	return ok
+}
+
+// StructFields describes the exported fields of a struct type, by name.
+func structFields(s *types.Struct) map[string]string {
+	result := make(map[string]string)
+	for i := 0; i < s.NumFields(); i++ {
+		if f := s.Field(i); f.Exported() {
+			result[f.Name()] = f.Name() + " " + typeString(f.Type())
+		}
+	}
+	return result
+}
+
+// InterfaceMethods describes the exported methods of an interface type
+// (including embedded ones), by name.
+func interfaceMethods(i *types.Interface) map[string]string {
+	result := make(map[string]string)
+	for j := 0; j < i.NumMethods(); j++ {
+		if m := i.Method(j); m.Exported() {
+			result[m.Name()] = m.Name() + strings.TrimPrefix(typeString(m.Type()), "func")
+		}
+	}
+	return result
+}
+
+// MethodSet describes the exported methods of a named type
+// (with either receiver kind, including promoted methods), by name.
+func methodSet(t types.Type) map[string]string {
+	result := make(map[string]string)
+	if isInterface(t.Underlying()) {
+		return result
+	}
+	mset := types.NewMethodSet(types.NewPointer(t))
+	for i := 0; i < mset.Len(); i++ {
+		if m := mset.At(i).Obj(); m.Exported() {
+			result[m.Name()] = "func (" + recvString(m) + ") " + m.Name() + strings.TrimPrefix(typeString(m.Type()), "func")
+		}
+	}
+	return result
+}
+
+func recvString(m types.Object) string {
+	recv := m.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return ""
+	}
+	return typeString(recv.Type())
+}
+
+// ObjectString describes a package-level object,
+// ignoring the names of function parameters and results,
+// which are not part of the API.
+func objectString(obj types.Object) string {
+	switch obj := obj.(type) {
+	case *types.Func:
+		return "func " + obj.Name() + strings.TrimPrefix(typeString(obj.Type()), "func")
+	case *types.Const:
+		return "const " + obj.Name() + " " + typeString(obj.Type()) + " = " + obj.Val().ExactString()
+	case *types.Var:
+		return "var " + obj.Name() + " " + typeString(obj.Type())
+	case *types.TypeName:
+		if obj.IsAlias() {
+			return "type " + obj.Name() + " = " + typeString(obj.Type())
+		}
+		return "type " + obj.Name() + typeParamsString(obj) + " " + typeString(obj.Type().Underlying())
+	}
+	return types.ObjectString(obj, qualifyByPath)
+}
+
+func typeParamsString(tn *types.TypeName) string {
+	named, ok := tn.Type().(*types.Named)
+	if !ok {
+		return ""
+	}
+	return typeParamListString(named.TypeParams())
+}
+
+// TypeString describes a type,
+// ignoring the names of parameters and results in function signatures.
+func typeString(t types.Type) string {
+	sig, ok := t.(*types.Signature)
+	if !ok {
+		return types.TypeString(t, qualifyByPath)
+	}
+
+	unnamed := func(tup *types.Tuple) *types.Tuple {
+		var vars []*types.Var
+		for i := 0; i < tup.Len(); i++ {
+			vars = append(vars, types.NewParam(token.NoPos, nil, "", tup.At(i).Type()))
+		}
+		return types.NewTuple(vars...)
+	}
+	s := types.NewSignatureType(nil, nil, nil, unnamed(sig.Params()), unnamed(sig.Results()), sig.Variadic())
+	return "func" + typeParamListString(sig.TypeParams()) + strings.TrimPrefix(types.TypeString(s, qualifyByPath), "func")
+}
+
+func typeParamListString(tps *types.TypeParamList) string {
+	if tps.Len() == 0 {
+		return ""
+	}
+	var parts []string
+	for i := 0; i < tps.Len(); i++ {
+		tp := tps.At(i)
+		parts = append(parts, tp.Obj().Name()+" "+typeString(tp.Constraint()))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func qualifyByPath(pkg *types.Package) string {
+	return pkg.Path()
+}
@@ -0,0 +1,159 @@
+//go:build apidiff
+
+package goproxyclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAPIDiff(t *testing.T) {
+	ctx := context.Background()
+
+	versions := map[string]map[string]string{
+		"v1.0.0": {
+			"api.go": `package api
+
+import "io"
+
+type T struct {
+	A int
+	B string
+}
+
+func (T) M() {}
+
+type I interface{ Read() }
+
+type J interface {
+	Read()
+	x()
+}
+
+func F(a int) error { return nil }
+
+func G(r io.Reader) {}
+
+const C = 1
+
+var Gone int
+
+type Generic[X any] struct{ V X }
+`,
+			"internal/x/x.go": "package x\n\nfunc Internal() {}\n",
+		},
+		"v1.1.0": {
+			"api.go": `package api
+
+import "io"
+
+type T struct {
+	A int
+	B string
+	C float64
+}
+
+func (T) M() {}
+
+func (*T) N() {}
+
+type I interface {
+	Read()
+	Write()
+}
+
+type J interface {
+	Read()
+	Write()
+	x()
+}
+
+func F(b int) error { return nil }
+
+func G(w io.Writer) {}
+
+const C = 2
+
+type Generic[X any] struct{ V X }
+
+func New() *T { return nil }
+`,
+			"internal/x/x.go": "package x\n\nfunc Internal(int) {}\n",
+			"sub/sub.go":      "package sub\n",
+		},
+	}
+
+	dest := t.TempDir()
+	for ver, files := range versions {
+		src := t.TempDir()
+		files["go.mod"] = "module example.com/api\n\ngo 1.23\n"
+		for name, content := range files {
+			path := filepath.Join(src, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := Publish(ctx, DirStorage(dest), src, ver, time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := httptest.NewServer(http.FileServer(http.Dir(dest)))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+	d, err := cl.APIDiff(ctx, "example.com/api", "v1.0.0", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type change struct {
+		Pkg, Name, Kind string
+		Breaking        bool
+	}
+	var got []change
+	for _, c := range d.Changes {
+		got = append(got, change{Pkg: c.Package, Name: c.Name, Kind: c.Kind, Breaking: c.Breaking})
+	}
+
+	const api = "example.com/api"
+	want := []change{
+		{api, "C", "changed", true},
+		{api, "G", "changed", true},
+		{api, "Gone", "removed", true},
+		{api, "I.Write", "added", true},
+		{api, "J.Write", "added", false},
+		{api, "T.C", "added", false},
+		{api, "T.N", "added", false},
+		{api, "New", "added", false},
+		{api + "/sub", "", "added", false},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if d.Incomplete {
+		t.Error("diff is incomplete, but the module imports only the standard library")
+	}
+	if got := d.Bump(); got != "major" {
+		t.Errorf("got bump %s, want major", got)
+	}
+
+	d, err = cl.APIDiff(ctx, "example.com/api", "v1.0.0", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Changes) != 0 || d.Bump() != "patch" {
+		t.Errorf("comparing a version with itself, got changes %v and bump %s", d.Changes, d.Bump())
+	}
+}
@@ -0,0 +1,66 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/bobg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// GoogleArtifactRegistryAuth returns an [Authenticator] for Go repositories in Google Artifact Registry.
+// It applies to requests for hosts ending in .pkg.dev
+// (such as us-central1-go.pkg.dev),
+// adding HTTP basic auth with username "oauth2accesstoken" and an OAuth2 access token as the password.
+//
+// Tokens come from ts, which should refresh them as needed
+// (as the token sources in golang.org/x/oauth2 do).
+// If ts is nil,
+// a token source based on Application Default Credentials is created on first use.
+// If that fails,
+// the request fails,
+// and the next request tries again
+// (so that credentials set up in the meantime are found).
+// See https://cloud.google.com/docs/authentication/application-default-credentials.
+func GoogleArtifactRegistryAuth(ts oauth2.TokenSource) Authenticator {
+	var mu sync.Mutex
+	getTS := func() (oauth2.TokenSource, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if ts != nil {
+			return ts, nil
+		}
+		// Not the request context:
+		// the token source keeps using this one for refreshes long after the request is done.
+		defaultTS, err := defaultTokenSource(context.Background(), "https://www.googleapis.com/auth/cloud-platform")
+		if err != nil {
+			return nil, err
+		}
+		ts = defaultTS
+		return ts, nil
+	}
+
+	return AuthFunc(func(ctx context.Context, req *http.Request) error {
+		if !strings.HasSuffix(req.URL.Hostname(), ".pkg.dev") {
+			return nil
+		}
+		ts, err := getTS()
+		if err != nil {
+			return errors.Wrap(err, "finding application default credentials")
+		}
+		tok, err := ts.Token()
+		if err != nil {
+			return errors.Wrap(err, "getting access token")
+		}
+		req.SetBasicAuth("oauth2accesstoken", tok.AccessToken)
+		return nil
+	})
+}
+
+// defaultTokenSource finds Application Default Credentials.
+// It is a variable so that tests can replace it.
+var defaultTokenSource = google.DefaultTokenSource
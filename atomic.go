@@ -0,0 +1,66 @@
+package goproxyclient
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bobg/errors"
+)
+
+// WithSyncWrites is an [Option] that makes files written by the client
+// (e.g. by [Client.Extract])
+// be flushed to stable storage with fsync before they are renamed into place.
+// This costs some speed but protects against truncated files after a crash or power loss.
+func WithSyncWrites() Option {
+	return func(c *config) {
+		c.syncWrites = true
+	}
+}
+
+// writeFileAtomic creates or replaces the file at path with the output of write.
+// The output goes first to a temporary file in the same directory,
+// which is renamed to path only when complete,
+// so an interrupted write never leaves a truncated file at path.
+// If doSync is true, the temporary file is synced before the rename.
+func writeFileAtomic(path string, mode os.FileMode, doSync bool, write func(io.Writer) error) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp*")
+	if err != nil {
+		return errors.Wrapf(err, "creating temp file for %s", path)
+	}
+	tmpName := tmp.Name()
+
+	success := false
+	defer func() {
+		if !success {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if err := write(tmp); err != nil {
+		return errors.Wrapf(err, "writing %s", path)
+	}
+	if doSync {
+		if err := tmp.Sync(); err != nil {
+			return errors.Wrapf(err, "syncing %s", path)
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "closing temp file for %s", path)
+	}
+	if err := os.Chmod(tmpName, mode); err != nil {
+		return errors.Wrapf(err, "setting mode of %s", path)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return errors.Wrapf(err, "renaming temp file to %s", path)
+	}
+
+	success = true
+	return nil
+}
@@ -0,0 +1,53 @@
+package goproxyclient
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		path = filepath.Join(dir, "file")
+	)
+
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed write leaves the old contents and no temp file.
+	err := writeFileAtomic(path, 0644, false, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return errors.New("interrupted")
+	})
+	if err == nil {
+		t.Fatal("got nil, want error")
+	}
+	if got, _ := os.ReadFile(path); string(got) != "old" {
+		t.Errorf("after failed write got %q, want %q", got, "old")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("after failed write got %d directory entries, want 1", len(entries))
+	}
+
+	err = writeFileAtomic(path, 0600, true, func(w io.Writer) error {
+		_, err := io.WriteString(w, "new")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "new" {
+		t.Errorf("got %q, want %q", got, "new")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("got mode %v, want 0600", perm)
+	}
+}
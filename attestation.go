@@ -0,0 +1,227 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+)
+
+// Attestation is an [in-toto] statement recording the provenance of a downloaded module zip file:
+// where and when it was fetched,
+// its hashes,
+// and whether it was verified.
+// The client produces these when configured with [WithAttestations].
+//
+// [in-toto]: https://github.com/in-toto/attestation/blob/main/spec/v1/statement.md
+type Attestation struct {
+	Type          string               `json:"_type"`
+	Subject       []AttestationSubject `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     DownloadPredicate    `json:"predicate"`
+}
+
+// AttestationSubject identifies the artifact that an [Attestation] is about.
+type AttestationSubject struct {
+	// Name is MODPATH@VERSION.
+	Name string `json:"name"`
+
+	// Digest maps hash algorithm names to hashes of the artifact.
+	// The "sha256" entry is the hex-encoded SHA-256 hash of the zip file,
+	// and the "h1" entry is the go command's h1: hash of the module
+	// (as in go.sum files).
+	// There is an entry for each other [Hasher] added with [WithHashers], too.
+	Digest map[string]string `json:"digest"`
+}
+
+// DownloadPredicate is the predicate of an [Attestation].
+type DownloadPredicate struct {
+	Module  string `json:"module"`
+	Version string `json:"version"`
+
+	// Proxy is the base URL of the Go module proxy that served the download,
+	// and URL is the full URL that was fetched.
+	Proxy string `json:"proxy"`
+	URL   string `json:"url"`
+
+	// Time is when the download began.
+	Time time.Time `json:"time"`
+
+	// Verification is "verified" if the download's hash was checked against a [VerifiedDB] and matched,
+	// or "unverified" if there was no hash to check against.
+	// (A download that fails verification gets no attestation.)
+	Verification string `json:"verification"`
+}
+
+const (
+	// InTotoStatementType is the value of [Attestation.Type].
+	InTotoStatementType = "https://in-toto.io/Statement/v1"
+
+	// DownloadPredicateType is the value of [Attestation.PredicateType].
+	DownloadPredicateType = "https://github.com/bobg/goproxyclient/download/v1"
+
+	// InTotoPayloadType is the payload type of a signed [Envelope] containing an [Attestation].
+	InTotoPayloadType = "application/vnd.in-toto+json"
+)
+
+// Envelope is a [DSSE] envelope holding a signed [Attestation].
+// Create one with [SignAttestation].
+//
+// [DSSE]: https://github.com/secure-systems-lab/dsse/blob/master/envelope.md
+type Envelope struct {
+	PayloadType string              `json:"payloadType"`
+	Payload     []byte              `json:"payload"` // base64-encoded in JSON
+	Signatures  []EnvelopeSignature `json:"signatures"`
+}
+
+// EnvelopeSignature is a signature in an [Envelope].
+type EnvelopeSignature struct {
+	// KeyID is the hex-encoded SHA-256 hash of the signer's public key.
+	KeyID string `json:"keyid"`
+	Sig   []byte `json:"sig"` // base64-encoded in JSON
+}
+
+// SignAttestation signs a with key,
+// producing a DSSE [Envelope].
+func SignAttestation(a Attestation, key ed25519.PrivateKey) (*Envelope, error) {
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding attestation")
+	}
+	pub := key.Public().(ed25519.PublicKey)
+	sig := ed25519.Sign(key, pae(InTotoPayloadType, payload))
+	return &Envelope{
+		PayloadType: InTotoPayloadType,
+		Payload:     payload,
+		Signatures:  []EnvelopeSignature{{KeyID: keyID(pub), Sig: sig}},
+	}, nil
+}
+
+// Verify checks that e has a valid signature from the holder of pub,
+// and returns the [Attestation] it contains.
+func (e *Envelope) Verify(pub ed25519.PublicKey) (Attestation, error) {
+	if e.PayloadType != InTotoPayloadType {
+		return Attestation{}, fmt.Errorf("unexpected payload type %q", e.PayloadType)
+	}
+	id := keyID(pub)
+	msg := pae(e.PayloadType, e.Payload)
+	for _, sig := range e.Signatures {
+		if sig.KeyID != "" && sig.KeyID != id {
+			continue
+		}
+		if ed25519.Verify(pub, msg, sig.Sig) {
+			var a Attestation
+			err := json.Unmarshal(e.Payload, &a)
+			return a, errors.Wrap(err, "decoding attestation")
+		}
+	}
+	return Attestation{}, errors.New("no valid signature")
+}
+
+// Pae is the DSSE "pre-authentication encoding" of a payload,
+// which is what is actually signed.
+func pae(payloadType string, payload []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "DSSEv1 %d %s %d ", len(payloadType), payloadType, len(payload))
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+func keyID(pub ed25519.PublicKey) string {
+	h := sha256.Sum256(pub)
+	return hex.EncodeToString(h[:])
+}
+
+// WithAttestations is an [Option] that makes the client write an [Attestation]
+// for each module zip file it downloads and checks
+// (e.g. in [Client.Extract] and [Client.DownloadToolchain])
+// into dir,
+// which is created if necessary.
+// The file for MODPATH@VERSION is named MODPATH@VERSION.intoto.json,
+// with MODPATH escaped (see [module.EscapePath]) and its slashes replaced by underscores.
+//
+// If key is non-nil,
+// each file holds a DSSE [Envelope] signed with key.
+// Otherwise each file holds a bare [Attestation].
+func WithAttestations(dir string, key ed25519.PrivateKey) Option {
+	return func(c *config) {
+		c.attestDir = dir
+		c.attestKey = key
+	}
+}
+
+// Attest writes an attestation for a downloaded zip file,
+// if the client is configured to.
+func (cl Client) attest(d *downloadedZip, mod, ver string, verified bool) error {
+	if cl.conf.attestDir == "" {
+		return nil
+	}
+
+	digest := map[string]string{"sha256": hex.EncodeToString(d.sha256)}
+	for _, h := range cl.conf.allHashers() {
+		if _, ok := digest[h.Name()]; ok {
+			continue
+		}
+		hash, err := h.HashZip(d.Name())
+		if err != nil {
+			return errors.Wrapf(err, "computing %s hash of zip for %s@%s", h.Name(), mod, ver)
+		}
+		digest[h.Name()] = hash
+	}
+
+	verification := "unverified"
+	if verified {
+		verification = "verified"
+	}
+
+	a := Attestation{
+		Type: InTotoStatementType,
+		Subject: []AttestationSubject{{
+			Name:   mod + "@" + ver,
+			Digest: digest,
+		}},
+		PredicateType: DownloadPredicateType,
+		Predicate: DownloadPredicate{
+			Module:       mod,
+			Version:      ver,
+			Proxy:        d.proxy,
+			URL:          d.url,
+			Time:         d.time,
+			Verification: verification,
+		},
+	}
+
+	var doc any = a
+	if cl.conf.attestKey != nil {
+		env, err := SignAttestation(a, cl.conf.attestKey)
+		if err != nil {
+			return errors.Wrapf(err, "signing attestation for %s@%s", mod, ver)
+		}
+		doc = env
+	}
+
+	escPath, err := module.EscapePath(mod)
+	if err != nil {
+		return errors.Wrapf(err, "escaping module path %s", mod)
+	}
+	if err := os.MkdirAll(cl.conf.attestDir, 0755); err != nil {
+		return errors.Wrapf(err, "creating %s", cl.conf.attestDir)
+	}
+	name := fmt.Sprintf("%s@%s.intoto.json", strings.ReplaceAll(escPath, "/", "_"), ver)
+
+	return writeFileAtomic(filepath.Join(cl.conf.attestDir, name), 0644, cl.conf.syncWrites, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	})
+}
@@ -0,0 +1,75 @@
+package goproxyclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAttestations(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adir := t.TempDir()
+	cl := New(s.URL, nil, WithAttestations(adir, key))
+	if err := cl.Extract(ctx, "example.com/multi", "v1.0.0", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(adir, "example.com_multi@v1.0.0.intoto.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatal(err)
+	}
+	a, err := env.Verify(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Type != InTotoStatementType || a.PredicateType != DownloadPredicateType {
+		t.Errorf("got types %s, %s", a.Type, a.PredicateType)
+	}
+	if len(a.Subject) != 1 || a.Subject[0].Name != "example.com/multi@v1.0.0" {
+		t.Fatalf("got subject %+v", a.Subject)
+	}
+	if d := a.Subject[0].Digest; len(d["sha256"]) != 64 || !strings.HasPrefix(d["h1"], "h1:") {
+		t.Errorf("got digest %v", d)
+	}
+	p := a.Predicate
+	if p.Proxy != s.URL || p.URL != s.URL+"/example.com/multi/@v/v1.0.0.zip" || p.Verification != "unverified" || p.Time.IsZero() {
+		t.Errorf("got predicate %+v", p)
+	}
+
+	// Tampering with the payload invalidates the signature.
+	env.Payload = []byte(strings.Replace(string(env.Payload), "unverified", "verified!!", 1))
+	if _, err := env.Verify(pub); err == nil {
+		t.Error("tampered envelope verified")
+	}
+
+	// A different key does not verify.
+	pub2, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.Verify(pub2); err == nil {
+		t.Error("envelope verified with the wrong key")
+	}
+}
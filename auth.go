@@ -0,0 +1,97 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Authenticator adds credentials to requests bound for a Go module proxy.
+// See [WithAuth].
+type Authenticator interface {
+	// Authenticate adds credentials to req, if it has any that are appropriate for req.URL.
+	// It should leave req unchanged otherwise.
+	Authenticate(ctx context.Context, req *http.Request) error
+}
+
+// AuthFunc is a function that implements the [Authenticator] interface.
+type AuthFunc func(ctx context.Context, req *http.Request) error
+
+// Authenticate implements [Authenticator].
+func (f AuthFunc) Authenticate(ctx context.Context, req *http.Request) error {
+	return f(ctx, req)
+}
+
+// Token is a credential with an expiration time.
+// A zero Expiry means the token does not expire.
+type Token struct {
+	Value  string
+	Expiry time.Time
+}
+
+// TokenFunc is the type of a function that obtains a fresh [Token].
+type TokenFunc func(context.Context) (Token, error)
+
+// refreshMargin is how long before its expiry a token is considered stale.
+const refreshMargin = 5 * time.Minute
+
+// tokenCache holds a token obtained from a [TokenFunc],
+// fetching a new one when the old one is missing or near expiry.
+// It is safe for concurrent use.
+type tokenCache struct {
+	fetch TokenFunc
+
+	// Sem holds a value while a call to fetch is in progress.
+	// Unlike a mutex,
+	// waiting for it can be canceled.
+	sem chan struct{}
+
+	mu  sync.Mutex // protects tok
+	tok Token
+}
+
+func newTokenCache(fetch TokenFunc) *tokenCache {
+	return &tokenCache{fetch: fetch, sem: make(chan struct{}, 1)}
+}
+
+func (tc *tokenCache) get(ctx context.Context) (string, error) {
+	if tok, ok := tc.cached(); ok {
+		return tok, nil
+	}
+
+	select {
+	case tc.sem <- struct{}{}:
+	case <-ctx.Done():
+		return "", context.Cause(ctx)
+	}
+	defer func() { <-tc.sem }()
+
+	// Another caller may have fetched a token while this one waited.
+	if tok, ok := tc.cached(); ok {
+		return tok, nil
+	}
+
+	tok, err := tc.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	tc.mu.Lock()
+	tc.tok = tok
+	tc.mu.Unlock()
+
+	return tok.Value, nil
+}
+
+// Cached returns the cached token,
+// if there is one that is not near expiry.
+func (tc *tokenCache) cached() (string, bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if tc.tok.Value != "" && (tc.tok.Expiry.IsZero() || time.Until(tc.tok.Expiry) > refreshMargin) {
+		return tc.tok.Value, true
+	}
+	return "", false
+}
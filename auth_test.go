@@ -0,0 +1,422 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestCodeArtifactAuth(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		calls  int
+		expiry = time.Now().Add(time.Hour)
+	)
+	fetch := func(context.Context) (Token, error) {
+		calls++
+		tok := Token{Value: "tok", Expiry: expiry}
+		expiry = time.Now() // subsequent tokens are immediately stale
+		return tok, nil
+	}
+
+	auth := CodeArtifactAuth("mydomain", "123456789012", fetch)
+
+	cases := []struct {
+		url      string
+		wantAuth bool
+	}{
+		{"https://mydomain-123456789012.d.codeartifact.us-west-2.amazonaws.com/go/myrepo/foo/@v/list", true},
+		{"https://otherdomain-123456789012.d.codeartifact.us-west-2.amazonaws.com/go/myrepo/foo/@v/list", false},
+		{"https://proxy.golang.org/foo/@v/list", false},
+	}
+
+	for _, tc := range cases {
+		req, err := http.NewRequest("GET", tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := auth.Authenticate(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		user, pass, ok := req.BasicAuth()
+		if ok != tc.wantAuth {
+			t.Errorf("%s: got auth %v, want %v", tc.url, ok, tc.wantAuth)
+			continue
+		}
+		if ok && (user != "aws" || pass != "tok") {
+			t.Errorf("%s: got %s:%s, want aws:tok", tc.url, user, pass)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("got %d token fetches, want 1", calls)
+	}
+
+	// The cached token is still fresh; now force a refresh.
+	auth = CodeArtifactAuth("mydomain", "123456789012", fetch)
+	for range 2 {
+		req, _ := http.NewRequest("GET", cases[0].url, nil)
+		if err := auth.Authenticate(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("got %d token fetches, want 3", calls)
+	}
+}
+
+func TestTokenCacheCancel(t *testing.T) {
+	var (
+		calls   atomic.Int32
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	tc := newTokenCache(func(context.Context) (Token, error) {
+		calls.Add(1)
+		close(started)
+		<-release
+		return Token{Value: "tok"}, nil
+	})
+
+	ctx := context.Background()
+	get := func(ctx context.Context) <-chan error {
+		ch := make(chan error, 1)
+		go func() {
+			tok, err := tc.get(ctx)
+			if err == nil && tok != "tok" {
+				err = fmt.Errorf("got token %q, want tok", tok)
+			}
+			ch <- err
+		}()
+		return ch
+	}
+
+	fetcher := get(ctx)
+	<-started
+	waiter := get(ctx)
+
+	// While the fetch is blocked,
+	// a caller whose context is canceled returns promptly.
+	cause := errors.New("gave up")
+	cctx, cancel := context.WithCancelCause(ctx)
+	canceled := get(cctx)
+	cancel(cause)
+	select {
+	case err := <-canceled:
+		if !errors.Is(err, cause) {
+			t.Errorf("got error %v, want %v", err, cause)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("canceled caller still waiting for the fetch")
+	}
+
+	// The other waiter gets the fetched token without fetching another.
+	close(release)
+	for _, ch := range []<-chan error{fetcher, waiter} {
+		if err := <-ch; err != nil {
+			t.Error(err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("got %d token fetches, want 1", n)
+	}
+}
+
+func TestParseCodeArtifactToken(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    Token
+		wantErr bool
+	}{
+		{in: `{"authorizationToken": "abc", "expiration": "2024-05-15T17:43:47Z"}`, want: Token{Value: "abc", Expiry: time.Date(2024, 5, 15, 17, 43, 47, 0, time.UTC)}},
+		{in: `{"authorizationToken": "abc", "expiration": 1715795027}`, want: Token{Value: "abc", Expiry: time.Unix(1715795027, 0)}},
+		{in: `{"authorizationToken": "abc"}`, want: Token{Value: "abc"}},
+		{in: `{"expiration": 1715795027}`, wantErr: true},
+		{in: `{"authorizationToken": "abc", "expiration": "tomorrow"}`, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := parseCodeArtifactToken([]byte(tc.in))
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: got no error, want error", tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tc.in, err)
+			continue
+		}
+		if got.Value != tc.want.Value || !got.Expiry.Equal(tc.want.Expiry) {
+			t.Errorf("%s: got %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestGoogleArtifactRegistryAuth(t *testing.T) {
+	ctx := context.Background()
+
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "tok"})
+	auth := GoogleArtifactRegistryAuth(ts)
+
+	cases := []struct {
+		url      string
+		wantAuth bool
+	}{
+		{"https://us-central1-go.pkg.dev/myproject/myrepo/example.com/foo/@v/list", true},
+		{"https://proxy.golang.org/example.com/foo/@v/list", false},
+		{"https://pkg.dev.example.com/example.com/foo/@v/list", false},
+	}
+
+	for _, tc := range cases {
+		req, err := http.NewRequest("GET", tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := auth.Authenticate(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		user, pass, ok := req.BasicAuth()
+		if ok != tc.wantAuth {
+			t.Errorf("%s: got auth %v, want %v", tc.url, ok, tc.wantAuth)
+			continue
+		}
+		if ok && (user != "oauth2accesstoken" || pass != "tok") {
+			t.Errorf("%s: got %s:%s, want oauth2accesstoken:tok", tc.url, user, pass)
+		}
+	}
+}
+
+func TestGoogleArtifactRegistryAuthRetry(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		calls int
+		fail  = true
+	)
+	orig := defaultTokenSource
+	defaultTokenSource = func(context.Context, ...string) (oauth2.TokenSource, error) {
+		calls++
+		if fail {
+			return nil, errors.New("no credentials")
+		}
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "tok"}), nil
+	}
+	defer func() { defaultTokenSource = orig }()
+
+	auth := GoogleArtifactRegistryAuth(nil)
+	authenticate := func() error {
+		req, err := http.NewRequest("GET", "https://us-central1-go.pkg.dev/myproject/myrepo/example.com/foo/@v/list", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return auth.Authenticate(ctx, req)
+	}
+
+	if err := authenticate(); err == nil {
+		t.Fatal("got no error without credentials")
+	}
+
+	// Once credentials are available, they are found and kept.
+	fail = false
+	for i := 0; i < 2; i++ {
+		if err := authenticate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("got %d lookups of default credentials, want 2", calls)
+	}
+}
+
+func TestAzureArtifactsPAT(t *testing.T) {
+	ctx := context.Background()
+
+	cases := []struct {
+		host     string
+		url      string
+		wantAuth bool
+	}{
+		{"", "https://pkgs.dev.azure.com/myorg/myproject/_packaging/myfeed/go/example.com/foo/@v/list", true},
+		{"", "https://myorg.pkgs.visualstudio.com/myproject/_packaging/myfeed/go/example.com/foo/@v/list", true},
+		{"", "https://proxy.golang.org/example.com/foo/@v/list", false},
+		{"myorg.pkgs.visualstudio.com", "https://myorg.pkgs.visualstudio.com/myproject/_packaging/myfeed/go/example.com/foo/@v/list", true},
+		{"myorg.pkgs.visualstudio.com", "https://otherorg.pkgs.visualstudio.com/myproject/_packaging/myfeed/go/example.com/foo/@v/list", false},
+		{"myorg.pkgs.visualstudio.com", "https://pkgs.dev.azure.com/myorg/myproject/_packaging/myfeed/go/example.com/foo/@v/list", false},
+	}
+
+	for _, tc := range cases {
+		conf := new(config)
+		WithAzureArtifactsPAT(tc.host, "mypat")(conf)
+		if len(conf.auth) != 1 {
+			t.Fatalf("got %d authenticators, want 1", len(conf.auth))
+		}
+
+		req, err := http.NewRequest("GET", tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := conf.auth[0].Authenticate(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		user, pass, ok := req.BasicAuth()
+		if ok != tc.wantAuth {
+			t.Errorf("host %q, %s: got auth %v, want %v", tc.host, tc.url, ok, tc.wantAuth)
+			continue
+		}
+		if ok && (user != "" || pass != "mypat") {
+			t.Errorf("host %q, %s: got %s:%s, want :mypat", tc.host, tc.url, user, pass)
+		}
+	}
+}
+
+func TestGitLabAuth(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("CI_SERVER_HOST", "")
+	t.Setenv("CI_JOB_TOKEN", "jobtok")
+
+	const (
+		gitlabURL = "https://gitlab.com/api/v4/projects/1234/packages/go/gitlab.com/foo/bar/@v/list"
+		corpURL   = "https://git.corp.example.com/api/v4/projects/1234/packages/go/example.com/foo/@v/list"
+	)
+
+	cases := []struct {
+		pattern, token, url, header, want string
+	}{
+		{"", "", gitlabURL, "JOB-TOKEN", "jobtok"},
+		{"", "pat", gitlabURL, "PRIVATE-TOKEN", "pat"},
+		{"", "pat", corpURL, "PRIVATE-TOKEN", ""},
+		{"*.corp.example.com", "pat", corpURL, "PRIVATE-TOKEN", "pat"},
+		{"*.corp.example.com", "", corpURL, "JOB-TOKEN", "jobtok"},
+		{"", "pat", "https://gitlab.com/foo/bar", "PRIVATE-TOKEN", ""},
+	}
+
+	for _, tc := range cases {
+		req, err := http.NewRequest("GET", tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := GitLabAuth(tc.pattern, tc.token).Authenticate(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get(tc.header); got != tc.want {
+			t.Errorf("pattern %q, token %q, %s: got %s %q, want %q", tc.pattern, tc.token, tc.url, tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestCredentialHelper(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+
+	ctx := context.Background()
+
+	conf := new(config)
+	WithCredentialHelper("sh", "-c", `read url; case "$url" in https://proxy.example.com/) echo "Authorization: Bearer xyz"; echo "X-Extra: 1";; esac`)(conf)
+	auth := conf.auth[0]
+
+	req, _ := http.NewRequest("GET", "https://proxy.example.com/example.com/foo/@v/list", nil)
+	if err := auth.Authenticate(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer xyz" {
+		t.Errorf("got Authorization %q, want %q", got, "Bearer xyz")
+	}
+	if got := req.Header.Get("X-Extra"); got != "1" {
+		t.Errorf("got X-Extra %q, want %q", got, "1")
+	}
+
+	req, _ = http.NewRequest("GET", "https://proxy.golang.org/example.com/foo/@v/list", nil)
+	if err := auth.Authenticate(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Header) != 0 {
+		t.Errorf("got headers %v, want none", req.Header)
+	}
+
+	conf = new(config)
+	WithCredentialHelper("sh", "-c", "exit 1")(conf)
+	req, _ = http.NewRequest("GET", "https://proxy.golang.org/example.com/foo/@v/list", nil)
+	if err := conf.auth[0].Authenticate(ctx, req); err == nil {
+		t.Error("got nil, want error")
+	}
+}
+
+func TestCredentialHelperConcurrency(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// The helper for each host logs its run,
+	// then waits until the helper for the other host has started too,
+	// so it fails unless the two run at once.
+	script := `read url
+case "$url" in
+https://a.example.com/) me=a; other=b;;
+*) me=b; other=a;;
+esac
+echo "$url" >> "$DIR/log"
+touch "$DIR/$me"
+i=0
+while [ ! -f "$DIR/$other" ]; do
+	i=$((i+1))
+	[ $i -gt 100 ] && exit 1
+	sleep 0.05
+done
+echo "Authorization: Bearer $me"`
+	t.Setenv("DIR", dir)
+
+	conf := new(config)
+	WithCredentialHelper("sh", "-c", script)(conf)
+	auth := conf.auth[0]
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, 6)
+	)
+	for _, host := range []string{"a", "b", "a", "b", "a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "https://"+host+".example.com/example.com/foo/@v/list", nil)
+			if err := auth.Authenticate(ctx, req); err != nil {
+				errs <- err
+				return
+			}
+			if got, want := req.Header.Get("Authorization"), "Bearer "+host; got != want {
+				errs <- fmt.Errorf("got Authorization %q for %s, want %q", got, host, want)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// Concurrent requests for the same host share one run of the helper.
+	log, err := os.ReadFile(filepath.Join(dir, "log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(log), "\n"); n != 2 {
+		t.Errorf("helper ran %d times, want 2:\n%s", n, log)
+	}
+}
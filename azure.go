@@ -0,0 +1,46 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// BasicAuth returns an [Authenticator] that adds HTTP basic auth with the given username and password
+// to requests for the given host.
+// The host is compared against the hostname of each request URL, without any port.
+func BasicAuth(host, username, password string) Authenticator {
+	return AuthFunc(func(_ context.Context, req *http.Request) error {
+		if strings.EqualFold(req.URL.Hostname(), host) {
+			req.SetBasicAuth(username, password)
+		}
+		return nil
+	})
+}
+
+// WithAzureArtifactsPAT is an [Option] that authenticates requests to Azure Artifacts feeds
+// using a personal access token (PAT).
+//
+// Azure Artifacts expects HTTP basic auth with the PAT as the password.
+// The username is ignored by the server and is sent empty here,
+// which is what Azure's own documentation shows.
+//
+// The PAT is sent only to the given host.
+// If host is empty,
+// it is sent to the hosts of both documented feed URL shapes:
+// pkgs.dev.azure.com (https://pkgs.dev.azure.com/ORG/PROJECT/_packaging/FEED/...)
+// and ORG.pkgs.visualstudio.com (https://ORG.pkgs.visualstudio.com/PROJECT/_packaging/FEED/...).
+// Pass distinct hosts in separate calls to use different PATs for different organizations
+// on the legacy visualstudio.com domain.
+func WithAzureArtifactsPAT(host, pat string) Option {
+	if host != "" {
+		return WithAuth(BasicAuth(host, "", pat))
+	}
+	return WithAuth(AuthFunc(func(_ context.Context, req *http.Request) error {
+		h := strings.ToLower(req.URL.Hostname())
+		if h == "pkgs.dev.azure.com" || strings.HasSuffix(h, ".pkgs.visualstudio.com") {
+			req.SetBasicAuth("", pat)
+		}
+		return nil
+	}))
+}
@@ -0,0 +1,77 @@
+package goproxyclient
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// WithBandwidthLimit is an [Option] that limits the combined rate,
+// in bytes per second,
+// at which the bodies of all .mod and .zip responses are read.
+// The limit is shared by all downloads from the client,
+// including concurrent ones.
+func WithBandwidthLimit(bytesPerSec int) Option {
+	return func(c *config) {
+		c.bandwidth = newByteLimiter(bytesPerSec)
+	}
+}
+
+// WithDownloadBandwidthLimit is an [Option] that limits the rate,
+// in bytes per second,
+// at which the body of each .mod and .zip response is read.
+// Each download is limited separately.
+// This may be combined with [WithBandwidthLimit].
+func WithDownloadBandwidthLimit(bytesPerSec int) Option {
+	return func(c *config) {
+		c.downloadBandwidth = bytesPerSec
+	}
+}
+
+func newByteLimiter(bytesPerSec int) *rate.Limiter {
+	// The burst size is one second's worth of bytes,
+	// which also bounds the size of each read.
+	return rate.NewLimiter(rate.Limit(bytesPerSec), bytesPerSec)
+}
+
+// limitedReader is an io.ReadCloser whose reads are throttled by one or more token-bucket limiters.
+type limitedReader struct {
+	ctx      context.Context
+	r        io.ReadCloser
+	limiters []*rate.Limiter
+}
+
+func (s single) limitBandwidth(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	var limiters []*rate.Limiter
+	if s.bandwidth != nil {
+		limiters = append(limiters, s.bandwidth)
+	}
+	if s.downloadBandwidth > 0 {
+		limiters = append(limiters, newByteLimiter(s.downloadBandwidth))
+	}
+	if len(limiters) == 0 {
+		return rc
+	}
+	return &limitedReader{ctx: ctx, r: rc, limiters: limiters}
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	for _, l := range lr.limiters {
+		if burst := l.Burst(); len(p) > burst {
+			p = p[:burst]
+		}
+	}
+
+	n, err := lr.r.Read(p)
+	for _, l := range lr.limiters {
+		if werr := l.WaitN(lr.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (lr *limitedReader) Close() error {
+	return lr.r.Close()
+}
@@ -0,0 +1,50 @@
+package goproxyclient
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBandwidthLimit(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cases := []struct {
+		name string
+		opt  Option
+	}{
+		{"global", WithBandwidthLimit(1000)},
+		{"per_download", WithDownloadBandwidthLimit(1000)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cl := New(s.URL, nil, tc.opt)
+
+			start := time.Now()
+
+			// The zip is 1965 bytes.
+			// The first 1000 are available immediately,
+			// the rest should take most of a second.
+			rc, err := cl.Zip(context.Background(), "example.com/multi", "v1.0.0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+
+			n, err := io.Copy(io.Discard, rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 1965 {
+				t.Errorf("got %d bytes, want 1965", n)
+			}
+			if elapsed := time.Since(start); elapsed < 800*time.Millisecond {
+				t.Errorf("download took %s, want at least 800ms", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package goproxyclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+)
+
+// Head describes the commit at the head of a branch.
+type Head struct {
+	// Version is the pseudo-version (or tagged version) for the commit.
+	Version string
+
+	// Time is the commit time.
+	Time time.Time
+
+	// Hash is the commit hash.
+	// If the proxy supplied no Origin data,
+	// this is the abbreviated hash from the pseudo-version.
+	Hash string
+
+	// Ref is the full name of the branch (e.g. "refs/heads/main"),
+	// if the proxy supplied it.
+	Ref string
+}
+
+// BranchHead gets information about the commit at the head of the given branch of a module.
+// Proxies resolve branch names
+// (as they do other non-canonical version queries)
+// to the pseudo-version for the branch's latest commit.
+func (cl Client) BranchHead(ctx context.Context, mod, branch string) (Head, error) {
+	info, err := cl.VersionInfo(ctx, mod, branch)
+	if err != nil {
+		return Head{}, errors.Wrapf(err, "getting info for %s@%s", mod, branch)
+	}
+
+	head := Head{Version: info.Version, Time: info.Time}
+	if o := info.Origin; o != nil {
+		head.Hash, head.Ref = o.Hash, o.Ref
+	}
+	if head.Hash == "" && module.IsPseudoVersion(info.Version) {
+		head.Hash, _ = module.PseudoVersionRev(info.Version)
+	}
+
+	return head, nil
+}
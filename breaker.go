@@ -0,0 +1,196 @@
+package goproxyclient
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bobg/errors"
+)
+
+// ErrCircuitOpen is the error for a proxy that a [Client] skips
+// because its circuit breaker is open
+// (see [WithCircuitBreaker]).
+var ErrCircuitOpen = errors.New("circuit breaker open after repeated failures")
+
+// CircuitBreakerPolicy says when a client stops sending requests to a failing proxy
+// and when it tries it again.
+//
+// A request counts as a failure
+// if it fails in the way that [RetryPolicy] retries:
+// with a 5xx status
+// (other than 501 Not Implemented),
+// or with a network error
+// (including a timeout; see [Timeouts]),
+// after any retries.
+// Any response from the proxy other than that
+// (including 404 Not Found)
+// counts as a success.
+// Requests that are canceled by their callers count as neither,
+// and so do requests that finish while the proxy is being skipped,
+// other than the probe described at Cooldown.
+type CircuitBreakerPolicy struct {
+	// Failures is the number of consecutive failures after which the proxy is skipped.
+	// Values less than 1 mean 1.
+	Failures int
+
+	// Cooldown is how long the proxy is skipped.
+	// After that,
+	// the next request to it goes through as a probe,
+	// while others continue to skip it.
+	// If the probe succeeds,
+	// the proxy is restored;
+	// if it fails,
+	// the proxy is skipped for another Cooldown.
+	Cooldown time.Duration
+}
+
+// DefaultCircuitBreakerPolicy is a reasonable [CircuitBreakerPolicy] for use with [WithCircuitBreaker].
+var DefaultCircuitBreakerPolicy = CircuitBreakerPolicy{
+	Failures: 5,
+	Cooldown: 30 * time.Second,
+}
+
+// WithCircuitBreaker is an [Option] that gives each of the client's proxies a circuit breaker
+// working according to p,
+// so that in a long-running program
+// a dead proxy doesn't add a timeout to every operation.
+// While a proxy's circuit breaker is open,
+// operations skip the proxy
+// as if it had failed immediately,
+// with an error satisfying errors.Is(err, ErrCircuitOpen).
+// As with any error other than 404 or 410,
+// that falls back to the next proxy in the client's list
+// only if the separator between them is a pipe (|)
+// (see [Parse]);
+// otherwise the operation fails without waiting.
+//
+// The state of the circuit breakers is shared by all copies of the client.
+// There are no circuit breakers for "direct" and "off" entries.
+func WithCircuitBreaker(p CircuitBreakerPolicy) Option {
+	return func(c *config) {
+		c.breaker = &p
+	}
+}
+
+// breaker is the circuit breaker for one proxy.
+// It is shared by the copies of a [single].
+// A nil *breaker never opens.
+type breaker struct {
+	policy CircuitBreakerPolicy
+
+	mu       sync.Mutex
+	failures int       // consecutive failures
+	openedAt time.Time // zero if closed
+	probing  bool      // a probe is in progress
+}
+
+func newBreaker(p CircuitBreakerPolicy) *breaker {
+	p.Failures = max(p.Failures, 1)
+	return &breaker{policy: p}
+}
+
+// Allow tells whether a request may be sent to the proxy.
+// If the breaker is open but its cooldown has passed,
+// it allows one request through as a probe,
+// and reports that it is one.
+func (b *breaker) allow() (ok, probe bool) {
+	if b == nil {
+		return true, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openedAt.IsZero() {
+		return true, false
+	}
+	if b.probing || time.Since(b.openedAt) < b.policy.Cooldown {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+// IsOpen tells whether the breaker is open.
+func (b *breaker) isOpen() bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return !b.openedAt.IsZero()
+}
+
+// Record records the outcome of a request that [breaker.allow] allowed,
+// and whether allow said it was a probe,
+// reporting whether the breaker opened or closed as a result.
+//
+// While the breaker is open,
+// only the probe's outcome counts.
+// Other requests finishing then were sent before it opened,
+// and say nothing about whether the proxy has recovered since.
+func (b *breaker) record(ctx context.Context, probe bool, err error) (changed, open bool) {
+	if b == nil {
+		return false, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasOpen := !b.openedAt.IsZero()
+	if probe {
+		b.probing = false
+	}
+
+	switch {
+	case wasOpen && !probe:
+		return false, true
+
+	case err != nil && ctx.Err() != nil:
+		// Canceled by the caller.
+		// If this was a probe, the next request can be one.
+		return false, wasOpen
+
+	case err == nil || !retryable(ctx, err):
+		b.failures = 0
+		b.openedAt = time.Time{}
+
+	default:
+		b.failures++
+		if wasOpen || b.failures >= b.policy.Failures {
+			b.openedAt = time.Now()
+		}
+	}
+
+	isOpen := !b.openedAt.IsZero()
+	return isOpen != wasOpen, isOpen
+}
+
+// Call calls f on s,
+// unless s's circuit breaker is open,
+// in which case it fails with [ErrCircuitOpen].
+// It records the outcome in the breaker and in s's [ProxyStats].
+func call[T any](ctx context.Context, s single, f func(context.Context, single) (T, error)) (T, error) {
+	ok, probe := s.breaker.allow()
+	if !ok {
+		s.stats.skip()
+		var zero T
+		return zero, errors.Wrapf(ErrCircuitOpen, "skipping %s", s.baseURL)
+	}
+
+	val, err := f(ctx, s)
+	s.stats.record(ctx, err)
+	if changed, open := s.breaker.record(ctx, probe, err); changed {
+		if open {
+			s.log(ctx, "circuit breaker opened", slog.String("proxy", s.baseURL), slog.Any("error", err))
+		} else {
+			s.log(ctx, "circuit breaker closed", slog.String("proxy", s.baseURL))
+		}
+		traceFrom(ctx).circuitBreaker(s.baseURL, open)
+	}
+	return val, err
+}
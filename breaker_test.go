@@ -0,0 +1,164 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		down     atomic.Bool
+		requests atomic.Int64
+		h        = testHandler(nil)
+	)
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		h.ServeHTTP(w, req)
+	}))
+	defer flaky.Close()
+
+	good := httptest.NewServer(testHandler(nil))
+	defer good.Close()
+
+	const cooldown = 100 * time.Millisecond
+
+	var changes []bool
+	ctx = WithTrace(ctx, &Trace{
+		CircuitBreaker: func(proxy string, open bool) {
+			if proxy != flaky.URL {
+				t.Errorf("got circuit breaker change for %s, want %s", proxy, flaky.URL)
+			}
+			changes = append(changes, open)
+		},
+	})
+
+	cl := New(flaky.URL+"|"+good.URL, nil, WithCircuitBreaker(CircuitBreakerPolicy{Failures: 2, Cooldown: cooldown}))
+
+	// Not-found responses don't count as failures.
+	for range 3 {
+		if _, err := cl.List(ctx, "example.com/nonexistent"); err == nil {
+			t.Fatal("got no error for nonexistent module")
+		}
+	}
+	if len(changes) != 0 {
+		t.Fatalf("got circuit breaker changes %v, want none", changes)
+	}
+
+	down.Store(true)
+	for range 2 {
+		if _, err := cl.List(ctx, "example.com/multi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(changes) != 1 || !changes[0] {
+		t.Fatalf("got circuit breaker changes %v, want [true]", changes)
+	}
+
+	// The flaky proxy is skipped now.
+	requests.Store(0)
+	if _, err := cl.List(ctx, "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("got %d requests to the open proxy, want 0", got)
+	}
+
+	// Without fallback, the operation fails right away.
+	cl2 := Client{conf: new(config)}
+	cl2.conf.chain.Store(&chain{first: cl.chain().first})
+	if _, err := cl2.List(ctx, "example.com/multi"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("got %v, want %v", err, ErrCircuitOpen)
+	}
+
+	// After the cooldown, a failed probe keeps the breaker open.
+	time.Sleep(cooldown)
+	if _, err := cl.List(ctx, "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests to the open proxy, want 1 (the probe)", got)
+	}
+	if _, err := cl.List(ctx, "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests to the open proxy, want 1", got)
+	}
+
+	// A successful probe closes it.
+	down.Store(false)
+	time.Sleep(cooldown)
+	var prov Provenance
+	if _, err := cl.List(WithProvenance(ctx, &prov), "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := prov.Proxy(); got != flaky.URL {
+		t.Errorf("got provenance %s, want %s", got, flaky.URL)
+	}
+	if len(changes) != 2 || changes[1] {
+		t.Errorf("got circuit breaker changes %v, want [true false]", changes)
+	}
+}
+
+func TestBreakerProbe(t *testing.T) {
+	ctx := context.Background()
+
+	const cooldown = 20 * time.Millisecond
+	var (
+		b      = newBreaker(CircuitBreakerPolicy{Failures: 1, Cooldown: cooldown})
+		netErr = &url.Error{Op: "Get", URL: "https://proxy.example.com/", Err: errors.New("connection refused")}
+	)
+
+	// A request sent while the breaker is closed...
+	if ok, probe := b.allow(); !ok || probe {
+		t.Fatalf("got allow %v, probe %v for closed breaker, want true, false", ok, probe)
+	}
+
+	// ...is still in progress when another fails and opens it.
+	b.allow()
+	if changed, open := b.record(ctx, false, netErr); !changed || !open {
+		t.Fatalf("got changed %v, open %v, want true, true", changed, open)
+	}
+
+	time.Sleep(cooldown)
+	if ok, probe := b.allow(); !ok || !probe {
+		t.Fatalf("got allow %v, probe %v after cooldown, want true, true", ok, probe)
+	}
+
+	// The earlier request's success says nothing about the proxy now.
+	if changed, open := b.record(ctx, false, nil); changed || !open {
+		t.Errorf("got changed %v, open %v for stale request, want false, true", changed, open)
+	}
+	if ok, _ := b.allow(); ok {
+		t.Error("got a second probe while the first is in progress")
+	}
+
+	// The probe's own failure restarts the cooldown.
+	if changed, open := b.record(ctx, true, netErr); changed || !open {
+		t.Errorf("got changed %v, open %v for failed probe, want false, true", changed, open)
+	}
+	if ok, _ := b.allow(); ok {
+		t.Error("got a request through during the new cooldown")
+	}
+
+	// And its success closes the breaker.
+	time.Sleep(cooldown)
+	if ok, probe := b.allow(); !ok || !probe {
+		t.Fatalf("got allow %v, probe %v after second cooldown, want true, true", ok, probe)
+	}
+	if changed, open := b.record(ctx, true, nil); !changed || open {
+		t.Errorf("got changed %v, open %v for successful probe, want true, false", changed, open)
+	}
+}
@@ -0,0 +1,465 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+)
+
+// DefaultCacheTTL is how long version lists and @latest responses
+// stay in a client's cache (see [WithCache])
+// unless changed with [WithCacheTTL].
+const DefaultCacheTTL = 5 * time.Minute
+
+// WithCache is an [Option] that makes the client keep the responses it gets
+// in the directory dir,
+// and consult it before sending requests.
+// The info, go.mod, and zip files for a canonical version of a module never change,
+// so they are reused indefinitely.
+// Version lists and @latest responses are reused
+// only for a limited time (see [WithCacheTTL]).
+//
+// If maxSize is positive,
+// the least recently used files are removed
+// when the total size of the cache exceeds that many bytes.
+//
+// The directory has the same layout as a module proxy,
+// so it can itself be used as one
+// (with a file:// URL in GOPROXY, for example),
+// except that if the client sends tenant IDs to proxies
+// or limits the rate of each tenant's requests,
+// files fetched for a tenant (see [WithTenant])
+// are kept under a separate @tenant subdirectory for each.
+// Failures writing to the cache are logged (see [WithLogger])
+// and otherwise ignored.
+//
+// Files are written to the cache as they are fetched,
+// before the client's other checks,
+// such as those of a [Scanner], a [ChecksumDB], or a [GoSum].
+// Files from the cache are still subject to those checks,
+// and a go.mod or zip file that fails one
+// is removed from the cache,
+// so that it is fetched again next time
+// rather than failing the same way.
+// Until then,
+// a program using the directory as a proxy may be served the file.
+func WithCache(dir string, maxSize int64) Option {
+	return func(c *config) {
+		c.cacheDir = dir
+		c.cacheMaxSize = maxSize
+	}
+}
+
+// WithCacheTTL sets how long version lists and @latest responses
+// stay in the client's cache (see [WithCache]).
+// The default is [DefaultCacheTTL].
+// A negative value means not to cache them at all.
+func WithCacheTTL(d time.Duration) Option {
+	return func(c *config) {
+		c.cacheTTL = d
+	}
+}
+
+// diskCache is the cache created by [WithCache].
+// A nil *diskCache is an empty cache that discards writes.
+type diskCache struct {
+	dir     string
+	maxSize int64
+	ttl     time.Duration
+	logger  *slog.Logger
+
+	mu sync.Mutex // protects used and known, and serializes evictions
+
+	// Used is the total size of the files in the cache
+	// as of the last walk of its directory (see [diskCache.doEvict]),
+	// adjusted for the files written and removed since,
+	// if known is true.
+	// It is tracked only if there is a maximum size.
+	used  int64
+	known bool
+}
+
+// walkDir walks the cache directory when evicting files from it.
+// It is a variable so that tests can replace it.
+var walkDir = filepath.WalkDir
+
+func newDiskCache(conf *config) *diskCache {
+	if conf.cacheDir == "" {
+		return nil
+	}
+	ttl := conf.cacheTTL
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &diskCache{
+		dir:     conf.cacheDir,
+		maxSize: conf.cacheMaxSize,
+		ttl:     ttl,
+		logger:  conf.logger,
+	}
+}
+
+// CacheName is the name in a [diskCache]
+// of the file with the given suffix ("info," "mod," or "zip")
+// for a module version.
+// Note, mod and ver are already escaped.
+func cacheName(mod, ver, suffix string) string {
+	return mod + "/@v/" + ver + "." + suffix
+}
+
+// CacheKey returns name, the name of a file in the client's [diskCache],
+// qualified by the tenant of ctx
+// when the client's responses may depend on the tenant (see [WithTenant]),
+// so that one tenant is never served another's cached files.
+// The "@" in the prefix cannot appear in an escaped module path,
+// so these names never collide with unqualified ones.
+func (cl Client) cacheKey(ctx context.Context, name string) string {
+	if !cl.chain().byTenant {
+		return name
+	}
+	tenant := Tenant(ctx)
+	if tenant == "" {
+		return name
+	}
+	sum := sha256.Sum256([]byte(tenant))
+	return "@tenant/" + hex.EncodeToString(sum[:]) + "/" + name
+}
+
+// Immutable tells whether responses for a version may be cached indefinitely.
+// Others, like branch names, may refer to different versions over time.
+func immutable(ver string) bool {
+	return module.CanonicalVersion(ver) == ver
+}
+
+func (c *diskCache) path(name string) string {
+	return filepath.Join(c.dir, filepath.FromSlash(name))
+}
+
+// File returns the absolute path of the named file in the cache,
+// or "" if it is not there.
+func (c *diskCache) file(name string) string {
+	if c == nil {
+		return ""
+	}
+	path, err := filepath.Abs(c.path(name))
+	if err != nil {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// Get reads the named file from the cache.
+// If expires is true,
+// the file must be newer than the cache's TTL.
+// Otherwise the file is marked as recently used.
+func (c *diskCache) get(ctx context.Context, name string, expires bool) ([]byte, bool) {
+	if c == nil || (expires && c.ttl < 0) {
+		return nil, false
+	}
+	path := c.path(name)
+	if expires {
+		fi, err := os.Stat(path)
+		if err != nil || time.Since(fi.ModTime()) > c.ttl {
+			traceFrom(ctx).cacheLookup(name, false)
+			return nil, false
+		}
+	} else {
+		c.touch(path)
+	}
+	data, err := os.ReadFile(path)
+	traceFrom(ctx).cacheLookup(name, err == nil)
+	if err == nil {
+		provenanceFrom(ctx).set("cache")
+	}
+	return data, err == nil
+}
+
+// Open opens the named file from the cache,
+// marking it as recently used.
+// The result is a [sizedBody].
+func (c *diskCache) open(ctx context.Context, name string) (io.ReadCloser, bool) {
+	if c == nil {
+		return nil, false
+	}
+	path := c.path(name)
+	f, err := os.Open(path)
+	if err != nil {
+		traceFrom(ctx).cacheLookup(name, false)
+		return nil, false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		traceFrom(ctx).cacheLookup(name, false)
+		return nil, false
+	}
+	traceFrom(ctx).cacheLookup(name, true)
+	provenanceFrom(ctx).set("cache")
+	c.touch(path)
+	return sizedBody{ReadCloser: f, size: fi.Size(), proxy: "cache", url: path}, true
+}
+
+// Size reports the size of the named file in the cache.
+func (c *diskCache) size(ctx context.Context, name string) (int64, bool) {
+	if c == nil {
+		return 0, false
+	}
+	fi, err := os.Stat(c.path(name))
+	traceFrom(ctx).cacheLookup(name, err == nil)
+	if err != nil {
+		return 0, false
+	}
+	provenanceFrom(ctx).set("cache")
+	return fi.Size(), true
+}
+
+// Touch updates the modification time of a file,
+// which is when it was last used,
+// for the purpose of evictions.
+func (c *diskCache) touch(path string) {
+	now := time.Now()
+	os.Chtimes(path, now, now)
+}
+
+// Put writes data to the named file in the cache.
+func (c *diskCache) put(ctx context.Context, name string, data []byte, expires bool) {
+	if c == nil || (expires && c.ttl < 0) {
+		return
+	}
+	err := c.write(name, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if err != nil {
+		c.log(ctx, "writing to cache", slog.String("name", name), slog.Any("error", err))
+		return
+	}
+	c.evict(ctx)
+}
+
+// PutZip copies a zip file from rc to the named file in the cache,
+// closing rc,
+// and returns the cached copy.
+// Since rc is consumed,
+// failures are returned as errors
+// rather than merely logged.
+func (c *diskCache) putZip(ctx context.Context, name string, rc io.ReadCloser) (io.ReadCloser, error) {
+	defer rc.Close()
+
+	err := c.write(name, func(w io.Writer) error {
+		_, err := io.Copy(w, rc)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "caching zip file")
+	}
+
+	path := c.path(name)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening cached zip file")
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "statting %s", path)
+	}
+
+	// The open file remains readable even if this removes it.
+	c.evict(ctx)
+
+	proxy, url := source(rc)
+	return sizedBody{ReadCloser: f, size: fi.Size(), proxy: proxy, url: url}, nil
+}
+
+// Remove removes a file from the cache,
+// if it is there.
+func (c *diskCache) remove(name string) {
+	path := c.path(name)
+	size := c.fileSize(path)
+	if err := os.Remove(path); err == nil {
+		c.adjust(-size)
+	}
+}
+
+// Uncache removes the file with the given suffix ("mod" or "zip")
+// for a module version from the client's cache, if it is there,
+// so that a file that failed the client's checks is fetched again next time
+// rather than served from the cache.
+func (cl Client) uncache(ctx context.Context, mod, ver, suffix string) {
+	if cl.conf.cache == nil {
+		return
+	}
+	escMod, err := module.EscapePath(mod)
+	if err != nil {
+		return
+	}
+	escVer, err := module.EscapeVersion(ver)
+	if err != nil {
+		return
+	}
+	cl.conf.cache.remove(cl.cacheKey(ctx, cacheName(escMod, escVer, suffix)))
+}
+
+func (c *diskCache) write(name string, write func(io.Writer) error) error {
+	path := c.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "creating directory for %s", path)
+	}
+	old := c.fileSize(path)
+	if err := writeFileAtomic(path, 0644, false, write); err != nil {
+		return err
+	}
+	c.adjust(c.fileSize(path) - old)
+	return nil
+}
+
+// FileSize returns the size of the file at path,
+// or 0 if there is no such file
+// or if the cache's size is not tracked.
+func (c *diskCache) fileSize(path string) int64 {
+	if c.maxSize <= 0 {
+		return 0
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+// Adjust adds delta to the tracked total size of the cache.
+func (c *diskCache) adjust(delta int64) {
+	if c.maxSize <= 0 {
+		return
+	}
+	c.mu.Lock()
+	c.used += delta
+	c.mu.Unlock()
+}
+
+// Evict removes the least recently used files from the cache
+// if its total size exceeds its maximum.
+// Failures are logged.
+//
+// The cache directory is walked only the first time
+// and when the tracked size (see [diskCache.used]) exceeds the maximum,
+// not on every call.
+// Files written to the directory by others,
+// such as another process sharing it,
+// are accounted for at the next walk.
+func (c *diskCache) evict(ctx context.Context) {
+	if c.maxSize <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.known && c.used <= c.maxSize {
+		return
+	}
+	if err := c.doEvict(); err != nil {
+		c.log(ctx, "evicting from cache", slog.Any("error", err))
+	}
+}
+
+// DoEvict walks the cache directory to find its total size
+// and, if that exceeds the maximum,
+// removes the least recently used files
+// until it is no more than 90% of the maximum,
+// so that the next few writes do not need another walk.
+// The caller must hold c.mu.
+func (c *diskCache) doEvict() error {
+
+	type entry struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var (
+		entries []entry
+		total   int64
+	)
+	err := walkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			// Skip directories and temporary files.
+			return nil
+		}
+		fi, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{path: path, size: fi.Size(), modTime: fi.ModTime()})
+		total += fi.Size()
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "walking %s", c.dir)
+	}
+	c.used, c.known = total, true
+	if total <= c.maxSize {
+		return nil
+	}
+
+	slices.SortFunc(entries, func(a, b entry) int {
+		return a.modTime.Compare(b.modTime)
+	})
+	target := c.maxSize - c.maxSize/10
+	for _, e := range entries {
+		if c.used <= target {
+			break
+		}
+		if err := os.Remove(e.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return errors.Wrapf(err, "removing %s", e.path)
+		}
+		c.used -= e.size
+	}
+	return nil
+}
+
+func (c *diskCache) log(ctx context.Context, msg string, attrs ...slog.Attr) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(ctx, slog.LevelWarn, msg, append(attrs, metadataAttrs(ctx)...)...)
+}
+
+// CachedBody reads a small response body (such as a go.mod file) from rc,
+// closing it,
+// and writes it to the named file in the cache.
+// It returns a replacement for rc.
+func (c *diskCache) cachedBody(ctx context.Context, name string, rc io.ReadCloser) (io.ReadCloser, error) {
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading response body")
+	}
+	c.put(ctx, name, data, false)
+
+	proxy, url := source(rc)
+	return sizedBody{ReadCloser: io.NopCloser(bytes.NewReader(data)), size: int64(len(data)), proxy: proxy, url: url}, nil
+}
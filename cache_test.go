@@ -0,0 +1,172 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bobg/goproxyclient/goproxytest"
+)
+
+func TestCache(t *testing.T) {
+	fsys, err := fs.Sub(testdata, "testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	readAll := func(t *testing.T, rc io.ReadCloser, err error) []byte {
+		t.Helper()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	// Each step makes the same request twice
+	// and checks how many of them reached the proxy.
+	type step struct {
+		name string
+		f    func(*testing.T, Client) ([]byte, error)
+	}
+	steps := []step{
+		{"info", func(t *testing.T, cl Client) ([]byte, error) {
+			ver, _, _, err := cl.Info(ctx, "example.com/multi", "v1.0.0")
+			return []byte(ver), err
+		}},
+		{"mod", func(t *testing.T, cl Client) ([]byte, error) {
+			rc, err := cl.Mod(ctx, "example.com/multi", "v1.0.0")
+			return readAll(t, rc, err), nil
+		}},
+		{"zip", func(t *testing.T, cl Client) ([]byte, error) {
+			rc, err := cl.Zip(ctx, "example.com/multi", "v1.0.0")
+			return readAll(t, rc, err), nil
+		}},
+		{"list", func(t *testing.T, cl Client) ([]byte, error) {
+			versions, err := cl.List(ctx, "example.com/multi")
+			return []byte(fmt.Sprint(versions)), err
+		}},
+		{"latest", func(t *testing.T, cl Client) ([]byte, error) {
+			ver, _, _, err := cl.Latest(ctx, "example.com/multi")
+			return []byte(ver), err
+		}},
+	}
+
+	run := func(t *testing.T, wantRequests map[string]int64, opts ...Option) {
+		h := goproxytest.New(fsys)
+		s := httptest.NewServer(h)
+		defer s.Close()
+
+		cl := New(s.URL, nil, opts...)
+
+		for _, st := range steps {
+			t.Run(st.name, func(t *testing.T) {
+				before := h.Requests()
+				got1, err := st.f(t, cl)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got2, err := st.f(t, cl)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got1, got2) {
+					t.Error("second result differs from first")
+				}
+				if n := h.Requests() - before; n != wantRequests[st.name] {
+					t.Errorf("got %d requests, want %d", n, wantRequests[st.name])
+				}
+			})
+		}
+	}
+
+	t.Run("cache", func(t *testing.T) {
+		dir := t.TempDir()
+		run(t, map[string]int64{"info": 1, "mod": 1, "zip": 1, "list": 1, "latest": 1}, WithCache(dir, 0))
+
+		// The cache has the layout of a proxy.
+		if _, err := os.Stat(filepath.Join(dir, "example.com", "multi", "@v", "v1.0.0.zip")); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("no_ttl", func(t *testing.T) {
+		run(t, map[string]int64{"info": 1, "mod": 1, "zip": 1, "list": 2, "latest": 2}, WithCache(t.TempDir(), 0), WithCacheTTL(-1))
+	})
+
+	t.Run("evict", func(t *testing.T) {
+		// Everything is evicted as soon as it is written.
+		run(t, map[string]int64{"info": 2, "mod": 2, "zip": 2, "list": 2, "latest": 2}, WithCache(t.TempDir(), 1))
+	})
+}
+
+func TestCacheEvict(t *testing.T) {
+	ctx := context.Background()
+
+	var walks int
+	defer func(orig func(string, fs.WalkDirFunc) error) { walkDir = orig }(walkDir)
+	walkDir = func(root string, fn fs.WalkDirFunc) error {
+		walks++
+		return filepath.WalkDir(root, fn)
+	}
+
+	dirSize := func(t *testing.T, dir string) int64 {
+		t.Helper()
+
+		var total int64
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += fi.Size()
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return total
+	}
+
+	const (
+		maxSize = 1000
+		puts    = 200
+	)
+	dir := t.TempDir()
+	c := newDiskCache(&config{cacheDir: dir, cacheMaxSize: maxSize})
+
+	data := bytes.Repeat([]byte("x"), 10)
+	for i := range puts {
+		c.put(ctx, cacheName("example.com/m", fmt.Sprintf("v1.0.%d", i), "mod"), data, false)
+		if got := dirSize(t, dir); got > maxSize {
+			t.Fatalf("after %d puts, got cache size %d, want no more than %d", i+1, got, maxSize)
+		}
+	}
+
+	// One walk at the start,
+	// then one each time the cache fills up,
+	// after which eviction leaves room for about 10 more files.
+	if walks > puts/5 {
+		t.Errorf("got %d walks of the cache in %d puts, want no more than %d", walks, puts, puts/5)
+	}
+	if got := dirSize(t, dir); c.used != got {
+		t.Errorf("tracked cache size %d, want %d", c.used, got)
+	}
+}
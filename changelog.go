@@ -0,0 +1,248 @@
+package goproxyclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/mid"
+)
+
+// Release describes a tagged release on a source-hosting service.
+type Release struct {
+	// Version is the module version of the release.
+	Version string
+
+	// Tag is the name of the release's tag in the repository.
+	// It differs from Version for modules in repository subdirectories.
+	Tag string
+
+	// Name is the title of the release.
+	Name string
+
+	// Notes are the release notes, typically in Markdown.
+	Notes string
+
+	// URL is the address of the release's web page.
+	URL string
+
+	// Time is when the release was published.
+	Time time.Time
+}
+
+// ReleaseSource fetches release notes from a source-hosting service.
+// Implementations include [GitHubReleases] and [GitLabReleases].
+type ReleaseSource interface {
+	// Release gets the release with the given tag in the given repository.
+	// It returns an error for which [IsNotFound] is true
+	// if there is no such release.
+	Release(ctx context.Context, repo Repo, tag string) (Release, error)
+}
+
+// ReleaseSourceFor returns a [ReleaseSource] suitable for repo,
+// based on its host,
+// or nil if there isn't one.
+// The source uses hc for its requests
+// (or a default HTTP client if hc is nil)
+// and sends the given access token, if any.
+func ReleaseSourceFor(repo Repo, hc *http.Client, token string) ReleaseSource {
+	u, err := url.Parse(repo.URL)
+	if err != nil {
+		return nil
+	}
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == "github.com":
+		return GitHubReleases{Client: hc, Token: token}
+	case host == "gitlab.com" || strings.HasPrefix(host, "gitlab."):
+		return GitLabReleases{Client: hc, Token: token, BaseURL: "https://" + u.Host}
+	}
+	return nil
+}
+
+// Changelog gets release notes for the versions of a module
+// after from, up to and including to,
+// in semver order.
+// It resolves the module's repository with [Client.Repo]
+// and gets the notes from src.
+// Versions with no release on the hosting service are skipped.
+func (cl Client) Changelog(ctx context.Context, mod, from, to string, src ReleaseSource) ([]Release, error) {
+	versions, err := cl.List(ctx, mod)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing versions of %s", mod)
+	}
+	repo, err := cl.Repo(ctx, mod)
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding repository for %s", mod)
+	}
+
+	var result []Release
+	for _, ver := range versionsInRange(versions, from, to) {
+		tag := ver
+		if repo.Subdir != "" {
+			tag = repo.Subdir + "/" + ver
+		}
+		rel, err := src.Release(ctx, repo, tag)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting release %s", tag)
+		}
+		rel.Version = ver
+		result = append(result, rel)
+	}
+	return result, nil
+}
+
+// GitHubReleases is a [ReleaseSource] for repositories on GitHub.
+type GitHubReleases struct {
+	// Client is the HTTP client to use.
+	// If nil, a default client is used.
+	Client *http.Client
+
+	// Token is an optional access token,
+	// which raises GitHub's API rate limits
+	// and allows access to private repositories.
+	Token string
+
+	// BaseURL is the base URL of the GitHub API.
+	// If empty, https://api.github.com is used.
+	BaseURL string
+}
+
+// Release implements [ReleaseSource].
+func (g GitHubReleases) Release(ctx context.Context, repo Repo, tag string) (Release, error) {
+	owner, name, err := ownerAndName(repo.URL)
+	if err != nil {
+		return Release{}, err
+	}
+	base := g.BaseURL
+	if base == "" {
+		base = "https://api.github.com"
+	}
+	q := fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", strings.TrimRight(base, "/"), owner, name, url.PathEscape(tag))
+
+	var resp struct {
+		TagName     string    `json:"tag_name"`
+		Name        string    `json:"name"`
+		Body        string    `json:"body"`
+		HTMLURL     string    `json:"html_url"`
+		PublishedAt time.Time `json:"published_at"`
+	}
+	header := http.Header{"Accept": {"application/vnd.github+json"}}
+	if g.Token != "" {
+		header.Set("Authorization", "Bearer "+g.Token)
+	}
+	if err := getJSON(ctx, g.Client, q, header, &resp); err != nil {
+		return Release{}, err
+	}
+	return Release{
+		Tag:   resp.TagName,
+		Name:  resp.Name,
+		Notes: resp.Body,
+		URL:   resp.HTMLURL,
+		Time:  resp.PublishedAt,
+	}, nil
+}
+
+// GitLabReleases is a [ReleaseSource] for repositories on GitLab.
+type GitLabReleases struct {
+	// Client is the HTTP client to use.
+	// If nil, a default client is used.
+	Client *http.Client
+
+	// Token is an optional personal, project, or group access token,
+	// sent in the PRIVATE-TOKEN header.
+	Token string
+
+	// BaseURL is the base URL of the GitLab instance.
+	// If empty, https://gitlab.com is used.
+	BaseURL string
+}
+
+// Release implements [ReleaseSource].
+func (g GitLabReleases) Release(ctx context.Context, repo Repo, tag string) (Release, error) {
+	u, err := url.Parse(repo.URL)
+	if err != nil {
+		return Release{}, errors.Wrapf(err, "parsing repository URL %s", repo.URL)
+	}
+	project := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	base := g.BaseURL
+	if base == "" {
+		base = "https://gitlab.com"
+	}
+	q := fmt.Sprintf("%s/api/v4/projects/%s/releases/%s", strings.TrimRight(base, "/"), url.PathEscape(project), url.PathEscape(tag))
+
+	var resp struct {
+		TagName     string    `json:"tag_name"`
+		Name        string    `json:"name"`
+		Description string    `json:"description"`
+		ReleasedAt  time.Time `json:"released_at"`
+		Links       struct {
+			Self string `json:"self"`
+		} `json:"_links"`
+	}
+	header := make(http.Header)
+	if g.Token != "" {
+		header.Set("PRIVATE-TOKEN", g.Token)
+	}
+	if err := getJSON(ctx, g.Client, q, header, &resp); err != nil {
+		return Release{}, err
+	}
+	return Release{
+		Tag:   resp.TagName,
+		Name:  resp.Name,
+		Notes: resp.Description,
+		URL:   resp.Links.Self,
+		Time:  resp.ReleasedAt,
+	}, nil
+}
+
+// OwnerAndName parses the owner and repository name
+// from a repository URL like https://github.com/OWNER/NAME.
+func ownerAndName(repoURL string) (string, string, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "parsing repository URL %s", repoURL)
+	}
+	p := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	owner, name := path.Split(p)
+	owner = strings.TrimSuffix(owner, "/")
+	if owner == "" || name == "" || strings.Contains(owner, "/") {
+		return "", "", fmt.Errorf("cannot parse owner and name from repository URL %s", repoURL)
+	}
+	return owner, name, nil
+}
+
+// GetJSON issues a GET request for q and decodes the JSON response into dest.
+// A non-200 response produces a [mid.CodeErr].
+func getJSON(ctx context.Context, hc *http.Client, q string, header http.Header, dest any) error {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", q, nil)
+	if err != nil {
+		return errors.Wrapf(err, "creating GET %s request", q)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "in GET %s", q)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return mid.CodeErr{C: resp.StatusCode, Err: fmt.Errorf("GET %s: %s", q, resp.Status)}
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(dest), "decoding response from GET %s", q)
+}
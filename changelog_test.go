@@ -0,0 +1,73 @@
+package goproxyclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChangelog(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	gh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/repos/bobg/errors/releases/tags/v1.1.0" {
+			http.NotFound(w, req)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"tag_name":     "v1.1.0",
+			"name":         "Version 1.1.0",
+			"body":         "Adds Newf.",
+			"html_url":     "https://github.com/bobg/errors/releases/tag/v1.1.0",
+			"published_at": "2024-05-15T17:43:47Z",
+		})
+	}))
+	defer gh.Close()
+
+	cl := New(s.URL, nil)
+	rels, err := cl.Changelog(ctx, "github.com/bobg/errors", "v0.10.0", "v1.1.0", GitHubReleases{BaseURL: gh.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 1 {
+		t.Fatalf("got %d releases, want 1", len(rels))
+	}
+	if rels[0].Version != "v1.1.0" || rels[0].Notes != "Adds Newf." {
+		t.Errorf("got %+v", rels[0])
+	}
+}
+
+func TestGitLabReleases(t *testing.T) {
+	gl := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/releases/sub%2Fv1.2.0" {
+			http.NotFound(w, req)
+			return
+		}
+		if req.Header.Get("PRIVATE-TOKEN") != "xyzzy" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"tag_name":"sub/v1.2.0","name":"1.2.0","description":"Notes.","released_at":"2024-01-01T00:00:00Z","_links":{"self":"https://gitlab.com/group/project/-/releases/sub%2Fv1.2.0"}}`))
+	}))
+	defer gl.Close()
+
+	src := GitLabReleases{BaseURL: gl.URL, Token: "xyzzy"}
+	repo := Repo{VCS: "git", URL: "https://gitlab.com/group/project.git", Subdir: "sub"}
+
+	rel, err := src.Release(context.Background(), repo, "sub/v1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.Tag != "sub/v1.2.0" || rel.Notes != "Notes." {
+		t.Errorf("got %+v", rel)
+	}
+
+	if _, err := src.Release(context.Background(), repo, "sub/v9.9.9"); !IsNotFound(err) {
+		t.Errorf("got error %v, want not found", err)
+	}
+}
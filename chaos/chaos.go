@@ -0,0 +1,281 @@
+// Package chaos wraps a [goproxyclient.Clientish] to inject failures,
+// for chaos testing of systems that use goproxyclient.
+//
+// Options add latency,
+// fail requests with the same kinds of errors that a real [goproxyclient.Client] returns
+// (such as a [mid.CodeErr] for an HTTP error status,
+// which works with [goproxyclient.IsNotFound]),
+// and cut go.mod and zip file downloads short.
+//
+// To simulate failures at the HTTP level instead,
+// use the goproxytest subpackage.
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/mid"
+
+	"github.com/bobg/goproxyclient"
+)
+
+// Client is a [goproxyclient.Clientish] that injects failures into requests to another one.
+// Create one with [New].
+// It is safe for concurrent use by multiple goroutines
+// if the underlying Clientish is.
+type Client struct {
+	cl goproxyclient.Clientish
+
+	latency, jitter time.Duration
+	errs            []injectedErr
+	errFunc         func(op, mod, ver string) error
+
+	flakiness float64
+	flakeCode int
+
+	truncation float64
+
+	mu  sync.Mutex // protects rng
+	rng *rand.Rand
+}
+
+var _ goproxyclient.Clientish = (*Client)(nil)
+
+type injectedErr struct {
+	prefix string
+	code   int
+}
+
+// Option is the type of an option that can be passed to [New].
+type Option func(*Client)
+
+// WithLatency delays each request by d,
+// plus a random duration up to jitter.
+// The delay is cut short if the request's context is canceled,
+// in which case the request fails with the context's error.
+func WithLatency(d, jitter time.Duration) Option {
+	return func(c *Client) {
+		c.latency = d
+		c.jitter = jitter
+	}
+}
+
+// WithError makes any request for a module whose path begins with prefix
+// fail with the given HTTP status code,
+// as if a proxy had responded with it.
+// Multiple WithError options may be given;
+// the first matching one applies.
+func WithError(prefix string, code int) Option {
+	return func(c *Client) {
+		c.errs = append(c.errs, injectedErr{prefix: prefix, code: code})
+	}
+}
+
+// WithErrorFunc calls f before each request.
+// If it returns an error,
+// the request fails with that error.
+// The op argument is the name of the [goproxyclient.Clientish] method
+// ("Info", "Latest", "List", "Mod", or "Zip"),
+// and ver is empty for Latest and List.
+//
+// This allows injecting any error,
+// such as [goproxyclient.ErrProxyOff] or a [*goproxyclient.HashMismatchError].
+func WithErrorFunc(f func(op, mod, ver string) error) Option {
+	return func(c *Client) {
+		c.errFunc = f
+	}
+}
+
+// WithFlakiness makes a random fraction p of requests
+// (0 <= p <= 1)
+// fail with the given HTTP status code.
+func WithFlakiness(p float64, code int) Option {
+	return func(c *Client) {
+		c.flakiness = p
+		c.flakeCode = code
+	}
+}
+
+// WithTruncation makes a random fraction p of go.mod and zip file downloads
+// (0 <= p <= 1)
+// fail with [io.ErrUnexpectedEOF],
+// as when a connection is dropped,
+// after a random number of bytes
+// (less than 4 KiB,
+// or at the end if the file is smaller).
+func WithTruncation(p float64) Option {
+	return func(c *Client) {
+		c.truncation = p
+	}
+}
+
+// WithSeed makes the random choices of [WithLatency], [WithFlakiness], and [WithTruncation] reproducible.
+// Without it,
+// they are different each time.
+func WithSeed(seed uint64) Option {
+	return func(c *Client) {
+		c.rng = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// New creates a [Client] that injects failures into requests to cl.
+func New(cl goproxyclient.Clientish, opts ...Option) *Client {
+	c := &Client{cl: cl}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.rng == nil {
+		c.rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return c
+}
+
+// Info implements [goproxyclient.Clientish].
+func (c *Client) Info(ctx context.Context, mod, ver string) (string, time.Time, map[string]json.RawMessage, error) {
+	if err := c.inject(ctx, "Info", mod, ver, "@v/"+ver+".info"); err != nil {
+		return "", time.Time{}, nil, err
+	}
+	return c.cl.Info(ctx, mod, ver)
+}
+
+// Latest implements [goproxyclient.Clientish].
+func (c *Client) Latest(ctx context.Context, mod string) (string, time.Time, map[string]json.RawMessage, error) {
+	if err := c.inject(ctx, "Latest", mod, "", "@latest"); err != nil {
+		return "", time.Time{}, nil, err
+	}
+	return c.cl.Latest(ctx, mod)
+}
+
+// List implements [goproxyclient.Clientish].
+func (c *Client) List(ctx context.Context, mod string) ([]string, error) {
+	if err := c.inject(ctx, "List", mod, "", "@v/list"); err != nil {
+		return nil, err
+	}
+	return c.cl.List(ctx, mod)
+}
+
+// Mod implements [goproxyclient.Clientish].
+func (c *Client) Mod(ctx context.Context, mod, ver string) (io.ReadCloser, error) {
+	if err := c.inject(ctx, "Mod", mod, ver, "@v/"+ver+".mod"); err != nil {
+		return nil, err
+	}
+	rc, err := c.cl.Mod(ctx, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+	return c.truncate(rc), nil
+}
+
+// Zip implements [goproxyclient.Clientish].
+func (c *Client) Zip(ctx context.Context, mod, ver string) (io.ReadCloser, error) {
+	if err := c.inject(ctx, "Zip", mod, ver, "@v/"+ver+".zip"); err != nil {
+		return nil, err
+	}
+	rc, err := c.cl.Zip(ctx, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+	return c.truncate(rc), nil
+}
+
+// Inject waits for the configured latency
+// and then produces any injected error for a request.
+// The suffix is the part of the request path after the module path,
+// used in error messages.
+func (c *Client) inject(ctx context.Context, op, mod, ver, suffix string) error {
+	q := mod + "/" + suffix
+
+	if d := c.delay(); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "in GET %s", q)
+		case <-timer.C:
+		}
+	}
+
+	for _, e := range c.errs {
+		if strings.HasPrefix(mod, e.prefix) {
+			return codeErr(q, e.code)
+		}
+	}
+	if c.errFunc != nil {
+		if err := c.errFunc(op, mod, ver); err != nil {
+			return err
+		}
+	}
+	if c.flakiness > 0 && c.float64() < c.flakiness {
+		return codeErr(q, c.flakeCode)
+	}
+	return nil
+}
+
+// CodeErr produces an error like the one a [goproxyclient.Client] returns
+// for a response with the given status code.
+func codeErr(q string, code int) error {
+	return mid.CodeErr{C: code, Err: fmt.Errorf("GET %s: %d %s", q, code, http.StatusText(code))}
+}
+
+func (c *Client) delay() time.Duration {
+	d := c.latency
+	if c.jitter > 0 {
+		c.mu.Lock()
+		d += time.Duration(c.rng.Int64N(int64(c.jitter)))
+		c.mu.Unlock()
+	}
+	return d
+}
+
+func (c *Client) float64() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.rng.Float64()
+}
+
+// Truncate wraps rc so that,
+// with probability c.truncation,
+// reading it fails partway through.
+func (c *Client) truncate(rc io.ReadCloser) io.ReadCloser {
+	if c.truncation <= 0 || c.float64() >= c.truncation {
+		return rc
+	}
+
+	c.mu.Lock()
+	limit := c.rng.Int64N(maxTruncation)
+	c.mu.Unlock()
+
+	return truncatedBody{r: io.LimitReader(rc, limit), c: rc}
+}
+
+const maxTruncation = 4096
+
+// truncatedBody is a response body that ends early,
+// with [io.ErrUnexpectedEOF] instead of [io.EOF].
+type truncatedBody struct {
+	r io.Reader
+	c io.Closer
+}
+
+func (b truncatedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (b truncatedBody) Close() error {
+	return b.c.Close()
+}
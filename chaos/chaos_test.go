@@ -0,0 +1,100 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bobg/goproxyclient"
+	"github.com/bobg/goproxyclient/fake"
+)
+
+func TestChaos(t *testing.T) {
+	ctx := context.Background()
+
+	f := fake.New()
+	big := make([]byte, 2*maxTruncation)
+	for _, mod := range []string{"example.com/a", "example.com/b"} {
+		if err := f.Add(mod, "v1.0.0", time.Now(), nil, map[string]string{"big.txt": string(big)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("error", func(t *testing.T) {
+		c := New(f, WithError("example.com/a", http.StatusGone))
+
+		_, err := c.List(ctx, "example.com/a")
+		if !goproxyclient.IsNotFound(err) {
+			t.Errorf("got %v, want not-found error", err)
+		}
+		if _, err := c.List(ctx, "example.com/b"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("error_func", func(t *testing.T) {
+		c := New(f, WithErrorFunc(func(op, mod, ver string) error {
+			if op == "Zip" {
+				return goproxyclient.ErrProxyOff
+			}
+			return nil
+		}))
+
+		if _, err := c.Zip(ctx, "example.com/a", "v1.0.0"); !errors.Is(err, goproxyclient.ErrProxyOff) {
+			t.Errorf("got %v, want ErrProxyOff", err)
+		}
+		if _, _, _, err := c.Info(ctx, "example.com/a", "v1.0.0"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("flakiness", func(t *testing.T) {
+		c := New(f, WithFlakiness(0.5, http.StatusBadGateway), WithSeed(1))
+
+		var failures int
+		for range 100 {
+			if _, err := c.List(ctx, "example.com/a"); err != nil {
+				var codeErr goproxyclient.CodeErr
+				if !errors.As(err, &codeErr) || codeErr.Code() != http.StatusBadGateway {
+					t.Fatalf("got %v, want 502 error", err)
+				}
+				failures++
+			}
+		}
+		if failures < 30 || failures > 70 {
+			t.Errorf("got %d failures out of 100, want about 50", failures)
+		}
+	})
+
+	t.Run("truncation", func(t *testing.T) {
+		c := New(f, WithTruncation(1))
+
+		rc, err := c.Zip(ctx, "example.com/a", "v1.0.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+
+		data, err := io.ReadAll(rc)
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("got %v, want io.ErrUnexpectedEOF", err)
+		}
+		if len(data) >= maxTruncation {
+			t.Errorf("got %d bytes, want fewer than %d", len(data), maxTruncation)
+		}
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		c := New(f, WithLatency(time.Hour, 0))
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		if _, err := c.List(ctx, "example.com/a"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want context.DeadlineExceeded", err)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// ChecksumDB is a trusted source of module hashes,
+// such as a checksum database.
+// The DB type in the sumdb subpackage
+// (github.com/bobg/goproxyclient/sumdb)
+// implements it,
+// as does [sumdb.Client].
+type ChecksumDB interface {
+	// Lookup returns the go.sum lines for the given module path and version,
+	// or for its go.mod file if ver has the suffix /go.mod.
+	// Each line is "MODPATH VERSION HASH".
+	// A module that is not to be looked up yields [sumdb.ErrGONOSUMDB].
+	Lookup(mod, ver string) ([]string, error)
+}
+
+// WithChecksumDB is an [Option] that makes the client verify the go.mod and zip files it fetches
+// (with [Client.Mod] and [Client.Zip],
+// and in operations built on them)
+// against the hashes in db,
+// as the go command does with sum.golang.org.
+// A file that does not match fails with a [*HashMismatchError].
+// A module for which db returns [sumdb.ErrGONOSUMDB] is not verified.
+//
+// If the client also has a [VerifiedDB] (see [WithVerifiedDB]),
+// hashes recorded there are used without consulting db,
+// and hashes from db are recorded there once files match them.
+func WithChecksumDB(db ChecksumDB) Option {
+	return func(c *config) {
+		c.checksumDB = db
+	}
+}
+
+// ExpectedHash returns the known hash for a module version
+// (or its go.mod file, if ver has the suffix /go.mod)
+// from the client's [GoSum],
+// or its [VerifiedDB],
+// or, failing those, its [ChecksumDB].
+// The first boolean result is false if there is no known hash.
+// The second is true if the hash came from the ChecksumDB
+// and should be recorded in the VerifiedDB once verified.
+func (cl Client) expectedHash(mod, ver string) (string, bool, bool, error) {
+	if h, ok := cl.goSumHash(mod, ver); ok {
+		return h, true, false, nil
+	}
+	if db := cl.conf.verified; db != nil {
+		if h, ok := db.Lookup(mod, ver); ok {
+			return h, true, false, nil
+		}
+	}
+	if cl.conf.checksumDB == nil {
+		return "", false, false, nil
+	}
+
+	lines, err := cl.conf.checksumDB.Lookup(mod, ver)
+	if errors.Is(err, sumdb.ErrGONOSUMDB) {
+		return "", false, false, nil
+	}
+	if err != nil {
+		return "", false, false, errors.Wrapf(err, "looking up %s %s in checksum database", mod, ver)
+	}
+
+	// Use the first hash that the client can check.
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != mod || fields[1] != ver {
+			continue
+		}
+		alg, _, _ := strings.Cut(fields[2], ":")
+		if strings.HasSuffix(ver, "/go.mod") && alg != "h1" {
+			continue
+		}
+		if cl.conf.hasher(alg) != nil {
+			return fields[2], true, true, nil
+		}
+	}
+	return "", false, false, fmt.Errorf("no usable hash for %s %s in checksum database", mod, ver)
+}
+
+// RecordHash adds a verified hash from the client's [ChecksumDB] to its [VerifiedDB],
+// if it has one.
+func (cl Client) recordHash(mod, ver, hash string) error {
+	if cl.conf.verified == nil {
+		return nil
+	}
+	return errors.Wrapf(cl.conf.verified.Add(mod, ver, hash), "recording hash for %s %s", mod, ver)
+}
+
+// VerifyModFile checks the contents of a go.mod file
+// against its known hash,
+// if there is one
+// (see [Client.expectedHash]).
+// It reports whether there was a hash to check against.
+func (cl Client) verifyModFile(mod, ver string, data []byte) (bool, error) {
+	modVer := ver + "/go.mod"
+	want, ok, record, err := cl.expectedHash(mod, modVer)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	got, err := hashModFile(data)
+	if err != nil {
+		return false, errors.Wrapf(err, "hashing go.mod for %s@%s", mod, ver)
+	}
+	if got != want {
+		return false, &HashMismatchError{Module: mod, Version: modVer, Want: want, Got: got}
+	}
+	if record {
+		return true, cl.recordHash(mod, modVer, got)
+	}
+	return true, nil
+}
+
+// HashModFile returns the hash of a go.mod file's contents
+// in the form used in go.sum files.
+func hashModFile(data []byte) (string, error) {
+	return dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+}
+
+// HasHashes tells whether the client has a source of known hashes
+// for verifying downloads.
+func (cl Client) hasHashes() bool {
+	return cl.conf.verified != nil || cl.conf.checksumDB != nil || cl.conf.goSum != nil
+}
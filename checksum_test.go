@@ -0,0 +1,238 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// mapChecksumDB is a [ChecksumDB] holding hashes in memory.
+// A nil entry means the module is not to be looked up.
+type mapChecksumDB map[string]*string
+
+func (m mapChecksumDB) Lookup(mod, ver string) ([]string, error) {
+	h, ok := m[mod+" "+ver]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	if h == nil {
+		return nil, sumdb.ErrGONOSUMDB
+	}
+	return []string{mod + " " + ver + " " + *h}, nil
+}
+
+func TestChecksumDB(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	ctx := context.Background()
+
+	zipHash, err := dirhash.HashZip(filepath.Join("testdata", "example.com", "multi", "@v", "v1.0.0.zip"), dirhash.Hash1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modHash, err := dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join("testdata", "example.com", "multi", "@v", "v1.0.0.mod"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bogus := "h1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+	fetch := func(cl Client) (modErr, zipErr error) {
+		rc, err := cl.Mod(ctx, "example.com/multi", "v1.0.0")
+		if err == nil {
+			_, err = io.ReadAll(rc)
+			rc.Close()
+		}
+		modErr = err
+
+		rc, err = cl.Zip(ctx, "example.com/multi", "v1.0.0")
+		if err == nil {
+			_, err = io.ReadAll(rc)
+			rc.Close()
+		}
+		return modErr, err
+	}
+
+	t.Run("match", func(t *testing.T) {
+		vdb, err := OpenVerifiedDB(filepath.Join(t.TempDir(), "verified.sum"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		db := mapChecksumDB{
+			"example.com/multi v1.0.0":        &zipHash,
+			"example.com/multi v1.0.0/go.mod": &modHash,
+		}
+		cl := New(s.URL, nil, WithChecksumDB(db), WithVerifiedDB(vdb))
+
+		modErr, zipErr := fetch(cl)
+		if modErr != nil {
+			t.Fatal(modErr)
+		}
+		if zipErr != nil {
+			t.Fatal(zipErr)
+		}
+
+		if h, ok := vdb.Lookup("example.com/multi", "v1.0.0"); !ok || h != zipHash {
+			t.Errorf("got recorded zip hash %q, %v; want %s", h, ok, zipHash)
+		}
+		if h, ok := vdb.Lookup("example.com/multi", "v1.0.0/go.mod"); !ok || h != modHash {
+			t.Errorf("got recorded go.mod hash %q, %v; want %s", h, ok, modHash)
+		}
+
+		// Now the recorded hashes suffice.
+		cl = New(s.URL, nil, WithChecksumDB(mapChecksumDB{}), WithVerifiedDB(vdb))
+		modErr, zipErr = fetch(cl)
+		if modErr != nil {
+			t.Fatal(modErr)
+		}
+		if zipErr != nil {
+			t.Fatal(zipErr)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		db := mapChecksumDB{
+			"example.com/multi v1.0.0":        &bogus,
+			"example.com/multi v1.0.0/go.mod": &bogus,
+		}
+		cl := New(s.URL, nil, WithChecksumDB(db))
+
+		modErr, zipErr := fetch(cl)
+		var mismatch *HashMismatchError
+		if !errors.As(modErr, &mismatch) || mismatch.Version != "v1.0.0/go.mod" {
+			t.Errorf("got %v for go.mod, want hash mismatch", modErr)
+		}
+		if !errors.As(zipErr, &mismatch) || mismatch.Version != "v1.0.0" {
+			t.Errorf("got %v for zip, want hash mismatch", zipErr)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		cl := New(s.URL, nil, WithChecksumDB(mapChecksumDB{}))
+
+		modErr, zipErr := fetch(cl)
+		if modErr == nil || zipErr == nil {
+			t.Errorf("got errors %v, %v; want lookup failures", modErr, zipErr)
+		}
+	})
+
+	t.Run("gonosumdb", func(t *testing.T) {
+		db := mapChecksumDB{
+			"example.com/multi v1.0.0":        nil,
+			"example.com/multi v1.0.0/go.mod": nil,
+		}
+		cl := New(s.URL, nil, WithChecksumDB(db))
+
+		modErr, zipErr := fetch(cl)
+		if modErr != nil {
+			t.Fatal(modErr)
+		}
+		if zipErr != nil {
+			t.Fatal(zipErr)
+		}
+	})
+}
+
+func TestChecksumDBRefetch(t *testing.T) {
+	ctx := context.Background()
+
+	// A proxy that serves tampered files the first time they are requested.
+	errorsZip, err := testdata.ReadFile("testdata/github.com/bobg/errors/@v/v1.1.0.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		mu       sync.Mutex
+		requests = make(map[string]int)
+	)
+	h := testHandler(nil)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		requests[req.URL.Path]++
+		n := requests[req.URL.Path]
+		mu.Unlock()
+
+		if n == 1 {
+			switch {
+			case strings.HasSuffix(req.URL.Path, ".mod"):
+				io.WriteString(w, "module example.com/evil\n")
+				return
+			case strings.HasSuffix(req.URL.Path, ".zip"):
+				w.Write(errorsZip)
+				return
+			}
+		}
+		h.ServeHTTP(w, req)
+	}))
+	defer s.Close()
+
+	zipHash, err := dirhash.HashZip(filepath.Join("testdata", "example.com", "multi", "@v", "v1.0.0.zip"), dirhash.Hash1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modData, err := os.ReadFile(filepath.Join("testdata", "example.com", "multi", "@v", "v1.0.0.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modHash, err := hashModFile(modData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := mapChecksumDB{
+		"example.com/multi v1.0.0":        &zipHash,
+		"example.com/multi v1.0.0/go.mod": &modHash,
+	}
+	cl := New(s.URL, nil, WithChecksumDB(db), WithCache(t.TempDir(), 0))
+
+	fetch := func() (modErr, zipErr error) {
+		rc, err := cl.Mod(ctx, "example.com/multi", "v1.0.0")
+		if err == nil {
+			_, err = io.ReadAll(rc)
+			rc.Close()
+		}
+		modErr = err
+
+		rc, err = cl.Zip(ctx, "example.com/multi", "v1.0.0")
+		if err == nil {
+			_, err = io.ReadAll(rc)
+			rc.Close()
+		}
+		return modErr, err
+	}
+
+	modErr, zipErr := fetch()
+	var mismatch *HashMismatchError
+	if !errors.As(modErr, &mismatch) {
+		t.Errorf("got %v for tampered go.mod, want hash mismatch", modErr)
+	}
+	if !errors.As(zipErr, &mismatch) {
+		t.Errorf("got %v for tampered zip, want hash mismatch", zipErr)
+	}
+
+	// The rejected files are not served from the cache,
+	// but fetched again.
+	modErr, zipErr = fetch()
+	if modErr != nil {
+		t.Errorf("fetching go.mod again: %v", modErr)
+	}
+	if zipErr != nil {
+		t.Errorf("fetching zip again: %v", zipErr)
+	}
+	for _, path := range []string{"/example.com/multi/@v/v1.0.0.mod", "/example.com/multi/@v/v1.0.0.zip"} {
+		if n := requests[path]; n != 2 {
+			t.Errorf("got %d requests for %s, want 2", n, path)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package goproxyclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/sumdb"
+)
+
+// ErrorClass is a broad category of error from a [Client] operation,
+// as determined by [Classify].
+type ErrorClass int
+
+const (
+	// NoError is the class of a nil error.
+	NoError ErrorClass = 0
+
+	// OtherError is the class of errors that fit no other class.
+	OtherError ErrorClass = 1
+
+	// NotFound means a proxy reported that the module or version does not exist
+	// (see [IsNotFound]).
+	NotFound ErrorClass = 2
+
+	// AuthFailed means a proxy rejected the client's credentials,
+	// or required some
+	// (with 401 Unauthorized or 403 Forbidden).
+	AuthFailed ErrorClass = 3
+
+	// RateLimited means a proxy refused a request because of its rate limits
+	// (with 429 Too Many Requests, or 503 Service Unavailable and a Retry-After header).
+	// See [WithRetryAfter].
+	RateLimited ErrorClass = 4
+
+	// Timeout means the operation ran out of time:
+	// its context's deadline passed,
+	// one of the client's [Timeouts] was exceeded,
+	// or a download stalled (see [ErrIdleTimeout]).
+	Timeout ErrorClass = 5
+
+	// Canceled means the operation's context was canceled,
+	// or the client was shut down (see [ErrShutdown]).
+	Canceled ErrorClass = 6
+
+	// ProxyBroken means a proxy could not be reached or failed on its end:
+	// a network error,
+	// a 5xx status,
+	// or an open circuit breaker (see [ErrCircuitOpen]).
+	ProxyBroken ErrorClass = 7
+
+	// VerifyFailed means downloaded content did not match its known hash
+	// (see [HashMismatchError]),
+	// or the checksum database misbehaved.
+	VerifyFailed ErrorClass = 8
+
+	// PolicyDenied means the client's own configuration refused the operation:
+	// a [Scanner] or [Policy] rejected it (see [PolicyError]),
+	// or it reached an "off" entry in the client's proxies (see [ErrProxyOff]).
+	PolicyDenied ErrorClass = 9
+
+	// UnsafeContent means a zip file was rejected as unsafe to extract
+	// (see [ExtractLimitError] and [UnsafePathError]).
+	UnsafeContent ErrorClass = 10
+
+	// NoSpace means there was not enough disk space
+	// (see [InsufficientSpaceError]).
+	NoSpace ErrorClass = 11
+)
+
+var errorClassNames = map[ErrorClass]string{
+	NoError:       "no error",
+	OtherError:    "other error",
+	NotFound:      "not found",
+	AuthFailed:    "authentication failed",
+	RateLimited:   "rate limited",
+	Timeout:       "timeout",
+	Canceled:      "canceled",
+	ProxyBroken:   "proxy broken",
+	VerifyFailed:  "verification failed",
+	PolicyDenied:  "denied by policy",
+	UnsafeContent: "unsafe content",
+	NoSpace:       "insufficient disk space",
+}
+
+func (c ErrorClass) String() string {
+	if s, ok := errorClassNames[c]; ok {
+		return s
+	}
+	return "unknown error class"
+}
+
+// Classify returns the [ErrorClass] of err,
+// an error from a [Client] operation,
+// so that callers can decide how to handle the many kinds of error the client can produce
+// (whether to retry, for example,
+// or what to tell the user)
+// without knowing all of their types.
+//
+// An error may fit more than one class,
+// as when a proxy's error response arrives after the operation's deadline has passed.
+// Classify prefers the classes describing the client's own decisions
+// (VerifyFailed, PolicyDenied, UnsafeContent, NoSpace)
+// to Timeout and Canceled,
+// and those to the classes describing a proxy's response.
+// For a [FallbackError],
+// only the error from the last proxy tried is considered
+// (as for [IsNotFound]).
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return NoError
+	}
+
+	var (
+		mismatch  *HashMismatchError
+		policyErr *PolicyError
+		limitErr  *ExtractLimitError
+		unsafeErr *UnsafePathError
+		spaceErr  InsufficientSpaceError
+		netErr    net.Error
+		codeErr   CodeErr
+		retryErr  retryAfterError
+		urlErr    *url.Error
+	)
+
+	switch {
+	case errors.As(err, &mismatch), errors.Is(err, sumdb.ErrSecurity):
+		return VerifyFailed
+
+	case errors.As(err, &policyErr), errors.Is(err, ErrProxyOff):
+		return PolicyDenied
+
+	case errors.As(err, &limitErr), errors.As(err, &unsafeErr):
+		return UnsafeContent
+
+	case errors.As(err, &spaceErr):
+		return NoSpace
+
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrIdleTimeout):
+		return Timeout
+
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return Timeout
+
+	case errors.Is(err, context.Canceled), errors.Is(err, ErrShutdown):
+		return Canceled
+	}
+
+	if errors.As(err, &codeErr) {
+		switch code := codeErr.Code(); {
+		case code == http.StatusNotFound || code == http.StatusGone:
+			return NotFound
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return AuthFailed
+		case code == http.StatusTooManyRequests:
+			return RateLimited
+		case code == http.StatusServiceUnavailable && errors.As(err, &retryErr):
+			return RateLimited
+		case code >= 500:
+			return ProxyBroken
+		}
+	}
+
+	if errors.Is(err, ErrCircuitOpen) || errors.As(err, &urlErr) {
+		return ProxyBroken
+	}
+
+	return OtherError
+}
@@ -0,0 +1,85 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/bobg/mid"
+	"golang.org/x/mod/sumdb"
+)
+
+func TestClassify(t *testing.T) {
+	codeErr := func(code int) error {
+		return mid.CodeErr{C: code, Err: fmt.Errorf("GET x: %s", http.StatusText(code))}
+	}
+	netErr := &url.Error{Op: "Get", URL: "https://proxy.example", Err: errors.New("connection refused")}
+
+	cases := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"nil", nil, NoError},
+		{"other", errors.New("oops"), OtherError},
+		{"404", codeErr(http.StatusNotFound), NotFound},
+		{"410", codeErr(http.StatusGone), NotFound},
+		{"401", codeErr(http.StatusUnauthorized), AuthFailed},
+		{"403", codeErr(http.StatusForbidden), AuthFailed},
+		{"429", codeErr(http.StatusTooManyRequests), RateLimited},
+		{"503_retry_after", mid.CodeErr{C: http.StatusServiceUnavailable, Err: retryAfterError{error: errors.New("busy")}}, RateLimited},
+		{"503", codeErr(http.StatusServiceUnavailable), ProxyBroken},
+		{"500", codeErr(http.StatusInternalServerError), ProxyBroken},
+		{"400", codeErr(http.StatusBadRequest), OtherError},
+		{"network", netErr, ProxyBroken},
+		{"circuit_open", fmt.Errorf("skipping x: %w", ErrCircuitOpen), ProxyBroken},
+		{"deadline", fmt.Errorf("in GET x: %w", context.DeadlineExceeded), Timeout},
+		{"idle", ErrIdleTimeout, Timeout},
+		{"canceled", &url.Error{Op: "Get", URL: "x", Err: context.Canceled}, Canceled},
+		{"shutdown", ErrShutdown, Canceled},
+		{"mismatch", &HashMismatchError{Module: "m", Version: "v1.0.0"}, VerifyFailed},
+		{"sumdb_security", fmt.Errorf("looking up m: %w", sumdb.ErrSecurity), VerifyFailed},
+		{"policy", &PolicyError{Err: errors.New("denied")}, PolicyDenied},
+		{"off", ErrProxyOff, PolicyDenied},
+		{"limit", &ExtractLimitError{Limit: "file count"}, UnsafeContent},
+		{"unsafe_path", &UnsafePathError{File: "../x", Err: errors.New("escapes")}, UnsafeContent},
+		{"space", InsufficientSpaceError{Dir: "/tmp"}, NoSpace},
+
+		// Only the last proxy's error counts.
+		{"fallback_not_found", FallbackError{Errs: []error{netErr, codeErr(http.StatusNotFound)}}, NotFound},
+		{"fallback_broken", FallbackError{Errs: []error{codeErr(http.StatusNotFound), netErr}}, ProxyBroken},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Classify(tc.err); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("client", func(t *testing.T) {
+		ctx := context.Background()
+
+		s := httptest.NewServer(testHandler(map[string]int{"example.com/private/": http.StatusUnauthorized}))
+		defer s.Close()
+
+		cl := New(s.URL, nil)
+		if _, err := cl.List(ctx, "example.com/nonexistent"); Classify(err) != NotFound {
+			t.Errorf("got %v (%s), want %s", err, Classify(err), NotFound)
+		}
+		if _, err := cl.List(ctx, "example.com/private"); Classify(err) != AuthFailed {
+			t.Errorf("got %v (%s), want %s", err, Classify(err), AuthFailed)
+		}
+
+		canceledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := cl.List(canceledCtx, "example.com/multi"); Classify(err) != Canceled {
+			t.Errorf("got %v (%s), want %s", err, Classify(err), Canceled)
+		}
+	})
+}
@@ -2,24 +2,68 @@
 package goproxyclient
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
 	"iter"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/bobg/errors"
 	"github.com/bobg/mid"
 	"golang.org/x/mod/module"
+
+	"github.com/bobg/goproxyclient/internal/protocol"
 )
 
 // Client is a client for talking to a sequence of one or more Go module proxies.
 // Create one with [New].
+//
+// A Client is safe for concurrent use by multiple goroutines,
+// including the state it shares among its copies
+// (such as rate limiters and a [VerifiedDB]).
+// Values supplied with options
+// (such as an [Authenticator], [Scanner], or [Hasher])
+// must be safe for concurrent use too.
+//
+// Concurrent identical info, @latest, list, and go.mod requests
+// (from any copies of the client)
+// are coalesced:
+// only one of them is sent to the proxies,
+// and all of the callers share its result.
+// [Trace] hooks are called only for the request actually sent.
+//
+// Copies of a Client share its list of proxies,
+// which can be replaced with [Client.Reload].
 type Client struct {
+	conf *config
+}
+
+// Chain is a client's list of proxies.
+// It is replaced as a whole by [Client.Reload],
+// so an operation that has loaded it
+// can finish with it
+// even if it is replaced in the meantime.
+type chain struct {
 	first single
 	rest  []nextSingle
+
+	// Conf is the configuration from which the singles were made.
+	// Its fields affecting individual requests
+	// (see [Client.Reload])
+	// take precedence over those in the client's config.
+	conf *config
+
+	// ByTenant tells whether requests to any of the proxies
+	// depend on the tenant of the operation
+	// (see [WithTenantHeader] and [WithTenantRateLimit]),
+	// so that operations for different tenants must not share them
+	// (see [coalesce]).
+	byTenant bool
 }
 
 type nextSingle struct {
@@ -27,54 +71,90 @@ type nextSingle struct {
 	afterAnyErr bool
 }
 
+// Chain returns the client's current list of proxies.
+func (cl Client) chain() *chain {
+	return cl.conf.chain.Load()
+}
+
 // New creates a new [Client] talking to a sequence of one or more Go module proxies.
 //
-// It calls [Parse] on the input string to get the sequence of proxies,
-// ignoring any "direct," "off," or empty entries.
-// If no proxies are specified,
-// it uses https://proxy.golang.org by default.
+// It parses the input string as [ParseConfig] does,
+// but without rejecting invalid entries,
+// and passes the result to [NewFromConfig].
 //
 // If hc is non-nil, it will use that HTTP client for all requests,
 // otherwise it will use a default HTTP client
 // (but a distinct one from [http.DefaultClient]).
-func New(goproxy string, hc *http.Client) Client {
-	seq := Parse(goproxy)
-	next, stop := iter.Pull2(seq)
-	defer stop()
+//
+// Further behavior can be customized with [Option] values.
+func New(goproxy string, hc *http.Client, opts ...Option) Client {
+	entries, _ := parseConfig(goproxy, false)
+	return NewFromConfig(entries, hc, opts...)
+}
+
+// NewFromConfig creates a new [Client] talking to the Go module proxies in entries,
+// which typically come from [ParseConfig].
+// An entry for "direct" means fetching modules directly from their version control repositories,
+// as the go command does
+// (see [WithVCSPolicy] and [WithVCSCacheDir]);
+// only git repositories are supported,
+// and the git command must be installed.
+// An entry for "off" makes any request that reaches it fail with [ErrProxyOff],
+// and entries after it are never reached.
+// If a request falls back from one entry to the next
+// and all of the entries tried fail,
+// the error is a [FallbackError].
+// If no proxies are specified,
+// it uses https://proxy.golang.org by default.
+//
+// The hc and opts arguments are as for [New].
+func NewFromConfig(entries []ProxyEntry, hc *http.Client, opts ...Option) Client {
+	conf := &config{requestIDHeader: DefaultRequestIDHeader, hc: hc}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	conf.cache = newDiskCache(conf)
+	conf.chain.Store(newChain(entries, hc, conf))
 
-	var first single
+	return Client{conf: conf}
+}
 
-	for {
-		val, _, ok := next()
-		if !ok {
-			return Client{first: newSingle("https://proxy.golang.org", hc)}
-		}
-		switch val {
-		case "direct", "off", "":
-			continue
+// NewChain makes a [chain] from entries and conf,
+// as described at [NewFromConfig].
+func newChain(entries []ProxyEntry, hc *http.Client, conf *config) *chain {
+	if slices.ContainsFunc(entries, ProxyEntry.IsDirect) {
+		dhc := hc
+		if dhc == nil {
+			dhc = &http.Client{}
 		}
-		first = newSingle(val, hc)
-		break
+		conf.direct = newDirect(conf.clientFor("direct", dhc), conf)
 	}
 
-	var rest []nextSingle
-
-	for {
-		val, afterAnyErr, ok := next()
-		if !ok {
-			break
+	var (
+		ch    = &chain{conf: conf}
+		found bool
+	)
+	for _, entry := range entries {
+		s := newSingle(entry.URL, hc, conf)
+		if found {
+			ch.rest = append(ch.rest, nextSingle{client: s, afterAnyErr: entry.AfterAnyErr})
+		} else {
+			ch.first, found = s, true
 		}
-		switch val {
-		case "direct", "off", "":
-			continue
+		if entry.IsOff() {
+			// Nothing after "off" can be reached.
+			break
 		}
-		rest = append(rest, nextSingle{
-			client:      newSingle(val, hc),
-			afterAnyErr: afterAnyErr,
-		})
+	}
+	if !found {
+		ch.first = newSingle("https://proxy.golang.org", hc, conf)
 	}
 
-	return Client{first: first, rest: rest}
+	ch.byTenant = ch.first.byTenant()
+	for _, next := range ch.rest {
+		ch.byTenant = ch.byTenant || next.client.byTenant()
+	}
+	return ch
 }
 
 // Parse parses a GOPROXY string structured as described at https://go.dev/ref/mod#goproxy-protocol:
@@ -107,24 +187,83 @@ func Parse(goproxy string) iter.Seq2[string, bool] {
 	}
 }
 
-func (cl Client) loop(errptr *error, f func(single)) {
-	f(cl.first)
-	if *errptr == nil {
-		return
+// Loop calls f on the client's proxies in order,
+// falling back from one to the next according to the rules described at [Parse],
+// and returns the first successful result.
+// In race mode (see [WithRace]),
+// it calls f on them concurrently instead
+// (see [race]);
+// with hedging (see [WithHedge]),
+// it may call f on the next proxy before the current one is done
+// (see [hedge]).
+func loop[T any](ctx context.Context, cl Client, f func(context.Context, single) (T, error)) (T, error) {
+	var zero T
+
+	done, err := cl.work().begin()
+	if err != nil {
+		return zero, err
 	}
-	for _, next := range cl.rest {
-		if !next.afterAnyErr {
-			if !IsNotFound(*errptr) {
-				return
-			}
+	defer done()
+
+	ch := cl.chain()
+	if cl.conf.race && len(ch.rest) > 0 {
+		return race(ctx, ch, f)
+	}
+	if cl.conf.hedge > 0 && len(ch.rest) > 0 {
+		return hedge(ctx, ch, cl.conf.hedge, f)
+	}
+
+	t := traceFrom(ctx)
+
+	var errs []error // from the proxies that have failed so far
+
+	s := ch.first
+	for i := 0; ; i++ {
+		t.proxyStart(s.baseURL)
+		val, err := call(ctx, s, f)
+
+		var next *single
+		if err != nil && i < len(ch.rest) && (ch.rest[i].afterAnyErr || IsNotFound(err)) {
+			next = &ch.rest[i].client
 		}
-		f(next.client) // will update *errptr
-		if *errptr == nil {
-			return
+		if next == nil {
+			t.proxyDone(s.baseURL, err, "")
+			switch {
+			case err == nil:
+				provenanceFrom(ctx).set(s.baseURL)
+			case len(errs) > 0:
+				err = FallbackError{Errs: append(errs, errors.Wrapf(err, "proxy %s", s.baseURL))}
+			}
+			return val, err
 		}
+		t.proxyDone(s.baseURL, err, next.baseURL)
+		errs = append(errs, errors.Wrapf(err, "proxy %s", s.baseURL))
+		s = *next
 	}
 }
 
+// FallbackError is the error from a [Client] operation
+// in which more than one proxy was tried and all of them failed.
+// Its message includes the errors from all of the proxies,
+// but it unwraps to the error from the last one,
+// so that (for example) [IsNotFound] is true of it
+// only if the last proxy tried reported that the module or version was not found.
+type FallbackError struct {
+	// Errs are the errors from the proxies tried, in order,
+	// each wrapped with the base URL of its proxy
+	// (or "direct" or "off").
+	Errs []error
+}
+
+func (e FallbackError) Error() string {
+	return "all proxies failed:\n" + errors.Join(e.Errs...).Error()
+}
+
+// Unwrap returns the error from the last proxy tried.
+func (e FallbackError) Unwrap() error {
+	return e.Errs[len(e.Errs)-1]
+}
+
 // Info gets information about a specific version of a Go module.
 // A Go module proxy produces a JSON object with Version and Time fields,
 // and possibly others.
@@ -145,6 +284,12 @@ func (cl Client) Info(ctx context.Context, mod, ver string) (string, time.Time,
 		err          error
 	)
 
+	if err := cl.checkModulePolicy(mod, ver, "info"); err != nil {
+		return "", tm, nil, err
+	}
+
+	origVer := ver
+
 	mod, err = module.EscapePath(mod)
 	if err != nil {
 		return "", tm, nil, errors.Wrap(err, "escaping module path")
@@ -154,15 +299,40 @@ func (cl Client) Info(ctx context.Context, mod, ver string) (string, time.Time,
 		return "", tm, nil, errors.Wrap(err, "escaping module version")
 	}
 
-	cl.loop(&err, func(s single) {
-		canonicalVer, tm, j, err = s.info(ctx, mod, ver)
+	cacheable := immutable(origVer)
+	if cacheable {
+		if data, ok := cl.conf.cache.get(ctx, cl.cacheKey(ctx, cacheName(mod, ver, "info")), false); ok {
+			if canonicalVer, tm, j, err := protocol.ParseInfo(data); err == nil {
+				return canonicalVer, tm, j, nil
+			}
+		}
+	}
+
+	ctx = ensureRequestID(ctx)
+	ctx, cancel := cl.metadataContext(ctx)
+	defer cancel()
+	var r infoResult
+	r, err = coalesce(ctx, cl, "info "+mod+"@"+ver, func(ctx context.Context) (infoResult, error) {
+		return loop(ctx, cl, func(ctx context.Context, s single) (infoResult, error) {
+			return newInfoResult(s.info(ctx, mod, ver))
+		})
 	})
+	canonicalVer, tm, j = r.ver, r.tm, maps.Clone(r.m)
+
+	if err == nil && cacheable && canonicalVer == origVer {
+		if data, err := json.Marshal(j); err == nil {
+			cl.conf.cache.put(ctx, cl.cacheKey(ctx, cacheName(mod, ver, "info")), data, false)
+		}
+	}
 
 	return canonicalVer, tm, j, err
 }
 
 // Latest gets info about the latest version of a Go module.
 // Its return values are the same as for [Client.Info].
+//
+// Normally the result comes from the first proxy able to answer,
+// but see [WithHighestLatest].
 func (cl Client) Latest(ctx context.Context, mod string) (string, time.Time, map[string]json.RawMessage, error) {
 	var (
 		canonicalVer string
@@ -171,14 +341,40 @@ func (cl Client) Latest(ctx context.Context, mod string) (string, time.Time, map
 		err          error
 	)
 
+	if err := cl.checkModulePolicy(mod, "", "latest"); err != nil {
+		return "", tm, nil, err
+	}
+
 	mod, err = module.EscapePath(mod)
 	if err != nil {
 		return "", tm, nil, errors.Wrap(err, "escaping module path")
 	}
 
-	cl.loop(&err, func(s single) {
-		canonicalVer, tm, j, err = s.latest(ctx, mod)
+	if data, ok := cl.conf.cache.get(ctx, cl.cacheKey(ctx, mod+"/@latest"), true); ok {
+		if canonicalVer, tm, j, err := protocol.ParseInfo(data); err == nil {
+			return canonicalVer, tm, j, nil
+		}
+	}
+
+	ctx = ensureRequestID(ctx)
+	ctx, cancel := cl.metadataContext(ctx)
+	defer cancel()
+	var r infoResult
+	r, err = coalesce(ctx, cl, "latest "+mod, func(ctx context.Context) (infoResult, error) {
+		if cl.conf.highestLatest {
+			return cl.highestLatest(ctx, mod)
+		}
+		return loop(ctx, cl, func(ctx context.Context, s single) (infoResult, error) {
+			return newInfoResult(s.latest(ctx, mod))
+		})
 	})
+	canonicalVer, tm, j = r.ver, r.tm, maps.Clone(r.m)
+
+	if err == nil {
+		if data, err := json.Marshal(j); err == nil {
+			cl.conf.cache.put(ctx, cl.cacheKey(ctx, mod+"/@latest"), data, true)
+		}
+	}
 
 	return canonicalVer, tm, j, err
 }
@@ -186,31 +382,79 @@ func (cl Client) Latest(ctx context.Context, mod string) (string, time.Time, map
 // List lists the available versions of a Go module.
 // The result is sorted in semver order
 // (see [semver.Sort]).
+//
+// Normally the result comes from the first proxy able to answer,
+// but see [WithListUnion].
 func (cl Client) List(ctx context.Context, mod string) ([]string, error) {
 	var (
 		versions []string
 		err      error
 	)
 
+	if err := cl.checkModulePolicy(mod, "", "list"); err != nil {
+		return nil, err
+	}
+
 	mod, err = module.EscapePath(mod)
 	if err != nil {
 		return nil, errors.Wrap(err, "escaping module path")
 	}
 
-	cl.loop(&err, func(s single) {
-		versions, err = s.list(ctx, mod)
+	if data, ok := cl.conf.cache.get(ctx, cl.cacheKey(ctx, mod+"/@v/list"), true); ok {
+		if versions, err := protocol.ParseList(bytes.NewReader(data)); err == nil {
+			return versions, nil
+		}
+	}
+
+	ctx = ensureRequestID(ctx)
+	ctx, cancel := cl.metadataContext(ctx)
+	defer cancel()
+	versions, err = coalesce(ctx, cl, "list "+mod, func(ctx context.Context) ([]string, error) {
+		if cl.conf.listUnion {
+			return cl.listUnion(ctx, mod)
+		}
+		return loop(ctx, cl, func(ctx context.Context, s single) ([]string, error) {
+			return s.list(ctx, mod)
+		})
 	})
+	versions = slices.Clone(versions)
+
+	if err == nil {
+		data := strings.Join(versions, "\n") + "\n"
+		cl.conf.cache.put(ctx, cl.cacheKey(ctx, mod+"/@v/list"), []byte(data), true)
+	}
 
 	return versions, err
 }
 
 // Mod gets the go.mod file for a specific version of a Go module.
+// The caller must close the result.
+// If ctx is canceled before the caller is done reading,
+// reads fail with the context's error.
+//
+// If the client has any [Scanner]s (see [WithScanner]),
+// a [ChecksumDB] (see [WithChecksumDB]),
+// or a [GoSum] (see [WithGoSum]),
+// the file is read into memory and checked before it is returned.
 func (cl Client) Mod(ctx context.Context, mod, ver string) (io.ReadCloser, error) {
+	if len(cl.conf.scanners) > 0 || cl.conf.checksumDB != nil || cl.conf.goSum != nil {
+		return cl.scannedMod(ctx, mod, ver)
+	}
+	return cl.fetchMod(ctx, mod, ver)
+}
+
+func (cl Client) fetchMod(ctx context.Context, mod, ver string) (io.ReadCloser, error) {
 	var (
 		rc  io.ReadCloser
 		err error
 	)
 
+	if err := cl.checkModulePolicy(mod, ver, "mod"); err != nil {
+		return nil, err
+	}
+
+	cacheable := immutable(ver) && cl.conf.cache != nil
+
 	mod, err = module.EscapePath(mod)
 	if err != nil {
 		return nil, errors.Wrap(err, "escaping module path")
@@ -220,20 +464,77 @@ func (cl Client) Mod(ctx context.Context, mod, ver string) (io.ReadCloser, error
 		return nil, errors.Wrap(err, "escaping module version")
 	}
 
-	cl.loop(&err, func(s single) {
-		rc, err = s.mod(ctx, mod, ver)
+	if cacheable {
+		if rc, ok := cl.conf.cache.open(ctx, cl.cacheKey(ctx, cacheName(mod, ver, "mod"))); ok {
+			return rc, nil
+		}
+	}
+
+	ctx = ensureRequestID(ctx)
+	ctx, cancel := cl.metadataContext(ctx)
+	defer cancel()
+	// The go.mod file is small,
+	// so it is read into memory
+	// where any concurrent callers wanting the same one can share it.
+	body, err := coalesce(ctx, cl, "mod "+mod+"@"+ver, func(ctx context.Context) (sharedBody, error) {
+		rc, err := loop(ctx, cl, func(ctx context.Context, s single) (io.ReadCloser, error) {
+			return s.mod(ctx, mod, ver)
+		})
+		if err != nil {
+			return sharedBody{}, err
+		}
+		return readShared(rc)
 	})
+	if err != nil {
+		return nil, err
+	}
+	rc = body.reader()
 
-	return rc, err
+	if cacheable {
+		return cl.conf.cache.cachedBody(ctx, cl.cacheKey(ctx, cacheName(mod, ver, "mod")), rc)
+	}
+
+	return rc, nil
 }
 
 // Zip gets the contents of a specific version of a Go module as a zip file.
+// The caller must close the result.
+// If ctx is canceled before the caller is done reading,
+// reads fail with the context's error.
+//
+// If the client has any [Scanner]s (see [WithScanner]),
+// a [ChecksumDB] (see [WithChecksumDB]),
+// or a [Policy] with rules for zip files (see [WithPolicy]),
+// the zip file is downloaded to a temporary file and checked before it is returned.
+// Otherwise,
+// if the client has a [GoSum] (see [WithGoSum])
+// with an h1: hash for the zip file,
+// the zip file is checked as it is read.
 func (cl Client) Zip(ctx context.Context, mod, ver string) (io.ReadCloser, error) {
+	if len(cl.conf.scanners) > 0 || cl.conf.checksumDB != nil || cl.conf.policy.checksZips() {
+		return cl.scannedZip(ctx, mod, ver)
+	}
+	if want, ok := cl.goSumHash(mod, ver); ok {
+		if strings.HasPrefix(want, "h1:") {
+			return cl.streamVerifiedZip(ctx, mod, ver, want)
+		}
+		return cl.scannedZip(ctx, mod, ver)
+	}
+	return cl.fetchZip(ctx, mod, ver)
+}
+
+func (cl Client) fetchZip(ctx context.Context, mod, ver string) (io.ReadCloser, error) {
 	var (
 		rc  io.ReadCloser
 		err error
 	)
 
+	if err := cl.checkModulePolicy(mod, ver, "zip"); err != nil {
+		return nil, err
+	}
+
+	cacheable := immutable(ver) && cl.conf.cache != nil
+
 	mod, err = module.EscapePath(mod)
 	if err != nil {
 		return nil, errors.Wrap(err, "escaping module path")
@@ -243,13 +544,65 @@ func (cl Client) Zip(ctx context.Context, mod, ver string) (io.ReadCloser, error
 		return nil, errors.Wrap(err, "escaping module version")
 	}
 
-	cl.loop(&err, func(s single) {
-		rc, err = s.zip(ctx, mod, ver)
+	if cacheable {
+		if rc, ok := cl.conf.cache.open(ctx, cl.cacheKey(ctx, cacheName(mod, ver, "zip"))); ok {
+			return rc, nil
+		}
+	}
+
+	ctx = ensureRequestID(ctx)
+	rc, err = loop(ctx, cl, func(ctx context.Context, s single) (io.ReadCloser, error) {
+		return s.zip(ctx, mod, ver)
 	})
 
+	if err == nil && cacheable {
+		return cl.conf.cache.putZip(ctx, cl.cacheKey(ctx, cacheName(mod, ver, "zip")), rc)
+	}
+
 	return rc, err
 }
 
+// ZipSize reports the size in bytes of the zip file for a specific version of a Go module,
+// as given by the proxy in the Content-Length of its response,
+// without downloading the file.
+// The result is -1 if the proxy does not report the size.
+func (cl Client) ZipSize(ctx context.Context, mod, ver string) (int64, error) {
+	var (
+		size int64
+		err  error
+	)
+
+	if err := cl.checkModulePolicy(mod, ver, "zip"); err != nil {
+		return 0, err
+	}
+
+	cacheable := immutable(ver)
+
+	mod, err = module.EscapePath(mod)
+	if err != nil {
+		return 0, errors.Wrap(err, "escaping module path")
+	}
+	ver, err = module.EscapeVersion(ver)
+	if err != nil {
+		return 0, errors.Wrap(err, "escaping module version")
+	}
+
+	if cacheable {
+		if size, ok := cl.conf.cache.size(ctx, cl.cacheKey(ctx, cacheName(mod, ver, "zip"))); ok {
+			return size, nil
+		}
+	}
+
+	ctx = ensureRequestID(ctx)
+	ctx, cancel := cl.metadataContext(ctx)
+	defer cancel()
+	size, err = loop(ctx, cl, func(ctx context.Context, s single) (int64, error) {
+		return s.zipSize(ctx, mod, ver)
+	})
+
+	return size, err
+}
+
 // CodeErr is the type of an error that has an associated HTTP status code.
 // This interface is satisfied by [mid.CodeErr] from github.com/bobg/mid.
 type CodeErr interface {
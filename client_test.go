@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
@@ -16,6 +17,8 @@ import (
 
 	"github.com/bobg/mid"
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/bobg/goproxyclient/goproxytest"
 )
 
 func TestClients(t *testing.T) {
@@ -36,7 +39,7 @@ func TestClients(t *testing.T) {
 	)
 
 	t.Run("single", func(t *testing.T) {
-		cl := newSingle(s1.URL, nil)
+		cl := newSingle(s1.URL, nil, nil)
 
 		t.Run("latest", func(t *testing.T) {
 			ver, tm, _, err := cl.latest(ctx, "github.com/bobg/errors")
@@ -388,17 +391,16 @@ func TestClients(t *testing.T) {
 }
 
 func testHandler(shortcircuit map[string]int) http.Handler {
-	return mid.Err(func(w http.ResponseWriter, req *http.Request) error {
-		reqPath := strings.Trim(req.URL.Path, "/")
-		for path, code := range shortcircuit {
-			if strings.HasPrefix(reqPath, path) {
-				return mid.CodeErr{C: code}
-			}
-		}
+	fsys, err := fs.Sub(testdata, "testdata")
+	if err != nil {
+		panic(err)
+	}
 
-		http.ServeFileFS(w, req, testdata, filepath.Join("testdata", reqPath))
-		return nil
-	})
+	var opts []goproxytest.Option
+	for path, code := range shortcircuit {
+		opts = append(opts, goproxytest.WithError(path, code))
+	}
+	return goproxytest.New(fsys, opts...)
 }
 
 //go:embed testdata
@@ -443,3 +445,138 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestFallbackError(t *testing.T) {
+	ctx := context.Background()
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	t.Run("one_proxy", func(t *testing.T) {
+		cl := New(broken.URL, nil)
+		_, err := cl.List(ctx, "example.com/multi")
+		if err == nil {
+			t.Fatal("got no error")
+		}
+		if errors.As(err, new(FallbackError)) {
+			t.Errorf("got FallbackError %v from a single proxy", err)
+		}
+	})
+
+	t.Run("all_failed", func(t *testing.T) {
+		cl := New(notFound.URL+","+broken.URL, nil)
+		_, err := cl.List(ctx, "example.com/multi")
+
+		var fbErr FallbackError
+		if !errors.As(err, &fbErr) {
+			t.Fatalf("got %v, want a FallbackError", err)
+		}
+		if len(fbErr.Errs) != 2 {
+			t.Fatalf("got %d errors, want 2", len(fbErr.Errs))
+		}
+		if !IsNotFound(fbErr.Errs[0]) {
+			t.Errorf("got %v from the first proxy, want not-found", fbErr.Errs[0])
+		}
+
+		// The error is classified by the last proxy's error.
+		if IsNotFound(err) {
+			t.Error("IsNotFound is true, want false")
+		}
+		var codeErr CodeErr
+		if !errors.As(err, &codeErr) || codeErr.Code() != http.StatusInternalServerError {
+			t.Errorf("got %v, want status %d", err, http.StatusInternalServerError)
+		}
+
+		msg := err.Error()
+		for _, want := range []string{notFound.URL, broken.URL, "404", "500"} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("error message %q does not contain %q", msg, want)
+			}
+		}
+	})
+
+	t.Run("fallback_succeeded", func(t *testing.T) {
+		good := httptest.NewServer(testHandler(nil))
+		defer good.Close()
+
+		cl := New(notFound.URL+","+good.URL, nil)
+		if _, err := cl.List(ctx, "example.com/multi"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestCompat(t *testing.T) {
+	// A server that answers 401 for missing modules,
+	// an HTML page for the zip endpoint,
+	// and has no @latest endpoint.
+	s1 := httptest.NewServer(mid.Err(func(w http.ResponseWriter, req *http.Request) error {
+		reqPath := strings.Trim(req.URL.Path, "/")
+		switch {
+		case strings.HasPrefix(reqPath, "github.com/bobg/mid"):
+			return mid.CodeErr{C: http.StatusUnauthorized}
+		case strings.HasSuffix(reqPath, "/@latest"):
+			return mid.CodeErr{C: http.StatusMethodNotAllowed}
+		case strings.HasSuffix(reqPath, ".zip"):
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, "<html><body>Not here</body></html>")
+			return nil
+		}
+		http.ServeFileFS(w, req, testdata, filepath.Join("testdata", reqPath))
+		return nil
+	}))
+	defer s1.Close()
+
+	s2 := httptest.NewServer(testHandler(nil))
+	defer s2.Close()
+
+	ctx := context.Background()
+
+	t.Run("without_compat", func(t *testing.T) {
+		cl := New(fmt.Sprintf("%s,%s", s1.URL, s2.URL), nil)
+		if _, err := cl.List(ctx, "github.com/bobg/mid"); err == nil {
+			t.Error("got nil, want error")
+		}
+		if _, _, _, err := cl.Latest(ctx, "github.com/bobg/errors"); err == nil {
+			t.Error("got nil, want error")
+		}
+	})
+
+	t.Run("with_compat", func(t *testing.T) {
+		cl := New(fmt.Sprintf("%s,%s", s1.URL, s2.URL), nil, WithCompat())
+
+		versions, err := cl.List(ctx, "github.com/bobg/mid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(versions) == 0 {
+			t.Error("got no versions")
+		}
+
+		ver, _, _, err := cl.Latest(ctx, "github.com/bobg/errors")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ver != "v1.1.0" {
+			t.Errorf("got %q, want v1.1.0", ver)
+		}
+
+		rc, err := cl.Zip(ctx, "github.com/bobg/errors", "v1.1.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		got, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(got), "<html>") {
+			t.Error("got HTML body, want zip file")
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package goproxyclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Clientish is the interface of the basic Go module proxy operations that [Client] provides.
+// Code that depends on Clientish rather than on Client directly
+// can be tested with an in-memory implementation,
+// such as the one in the fake subpackage.
+//
+// Implementations should be safe for concurrent use by multiple goroutines,
+// as Client is.
+type Clientish interface {
+	Info(ctx context.Context, mod, ver string) (string, time.Time, map[string]json.RawMessage, error)
+	Latest(ctx context.Context, mod string) (string, time.Time, map[string]json.RawMessage, error)
+	List(ctx context.Context, mod string) ([]string, error)
+	Mod(ctx context.Context, mod, ver string) (io.ReadCloser, error)
+	Zip(ctx context.Context, mod, ver string) (io.ReadCloser, error)
+}
+
+var _ Clientish = Client{}
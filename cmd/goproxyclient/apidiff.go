@@ -0,0 +1,62 @@
+//go:build apidiff
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bobg/subcmd/v2"
+)
+
+// APIDiffSubcmds returns the apidiff subcommand,
+// which exists only when building with the apidiff build tag.
+func (c maincmd) apidiffSubcmds() subcmd.Map {
+	return subcmd.Commands(
+		"apidiff", c.apidiff, "report changes to the exported API of a module between two versions", subcmd.Params(
+			"-json", subcmd.Bool, false, "produce JSON output",
+			"module", subcmd.String, "", "module path",
+			"old", subcmd.String, "", "old version",
+			"new", subcmd.String, "", "new version",
+		),
+	)
+}
+
+func (c maincmd) apidiff(ctx context.Context, asJSON bool, mod, oldVer, newVer string, _ []string) error {
+	d, err := c.cl.APIDiff(ctx, mod, oldVer, newVer)
+	if err != nil {
+		return wrapf(err, "comparing %s %s and %s", mod, oldVer, newVer)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return wrap(enc.Encode(d), "encoding API diff")
+	}
+
+	for _, ch := range d.Changes {
+		name := ch.Package
+		if ch.Name != "" {
+			name += "." + ch.Name
+		}
+		mark := " "
+		if ch.Breaking {
+			mark = "!"
+		}
+		printf("%s %s %s\n", mark, ch.Kind, name)
+		if ch.Old != "" {
+			printf("    old: %s\n", ch.Old)
+		}
+		if ch.New != "" {
+			printf("    new: %s\n", ch.New)
+		}
+	}
+	printf("breaking: %t\n", d.Breaking())
+	printf("suggested bump: %s\n", d.Bump())
+	if d.Incomplete {
+		fmt.Println(tr("(some imports outside the module could not be loaded, so some changes may be missing or misreported)"))
+	}
+	return nil
+}
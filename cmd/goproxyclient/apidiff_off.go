@@ -0,0 +1,11 @@
+//go:build !apidiff
+
+package main
+
+import "github.com/bobg/subcmd/v2"
+
+// APIDiffSubcmds returns nothing
+// when building without the apidiff build tag.
+func (c maincmd) apidiffSubcmds() subcmd.Map {
+	return nil
+}
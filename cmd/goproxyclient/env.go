@@ -0,0 +1,215 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/bobg/goproxyclient"
+)
+
+// envReport is the effective configuration printed by the env command.
+type envReport struct {
+	GOPROXY       string // with any passwords redacted
+	Proxies       []envProxy
+	Race          bool        `json:",omitempty"` // proxies are queried all at once
+	Hedge         string      `json:",omitempty"` // delay before also querying the next proxy
+	ListUnion     bool        `json:",omitempty"` // version lists come from all proxies
+	HighestLatest bool        `json:",omitempty"` // latest versions come from all proxies
+	StateDir      string      `json:",omitempty"`
+	Cache         *envCache   `json:",omitempty"`
+	Journal       *envJournal `json:",omitempty"`
+	SumDB         string      `json:",omitempty"` // "off" if verification is turned off
+	GONOSUMDB     string      `json:",omitempty"`
+	VerifiedDB    string      `json:",omitempty"`
+	GoSum         string      `json:",omitempty"`
+	Quarantine    string      `json:",omitempty"`
+	Attestations  string      `json:",omitempty"`
+	AttestKey     string      `json:",omitempty"`
+	Policy        string      `json:",omitempty"`
+	Messages      string      `json:",omitempty"` // file or directory of translations
+}
+
+// envProxy describes one entry in the proxy chain.
+type envProxy struct {
+	// URL is the entry's URL with any password redacted,
+	// or "direct" or "off."
+	URL string
+
+	// Fallback says when a request that fails at this entry goes to the next one:
+	// "not-found" (for a 404 or 410 response),
+	// "any-error,"
+	// or "none" (for the last entry, and for "off").
+	Fallback string
+
+	// Auth lists the sources of credentials for requests to this entry.
+	Auth []string `json:",omitempty"`
+
+	// OutboundProxy is the HTTP or SOCKS5 proxy that requests to this entry go through,
+	// if any.
+	OutboundProxy string `json:",omitempty"`
+}
+
+type envCache struct {
+	Dir     string
+	MaxSize int64 `json:",omitempty"`
+	TTL     string
+}
+
+type envJournal struct {
+	File    string
+	MaxSize int64 `json:",omitempty"`
+	Keep    int   `json:",omitempty"` // number of rotated files kept
+}
+
+// envProxies describes the entries in a proxy chain.
+// The outbound proxy for an entry is the one in upstream for its URL,
+// or else outbound if outboundSet is true,
+// or else the one from the environment.
+func envProxies(entries []goproxyclient.ProxyEntry, outbound *url.URL, outboundSet bool, upstream map[string]*url.URL) []envProxy {
+	result := make([]envProxy, 0, len(entries))
+	for i, e := range entries {
+		p := envProxy{URL: e.URL, Fallback: "none"}
+		if i+1 < len(entries) && !e.IsOff() {
+			p.Fallback = "not-found"
+			if entries[i+1].AfterAnyErr {
+				p.Fallback = "any-error"
+			}
+		}
+
+		switch {
+		case e.IsOff():
+			// Nothing else to say.
+
+		case e.IsDirect():
+			p.Auth = []string{"version control tools' own credentials"}
+
+		default:
+			u, err := url.Parse(e.URL)
+			if err != nil {
+				break
+			}
+			p.URL = u.Redacted()
+			if u.User != nil {
+				p.Auth = append(p.Auth, "URL userinfo")
+			}
+
+			proxyURL, ok := upstream[e.URL]
+			if !ok && outboundSet {
+				proxyURL, ok = outbound, true
+			}
+			if !ok {
+				proxyURL, _ = http.ProxyFromEnvironment(&http.Request{URL: u})
+			}
+			if proxyURL != nil {
+				p.OutboundProxy = proxyURL.Redacted()
+			}
+		}
+
+		result = append(result, p)
+	}
+	return result
+}
+
+// envGOPROXY reconstructs a GOPROXY string from the entries of a proxy chain
+// and their descriptions,
+// with any passwords redacted.
+func envGOPROXY(entries []goproxyclient.ProxyEntry, proxies []envProxy) string {
+	var buf strings.Builder
+	for i, e := range entries {
+		switch {
+		case i == 0:
+		case e.AfterAnyErr:
+			buf.WriteByte('|')
+		default:
+			buf.WriteByte(',')
+		}
+		buf.WriteString(proxies[i].URL)
+	}
+	return buf.String()
+}
+
+func (c maincmd) env(_ context.Context, asJSON bool, _ []string) error {
+	r := c.envReport
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return wrap(enc.Encode(r), "encoding configuration")
+	}
+
+	printf("GOPROXY: %s\n", r.GOPROXY)
+	switch {
+	case r.Race:
+		fmt.Println(tr("proxies (queried all at once, fallback rules ignored):"))
+	case r.Hedge != "":
+		printf("proxies (next one also queried after %s without an answer):\n", r.Hedge)
+	default:
+		fmt.Println(tr("proxies:"))
+	}
+	for i, p := range r.Proxies {
+		printf("  %d. %s\n", i+1, p.URL)
+		switch p.Fallback {
+		case "not-found":
+			fmt.Println(tr("     fallback:       next entry on 404 or 410"))
+		case "any-error":
+			fmt.Println(tr("     fallback:       next entry on any error"))
+		default:
+			fmt.Println(tr("     fallback:       none"))
+		}
+		if p.URL == "off" {
+			continue
+		}
+		printf("     auth:           %s\n", cmp.Or(strings.Join(p.Auth, ", "), tr("none")))
+		if p.URL != "direct" {
+			printf("     outbound proxy: %s\n", cmp.Or(p.OutboundProxy, tr("none")))
+		}
+	}
+
+	if r.ListUnion {
+		fmt.Println(tr("version lists:      combined from all proxies"))
+	}
+	if r.HighestLatest {
+		fmt.Println(tr("latest versions:    highest from all proxies"))
+	}
+	printf("state dir:          %s\n", cmp.Or(r.StateDir, tr("none")))
+	if r.Cache != nil {
+		printf("cache:              %s (TTL %s", r.Cache.Dir, r.Cache.TTL)
+		if r.Cache.MaxSize > 0 {
+			printf(", max size %d bytes", r.Cache.MaxSize)
+		}
+		fmt.Println(")")
+	} else {
+		fmt.Println(tr("cache:              none"))
+	}
+	if r.Journal != nil {
+		printf("journal:            %s", r.Journal.File)
+		if r.Journal.MaxSize > 0 {
+			printf(" (max size %d bytes, keeping %d old files)", r.Journal.MaxSize, r.Journal.Keep)
+		}
+		fmt.Println()
+	} else {
+		fmt.Println(tr("journal:            none"))
+	}
+	printf("checksum db:        %s\n", cmp.Or(r.SumDB, tr("none")))
+	if r.SumDB != "" && r.SumDB != "off" && r.GONOSUMDB != "" {
+		printf("  not for:          %s\n", r.GONOSUMDB)
+	}
+	printf("verified db:        %s\n", cmp.Or(r.VerifiedDB, tr("none")))
+	if r.VerifiedDB != "" {
+		printf("  quarantine:       %s\n", cmp.Or(r.Quarantine, tr("none")))
+	}
+	printf("go.sum:             %s\n", cmp.Or(r.GoSum, tr("none")))
+	printf("attestations:       %s\n", cmp.Or(r.Attestations, tr("none")))
+	if r.Attestations != "" {
+		printf("  signing key:      %s\n", cmp.Or(r.AttestKey, tr("none")))
+	}
+	printf("policy:             %s\n", cmp.Or(r.Policy, tr("none")))
+	printf("messages:           %s\n", cmp.Or(r.Messages, tr("none")))
+	return nil
+}
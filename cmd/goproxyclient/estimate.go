@@ -0,0 +1,96 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"github.com/bobg/goproxyclient"
+)
+
+type estimateJSON struct {
+	Modules []estimateModuleJSON
+	Total   int64
+	Unknown int
+}
+
+type estimateModuleJSON struct {
+	Path, Version string
+	Size          int64 // -1 if unknown
+}
+
+func (c maincmd) estimate(ctx context.Context, buildList, work string, asJSON bool, args []string) error {
+	var versions []module.Version
+	if buildList != "" {
+		var r io.Reader = os.Stdin
+		if buildList != "-" {
+			f, err := os.Open(buildList)
+			if err != nil {
+				return wrap(err, "opening -buildlist file")
+			}
+			defer f.Close()
+			r = f
+		}
+		vs, err := goproxyclient.ParseBuildList(r)
+		if err != nil {
+			return wrap(err, "parsing -buildlist file")
+		}
+		versions = vs
+	}
+	args, err := appendWorkspace(args, work, true)
+	if err != nil {
+		return err
+	}
+	for _, arg := range args {
+		mod, ver, ok := strings.Cut(arg, "@")
+		if !ok {
+			return errorf("argument %s is not in MODULE@VERSION form", arg)
+		}
+		versions = append(versions, module.Version{Path: mod, Version: ver})
+	}
+	if len(versions) == 0 {
+		return errorf("no module versions given (use arguments, -buildlist, or -work)")
+	}
+
+	est, err := c.cl.EstimateZipSizes(ctx, versions)
+	if err != nil {
+		return err
+	}
+
+	mvs := slices.SortedFunc(maps.Keys(est.Sizes), func(a, b module.Version) int {
+		return cmp.Or(strings.Compare(a.Path, b.Path), semver.Compare(a.Version, b.Version))
+	})
+
+	if asJSON {
+		out := estimateJSON{Total: est.Total, Unknown: est.Unknown}
+		for _, mv := range mvs {
+			out.Modules = append(out.Modules, estimateModuleJSON{Path: mv.Path, Version: mv.Version, Size: est.Sizes[mv]})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return wrap(enc.Encode(out), "encoding estimate")
+	}
+
+	for _, mv := range mvs {
+		sizeStr := tr("unknown size")
+		if size := est.Sizes[mv]; size >= 0 {
+			sizeStr = sprintf("%d bytes", size)
+		}
+		printf("%s@%s: %s\n", mv.Path, mv.Version, sizeStr)
+	}
+	printf("total: %d bytes in %d zip files", est.Total, len(mvs)-est.Unknown)
+	if est.Unknown > 0 {
+		printf(", plus %d of unknown size", est.Unknown)
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+
+	"github.com/bobg/goproxyclient"
+)
+
+// explain performs a single client operation
+// while narrating each step.
+func (c maincmd) explain(ctx context.Context, op, arg string, _ []string) error {
+	mod, ver, hasVer := strings.Cut(arg, "@")
+	switch op {
+	case "info", "mod", "zip":
+		if !hasVer {
+			return errorf("%s requires an argument in MODULE@VERSION form", op)
+		}
+	case "latest", "list":
+		if hasVer {
+			return errorf("%s requires an argument with no @VERSION", op)
+		}
+	default:
+		return errorf(`unknown operation %q (want "info," "latest," "list," "mod," or "zip")`, op)
+	}
+
+	step := func(format string, args ...any) {
+		fmt.Printf("- %s\n", sprintf(format, args...))
+	}
+
+	escMod, err := module.EscapePath(mod)
+	if err != nil {
+		step("escaping module path %s: %s", mod, err)
+		return wrap(err, "escaping module path")
+	}
+	step("module path %s escapes to %s", mod, escMod)
+	if hasVer {
+		escVer, err := module.EscapeVersion(ver)
+		if err != nil {
+			step("escaping version %s: %s", ver, err)
+			return wrap(err, "escaping module version")
+		}
+		step("version %s escapes to %s", ver, escVer)
+	}
+
+	if goproxyclient.RequestID(ctx) == "" {
+		ctx = goproxyclient.WithRequestID(ctx, fmt.Sprintf("explain-%d", time.Now().UnixNano()))
+	}
+	step("request ID is %s", goproxyclient.RequestID(ctx))
+
+	var prov goproxyclient.Provenance
+	ctx = goproxyclient.WithProvenance(ctx, &prov)
+
+	ctx = goproxyclient.WithTrace(ctx, &goproxyclient.Trace{
+		CacheLookup: func(name string, hit bool) {
+			if hit {
+				step("cache hit for %s", name)
+			} else {
+				step("cache miss for %s", name)
+			}
+		},
+		ProxyStart: func(proxy string) {
+			step("trying %s", proxy)
+		},
+		ProxyDone: func(proxy string, err error, next string) {
+			switch {
+			case err == nil:
+				step("%s succeeded", proxy)
+			case next != "":
+				step("%s failed (%s), falling back to %s", proxy, err, next)
+			case c.envReport.Race, c.envReport.Hedge != "":
+				step("%s failed (%s)", proxy, err)
+			default:
+				step("%s failed (%s), not falling back", proxy, err)
+			}
+		},
+		GotResponse: func(method, url string, status int, elapsed time.Duration, err error) {
+			if err != nil {
+				step("  %s %s: %s (after %s)", method, url, err, elapsed.Round(time.Millisecond))
+				return
+			}
+			step("  %s %s: %d %s (in %s)", method, url, status, http.StatusText(status), elapsed.Round(time.Millisecond))
+		},
+		Retry: func(url string, delay time.Duration, err error) {
+			step("  retrying %s in %s", url, delay)
+		},
+		Verified: func(mod, ver, kind string, ok bool, err error) {
+			switch {
+			case err != nil:
+				step("verifying %s file for %s@%s: %s", kind, mod, ver, err)
+			case ok:
+				step("verified %s file for %s@%s against its known hash", kind, mod, ver)
+			default:
+				step("no known hash for %s file of %s@%s, not verified", kind, mod, ver)
+			}
+		},
+	})
+
+	var result string
+	switch op {
+	case "info":
+		canonical, tm, _, err := c.cl.Info(ctx, mod, ver)
+		if err != nil {
+			return err
+		}
+		result = fmt.Sprintf("%s (%s)", canonical, tm.Format(time.RFC3339))
+
+	case "latest":
+		latest, tm, _, err := c.cl.Latest(ctx, mod)
+		if err != nil {
+			return err
+		}
+		result = fmt.Sprintf("%s (%s)", latest, tm.Format(time.RFC3339))
+
+	case "list":
+		versions, err := c.cl.List(ctx, mod)
+		if err != nil {
+			return err
+		}
+		result = sprintf("%d versions", len(versions))
+
+	case "mod", "zip":
+		get := c.cl.Mod
+		if op == "zip" {
+			get = c.cl.Zip
+		}
+		rc, err := get(ctx, mod, ver)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		n, err := io.Copy(io.Discard, rc)
+		if err != nil {
+			return wrapf(err, "reading %s file", op)
+		}
+		result = sprintf("%d bytes", n)
+	}
+
+	step("result: %s, from %s", result, prov.Proxy())
+	return nil
+}
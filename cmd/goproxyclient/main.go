@@ -1,149 +1,1389 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"crypto/ed25519"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/bobg/errors"
 	"github.com/bobg/subcmd/v2"
+	"golang.org/x/mod/module"
 	"golang.org/x/mod/semver"
+	"golang.org/x/time/rate"
 
 	"github.com/bobg/goproxyclient"
+	"github.com/bobg/goproxyclient/sumdb"
 )
 
 func main() {
 	if err := run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		os.Exit(1)
+		fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(exitCode(err))
 	}
 }
 
+// exitCodes are the exit statuses for errors of each class
+// (see [goproxyclient.Classify]).
+// They start at 3,
+// after 1 for other errors
+// and 2 for bad usage
+// (which the flag package also uses).
+var exitCodes = map[goproxyclient.ErrorClass]int{
+	goproxyclient.NotFound:      3,
+	goproxyclient.AuthFailed:    4,
+	goproxyclient.RateLimited:   5,
+	goproxyclient.Timeout:       6,
+	goproxyclient.Canceled:      7,
+	goproxyclient.ProxyBroken:   8,
+	goproxyclient.VerifyFailed:  9,
+	goproxyclient.PolicyDenied:  10,
+	goproxyclient.UnsafeContent: 11,
+	goproxyclient.NoSpace:       12,
+}
+
+// ExitCode returns the exit status for err.
+func exitCode(err error) int {
+	var (
+		usageErr subcmd.UsageErr
+		parseErr subcmd.ParseErr
+	)
+	if errors.As(err, &usageErr) || errors.As(err, &parseErr) || errors.Is(err, subcmd.ErrTooFewArgs) {
+		return 2
+	}
+	if code, ok := exitCodes[goproxyclient.Classify(err)]; ok {
+		return code
+	}
+	return 1
+}
+
 func run() error {
 	goproxy := os.Getenv("GOPROXY")
 	if goproxy == "" {
 		goproxy = "https://proxy.golang.org"
 	}
 
+	var (
+		progressMode, bwlimit string
+		jobs                  int
+		unordered             bool
+		idleTimeout           time.Duration
+		outboundProxy         string
+		upstreamProxies       []string
+		hostMap               = make(map[string]string)
+		dnsServer             string
+		ipv4Only, preferIPv4  bool
+		dialTimeout           time.Duration
+		tlsTimeout            time.Duration
+		headerTimeout         time.Duration
+		metadataTimeout       time.Duration
+		rateLimit             float64
+		hostRates             = make(map[string]float64)
+		retries               int
+		retryAfter            time.Duration
+		race, listUnion       bool
+		hedge                 time.Duration
+		highestLatest         bool
+		verbose               bool
+		requestID             string
+		verifiedDB            string
+		goSumFile             string
+		quarantineDir         string
+		attestDir, attestKey  string
+		stateDir              string
+		policyFile            string
+		gosumdb               string
+		sumdbDir              string
+		cacheDir, cacheSize   string
+		cacheTTL              time.Duration
+		journalFile           string
+		journalSize           string
+		journalKeep           int
+		logger                *slog.Logger
+		messagesPath          = os.Getenv("GOPROXYCLIENT_MESSAGES")
+	)
+
 	flag.StringVar(&goproxy, "proxy", goproxy, "Go module proxy URL")
+	flag.StringVar(&progressMode, "progress", "", `progress reporting for multi-argument commands: "json" for JSON events on stderr`)
+	flag.StringVar(&bwlimit, "bwlimit", "", "maximum download rate in bytes per second, with optional suffix k, M, or G")
+	flag.DurationVar(&idleTimeout, "idle-timeout", 0, "abort downloads that receive no data for this long (0 for no limit)")
+	flag.StringVar(&outboundProxy, "outbound-proxy", "", `route requests through this HTTP or SOCKS5 proxy URL ("direct" to ignore the environment)`)
+	flag.Func("outbound-proxy-for", "UPSTREAM=URL: like -outbound-proxy but only for the given Go module proxy (may be repeated)", func(s string) error {
+		upstreamProxies = append(upstreamProxies, s)
+		return nil
+	})
+	flag.Func("resolve", "HOST=ADDR: connect to ADDR when contacting HOST (may be repeated)", func(s string) error {
+		host, addr, ok := strings.Cut(s, "=")
+		if !ok || host == "" || addr == "" {
+			return errorf("%q is not of the form HOST=ADDR", s)
+		}
+		hostMap[host] = addr
+		return nil
+	})
+	flag.StringVar(&dnsServer, "dns", "", "look up proxy hosts using this DNS server (HOST or HOST:PORT)")
+	flag.BoolVar(&ipv4Only, "4", false, "connect only over IPv4")
+	flag.BoolVar(&preferIPv4, "prefer-ipv4", false, "try connecting over IPv4 before IPv6")
+	flag.DurationVar(&dialTimeout, "dial-timeout", 0, "maximum time to wait for a connection (default 30s)")
+	flag.DurationVar(&tlsTimeout, "tls-timeout", 0, "maximum time to wait for a TLS handshake (default 10s)")
+	flag.DurationVar(&headerTimeout, "header-timeout", 0, "maximum time to wait for a response after sending a request (0 for no limit)")
+	flag.DurationVar(&metadataTimeout, "metadata-timeout", 0, "maximum time for a whole info, latest, list, or go.mod request, including fallbacks and retries (0 for no limit)")
+	flag.Float64Var(&rateLimit, "ratelimit", 0, "maximum requests per second to each proxy host (0 for no limit)")
+	flag.Func("ratelimit-for", "HOST=N: like -ratelimit but only for the given proxy host, overriding -ratelimit (may be repeated)", func(s string) error {
+		host, n, ok := strings.Cut(s, "=")
+		if !ok || host == "" {
+			return errorf("%q is not of the form HOST=N", s)
+		}
+		r, err := strconv.ParseFloat(n, 64)
+		if err != nil || r <= 0 {
+			return errorf("rate in %q is not a positive number", s)
+		}
+		hostRates[host] = r
+		return nil
+	})
+	flag.IntVar(&retries, "retries", 0, "number of times to retry requests that fail with server or network errors")
+	flag.DurationVar(&retryAfter, "retry-after", 0, "wait up to this long in total to retry requests that a proxy rate-limits (with 429 Too Many Requests, or 503 and Retry-After)")
+	flag.BoolVar(&race, "race", false, "query all proxies at once and use the first successful response")
+	flag.DurationVar(&hedge, "hedge", 0, "also query the next proxy when one hasn't answered for this long, and use the first successful response (0 for no hedging)")
+	flag.BoolVar(&listUnion, "list-union", false, "list versions from all proxies and combine the results")
+	flag.BoolVar(&highestLatest, "highest-latest", false, "ask all proxies for the latest version of a module and use the highest")
+	flag.BoolVar(&verbose, "v", false, "log each request to a proxy on stderr")
+	flag.StringVar(&requestID, "request-id", "", "request ID to send to proxies (default: a random one per operation)")
+	flag.StringVar(&policyFile, "policy", "", "JSON file with rules for which modules may be fetched")
+	flag.StringVar(&stateDir, "state", "", `directory for persistent state ("default" for the standard location)`)
+	flag.StringVar(&cacheDir, "cache", "", "cache proxy responses in this directory")
+	flag.StringVar(&cacheSize, "cache-size", "", "with -cache, maximum size of the cache in bytes, with optional suffix k, M, or G")
+	flag.DurationVar(&cacheTTL, "cache-ttl", 0, "with -cache, how long to cache version lists and latest versions (default 5m)")
+	flag.StringVar(&journalFile, "journal", "", "append a JSON record of each request to a proxy to this file")
+	flag.StringVar(&journalSize, "journal-size", "", "with -journal, rotate the file when it reaches this many bytes, with optional suffix k, M, or G")
+	flag.IntVar(&journalKeep, "journal-keep", 5, "with -journal-size, number of rotated files to keep")
+	flag.StringVar(&gosumdb, "sumdb", "", `verify go.mod and zip files against this checksum database, in GOSUMDB format ("default" for $GOSUMDB or sum.golang.org)`)
+	flag.StringVar(&verifiedDB, "verified", "", "go.sum-format file of known module hashes to check downloads against")
+	flag.StringVar(&goSumFile, "gosum", "", "go.sum file whose hashes go.mod and zip files must match")
+	flag.StringVar(&quarantineDir, "quarantine", "", "with -verified, move downloads that fail verification to this directory instead of deleting them")
+	flag.StringVar(&attestDir, "attest", "", "write a provenance attestation for each downloaded zip file into this directory")
+	flag.StringVar(&attestKey, "attest-key", "", "with -attest, sign attestations with the Ed25519 private key in this PEM file")
+	flag.IntVar(&jobs, "j", 1, "number of arguments to process concurrently in multi-argument commands")
+	flag.BoolVar(&unordered, "unordered", false, "with -j, emit each argument's output as soon as it is ready instead of in argument order")
+	flag.StringVar(&messagesPath, "messages", messagesPath, "JSON file of translated messages, or a directory of them by locale (default $GOPROXYCLIENT_MESSAGES)")
 	flag.Parse()
 
-	cl := goproxyclient.New(goproxy, nil)
+	if messagesPath != "" {
+		if err := loadMessages(messagesPath); err != nil {
+			return wrap(err, "loading -messages")
+		}
+	}
+
+	prog, err := newProgress(progressMode, os.Stderr)
+	if err != nil {
+		return err
+	}
 
-	return subcmd.Run(context.Background(), maincmd{cl: cl}, flag.Args())
+	var (
+		opts []goproxyclient.Option
+		env  = envReport{Messages: messagesPath}
+	)
+	if bwlimit != "" {
+		n, err := parseByteCount(bwlimit)
+		if err != nil {
+			return wrap(err, "parsing -bwlimit")
+		}
+		opts = append(opts, goproxyclient.WithBandwidthLimit(n))
+	}
+	if verbose {
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		opts = append(opts, goproxyclient.WithLogger(logger))
+	}
+	if policyFile != "" {
+		p, err := goproxyclient.LoadPolicy(policyFile)
+		if err != nil {
+			return wrap(err, "loading -policy file")
+		}
+		opts = append(opts, goproxyclient.WithPolicy(p))
+		env.Policy = policyFile
+	}
+	if stateDir != "" {
+		sd, err := openStateDir(stateDir)
+		if err != nil {
+			return wrap(err, "opening -state directory")
+		}
+		env.StateDir = string(sd)
+		if verifiedDB == "" {
+			verifiedDB = sd.Path(goproxyclient.StateVerified)
+		}
+		if quarantineDir == "" {
+			quarantineDir = sd.Path(goproxyclient.StateQuarantine)
+		}
+		sumdbDir = sd.Path(goproxyclient.StateSumDB)
+		if cacheDir == "" {
+			cacheDir = sd.Path(goproxyclient.StateCache)
+		}
+	}
+	if cacheDir != "" {
+		var maxSize int
+		if cacheSize != "" {
+			maxSize, err = parseByteCount(cacheSize)
+			if err != nil {
+				return wrap(err, "parsing -cache-size")
+			}
+		}
+		opts = append(opts, goproxyclient.WithCache(cacheDir, int64(maxSize)))
+		if cacheTTL != 0 {
+			opts = append(opts, goproxyclient.WithCacheTTL(cacheTTL))
+		}
+		env.Cache = &envCache{Dir: cacheDir, MaxSize: int64(maxSize), TTL: cmp.Or(cacheTTL, goproxyclient.DefaultCacheTTL).String()}
+	}
+	if journalFile != "" {
+		var maxSize int
+		if journalSize != "" {
+			maxSize, err = parseByteCount(journalSize)
+			if err != nil {
+				return wrap(err, "parsing -journal-size")
+			}
+		}
+		j, err := goproxyclient.OpenJournal(journalFile, int64(maxSize), journalKeep)
+		if err != nil {
+			return wrap(err, "opening -journal file")
+		}
+		defer j.Close()
+		opts = append(opts, goproxyclient.WithJournal(j))
+		env.Journal = &envJournal{File: journalFile, MaxSize: int64(maxSize)}
+		if maxSize > 0 {
+			env.Journal.Keep = journalKeep
+		}
+	}
+	if gosumdb != "" {
+		if gosumdb == "default" {
+			gosumdb = os.Getenv("GOSUMDB")
+		}
+		gonosumdb := cmp.Or(os.Getenv("GONOSUMDB"), os.Getenv("GOPRIVATE"))
+		sumdbOpts := []sumdb.Option{sumdb.WithGONOSUMDB(gonosumdb)}
+		if sumdbDir != "" {
+			sumdbOpts = append(sumdbOpts, sumdb.WithDir(sumdbDir))
+		}
+		if logger != nil {
+			sumdbOpts = append(sumdbOpts, sumdb.WithLogger(logger))
+		}
+		db, err := sumdb.New(gosumdb, sumdbOpts...)
+		switch {
+		case errors.Is(err, sumdb.ErrOff):
+			// No verification.
+			env.SumDB = "off"
+		case err != nil:
+			return wrap(err, "parsing -sumdb")
+		default:
+			opts = append(opts, goproxyclient.WithChecksumDB(db))
+			env.SumDB, env.GONOSUMDB = db.Name(), gonosumdb
+		}
+	}
+	if verifiedDB != "" {
+		db, err := goproxyclient.OpenVerifiedDB(verifiedDB)
+		if err != nil {
+			return wrap(err, "opening -verified file")
+		}
+		opts = append(opts, goproxyclient.WithVerifiedDB(db))
+		env.VerifiedDB = verifiedDB
+	}
+	if goSumFile != "" {
+		g, err := goproxyclient.ReadGoSum(goSumFile)
+		if err != nil {
+			return wrap(err, "reading -gosum file")
+		}
+		opts = append(opts, goproxyclient.WithGoSum(g))
+		env.GoSum = goSumFile
+	}
+	if quarantineDir != "" {
+		opts = append(opts, goproxyclient.WithQuarantine(quarantineDir))
+		env.Quarantine = quarantineDir
+	}
+	if attestDir != "" {
+		var key ed25519.PrivateKey
+		if attestKey != "" {
+			key, err = readEd25519Key(attestKey)
+			if err != nil {
+				return wrap(err, "reading -attest-key")
+			}
+		}
+		opts = append(opts, goproxyclient.WithAttestations(attestDir, key))
+		env.Attestations, env.AttestKey = attestDir, attestKey
+	}
+	if rateLimit > 0 {
+		opts = append(opts, goproxyclient.WithRateLimit(rateLimit, 1))
+	}
+	for host, r := range hostRates {
+		opts = append(opts, goproxyclient.WithHostRateLimiter(host, rate.NewLimiter(rate.Limit(r), 1)))
+	}
+	if retries > 0 {
+		policy := goproxyclient.DefaultRetryPolicy
+		policy.Attempts = retries + 1
+		opts = append(opts, goproxyclient.WithRetry(policy))
+	}
+	if retryAfter > 0 {
+		opts = append(opts, goproxyclient.WithRetryAfter(retryAfter))
+	}
+	if race {
+		opts = append(opts, goproxyclient.WithRace())
+		env.Race = true
+	}
+	if hedge > 0 {
+		opts = append(opts, goproxyclient.WithHedge(hedge))
+		if !race {
+			env.Hedge = hedge.String()
+		}
+	}
+	if listUnion {
+		opts = append(opts, goproxyclient.WithListUnion())
+		env.ListUnion = true
+	}
+	if highestLatest {
+		opts = append(opts, goproxyclient.WithHighestLatest())
+		env.HighestLatest = true
+	}
+
+	var (
+		outboundURL  *url.URL
+		upstreamURLs = make(map[string]*url.URL)
+	)
+	if outboundProxy != "" {
+		outboundURL, err = parseOutboundProxy(outboundProxy)
+		if err != nil {
+			return wrap(err, "parsing -outbound-proxy")
+		}
+		opts = append(opts, goproxyclient.WithOutboundProxy(outboundURL))
+	}
+	for _, s := range upstreamProxies {
+		upstream, proxy, ok := strings.Cut(s, "=")
+		if !ok {
+			return errorf("-outbound-proxy-for value %q is not of the form UPSTREAM=URL", s)
+		}
+		u, err := parseOutboundProxy(proxy)
+		if err != nil {
+			return wrap(err, "parsing -outbound-proxy-for")
+		}
+		opts = append(opts, goproxyclient.WithUpstreamOutboundProxy(upstream, u))
+		upstreamURLs[strings.TrimRight(upstream, "/")] = u
+	}
+	if len(hostMap) > 0 {
+		opts = append(opts, goproxyclient.WithHostMap(hostMap))
+	}
+	if dnsServer != "" {
+		opts = append(opts, goproxyclient.WithResolver(newResolver(dnsServer)))
+	}
+	if ipv4Only {
+		opts = append(opts, goproxyclient.WithIPv4Only())
+	}
+	if preferIPv4 {
+		opts = append(opts, goproxyclient.WithPreferIPv4())
+	}
+	opts = append(opts, goproxyclient.WithTimeouts(goproxyclient.Timeouts{
+		Connect:        dialTimeout,
+		TLS:            tlsTimeout,
+		ResponseHeader: headerTimeout,
+		MetadataTotal:  metadataTimeout,
+		DownloadIdle:   idleTimeout,
+	}))
+
+	vcsPolicy, err := goproxyclient.ParseGOVCS(os.Getenv("GOVCS"), os.Getenv("GOPRIVATE"))
+	if err != nil {
+		return wrap(err, "parsing $GOVCS")
+	}
+	opts = append(opts, goproxyclient.WithVCSPolicy(vcsPolicy))
+
+	insecure, err := goproxyclient.ParsePathPatterns(os.Getenv("GOINSECURE"))
+	if err != nil {
+		return wrap(err, "parsing $GOINSECURE")
+	}
+	opts = append(opts, goproxyclient.WithInsecure(insecure))
+
+	entries, err := goproxyclient.ParseConfig(goproxy)
+	if err != nil {
+		return wrap(err, "parsing -proxy")
+	}
+	cl := goproxyclient.NewFromConfig(entries, nil, opts...)
+
+	env.Proxies = envProxies(entries, outboundURL, outboundProxy != "", upstreamURLs)
+	env.GOPROXY = envGOPROXY(entries, env.Proxies)
+
+	c := maincmd{
+		cl:        cl,
+		opts:      opts,
+		envReport: env,
+		prog:      prog,
+		jobs:      jobs,
+		unordered: unordered,
+	}
+	ctx := context.Background()
+	if requestID != "" {
+		ctx = goproxyclient.WithRequestID(ctx, requestID)
+	}
+	return subcmd.Run(ctx, c, flag.Args())
 }
 
 type maincmd struct {
-	cl goproxyclient.Client
+	cl        goproxyclient.Client
+	opts      []goproxyclient.Option // for check-proxy
+	envReport envReport              // for env
+	prog      *progress
+	jobs      int
+	unordered bool
+}
+
+// each calls f on each of args,
+// reporting progress if requested with -progress.
+//
+// With -j 1 (the default),
+// the calls happen one at a time,
+// each writing directly to standard output,
+// and each stops at the first error.
+//
+// Otherwise up to -j calls run concurrently,
+// each writing to its own buffer.
+// The buffers are copied to standard output in the order of args
+// (or, with -unordered, as each call finishes).
+// All calls run to completion,
+// and the first error in the order of args is returned.
+func (c maincmd) each(args []string, f func(arg string, w io.Writer) error) error {
+	c.prog.begin(len(args))
+	defer c.prog.end()
+
+	if c.jobs <= 1 {
+		for _, arg := range args {
+			c.prog.start(arg)
+			err := f(arg, os.Stdout)
+			c.prog.finish(arg, err)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	type result struct {
+		buf  bytes.Buffer
+		err  error
+		done chan struct{}
+	}
+
+	var (
+		results = make([]*result, len(args))
+		sem     = make(chan struct{}, c.jobs)
+		outMu   sync.Mutex // for -unordered
+	)
+	for i := range results {
+		results[i] = &result{done: make(chan struct{})}
+	}
+
+	for i, arg := range args {
+		go func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			r := results[i]
+			defer close(r.done)
+
+			c.prog.start(arg)
+			r.err = f(arg, &r.buf)
+			c.prog.finish(arg, r.err)
+
+			if c.unordered {
+				outMu.Lock()
+				defer outMu.Unlock()
+				io.Copy(os.Stdout, &r.buf)
+			}
+		}()
+	}
+
+	var firstErr error
+	for _, r := range results {
+		<-r.done
+		if !c.unordered {
+			io.Copy(os.Stdout, &r.buf)
+		}
+		if r.err != nil && firstErr == nil {
+			firstErr = r.err
+		}
+	}
+	return firstErr
+}
+
+// NewResolver returns a resolver that sends DNS queries to server,
+// which is a host with an optional port (default 53).
+func newResolver(server string) *net.Resolver {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// OpenStateDir opens the state directory named by the -state flag,
+// where "default" means [goproxyclient.DefaultStateDir].
+func openStateDir(dir string) (goproxyclient.StateDir, error) {
+	if dir == "default" {
+		d, err := goproxyclient.DefaultStateDir()
+		if err != nil {
+			return "", err
+		}
+		dir = string(d)
+	}
+	return goproxyclient.OpenStateDir(dir)
+}
+
+// ReadEd25519Key reads an Ed25519 private key from a PEM-encoded PKCS #8 file,
+// such as the one produced by "openssl genpkey -algorithm ed25519".
+func readEd25519Key(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errorf("no PEM data in %s", path)
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, wrapf(err, "parsing key in %s", path)
+	}
+	key, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errorf("key in %s is a %T, not Ed25519", path, k)
+	}
+	return key, nil
+}
+
+// ReadEd25519PublicKey reads an Ed25519 public key from a PEM-encoded PKIX file,
+// such as the one produced by "openssl pkey -pubout".
+func readEd25519PublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errorf("no PEM data in %s", path)
+	}
+	k, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, wrapf(err, "parsing key in %s", path)
+	}
+	pub, ok := k.(ed25519.PublicKey)
+	if !ok {
+		return nil, errorf("key in %s is a %T, not Ed25519", path, k)
+	}
+	return pub, nil
+}
+
+// ParseOutboundProxy parses the value of an -outbound-proxy or -outbound-proxy-for flag.
+// The string "direct" yields nil.
+func parseOutboundProxy(s string) (*url.URL, error) {
+	if s == "direct" {
+		return nil, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	default:
+		return nil, errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+}
+
+// parseByteCount parses a number of bytes,
+// optionally with a suffix k, M, or G (powers of 1024).
+func parseByteCount(s string) (int, error) {
+	mult := 1
+	switch {
+	case strings.HasSuffix(s, "k"), strings.HasSuffix(s, "K"):
+		mult = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		mult = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		mult = 1 << 30
+	}
+	if mult > 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, errorf("value must be positive")
+	}
+	return n * mult, nil
 }
 
 func (c maincmd) Subcmds() subcmd.Map {
-	return subcmd.Commands(
+	m := subcmd.Commands(
+		"changelog", c.changelog, "show release notes for the versions of a module between two versions", subcmd.Params(
+			"-token", subcmd.String, "", "access token for the hosting service (default $GITHUB_TOKEN or $GITLAB_TOKEN)",
+			"module", subcmd.String, "", "module path",
+			"from", subcmd.String, "", "starting version (exclusive)",
+			"to", subcmd.String, "", "ending version (inclusive)",
+		),
+		"check-proxy", c.checkProxy, "check that Go module proxies implement the GOPROXY protocol", subcmd.Params(
+			"-probe", subcmd.String, "", "module version to request, in MODULE@VERSION or MODULE form (default "+goproxyclient.DefaultProbe.String()+")",
+		),
+		"download", c.download, "download module versions and describe them as go mod download -json does", subcmd.Params(
+			"-dry-run", subcmd.Bool, false, "show what would be fetched without doing it",
+			"-work", subcmd.String, "", "go.work file: also download the requirements of its modules",
+		),
+		"env", c.env, "print the effective configuration", subcmd.Params(
+			"-json", subcmd.Bool, false, "produce JSON output",
+		),
+		"estimate", c.estimate, "report the total size of module zip files without downloading them", subcmd.Params(
+			"-buildlist", subcmd.String, "", `file with a build list, as from "go list -m all" ("-" for standard input)`,
+			"-work", subcmd.String, "", "go.work file: also estimate the requirements of its modules",
+			"-json", subcmd.Bool, false, "produce JSON output",
+		),
+		"exists", c.exists, "check whether module versions exist without downloading anything", nil,
+		"explain", c.explain, "perform one operation while explaining each step", subcmd.Params(
+			"op", subcmd.String, "", "operation: info, latest, list, mod, or zip",
+			"module", subcmd.String, "", "module in MODULE@VERSION form (MODULE for latest and list)",
+		),
+		"extract", c.extract, "extract the contents of a module into a directory", subcmd.Params(
+			"-dry-run", subcmd.Bool, false, "show what would be fetched without doing it",
+			"-include", subcmd.String, "", "comma-separated globs: extract only matching files",
+			"-exclude", subcmd.String, "", "comma-separated globs: skip matching files (e.g. testdata,*.md)",
+			"module", subcmd.String, "", "module in MODULE@VERSION form",
+			"dir", subcmd.String, "", "destination directory",
+		),
+		"head", c.head, "get the latest commit on a module branch", nil,
+		"imports", c.imports, "list the imports of the packages in a module", subcmd.Params(
+			"-external", subcmd.Bool, false, "list only imports from outside the module and the standard library",
+			"-tests", subcmd.Bool, false, "include the imports of test files",
+		),
 		"info", c.info, "get module info", nil,
 		"latest", c.latest, "get the latest module version", nil,
 		"list", c.list, "list module versions", nil,
+		"mirror", c.mirror, "copy modules into a local directory or S3 bucket", subcmd.Params(
+			"-dry-run", subcmd.Bool, false, "show what would be copied without doing it",
+			"-work", subcmd.String, "", "go.work file: also mirror the requirements of its modules",
+			"-to", subcmd.String, "", "destination: a directory or s3://BUCKET/PREFIX",
+			"-s3-region", subcmd.String, os.Getenv("AWS_REGION"), "S3 region",
+			"-s3-endpoint", subcmd.String, "", "base URL of an S3-compatible service (default: Amazon S3)",
+		),
 		"mod", c.mod, "get the go.mod file for a module", nil,
+		"packages", c.packages, "list the packages in a module", nil,
+		"publish", c.publish, "publish a local module version into a directory servable as a proxy", subcmd.Params(
+			"-dest", subcmd.String, "", "destination directory",
+			"-time", subcmd.String, "", "time of the version, in RFC 3339 format (default: now)",
+			"dir", subcmd.String, "", "directory containing the module's go.mod file",
+			"version", subcmd.String, "", "version to publish",
+		),
+		"query", c.query, "resolve version queries as go get does", subcmd.Params(
+			"-from", subcmd.String, "", "current version, for upgrade and patch queries",
+		),
+		"rdeps", c.rdeps, "find modules in a corpus that require a module", subcmd.Params(
+			"-corpus", subcmd.String, "", "file listing the module versions to search",
+		),
+		"report", c.report, "summarize release cadence and status of modules", subcmd.Params(
+			"-json", subcmd.Bool, false, "produce JSON output",
+			"-work", subcmd.String, "", "go.work file: also report on the requirements of its modules",
+		),
+		"repo", c.repo, "find the source repository for a module", nil,
+		"rezip", c.rezip, "re-create a module zip file from an extracted directory", subcmd.Params(
+			"-o", subcmd.String, "", "write the zip file here (default: standard output, unless -verify is given)",
+			"-verify", subcmd.Bool, false, "check that the directory matches the original module",
+			"module", subcmd.String, "", "module in MODULE@VERSION form",
+			"dir", subcmd.String, "", "directory holding the module's files",
+		),
+		"sign", c.sign, "write detached signatures for files such as bundles", subcmd.Params(
+			"-key", subcmd.String, "", "PEM-encoded Ed25519 private key",
+		),
+		"submodules", c.submodules, "find modules nested under a module path", subcmd.Params(
+			"-corpus", subcmd.String, "", "file listing module versions, as additional candidates",
+		),
+		"toolchain", c.toolchain, "list or download Go toolchains", nil,
+		"verify", c.verify, "check files against their detached signatures", subcmd.Params(
+			"-pubkey", subcmd.String, "", "PEM-encoded Ed25519 public key",
+		),
 		"zip", c.zip, "get the zip file for a module", nil,
 	)
+	maps.Copy(m, c.apidiffSubcmds())
+	return m
 }
 
-func (c maincmd) info(ctx context.Context, args []string) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
+func (c maincmd) changelog(ctx context.Context, token, mod, from, to string, _ []string) error {
+	repo, err := c.cl.Repo(ctx, mod)
+	if err != nil {
+		return wrapf(err, "finding repository for %s", mod)
+	}
 
-	for _, arg := range args {
+	var src goproxyclient.ReleaseSource
+	switch s := goproxyclient.ReleaseSourceFor(repo, nil, token).(type) {
+	case goproxyclient.GitHubReleases:
+		if s.Token == "" {
+			s.Token = os.Getenv("GITHUB_TOKEN")
+		}
+		src = s
+	case goproxyclient.GitLabReleases:
+		if s.Token == "" {
+			s.Token = os.Getenv("GITLAB_TOKEN")
+		}
+		src = s
+	default:
+		return errorf("no release notes source for repository %s", repo.URL)
+	}
+
+	rels, err := c.cl.Changelog(ctx, mod, from, to, src)
+	if err != nil {
+		return wrapf(err, "getting changelog for %s", mod)
+	}
+	for i, rel := range rels {
+		if i > 0 {
+			fmt.Println()
+		}
+		title := rel.Version
+		if rel.Name != "" && rel.Name != rel.Version {
+			title += " - " + rel.Name
+		}
+		if !rel.Time.IsZero() {
+			title += " (" + rel.Time.Format(time.DateOnly) + ")"
+		}
+		printf("## %s\n\n", title)
+		if notes := strings.TrimSpace(rel.Notes); notes != "" {
+			fmt.Println(notes)
+		}
+		if rel.URL != "" {
+			printf("\n%s\n", rel.URL)
+		}
+	}
+	return nil
+}
+
+func (c maincmd) checkProxy(ctx context.Context, probeArg string, args []string) error {
+	var probe module.Version
+	if probeArg != "" {
+		probe.Path, probe.Version, _ = strings.Cut(probeArg, "@")
+	}
+
+	var failed atomic.Int64
+	err := c.each(args, func(arg string, w io.Writer) error {
+		report, err := goproxyclient.CheckProxy(ctx, arg, nil, probe, c.opts...)
+		if err != nil {
+			return wrapf(err, "checking %s", arg)
+		}
+
+		status := "ok"
+		if !report.OK() {
+			status = "FAIL"
+			failed.Add(1)
+		}
+		fprintf(w, "%s (%s): %s\n", report.Proxy, report.Probe, status)
+		for _, check := range report.Checks {
+			result := "ok"
+			switch {
+			case check.Err != nil && check.Optional:
+				result = "warn"
+			case check.Err != nil:
+				result = "FAIL"
+			}
+			fprintf(w, "  %-4s  %-15s  %s", result, check.Name, check.Elapsed.Round(time.Millisecond))
+			if check.Err != nil {
+				fprintf(w, "  %s", check.Err)
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n := failed.Load(); n > 0 {
+		return errorf("%d of %d proxies failed", n, len(args))
+	}
+	return nil
+}
+
+func (c maincmd) extract(ctx context.Context, dryRun bool, include, exclude, arg, dir string, _ []string) error {
+	parts := strings.Split(arg, "@")
+	if len(parts) != 2 {
+		return errorf("argument %s is not in MODULE@VERSION form", arg)
+	}
+	filter, err := goproxyclient.ParseExtractFilter(include, exclude)
+	if err != nil {
+		return wrap(err, "parsing -include and -exclude")
+	}
+	if dryRun {
+		return c.dryRunZip(ctx, parts[0], parts[1], dir)
+	}
+	err = c.cl.ExtractFiltered(ctx, parts[0], parts[1], dir, filter)
+	return wrapf(err, "extracting %s", arg)
+}
+
+// dryRunZip describes the download and extraction of a module zip file
+// without doing it.
+func (c maincmd) dryRunZip(ctx context.Context, mod, ver, dir string) error {
+	sizeStr, err := c.zipSizeString(ctx, mod, ver)
+	if err != nil {
+		return err
+	}
+	printf("would fetch %s@%s (%s) and extract into %s\n", mod, ver, sizeStr, dir)
+	return nil
+}
+
+// zipSizeString describes the size of a module zip file
+// without downloading it.
+func (c maincmd) zipSizeString(ctx context.Context, mod, ver string) (string, error) {
+	size, err := c.cl.ZipSize(ctx, mod, ver)
+	if err != nil {
+		return "", wrapf(err, "getting zip size for %s@%s", mod, ver)
+	}
+	if size < 0 {
+		return tr("unknown size"), nil
+	}
+	return sprintf("%d bytes", size), nil
+}
+
+func (c maincmd) head(ctx context.Context, args []string) error {
+	return c.each(args, func(arg string, w io.Writer) error {
 		parts := strings.Split(arg, "@")
 		if len(parts) != 2 {
-			return fmt.Errorf("argument %s is not in MODULE@VERSION form", arg)
+			return errorf("argument %s is not in MODULE@BRANCH form", arg)
 		}
-		_, _, m, err := c.cl.Info(ctx, parts[0], parts[1])
+		head, err := c.cl.BranchHead(ctx, parts[0], parts[1])
 		if err != nil {
-			return errors.Wrapf(err, "getting info for %s", arg)
+			return wrapf(err, "getting branch head for %s", arg)
 		}
-		if err := enc.Encode(m); err != nil {
-			return errors.Wrapf(err, "encoding info for %s", arg)
+
+		if len(args) > 1 {
+			fprintf(w, "%s: ", arg)
+		}
+		fmt.Fprintln(w, head.Version, head.Hash, head.Time.Format(time.RFC3339))
+		return nil
+	})
+}
+
+func (c maincmd) imports(ctx context.Context, external, tests bool, args []string) error {
+	if len(args) != 1 {
+		return errorf("exactly one argument is required")
+	}
+	parts := strings.Split(args[0], "@")
+	if len(parts) != 2 {
+		return errorf("argument %s is not in MODULE@VERSION form", args[0])
+	}
+	g, err := c.cl.Imports(ctx, parts[0], parts[1], tests)
+	if err != nil {
+		return wrapf(err, "getting imports for %s", args[0])
+	}
+
+	if external {
+		for _, p := range g.External() {
+			fmt.Println(p)
+		}
+		return nil
+	}
+
+	pkgs := slices.Sorted(maps.Keys(g.Packages))
+	for _, pkg := range pkgs {
+		printf("%s:\n", pkg)
+		for _, p := range g.Packages[pkg] {
+			printf("  %s\n", p)
 		}
 	}
 
 	return nil
 }
 
+func (c maincmd) exists(ctx context.Context, args []string) error {
+	return c.each(args, func(arg string, w io.Writer) error {
+		mod, ver, ok := strings.Cut(arg, "@")
+		if !ok {
+			return errorf("argument %s is not in MODULE@VERSION form", arg)
+		}
+		exists, err := c.cl.Exists(ctx, mod, ver)
+		if err != nil {
+			return wrapf(err, "checking %s", arg)
+		}
+		if exists {
+			fprintf(w, "%s: found\n", arg)
+		} else {
+			fprintf(w, "%s: not found\n", arg)
+		}
+		return nil
+	})
+}
+
+func (c maincmd) download(ctx context.Context, dryRun bool, work string, args []string) error {
+	args, err := appendWorkspace(args, work, true)
+	if err != nil {
+		return err
+	}
+	return c.each(args, func(arg string, w io.Writer) error {
+		parts := strings.Split(arg, "@")
+		if len(parts) != 2 {
+			return errorf("argument %s is not in MODULE@VERSION form", arg)
+		}
+		if dryRun {
+			ver, _, _, err := c.cl.Info(ctx, parts[0], parts[1])
+			if err != nil {
+				return wrapf(err, "getting info for %s", arg)
+			}
+			sizeStr, err := c.zipSizeString(ctx, parts[0], ver)
+			if err != nil {
+				return err
+			}
+			fprintf(w, "would fetch %s@%s (%s)\n", parts[0], ver, sizeStr)
+			return nil
+		}
+		d, err := c.cl.Download(ctx, parts[0], parts[1])
+		if err != nil {
+			return wrapf(err, "downloading %s", arg)
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return wrapf(enc.Encode(d), "encoding description of %s", arg)
+	})
+}
+
+func (c maincmd) info(ctx context.Context, args []string) error {
+	return c.each(args, func(arg string, w io.Writer) error {
+		parts := strings.Split(arg, "@")
+		if len(parts) != 2 {
+			return errorf("argument %s is not in MODULE@VERSION form", arg)
+		}
+		_, _, m, err := c.cl.Info(ctx, parts[0], parts[1])
+		if err != nil {
+			return wrapf(err, "getting info for %s", arg)
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return wrapf(enc.Encode(m), "encoding info for %s", arg)
+	})
+}
+
 func (c maincmd) latest(ctx context.Context, args []string) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
+	return c.each(args, func(arg string, w io.Writer) error {
+		ver, _, m, err := c.cl.Latest(ctx, arg)
+		if err != nil {
+			return wrapf(err, "getting latest info for %s", arg)
+		}
 
-	for _, arg := range args {
-		_, _, m, err := c.cl.Latest(ctx, arg)
+		// Warn about deprecated modules and retracted versions,
+		// so that scripted upgrades don't move onto them unawares.
+		status, err := c.cl.Status(ctx, arg)
 		if err != nil {
-			return errors.Wrapf(err, "getting latest info for %s", arg)
+			return wrapf(err, "getting status of %s", arg)
 		}
-		if err := enc.Encode(m); err != nil {
-			return errors.Wrapf(err, "encoding latest info for %s", arg)
+		if status.Deprecated != "" {
+			fprintf(os.Stderr, "WARNING: module %s is deprecated: %s\n", arg, status.Deprecated)
+			m["Deprecated"] = jsonString(status.Deprecated)
+		}
+		if retracted, rationale := status.Retracted(ver); retracted {
+			if rationale == "" {
+				fprintf(os.Stderr, "WARNING: %s@%s is retracted\n", arg, ver)
+			} else {
+				fprintf(os.Stderr, "WARNING: %s@%s is retracted: %s\n", arg, ver, rationale)
+			}
+			m["Retracted"] = json.RawMessage("true")
+			if rationale != "" {
+				m["RetractRationale"] = jsonString(rationale)
+			}
 		}
-	}
 
-	return nil
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return wrapf(enc.Encode(m), "encoding latest info for %s", arg)
+	})
+}
+
+// jsonString returns the JSON encoding of s.
+func jsonString(s string) json.RawMessage {
+	j, _ := json.Marshal(s)
+	return j
 }
 
 func (c maincmd) list(ctx context.Context, args []string) error {
-	for _, arg := range args {
+	return c.each(args, func(arg string, w io.Writer) error {
 		versions, err := c.cl.List(ctx, arg)
 		if err != nil {
-			return errors.Wrapf(err, "getting versions for %s", arg)
+			return wrapf(err, "getting versions for %s", arg)
 		}
 		semver.Sort(versions)
 
 		if len(args) > 1 {
-			fmt.Printf("%s:\n", arg)
+			fprintf(w, "%s:\n", arg)
 		}
 
 		for _, v := range versions {
 			if len(args) > 1 {
-				fmt.Print("  ")
+				fmt.Fprint(w, "  ")
+			}
+			fmt.Fprintln(w, v)
+		}
+		return nil
+	})
+}
+
+func (c maincmd) mirror(ctx context.Context, dryRun bool, work, to, region, endpoint string, args []string) error {
+	if to == "" {
+		return errorf("-to is required")
+	}
+	args, err := appendWorkspace(args, work, true)
+	if err != nil {
+		return err
+	}
+	st, err := parseStorage(to, region, endpoint)
+	if err != nil {
+		return wrap(err, "parsing -to")
+	}
+	m := goproxyclient.NewMirror(c.cl, st)
+
+	return c.each(args, func(arg string, w io.Writer) error {
+		mod, ver, ok := strings.Cut(arg, "@")
+		if !ok {
+			if dryRun {
+				versions, err := c.cl.List(ctx, mod)
+				if err != nil {
+					return wrapf(err, "listing versions of %s", mod)
+				}
+				for _, ver := range versions {
+					if err := c.dryRunMirror(ctx, st, mod, ver, w); err != nil {
+						return err
+					}
+				}
+				return nil
 			}
-			fmt.Println(v)
+			return wrapf(m.Sync(ctx, mod), "mirroring %s", mod)
+		}
+		ver, _, _, err := c.cl.Info(ctx, mod, ver)
+		if err != nil {
+			return wrapf(err, "getting info for %s", arg)
 		}
+		if dryRun {
+			return c.dryRunMirror(ctx, st, mod, ver, w)
+		}
+		return wrapf(m.SyncVersion(ctx, mod, ver), "mirroring %s", arg)
+	})
+}
+
+// dryRunMirror describes the copying of a module version into st
+// without doing it.
+func (c maincmd) dryRunMirror(ctx context.Context, st goproxyclient.Storage, mod, ver string, w io.Writer) error {
+	escPath, err := module.EscapePath(mod)
+	if err != nil {
+		return wrapf(err, "escaping module path %s", mod)
+	}
+	escVer, err := module.EscapeVersion(ver)
+	if err != nil {
+		return wrapf(err, "escaping version %s", ver)
 	}
+	prefix := escPath + "/@v/" + escVer
 
+	var missing []string
+	for _, suffix := range []string{".info", ".mod", ".zip"} {
+		ok, err := st.Exists(ctx, prefix+suffix)
+		if err != nil {
+			return wrapf(err, "checking for %s", prefix+suffix)
+		}
+		if !ok {
+			missing = append(missing, suffix)
+		}
+	}
+	if len(missing) == 0 {
+		fprintf(w, "%s@%s is already mirrored\n", mod, ver)
+		return nil
+	}
+	if !slices.Contains(missing, ".zip") {
+		fprintf(w, "would copy %s@%s (%s)\n", mod, ver, strings.Join(missing, ", "))
+		return nil
+	}
+	sizeStr, err := c.zipSizeString(ctx, mod, ver)
+	if err != nil {
+		return err
+	}
+	fprintf(w, "would copy %s@%s (%s; zip file %s)\n", mod, ver, strings.Join(missing, ", "), sizeStr)
 	return nil
 }
 
+func (c maincmd) publish(ctx context.Context, dest, tmStr, dir, ver string, _ []string) error {
+	if dest == "" {
+		return errorf("-dest is required")
+	}
+	var tm time.Time
+	if tmStr != "" {
+		var err error
+		tm, err = time.Parse(time.RFC3339, tmStr)
+		if err != nil {
+			return wrap(err, "parsing -time")
+		}
+	}
+	mod, err := goproxyclient.Publish(ctx, goproxyclient.DirStorage(dest), dir, ver, tm)
+	if err != nil {
+		return wrapf(err, "publishing %s", dir)
+	}
+	printf("published %s@%s\n", mod, ver)
+	return nil
+}
+
+func (c maincmd) query(ctx context.Context, from string, args []string) error {
+	return c.each(args, func(arg string, w io.Writer) error {
+		mod, query, ok := strings.Cut(arg, "@")
+		if !ok {
+			query = "latest"
+		}
+		ver, err := c.cl.QueryFrom(ctx, mod, query, from)
+		if err != nil {
+			return wrapf(err, "resolving %s", arg)
+		}
+		fprintf(w, "%s@%s\n", mod, ver)
+		return nil
+	})
+}
+
+// AppendWorkspace appends to args
+// the module versions to fetch for the requirements of the workspace
+// in the go.work file at path
+// (see [goproxyclient.Workspace]),
+// in MODPATH@VERSION form,
+// or as bare module paths if withVersions is false.
+// Arguments are not repeated.
+// If path is empty, args is returned unchanged.
+func appendWorkspace(args []string, path string, withVersions bool) ([]string, error) {
+	if path == "" {
+		return args, nil
+	}
+	ws, err := goproxyclient.LoadWorkspace(path)
+	if err != nil {
+		return nil, wrap(err, "loading -work file")
+	}
+	for _, req := range ws.Requires {
+		mv, ok := req.Fetch()
+		if !ok {
+			continue
+		}
+		arg := mv.Path
+		if withVersions {
+			arg += "@" + mv.Version
+		}
+		if !slices.Contains(args, arg) {
+			args = append(args, arg)
+		}
+	}
+	return args, nil
+}
+
+// ParseStorage parses the value of the mirror command's -to flag:
+// either s3://BUCKET/PREFIX or a directory.
+func parseStorage(to, region, endpoint string) (goproxyclient.Storage, error) {
+	rest, ok := strings.CutPrefix(to, "s3://")
+	if !ok {
+		return goproxyclient.DirStorage(to), nil
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, errorf("no bucket in %s", to)
+	}
+	if region == "" && endpoint == "" {
+		return nil, errorf("-s3-region is required for %s", to)
+	}
+	return goproxyclient.S3Storage{
+		Bucket:      bucket,
+		Prefix:      strings.Trim(prefix, "/"),
+		Region:      region,
+		Credentials: goproxyclient.AWSCredentialsFromEnv(),
+		Endpoint:    endpoint,
+	}, nil
+}
+
 func (c maincmd) mod(ctx context.Context, args []string) error {
 	if len(args) != 1 {
-		return fmt.Errorf("exactly one argument is required")
+		return errorf("exactly one argument is required")
 	}
 	parts := strings.Split(args[0], "@")
 	if len(parts) != 2 {
-		return fmt.Errorf("argument %s is not in MODULE@VERSION form", args[0])
+		return errorf("argument %s is not in MODULE@VERSION form", args[0])
+	}
+	_, err := c.cl.ModTo(ctx, parts[0], parts[1], os.Stdout)
+	return wrapf(err, "getting mod file for %s", args[0])
+}
+
+func (c maincmd) packages(ctx context.Context, args []string) error {
+	return c.each(args, func(arg string, w io.Writer) error {
+		parts := strings.Split(arg, "@")
+		if len(parts) != 2 {
+			return errorf("argument %s is not in MODULE@VERSION form", arg)
+		}
+		pkgs, err := c.cl.Packages(ctx, parts[0], parts[1], nil)
+		if err != nil {
+			return wrapf(err, "getting packages for %s", arg)
+		}
+		for _, pkg := range pkgs {
+			fmt.Fprintln(w, pkg)
+		}
+		return nil
+	})
+}
+
+func (c maincmd) rdeps(ctx context.Context, corpusFile string, args []string) error {
+	if corpusFile == "" {
+		return errorf("-corpus is required")
+	}
+	corpus, err := goproxyclient.LoadCorpus(c.cl, corpusFile)
+	if err != nil {
+		return wrap(err, "loading corpus")
+	}
+
+	return c.each(args, func(arg string, w io.Writer) error {
+		rdeps, err := corpus.ReverseDeps(ctx, arg)
+		if err != nil {
+			return wrapf(err, "finding reverse dependencies of %s", arg)
+		}
+
+		if len(args) > 1 {
+			fprintf(w, "%s:\n", arg)
+		}
+
+		for _, rdep := range rdeps {
+			if len(args) > 1 {
+				fmt.Fprint(w, "  ")
+			}
+			fprintf(w, "%s@%s requires %s\n", rdep.Module.Path, rdep.Module.Version, rdep.Requires)
+		}
+		return nil
+	})
+}
+
+func (c maincmd) submodules(ctx context.Context, corpusFile string, args []string) error {
+	var corpus *goproxyclient.Corpus
+	if corpusFile != "" {
+		var err error
+		corpus, err = goproxyclient.LoadCorpus(c.cl, corpusFile)
+		if err != nil {
+			return wrap(err, "loading corpus")
+		}
+	}
+
+	return c.each(args, func(arg string, w io.Writer) error {
+		mods, err := c.cl.Submodules(ctx, arg, corpus)
+		if err != nil {
+			return wrapf(err, "finding submodules of %s", arg)
+		}
+
+		if len(args) > 1 {
+			fprintf(w, "%s:\n", arg)
+		}
+
+		for _, mv := range mods {
+			if len(args) > 1 {
+				fmt.Fprint(w, "  ")
+			}
+			fprintf(w, "%s@%s\n", mv.Path, mv.Version)
+		}
+		return nil
+	})
+}
+
+func (c maincmd) repo(ctx context.Context, args []string) error {
+	return c.each(args, func(arg string, w io.Writer) error {
+		repo, err := c.cl.Repo(ctx, arg)
+		if err != nil {
+			return wrapf(err, "finding repository for %s", arg)
+		}
+
+		if len(args) > 1 {
+			fprintf(w, "%s: ", arg)
+		}
+		fmt.Fprint(w, repo.VCS, " ", repo.URL)
+		if repo.Subdir != "" {
+			fmt.Fprint(w, " ", repo.Subdir)
+		}
+		fmt.Fprintln(w)
+		return nil
+	})
+}
+
+func (c maincmd) rezip(ctx context.Context, out string, verify bool, arg, dir string, _ []string) error {
+	mod, ver, ok := strings.Cut(arg, "@")
+	if !ok {
+		return errorf("argument %s is not in MODULE@VERSION form", arg)
+	}
+
+	if verify {
+		hash, err := c.cl.VerifyRezip(ctx, mod, ver, dir)
+		if err != nil {
+			return wrapf(err, "verifying %s", dir)
+		}
+		fprintf(os.Stderr, "%s matches %s (%s)\n", dir, arg, hash)
+		if out == "" {
+			return nil
+		}
+	}
+
+	if out == "" {
+		return goproxyclient.Rezip(os.Stdout, mod, ver, dir)
+	}
+
+	return goproxyclient.RezipFile(out, mod, ver, dir)
+}
+
+func (c maincmd) sign(_ context.Context, keyFile string, args []string) error {
+	if keyFile == "" {
+		return errorf("-key is required")
 	}
-	mod, err := c.cl.Mod(ctx, parts[0], parts[1])
+	key, err := readEd25519Key(keyFile)
 	if err != nil {
-		return errors.Wrapf(err, "getting mod file for %s", args[0])
+		return wrap(err, "reading -key")
+	}
+	for _, arg := range args {
+		if err := goproxyclient.SignFile(arg, key); err != nil {
+			return err
+		}
 	}
-	defer mod.Close()
+	return nil
+}
 
-	_, err = io.Copy(os.Stdout, mod)
-	return errors.Wrapf(err, "writing mod file for %s", args[0])
+func (c maincmd) verify(_ context.Context, pubkeyFile string, args []string) error {
+	if pubkeyFile == "" {
+		return errorf("-pubkey is required")
+	}
+	pub, err := readEd25519PublicKey(pubkeyFile)
+	if err != nil {
+		return wrap(err, "reading -pubkey")
+	}
+	for _, arg := range args {
+		if err := goproxyclient.VerifyFile(arg, pub); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (c maincmd) zip(ctx context.Context, args []string) error {
 	if len(args) != 1 {
-		return fmt.Errorf("exactly one argument is required")
+		return errorf("exactly one argument is required")
 	}
 	parts := strings.Split(args[0], "@")
 	if len(parts) != 2 {
-		return fmt.Errorf("argument %s is not in MODULE@VERSION form", args[0])
+		return errorf("argument %s is not in MODULE@VERSION form", args[0])
 	}
-	z, err := c.cl.Zip(ctx, parts[0], parts[1])
-	if err != nil {
-		return errors.Wrapf(err, "getting zip file for %s", args[0])
-	}
-	defer z.Close()
-
-	_, err = io.Copy(os.Stdout, z)
-	return errors.Wrapf(err, "writing zip file for %s", args[0])
+	_, err := c.cl.ZipTo(ctx, parts[0], parts[1], os.Stdout)
+	return wrapf(err, "getting zip file for %s", args[0])
 }
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/bobg/goproxyclient"
+	"github.com/bobg/goproxyclient/goproxytest"
+)
+
+// TestMain runs the command itself,
+// instead of the tests,
+// when the test binary is started by [runCmd].
+func TestMain(m *testing.M) {
+	if os.Getenv("GOPROXYCLIENT_TEST_MAIN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// RunCmd runs the command with the given arguments
+// and returns its standard output, standard error, and exit code.
+// Each of env is a NAME=VALUE pair to add to its environment.
+// The Go environment variables that the command reads
+// are removed from the environment first.
+func runCmd(t *testing.T, env []string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		switch name {
+		case "GOPROXY", "GOSUMDB", "GONOSUMDB", "GOPRIVATE", "GOVCS", "GOINSECURE", "GOPROXYCLIENT_MESSAGES":
+			continue
+		}
+		cmd.Env = append(cmd.Env, kv)
+	}
+	cmd.Env = append(cmd.Env, "GOPROXYCLIENT_TEST_MAIN=1")
+	cmd.Env = append(cmd.Env, env...)
+
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		code = exitErr.ExitCode()
+	case err != nil:
+		t.Fatal(err)
+	}
+	return outBuf.String(), errBuf.String(), code
+}
+
+func TestJobsOrder(t *testing.T) {
+	mods := []string{"example.com/multi", "github.com/bobg/mid", "github.com/bobg/errors", "github.com/bobg/subcmd/v2"}
+
+	// Each module's version list takes longer than the next one's,
+	// so that with -j the arguments finish in reverse order.
+	h := goproxytest.New(os.DirFS("../../testdata"))
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for i, mod := range mods {
+			if req.URL.Path == "/"+mod+"/@v/list" {
+				time.Sleep(time.Duration(len(mods)-i) * 50 * time.Millisecond)
+			}
+		}
+		h.ServeHTTP(w, req)
+	}))
+	defer s.Close()
+
+	want, _, code := runCmd(t, nil, append([]string{"-proxy", s.URL, "list"}, mods...)...)
+	if code != 0 {
+		t.Fatalf("got exit code %d without -j", code)
+	}
+
+	var headers []string
+	for _, line := range strings.Split(want, "\n") {
+		if mod, ok := strings.CutSuffix(line, ":"); ok {
+			headers = append(headers, mod)
+		}
+	}
+	if !slices.Equal(headers, mods) {
+		t.Fatalf("got modules %v without -j, want %v", headers, mods)
+	}
+
+	for _, jobs := range []string{"2", "4"} {
+		got, _, code := runCmd(t, nil, append([]string{"-proxy", s.URL, "-j", jobs, "list"}, mods...)...)
+		if code != 0 {
+			t.Errorf("got exit code %d with -j %s", code, jobs)
+		}
+		if got != want {
+			t.Errorf("with -j %s, got:\n%s\nwant:\n%s", jobs, got, want)
+		}
+	}
+}
+
+func TestLatestWarnings(t *testing.T) {
+	fsys := fstest.MapFS{
+		"example.com/old/@latest":        {Data: []byte(`{"Version":"v1.1.0","Time":"2024-02-01T00:00:00Z"}`)},
+		"example.com/old/@v/list":        {Data: []byte("v1.0.0\nv1.1.0\n")},
+		"example.com/old/@v/v1.1.0.info": {Data: []byte(`{"Version":"v1.1.0","Time":"2024-02-01T00:00:00Z"}`)},
+		"example.com/old/@v/v1.1.0.mod": {Data: []byte("// Deprecated: use example.com/new instead.\nmodule example.com/old\n\n" +
+			"retract v1.1.0 // Published by mistake.\n")},
+		"example.com/fine/@latest":        {Data: []byte(`{"Version":"v1.0.0","Time":"2024-01-01T00:00:00Z"}`)},
+		"example.com/fine/@v/list":        {Data: []byte("v1.0.0\n")},
+		"example.com/fine/@v/v1.0.0.info": {Data: []byte(`{"Version":"v1.0.0","Time":"2024-01-01T00:00:00Z"}`)},
+		"example.com/fine/@v/v1.0.0.mod":  {Data: []byte("module example.com/fine\n")},
+	}
+	s := goproxytest.NewServer(fsys)
+	defer s.Close()
+
+	t.Run("deprecated", func(t *testing.T) {
+		stdout, stderr, code := runCmd(t, nil, "-proxy", s.URL, "latest", "example.com/old")
+		if code != 0 {
+			t.Errorf("got exit code %d, want 0 (stderr: %s)", code, stderr)
+		}
+		for _, want := range []string{
+			"WARNING: module example.com/old is deprecated: use example.com/new instead.\n",
+			"WARNING: example.com/old@v1.1.0 is retracted: Published by mistake.\n",
+		} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("got stderr %q, want it to contain %q", stderr, want)
+			}
+		}
+
+		// The warnings are not mixed into the JSON output.
+		var got struct {
+			Version          string
+			Deprecated       string
+			Retracted        bool
+			RetractRationale string
+		}
+		if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+			t.Fatalf("decoding %q: %s", stdout, err)
+		}
+		if got.Version != "v1.1.0" || got.Deprecated != "use example.com/new instead." || !got.Retracted || got.RetractRationale != "Published by mistake." {
+			t.Errorf("got %+v", got)
+		}
+	})
+
+	t.Run("fine", func(t *testing.T) {
+		stdout, stderr, code := runCmd(t, nil, "-proxy", s.URL, "latest", "example.com/fine")
+		if code != 0 {
+			t.Errorf("got exit code %d, want 0", code)
+		}
+		if stderr != "" {
+			t.Errorf("got stderr %q, want none", stderr)
+		}
+		if strings.Contains(stdout, "Deprecated") || strings.Contains(stdout, "Retracted") {
+			t.Errorf("got %s, want no deprecation or retraction", stdout)
+		}
+	})
+}
+
+func TestExitCodes(t *testing.T) {
+	s := goproxytest.NewServer(os.DirFS("../../testdata"))
+	defer s.Close()
+
+	cases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"success", []string{"-proxy", s.URL, "info", "example.com/multi@v1.0.0"}, 0},
+		{"other", []string{"-proxy", s.URL, "info", "example.com/multi"}, 1},
+		{"unknown_flag", []string{"-bogus"}, 2},
+		{"unknown_command", []string{"bogus"}, 2},
+		{"missing_argument", []string{"extract", "example.com/multi@v1.0.0"}, 2},
+		{"not_found", []string{"-proxy", s.URL, "info", "example.com/missing@v1.0.0"}, 3},
+		{"off", []string{"-proxy", "off", "info", "example.com/multi@v1.0.0"}, 10},
+	}
+
+	// Each error class has its own exit status,
+	// distinct from those for other errors and bad usage.
+	seen := make(map[int]goproxyclient.ErrorClass)
+	for class := goproxyclient.NotFound; class <= goproxyclient.NoSpace; class++ {
+		code, ok := exitCodes[class]
+		if !ok || code < 3 {
+			t.Errorf("got exit code %d for %s, want 3 or more", code, class)
+		}
+		if prev, ok := seen[code]; ok {
+			t.Errorf("exit code %d is for both %s and %s", code, prev, class)
+		}
+		seen[code] = class
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, stderr, code := runCmd(t, nil, tc.args...)
+			if code != tc.want {
+				t.Errorf("got exit code %d, want %d (stderr: %s)", code, tc.want, stderr)
+			}
+		})
+	}
+}
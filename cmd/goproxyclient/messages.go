@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bobg/errors"
+)
+
+// Messages is the catalog of translations for the command's user-facing strings
+// (errors, warnings, tables, and the like),
+// loaded by loadMessages.
+// Each key is a message's English format string,
+// exactly as it appears in the source,
+// and each value is its translation,
+// with the same formatting verbs
+// (which may be reordered using explicit argument indexes, as in %[2]s).
+// Messages with no translation are printed in English.
+var messages map[string]string
+
+// Tr returns the translation of the English message s,
+// or s itself if there is none.
+func tr(s string) string {
+	if t, ok := messages[s]; ok {
+		return t
+	}
+	return s
+}
+
+func printf(format string, args ...any) {
+	fmt.Printf(tr(format), args...)
+}
+
+func sprintf(format string, args ...any) string {
+	return fmt.Sprintf(tr(format), args...)
+}
+
+func fprintf(w io.Writer, format string, args ...any) {
+	fmt.Fprintf(w, tr(format), args...)
+}
+
+func errorf(format string, args ...any) error {
+	return fmt.Errorf(tr(format), args...)
+}
+
+func wrap(err error, msg string) error {
+	return errors.Wrap(err, tr(msg))
+}
+
+func wrapf(err error, format string, args ...any) error {
+	return errors.Wrapf(err, tr(format), args...)
+}
+
+// LoadMessages loads the catalog of translations from path
+// (see [messages]),
+// a JSON file holding an object that maps English messages to their translations.
+//
+// If path is a directory,
+// the file in it is chosen according to the user's locale,
+// as given by the first of $LC_ALL, $LC_MESSAGES, and $LANG that is set.
+// For a locale like de_DE.UTF-8
+// that is de_DE.json if it exists,
+// and otherwise de.json.
+// If there is neither (or no locale),
+// messages are printed in English.
+func loadMessages(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		var ok bool
+		path, ok = localeFile(path)
+		if !ok {
+			return nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return wrapf(err, "parsing %s", path)
+	}
+	messages = m
+	return nil
+}
+
+// LocaleFile finds the catalog file in dir for the user's locale
+// (see [loadMessages]).
+func localeFile(dir string) (string, bool) {
+	locale := os.Getenv("LC_ALL")
+	if locale == "" {
+		locale = os.Getenv("LC_MESSAGES")
+	}
+	if locale == "" {
+		locale = os.Getenv("LANG")
+	}
+
+	// Remove any codeset and modifier, as in de_DE.UTF-8@euro.
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return "", false
+	}
+
+	// Try the full locale (de_DE), then the language alone (de).
+	candidates := []string{locale}
+	if lang, _, ok := strings.Cut(locale, "_"); ok {
+		candidates = append(candidates, lang)
+	}
+	for _, c := range candidates {
+		path := filepath.Join(dir, c+".json")
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocaleFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"de.json", "de_CH.json", "fr.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name                    string
+		lcAll, lcMessages, lang string
+		want                    string // the empty string for none
+	}{
+		{name: "lc_all_first", lcAll: "fr_FR.UTF-8", lcMessages: "de_CH", lang: "de_CH", want: "fr.json"},
+		{name: "lc_messages_before_lang", lcMessages: "de_CH.UTF-8", lang: "fr", want: "de_CH.json"},
+		{name: "lang", lang: "fr", want: "fr.json"},
+		{name: "language_fallback", lang: "de_DE.UTF-8", want: "de.json"},
+		{name: "full_locale", lang: "de_CH.UTF-8", want: "de_CH.json"},
+		{name: "modifier", lang: "de_DE.UTF-8@euro", want: "de.json"},
+		{name: "no_catalog", lang: "ja_JP.UTF-8"},
+		{name: "c", lcAll: "C", lang: "de_DE.UTF-8"},
+		{name: "posix", lang: "POSIX"},
+		{name: "unset"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("LC_ALL", tc.lcAll)
+			t.Setenv("LC_MESSAGES", tc.lcMessages)
+			t.Setenv("LANG", tc.lang)
+
+			got, ok := localeFile(dir)
+			if tc.want == "" {
+				if ok {
+					t.Errorf("got %s, want none", got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("got none, want %s", tc.want)
+			}
+			if want := filepath.Join(dir, tc.want); got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadMessages(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	const catalog = `{"unknown size": "Größe unbekannt", "%s@%s not found": "%[2]s von %[1]s nicht gefunden"}`
+	var (
+		file      = write("messages.json", catalog)
+		localeDir = filepath.Dir(write("locales/de.json", catalog))
+		badFile   = write("bad.json", `{"unknown size": `)
+		badDir    = filepath.Dir(write("bad/de.json", `["unknown size"]`))
+		emptyDir  = filepath.Join(dir, "empty")
+	)
+	if err := os.Mkdir(emptyDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		path    string
+		wantErr bool
+		want    string // translation of "unknown size"
+	}{
+		{name: "file", path: file, want: "Größe unbekannt"},
+		{name: "locale_dir", path: localeDir, want: "Größe unbekannt"},
+		{name: "no_locale_catalog", path: emptyDir, want: "unknown size"},
+		{name: "missing", path: filepath.Join(dir, "missing.json"), wantErr: true},
+		{name: "malformed", path: badFile, wantErr: true},
+		{name: "malformed_locale_catalog", path: badDir, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("LC_ALL", "")
+			t.Setenv("LC_MESSAGES", "")
+			t.Setenv("LANG", "de_DE.UTF-8")
+
+			messages = nil
+			t.Cleanup(func() { messages = nil })
+
+			err := loadMessages(tc.path)
+			if tc.wantErr {
+				if err == nil {
+					t.Error("got no error")
+				}
+				if messages != nil {
+					t.Errorf("got catalog %v after error, want none", messages)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tr("unknown size"); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+
+			// A message missing from the catalog is printed in English.
+			if got := tr("total: %d bytes in %d zip files"); got != "total: %d bytes in %d zip files" {
+				t.Errorf("got %q for a missing message, want it unchanged", got)
+			}
+		})
+	}
+
+	t.Run("reordered_args", func(t *testing.T) {
+		messages = nil
+		t.Cleanup(func() { messages = nil })
+
+		if err := loadMessages(file); err != nil {
+			t.Fatal(err)
+		}
+		err := errorf("%s@%s not found", "example.com/multi", "v1.0.0")
+		if got, want := err.Error(), "v1.0.0 von example.com/multi nicht gefunden"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		if got := sprintf("%d bytes", 3); got != "3 bytes" {
+			t.Errorf("got %q for a missing message, want 3 bytes", got)
+		}
+	})
+}
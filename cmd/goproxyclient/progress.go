@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// progress emits machine-readable progress events for bulk operations.
+// A nil *progress emits nothing.
+type progress struct {
+	enc *json.Encoder
+
+	mu                  sync.Mutex
+	total, done, failed int
+}
+
+type progressEvent struct {
+	Time   time.Time
+	Event  string // "begin", "start", "finish", "fail", or "end"
+	Item   string `json:",omitempty"`
+	Error  string `json:",omitempty"`
+	Total  int
+	Done   int
+	Failed int
+}
+
+func newProgress(mode string, w io.Writer) (*progress, error) {
+	switch mode {
+	case "":
+		return nil, nil
+	case "json":
+		return &progress{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, errorf("unknown progress mode %q", mode)
+	}
+}
+
+func (p *progress) begin(total int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.total, p.done, p.failed = total, 0, 0
+	p.emit("begin", "", nil)
+}
+
+func (p *progress) start(item string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.emit("start", item, nil)
+}
+
+func (p *progress) finish(item string, err error) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err != nil {
+		p.failed++
+		p.emit("fail", item, err)
+		return
+	}
+	p.done++
+	p.emit("finish", item, nil)
+}
+
+func (p *progress) end() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.emit("end", "", nil)
+}
+
+// Emit writes an event.
+// The caller must hold p.mu.
+func (p *progress) emit(event, item string, err error) {
+	ev := progressEvent{
+		Time:   time.Now(),
+		Event:  event,
+		Item:   item,
+		Total:  p.total,
+		Done:   p.done,
+		Failed: p.failed,
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	if err := p.enc.Encode(ev); err != nil {
+		fprintf(os.Stderr, "Error writing progress event: %s\n", err)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bobg/goproxyclient/goproxytest"
+)
+
+func TestProgress(t *testing.T) {
+	s := goproxytest.NewServer(os.DirFS("../../testdata"))
+	defer s.Close()
+
+	args := []string{"example.com/multi@v1.0.0", "github.com/bobg/mid@v1.9.0", "example.com/missing@v1.0.0"}
+
+	// events runs the download command with the given flags
+	// and returns the progress events it reports.
+	events := func(t *testing.T, flags ...string) []progressEvent {
+		t.Helper()
+
+		flags = append([]string{"-proxy", s.URL, "-progress", "json"}, flags...)
+		_, stderr, code := runCmd(t, nil, append(append(flags, "download"), args...)...)
+		if code == 0 {
+			t.Error("got exit code 0, want failure")
+		}
+
+		var result []progressEvent
+		for _, line := range strings.Split(stderr, "\n") {
+			if !strings.HasPrefix(line, "{") {
+				continue
+			}
+			var ev progressEvent
+			if err := json.Unmarshal([]byte(line), &ev); err != nil {
+				t.Fatal(err)
+			}
+			result = append(result, ev)
+		}
+		return result
+	}
+
+	t.Run("sequential", func(t *testing.T) {
+		type event struct {
+			Event, Item         string
+			Total, Done, Failed int
+		}
+		var got []event
+		for _, ev := range events(t) {
+			got = append(got, event{Event: ev.Event, Item: ev.Item, Total: ev.Total, Done: ev.Done, Failed: ev.Failed})
+		}
+		want := []event{
+			{Event: "begin", Total: 3},
+			{Event: "start", Item: args[0], Total: 3},
+			{Event: "finish", Item: args[0], Total: 3, Done: 1},
+			{Event: "start", Item: args[1], Total: 3, Done: 1},
+			{Event: "finish", Item: args[1], Total: 3, Done: 2},
+			{Event: "start", Item: args[2], Total: 3, Done: 2},
+			{Event: "fail", Item: args[2], Total: 3, Done: 2, Failed: 1},
+			{Event: "end", Total: 3, Done: 2, Failed: 1},
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		evs := events(t, "-j", "3")
+		if len(evs) != 8 {
+			t.Fatalf("got %d events, want 8", len(evs))
+		}
+		if evs[0].Event != "begin" || evs[7].Event != "end" {
+			t.Errorf("got %s first and %s last, want begin and end", evs[0].Event, evs[7].Event)
+		}
+
+		var (
+			started  = make(map[string]bool)
+			finished = make(map[string]string)
+			done     int
+		)
+		for _, ev := range evs[1:7] {
+			switch ev.Event {
+			case "start":
+				started[ev.Item] = true
+			case "finish", "fail":
+				if !started[ev.Item] {
+					t.Errorf("got %s for %s before start", ev.Event, ev.Item)
+				}
+				finished[ev.Item] = ev.Event
+				if ev.Done+ev.Failed != done+1 {
+					t.Errorf("got %d done and %d failed after %d finished, want %d in total", ev.Done, ev.Failed, done, done+1)
+				}
+				done++
+			default:
+				t.Errorf("got unexpected %s event", ev.Event)
+			}
+			if ev.Total != 3 {
+				t.Errorf("got total %d in %s event, want 3", ev.Total, ev.Event)
+			}
+		}
+		for i, want := range []string{"finish", "finish", "fail"} {
+			if got := finished[args[i]]; got != want {
+				t.Errorf("got %q for %s, want %q", got, args[i], want)
+			}
+		}
+		if last := evs[7]; last.Done != 2 || last.Failed != 1 {
+			t.Errorf("got %d done and %d failed at end, want 2 and 1", last.Done, last.Failed)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+type moduleReport struct {
+	Module          string
+	Versions        int
+	Latest          string
+	LatestTime      time.Time
+	LatestAge       string
+	MeanInterval    string `json:",omitempty"`
+	MedianInterval  string `json:",omitempty"`
+	Deprecated      string `json:",omitempty"`
+	LatestRetracted bool
+	Retracted       int
+	NewerMajor      string `json:",omitempty"`
+}
+
+func (c maincmd) report(ctx context.Context, asJSON bool, work string, args []string) error {
+	args, err := appendWorkspace(args, work, false)
+	if err != nil {
+		return err
+	}
+	return c.each(args, func(arg string, w io.Writer) error {
+		r, err := c.moduleReport(ctx, arg)
+		if err != nil {
+			return wrapf(err, "reporting on %s", arg)
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return wrapf(enc.Encode(r), "encoding report for %s", arg)
+		}
+
+		fprintf(w, "%s:\n", r.Module)
+		fprintf(w, "  versions:        %d (%d retracted)\n", r.Versions, r.Retracted)
+		fprintf(w, "  latest:          %s (%s, %s ago)", r.Latest, r.LatestTime.Format(time.DateOnly), r.LatestAge)
+		if r.LatestRetracted {
+			fmt.Fprint(w, tr(" RETRACTED"))
+		}
+		fmt.Fprintln(w)
+		if r.MeanInterval != "" {
+			fprintf(w, "  mean interval:   %s\n", r.MeanInterval)
+			fprintf(w, "  median interval: %s\n", r.MedianInterval)
+		}
+		if r.Deprecated != "" {
+			fprintf(w, "  DEPRECATED: %s\n", r.Deprecated)
+		}
+		if r.NewerMajor != "" {
+			fprintf(w, "  newer major:     %s\n", r.NewerMajor)
+		}
+		return nil
+	})
+}
+
+func (c maincmd) moduleReport(ctx context.Context, mod string) (moduleReport, error) {
+	status, err := c.cl.Status(ctx, mod)
+	if err != nil {
+		return moduleReport{}, err
+	}
+	timeline, err := c.cl.Timeline(ctx, mod)
+	if err != nil {
+		return moduleReport{}, wrap(err, "getting version timeline")
+	}
+
+	r := moduleReport{
+		Module:     mod,
+		Versions:   len(timeline),
+		Latest:     status.Latest,
+		Deprecated: status.Deprecated,
+	}
+	r.LatestRetracted, _ = status.Retracted(status.Latest)
+
+	times := make([]time.Time, 0, len(timeline))
+	for _, vt := range timeline {
+		if retracted, _ := status.Retracted(vt.Version); retracted {
+			r.Retracted++
+		}
+		times = append(times, vt.Time)
+		if vt.Version == status.Latest {
+			r.LatestTime = vt.Time
+		}
+	}
+	if r.LatestTime.IsZero() {
+		// The latest version may be a pseudo-version not in the list.
+		_, r.LatestTime, _, err = c.cl.Info(ctx, mod, status.Latest)
+		if err != nil {
+			return moduleReport{}, wrapf(err, "getting info for %s", status.Latest)
+		}
+	}
+	r.LatestAge = formatDuration(time.Since(r.LatestTime))
+
+	if len(times) > 1 {
+		intervals := make([]time.Duration, 0, len(times)-1)
+		for i := 1; i < len(times); i++ {
+			intervals = append(intervals, times[i].Sub(times[i-1]))
+		}
+		slices.Sort(intervals)
+		r.MeanInterval = formatDuration(times[len(times)-1].Sub(times[0]) / time.Duration(len(intervals)))
+		r.MedianInterval = formatDuration(intervals[len(intervals)/2])
+	}
+
+	upgrade, err := c.cl.MajorUpgrades(ctx, mod)
+	if err != nil {
+		return moduleReport{}, wrap(err, "checking for newer major versions")
+	}
+	if latest := upgrade.Latest(); latest.Path != "" {
+		r.NewerMajor = latest.String()
+	}
+
+	return r, nil
+}
+
+// formatDuration renders a duration in days,
+// or in hours when under a day.
+func formatDuration(d time.Duration) string {
+	if d < 24*time.Hour {
+		return sprintf("%.1fh", d.Hours())
+	}
+	return sprintf("%.1fd", d.Hours()/24)
+}
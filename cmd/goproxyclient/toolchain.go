@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/bobg/subcmd/v2"
+
+	"github.com/bobg/goproxyclient"
+)
+
+type toolchaincmd struct {
+	maincmd
+}
+
+func (c maincmd) toolchain(ctx context.Context, args []string) error {
+	return subcmd.Run(ctx, toolchaincmd{maincmd: c}, args)
+}
+
+func (c toolchaincmd) Subcmds() subcmd.Map {
+	return subcmd.Commands(
+		"list", c.list, "list available Go toolchains", subcmd.Params(
+			"-goos", subcmd.String, runtime.GOOS, "target operating system",
+			"-goarch", subcmd.String, runtime.GOARCH, "target architecture",
+		),
+		"download", c.download, "download and extract a Go toolchain", subcmd.Params(
+			"-dry-run", subcmd.Bool, false, "show what would be fetched without doing it",
+			"-goos", subcmd.String, runtime.GOOS, "target operating system",
+			"-goarch", subcmd.String, runtime.GOARCH, "target architecture",
+			"version", subcmd.String, "", "Go release, e.g. go1.22.0",
+			"dir", subcmd.String, "", "destination directory",
+		),
+	)
+}
+
+func (c toolchaincmd) list(ctx context.Context, goos, goarch string, _ []string) error {
+	versions, err := c.cl.Toolchains(ctx, goos, goarch)
+	if err != nil {
+		return wrap(err, "listing toolchains")
+	}
+	for _, v := range versions {
+		fmt.Println(v)
+	}
+	return nil
+}
+
+func (c toolchaincmd) download(ctx context.Context, dryRun bool, goos, goarch, version, dir string, _ []string) error {
+	if dryRun {
+		return c.dryRunZip(ctx, goproxyclient.ToolchainModule, goproxyclient.ToolchainVersion(version, goos, goarch), dir)
+	}
+	err := c.cl.DownloadToolchain(ctx, version, goos, goarch, dir)
+	return wrapf(err, "downloading toolchain %s for %s/%s", version, goos, goarch)
+}
@@ -0,0 +1,105 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobg/errors"
+)
+
+// CodeArtifactAuth returns an [Authenticator] for Go repositories in the AWS CodeArtifact domain with the given name and owner (an AWS account ID).
+// It applies to requests for hosts of the form DOMAIN-OWNER.d.codeartifact.REGION.amazonaws.com,
+// adding HTTP basic auth with username "aws" and a CodeArtifact authorization token as the password.
+//
+// Tokens are obtained by calling fetch,
+// and fetched again when they are close to expiring,
+// so a long-running process using this Authenticator does not start failing when its first token expires.
+// If fetch is nil,
+// [CodeArtifactCLI] is used with the given domain and owner and the default region.
+// Callers preferring the AWS SDK can supply a fetch function that calls GetAuthorizationToken.
+func CodeArtifactAuth(domain, owner string, fetch TokenFunc) Authenticator {
+	if fetch == nil {
+		fetch = CodeArtifactCLI(domain, owner, "")
+	}
+	var (
+		prefix = fmt.Sprintf("%s-%s.d.codeartifact.", domain, owner)
+		tc     = newTokenCache(fetch)
+	)
+	return AuthFunc(func(ctx context.Context, req *http.Request) error {
+		host := req.URL.Hostname()
+		if !strings.HasPrefix(host, prefix) || !strings.HasSuffix(host, ".amazonaws.com") {
+			return nil
+		}
+		tok, err := tc.get(ctx)
+		if err != nil {
+			return errors.Wrap(err, "getting CodeArtifact authorization token")
+		}
+		req.SetBasicAuth("aws", tok)
+		return nil
+	})
+}
+
+// CodeArtifactCLI returns a [TokenFunc] that obtains CodeArtifact authorization tokens by running
+//
+//	aws codeartifact get-authorization-token --domain DOMAIN --domain-owner OWNER [--region REGION] --output json
+//
+// The aws command must be in $PATH and configured with suitable credentials.
+// If region is empty, the aws command's default is used.
+func CodeArtifactCLI(domain, owner, region string) TokenFunc {
+	return func(ctx context.Context) (Token, error) {
+		args := []string{"codeartifact", "get-authorization-token", "--domain", domain, "--domain-owner", owner, "--output", "json"}
+		if region != "" {
+			args = append(args, "--region", region)
+		}
+
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "aws", args...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return Token{}, errors.Wrapf(err, "running aws codeartifact get-authorization-token: %s", strings.TrimSpace(stderr.String()))
+		}
+		return parseCodeArtifactToken(out)
+	}
+}
+
+func parseCodeArtifactToken(out []byte) (Token, error) {
+	var result struct {
+		AuthorizationToken string          `json:"authorizationToken"`
+		Expiration         json.RawMessage `json:"expiration"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return Token{}, errors.Wrap(err, "parsing get-authorization-token output")
+	}
+	if result.AuthorizationToken == "" {
+		return Token{}, fmt.Errorf("no authorizationToken in get-authorization-token output")
+	}
+
+	tok := Token{Value: result.AuthorizationToken}
+
+	// The aws command renders the expiration as an RFC3339 string,
+	// or as seconds since the epoch, depending on its version and configuration.
+	var s string
+	if err := json.Unmarshal(result.Expiration, &s); err == nil {
+		tok.Expiry, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return Token{}, errors.Wrapf(err, "parsing expiration %s", s)
+		}
+		return tok, nil
+	}
+	if len(result.Expiration) > 0 {
+		secs, err := strconv.ParseFloat(string(result.Expiration), 64)
+		if err != nil {
+			return Token{}, errors.Wrapf(err, "parsing expiration %s", result.Expiration)
+		}
+		tok.Expiry = time.Unix(int64(secs), 0)
+	}
+	return tok, nil
+}
@@ -0,0 +1,69 @@
+package goproxyclient
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// CompatStatus reports the status code that a response should be treated as having
+// when the client is in compatibility mode (see [WithCompat]),
+// together with a short explanation when that differs from the actual status code.
+//
+// Some proxy servers respond to requests for missing modules with 401 (Unauthorized)
+// when the request lacks credentials,
+// rather than the 404 (Not Found) that the protocol calls for.
+// Others respond with 200 (OK) and an HTML error or login page.
+// Both cases are mapped to 404 so that fallback to the next proxy in the chain still happens.
+func compatStatus(resp *http.Response) (int, string) {
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return http.StatusNotFound, "possible missing module"
+
+	case http.StatusOK:
+		if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && mediaType == "text/html" {
+			return http.StatusNotFound, "HTML response body"
+		}
+	}
+	return resp.StatusCode, ""
+}
+
+// CompatLatest emulates the @latest endpoint,
+// which some proxy servers do not implement or implement incorrectly,
+// by listing the module's versions and getting info for the highest one.
+// It follows the go command's preference for release versions over prereleases.
+// If that fails,
+// the error from the original @latest query (origErr) is returned.
+//
+// Note, modpath is already escaped.
+func (s single) compatLatest(ctx context.Context, modpath string, origErr error) (string, time.Time, map[string]json.RawMessage, error) {
+	versions, err := s.list(ctx, modpath)
+	if err != nil || len(versions) == 0 {
+		return "", time.Time{}, nil, origErr
+	}
+
+	// Versions are sorted in ascending semver order.
+	best := versions[len(versions)-1]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if semver.Prerelease(versions[i]) == "" {
+			best = versions[i]
+			break
+		}
+	}
+
+	escVer, err := module.EscapeVersion(best)
+	if err != nil {
+		return "", time.Time{}, nil, origErr
+	}
+
+	ver, tm, m, err := s.info(ctx, modpath, escVer)
+	if err != nil {
+		return "", time.Time{}, nil, origErr
+	}
+	return ver, tm, m, nil
+}
@@ -0,0 +1,106 @@
+package goproxyclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentUse runs a mixed workload against a single [Client] from many goroutines.
+// Run it with -race to check the concurrency guarantee documented on Client.
+func TestConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+
+	s1 := httptest.NewServer(testHandler(map[string]int{"/github.com/bobg/": 404}))
+	defer s1.Close()
+	s2 := httptest.NewServer(testHandler(nil))
+	defer s2.Close()
+
+	db, err := OpenVerifiedDB(filepath.Join(t.TempDir(), "verified"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cl := New(s1.URL+","+s2.URL, nil,
+		WithRateLimit(1000, 10),
+		WithScheduler(DefaultSchedulerPolicy),
+		WithBandwidthLimit(10<<20),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithVerifiedDB(db),
+		WithAttestations(t.TempDir(), nil),
+		WithScanner(ScanFunc(func(_ context.Context, _ Artifact, r io.Reader) error {
+			_, err := io.Copy(io.Discard, r)
+			return err
+		})),
+	)
+
+	const (
+		goroutines = 16
+		iterations = 10
+	)
+
+	ops := []func(context.Context) error{
+		func(ctx context.Context) error {
+			_, _, _, err := cl.Info(ctx, "example.com/multi", "v1.0.0")
+			return err
+		},
+		func(ctx context.Context) error {
+			_, _, _, err := cl.Latest(ctx, "github.com/bobg/errors")
+			return err
+		},
+		func(ctx context.Context) error {
+			_, err := cl.List(ctx, "example.com/multi")
+			return err
+		},
+		func(ctx context.Context) error {
+			rc, err := cl.Mod(ctx, "example.com/multi", "v1.0.0")
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			_, err = io.Copy(io.Discard, rc)
+			return err
+		},
+		func(ctx context.Context) error {
+			rc, err := cl.Zip(ctx, "example.com/multi", "v1.0.0")
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			_, err = io.Copy(io.Discard, rc)
+			return err
+		},
+		func(ctx context.Context) error {
+			return cl.Extract(ctx, "example.com/multi", "v1.0.0", t.TempDir())
+		},
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, goroutines*iterations)
+	)
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range iterations {
+				op := ops[(g+i)%len(ops)]
+				ctx := WithRequestID(ctx, fmt.Sprintf("g%d-i%d", g, i))
+				if err := op(ctx); err != nil {
+					errs <- fmt.Errorf("goroutine %d, iteration %d: %w", g, i, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,195 @@
+package goproxyclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Corpus is a set of module versions,
+// such as the contents of a local mirror or a snapshot of index.golang.org,
+// that can be searched for reverse dependencies.
+// Create one with [NewCorpus] or [LoadCorpus].
+//
+// The requirements of each module version are fetched (from its go.mod file) on first use
+// and remembered.
+// A Corpus is safe for concurrent use by multiple goroutines.
+type Corpus struct {
+	cl       Client
+	versions []module.Version
+
+	mu   sync.Mutex
+	reqs map[module.Version][]module.Version
+}
+
+// NewCorpus creates a [Corpus] containing the given module versions,
+// using cl to fetch their go.mod files.
+func NewCorpus(cl Client, versions []module.Version) *Corpus {
+	return &Corpus{
+		cl:       cl,
+		versions: versions,
+		reqs:     make(map[module.Version][]module.Version),
+	}
+}
+
+// LoadCorpus creates a [Corpus] from the module versions listed in a file,
+// using cl to fetch their go.mod files.
+//
+// Each line of the file is either MODPATH@VERSION, "MODPATH VERSION",
+// or a JSON object with Path and Version fields
+// (the format produced by https://index.golang.org/index).
+// Blank lines and lines beginning with # are ignored.
+func LoadCorpus(cl Client, path string) (*Corpus, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %s", path)
+	}
+	defer f.Close()
+
+	versions, err := parseCorpus(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+	return NewCorpus(cl, versions), nil
+}
+
+func parseCorpus(r io.Reader) ([]module.Version, error) {
+	var (
+		sc       = bufio.NewScanner(r)
+		versions []module.Version
+		lineno   int
+	)
+	for sc.Scan() {
+		lineno++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var mv module.Version
+		if strings.HasPrefix(line, "{") {
+			if err := json.Unmarshal([]byte(line), &mv); err != nil {
+				return nil, errors.Wrapf(err, "line %d", lineno)
+			}
+		} else if p, v, ok := strings.Cut(line, "@"); ok {
+			mv = module.Version{Path: p, Version: v}
+		} else if fields := strings.Fields(line); len(fields) == 2 {
+			mv = module.Version{Path: fields[0], Version: fields[1]}
+		}
+		if mv.Path == "" || mv.Version == "" {
+			return nil, fmt.Errorf("line %d: malformed module version", lineno)
+		}
+		versions = append(versions, mv)
+	}
+	return versions, sc.Err()
+}
+
+// ReverseDep is a module version that requires some other module.
+// See [Corpus.ReverseDeps].
+type ReverseDep struct {
+	// Module is the requiring module version.
+	Module module.Version
+
+	// Requires is the version of the other module that it requires.
+	Requires string
+}
+
+// corpusConcurrency is the number of go.mod files a [Corpus] fetches at once.
+const corpusConcurrency = 8
+
+// ReverseDeps reports which module versions in the corpus require the given module
+// (in any version),
+// sorted by module path and version.
+func (c *Corpus) ReverseDeps(ctx context.Context, mod string) ([]ReverseDep, error) {
+	var (
+		wg     sync.WaitGroup
+		sem    = make(chan struct{}, corpusConcurrency)
+		mu     sync.Mutex
+		result []ReverseDep
+		errs   []error
+	)
+
+	for _, mv := range c.versions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			reqs, err := c.requirements(ctx, mv)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "getting requirements of %s@%s", mv.Path, mv.Version))
+				return
+			}
+			for _, req := range reqs {
+				if req.Path == mod {
+					result = append(result, ReverseDep{Module: mv, Requires: req.Version})
+					break
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	slices.SortFunc(result, func(a, b ReverseDep) int {
+		if c := strings.Compare(a.Module.Path, b.Module.Path); c != 0 {
+			return c
+		}
+		return semver.Compare(a.Module.Version, b.Module.Version)
+	})
+	return result, nil
+}
+
+// Requirements returns the requirements in the go.mod file of the given module version.
+// A module version whose go.mod file cannot be found has no requirements.
+func (c *Corpus) requirements(ctx context.Context, mv module.Version) ([]module.Version, error) {
+	c.mu.Lock()
+	reqs, ok := c.reqs[mv]
+	c.mu.Unlock()
+	if ok {
+		return reqs, nil
+	}
+
+	f, err := c.cl.modFile(ctx, mv.Path, mv.Version)
+	if IsNotFound(err) {
+		f, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f != nil {
+		for _, r := range f.Require {
+			reqs = append(reqs, r.Mod)
+		}
+	}
+
+	c.mu.Lock()
+	c.reqs[mv] = reqs
+	c.mu.Unlock()
+
+	return reqs, nil
+}
@@ -0,0 +1,64 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/module"
+)
+
+func TestReverseDeps(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	versions, err := parseCorpus(strings.NewReader(`# test corpus
+github.com/bobg/errors@v1.1.0
+github.com/bobg/mid v1.9.0
+{"Path":"github.com/bobg/subcmd/v2","Version":"v2.3.0","Timestamp":"2024-07-03T15:49:15Z"}
+
+example.com/multi@v1.0.0
+example.com/missing@v1.0.0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 5 {
+		t.Fatalf("got %d versions, want 5", len(versions))
+	}
+
+	var (
+		ctx    = context.Background()
+		corpus = NewCorpus(New(s.URL, nil), versions)
+	)
+
+	got, err := corpus.ReverseDeps(ctx, "github.com/bobg/errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ReverseDep{
+		{Module: module.Version{Path: "example.com/multi", Version: "v1.0.0"}, Requires: "v1.1.0"},
+		{Module: module.Version{Path: "github.com/bobg/mid", Version: "v1.9.0"}, Requires: "v1.1.0"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got, err = corpus.ReverseDeps(ctx, "github.com/google/go-cmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []ReverseDep{
+		{Module: module.Version{Path: "github.com/bobg/mid", Version: "v1.9.0"}, Requires: "v0.5.1"},
+		{Module: module.Version{Path: "github.com/bobg/subcmd/v2", Version: "v2.3.0"}, Requires: "v0.5.6"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := parseCorpus(strings.NewReader("bogus\n")); err == nil {
+		t.Error("got nil, want error for malformed line")
+	}
+}
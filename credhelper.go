@@ -0,0 +1,131 @@
+package goproxyclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/textproto"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bobg/errors"
+)
+
+// credentialHelperTTL is how long headers from a credential helper are reused
+// before the helper is run again.
+const credentialHelperTTL = 5 * time.Minute
+
+// WithCredentialHelper is an [Option] that obtains request headers from an external command,
+// in the style of Docker credential helpers.
+// This allows any authentication scheme to be plugged in without changes to this package.
+//
+// For each proxy host,
+// the command is run with the given arguments,
+// with the URL of the host (e.g. "https://proxy.example.com/") on its standard input.
+// It must write zero or more HTTP header lines to its standard output,
+// such as
+//
+//	Authorization: Bearer xyz
+//
+// optionally followed by a blank line.
+// Those headers are added to every request for that host.
+// A helper with no credentials for a host should exit successfully with no output.
+// A helper that exits unsuccessfully causes the request to fail.
+//
+// Headers are cached for each host for a few minutes
+// before the command is run again.
+// The command runs for one request to a host at a time;
+// others for the same host wait for its headers,
+// and those for other hosts do not wait.
+func WithCredentialHelper(command string, args ...string) Option {
+	h := &credentialHelper{
+		command: command,
+		args:    args,
+		hosts:   make(map[string]*credentialHelperHost),
+	}
+	return WithAuth(h)
+}
+
+type credentialHelper struct {
+	command string
+	args    []string
+
+	mu    sync.Mutex
+	hosts map[string]*credentialHelperHost // host URL -> headers
+}
+
+type credentialHelperHost struct {
+	// Sem holds a value while a request for the host is using the fields below,
+	// which may include running the command.
+	// Unlike a mutex,
+	// waiting for it can be canceled.
+	sem chan struct{}
+
+	header  http.Header
+	expires time.Time
+}
+
+// Authenticate implements [Authenticator].
+func (h *credentialHelper) Authenticate(ctx context.Context, req *http.Request) error {
+	hostURL := req.URL.Scheme + "://" + req.URL.Host + "/"
+
+	h.mu.Lock()
+	host, ok := h.hosts[hostURL]
+	if !ok {
+		host = &credentialHelperHost{sem: make(chan struct{}, 1)}
+		h.hosts[hostURL] = host
+	}
+	h.mu.Unlock()
+
+	select {
+	case host.sem <- struct{}{}:
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+	defer func() { <-host.sem }()
+
+	if time.Now().After(host.expires) {
+		header, err := h.run(ctx, hostURL)
+		if err != nil {
+			return err
+		}
+		host.header, host.expires = header, time.Now().Add(credentialHelperTTL)
+	}
+
+	for name, vals := range host.header {
+		for _, val := range vals {
+			req.Header.Add(name, val)
+		}
+	}
+	return nil
+}
+
+func (h *credentialHelper) run(ctx context.Context, hostURL string) (http.Header, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, h.command, h.args...)
+	cmd.Stdin = strings.NewReader(hostURL + "\n")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "running credential helper %s for %s: %s", h.command, hostURL, strings.TrimSpace(stderr.String()))
+	}
+	return parseHelperHeaders(out)
+}
+
+func parseHelperHeaders(out []byte) (http.Header, error) {
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	// ReadMIMEHeader requires a terminating blank line.
+	out = append(bytes.TrimRight(out, "\r\n"), "\n\n"...)
+	tr := textproto.NewReader(bufio.NewReader(bytes.NewReader(out)))
+	header, err := tr.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.Wrap(err, "parsing credential helper output")
+	}
+	return http.Header(header), nil
+}
@@ -0,0 +1,83 @@
+package goproxyclient
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/bobg/errors"
+)
+
+// ErrIdleTimeout is the error returned by reads from the result of [Client.Mod] or [Client.Zip]
+// when no data arrives within the time set with [WithIdleTimeout].
+var ErrIdleTimeout = errors.New("idle timeout reading response body")
+
+// WithIdleTimeout is an [Option] that aborts a read from the result of [Client.Mod] or [Client.Zip]
+// if no data arrives for the given duration,
+// causing it to fail with [ErrIdleTimeout].
+//
+// Unlike an overall timeout on the [http.Client],
+// this does not limit the total time of a download,
+// so it is suitable for arbitrarily large files on slow connections
+// while still detecting stalled ones.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.idleTimeout = d
+	}
+}
+
+// ctxBody is an io.ReadCloser that stops reading when its context is canceled,
+// or when a single read waits longer than its idle timeout (if nonzero).
+// A read in progress when that happens
+// (e.g. one stalled on an unresponsive connection)
+// is aborted by closing the underlying body,
+// and it and all later reads return the cause:
+// the context's error, or [ErrIdleTimeout].
+type ctxBody struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	idle   time.Duration
+	r      io.ReadCloser
+	stop   func() bool
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newCtxBody(ctx context.Context, r io.ReadCloser, idle time.Duration) *ctxBody {
+	ctx, cancel := context.WithCancelCause(ctx)
+	b := &ctxBody{ctx: ctx, cancel: cancel, idle: idle, r: r}
+	b.stop = context.AfterFunc(ctx, func() { b.closeBody() })
+	return b
+}
+
+func (b *ctxBody) Read(p []byte) (int, error) {
+	if b.ctx.Err() != nil {
+		return 0, context.Cause(b.ctx)
+	}
+
+	if b.idle > 0 {
+		t := time.AfterFunc(b.idle, func() { b.cancel(ErrIdleTimeout) })
+		defer t.Stop()
+	}
+
+	n, err := b.r.Read(p)
+	if err != nil && b.ctx.Err() != nil {
+		return n, context.Cause(b.ctx)
+	}
+	return n, err
+}
+
+func (b *ctxBody) Close() error {
+	b.stop()
+	b.cancel(nil)
+	return b.closeBody()
+}
+
+func (b *ctxBody) closeBody() error {
+	b.closeOnce.Do(func() {
+		b.closeErr = b.r.Close()
+	})
+	return b.closeErr
+}
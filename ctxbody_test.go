@@ -0,0 +1,100 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// stallingServer returns a test server that sends part of a response and then stalls.
+// The caller must call the returned function when done.
+func stallingServer() (*httptest.Server, func()) {
+	unblock := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Length", "1000")
+		w.Write([]byte("PK"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-unblock:
+		case <-req.Context().Done():
+		}
+	}))
+	return s, func() {
+		close(unblock)
+		s.Close()
+	}
+}
+
+func TestCtxBody(t *testing.T) {
+	s, done := stallingServer()
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cl := New(s.URL, nil)
+	rc, err := cl.Zip(ctx, "example.com/slow", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	buf := make([]byte, 2)
+	if _, err := rc.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	errch := make(chan error, 1)
+	go func() {
+		_, err := rc.Read(buf)
+		errch <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errch:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("read did not return after cancellation")
+	}
+
+	if _, err := rc.Read(buf); !errors.Is(err, context.Canceled) {
+		t.Errorf("after cancellation, got %v, want context.Canceled", err)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	s, done := stallingServer()
+	defer done()
+
+	cl := New(s.URL, nil, WithIdleTimeout(100*time.Millisecond))
+	rc, err := cl.Zip(context.Background(), "example.com/slow", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	buf := make([]byte, 2)
+	if _, err := rc.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// A slow reader does not trigger the timeout.
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	_, err = rc.Read(buf)
+	if !errors.Is(err, ErrIdleTimeout) {
+		t.Errorf("got %v, want ErrIdleTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("read took %s to time out", elapsed)
+	}
+}
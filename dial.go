@@ -0,0 +1,154 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// WithResolver is an [Option] that makes the client use r
+// to look up the addresses of proxy hosts,
+// instead of the system's default resolver.
+// Set r.PreferGo and r.Dial to query a specific DNS server.
+//
+// This option takes effect only when the [http.Client] passed to [New]
+// is nil or has a Transport that is nil or an [*http.Transport].
+func WithResolver(r *net.Resolver) Option {
+	return func(c *config) {
+		c.dial.resolver = r
+	}
+}
+
+// WithHostMap is an [Option] that maps hostnames to addresses,
+// like an /etc/hosts file that applies only to this client.
+// When the client connects to a host that is a key in m,
+// it dials the corresponding value
+// (an IP address, or another hostname)
+// instead.
+// TLS certificates are still verified against the original hostname.
+// This option may be given more than once;
+// the maps are merged.
+//
+// This option takes effect only when the [http.Client] passed to [New]
+// is nil or has a Transport that is nil or an [*http.Transport].
+func WithHostMap(m map[string]string) Option {
+	return func(c *config) {
+		if c.dial.hostMap == nil {
+			c.dial.hostMap = make(map[string]string)
+		}
+		for host, addr := range m {
+			c.dial.hostMap[host] = addr
+		}
+	}
+}
+
+// WithIPv4Only is an [Option] that makes the client connect to proxy hosts
+// (and outbound proxies)
+// only over IPv4,
+// ignoring any IPv6 addresses they have.
+// This is useful for hosts with broken AAAA records.
+//
+// This option takes effect only when the [http.Client] passed to [New]
+// is nil or has a Transport that is nil or an [*http.Transport].
+func WithIPv4Only() Option {
+	return func(c *config) {
+		c.dial.ipv4Only = true
+	}
+}
+
+// WithPreferIPv4 is an [Option] that makes the client try connecting to hosts over IPv4 first,
+// trying IPv6 only if that fails.
+// By contrast, the default is to race IPv4 and IPv6 connections
+// ("Happy Eyeballs," RFC 6555)
+// giving a head start to whichever family the resolver lists first.
+//
+// This option takes effect only when the [http.Client] passed to [New]
+// is nil or has a Transport that is nil or an [*http.Transport].
+func WithPreferIPv4() Option {
+	return func(c *config) {
+		c.dial.preferIPv4 = true
+	}
+}
+
+// WithDialTimeout is an [Option] setting the maximum time to wait for a connection to be established.
+// The default is 30 seconds.
+//
+// This option takes effect only when the [http.Client] passed to [New]
+// is nil or has a Transport that is nil or an [*http.Transport].
+func WithDialTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.dial.timeout = d
+	}
+}
+
+// WithFallbackDelay is an [Option] setting how long to wait
+// for a connection using the first address family
+// before racing a connection using the other
+// (see [net.Dialer.FallbackDelay]).
+// The default is 300 milliseconds.
+// A negative value disables the race,
+// so the other family is tried only after the first fails.
+//
+// This option takes effect only when the [http.Client] passed to [New]
+// is nil or has a Transport that is nil or an [*http.Transport].
+func WithFallbackDelay(d time.Duration) Option {
+	return func(c *config) {
+		c.dial.fallbackDelay = d
+	}
+}
+
+type dialConfig struct {
+	resolver *net.Resolver
+	hostMap  map[string]string
+
+	ipv4Only      bool
+	preferIPv4    bool
+	timeout       time.Duration
+	fallbackDelay time.Duration
+}
+
+func (d dialConfig) isSet() bool {
+	return d.resolver != nil || len(d.hostMap) > 0 || d.ipv4Only || d.preferIPv4 || d.timeout != 0 || d.fallbackDelay != 0
+}
+
+// DialContext is a replacement for [http.Transport.DialContext]
+// that honors the dialing options.
+func (d dialConfig) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		if mapped, ok := d.hostMap[host]; ok {
+			addr = net.JoinHostPort(mapped, port)
+		}
+	}
+
+	timeout := d.timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second // the value used by [http.DefaultTransport]
+	}
+
+	dialer := &net.Dialer{
+		Timeout:       timeout,
+		KeepAlive:     30 * time.Second,
+		FallbackDelay: d.fallbackDelay,
+		Resolver:      d.resolver,
+	}
+
+	if network != "tcp" {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	if d.ipv4Only {
+		return dialer.DialContext(ctx, "tcp4", addr)
+	}
+	if d.preferIPv4 {
+		conn, err := dialer.DialContext(ctx, "tcp4", addr)
+		if err == nil || ctx.Err() != nil {
+			return conn, err
+		}
+		conn, err6 := dialer.DialContext(ctx, "tcp6", addr)
+		if err6 != nil {
+			return nil, errors.Join(err, err6)
+		}
+		return conn, nil
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
@@ -0,0 +1,79 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHostMap(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cl := New("http://proxy.invalid:"+u.Port(), nil, WithHostMap(map[string]string{"proxy.invalid": u.Hostname()}))
+	if _, err := cl.List(ctx, "github.com/bobg/errors"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+
+	errDNS := errors.New("no DNS for you")
+	r := &net.Resolver{
+		PreferGo: true,
+		Dial: func(context.Context, string, string) (net.Conn, error) {
+			return nil, errDNS
+		},
+	}
+
+	cl := New("http://proxy.invalid", nil, WithResolver(r))
+	_, err := cl.List(ctx, "github.com/bobg/errors")
+	// The resolver's error is not wrapped by [net.DNSError], so check its text.
+	if err == nil || !strings.Contains(err.Error(), errDNS.Error()) {
+		t.Errorf("got error %v, want %v", err, errDNS)
+	}
+}
+
+func TestIPv4(t *testing.T) {
+	ctx := context.Background()
+
+	l6, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %s", err)
+	}
+	s6 := httptest.NewUnstartedServer(testHandler(nil))
+	s6.Listener = l6
+	s6.Start()
+	defer s6.Close()
+
+	u, err := url.Parse(s6.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		goproxy = "http://proxy.invalid:" + u.Port()
+		hostMap = WithHostMap(map[string]string{"proxy.invalid": "::1"})
+	)
+
+	cl := New(goproxy, nil, hostMap, WithIPv4Only())
+	if _, err := cl.List(ctx, "github.com/bobg/errors"); err == nil {
+		t.Error("with WithIPv4Only, got nil error connecting to IPv6-only server")
+	}
+
+	cl = New(goproxy, nil, hostMap, WithPreferIPv4())
+	if _, err := cl.List(ctx, "github.com/bobg/errors"); err != nil {
+		t.Errorf("with WithPreferIPv4, got error %v connecting to IPv6-only server", err)
+	}
+}
@@ -0,0 +1,517 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/mid"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	modzip "golang.org/x/mod/zip"
+
+	"github.com/bobg/goproxyclient/internal/protocol"
+)
+
+// Direct fetches modules directly from their version control repositories,
+// as the go command does for a "direct" entry in GOPROXY
+// (see https://go.dev/ref/mod#vcs).
+//
+// A module's repository is found from its go-import meta tag
+// (as in [Client.Repo]).
+// Only git repositories are supported.
+// Each is fetched with the git command into a cache directory
+// (see [WithVCSCacheDir])
+// and refetched when it is more than [directRefresh] old.
+// Version lists, info, go.mod files, and zip files are produced from the cached copy,
+// in the same form a proxy would serve them.
+type direct struct {
+	client   *http.Client // for go-import lookups
+	policy   VCSPolicy
+	insecure PathPatterns
+	dir      string
+
+	mu    sync.Mutex
+	repos map[string]*gitRepo // keyed by repository URL
+}
+
+// directRefresh is how long a fetched repository is used before it is fetched again.
+const directRefresh = time.Minute
+
+func newDirect(hc *http.Client, conf *config) *direct {
+	dir := conf.vcsCacheDir
+	if dir == "" {
+		dir = defaultVCSCacheDir()
+	}
+	return &direct{client: hc, policy: conf.vcsPolicy, insecure: conf.insecure, dir: dir}
+}
+
+// DefaultVCSCacheDir returns the directory in which repositories are cached by default:
+// goproxyclient/vcs in the user's cache directory,
+// or in the temporary directory if there is no user cache directory.
+func defaultVCSCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "goproxyclient", "vcs")
+}
+
+// gitRepo is a local copy of a remote git repository.
+type gitRepo struct {
+	url, dir string
+
+	mu      sync.Mutex // serializes fetches
+	fetched time.Time
+}
+
+// headRef is where the remote repository's HEAD is stored in a [gitRepo].
+const headRef = "refs/goproxyclient/HEAD"
+
+func (d *direct) repo(url string) *gitRepo {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if r, ok := d.repos[url]; ok {
+		return r
+	}
+	if d.repos == nil {
+		d.repos = make(map[string]*gitRepo)
+	}
+	dir, _ := filepath.Abs(filepath.Join(d.dir, fmt.Sprintf("%x", sha256.Sum256([]byte(url)))))
+	r := &gitRepo{url: url, dir: dir}
+	d.repos[url] = r
+	return r
+}
+
+// Fetch brings the local copy of the repository up to date,
+// creating it if necessary,
+// unless that was done within the last [directRefresh].
+func (r *gitRepo) fetch(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.fetched) < directRefresh {
+		return nil
+	}
+
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(r.dir, 0o755); err != nil {
+			return errors.Wrapf(err, "creating %s", r.dir)
+		}
+		if _, err := runGit(ctx, r.dir, "init", "-q"); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return errors.Wrapf(err, "checking %s", r.dir)
+	}
+
+	_, err := runGit(ctx, r.dir, "fetch", "-q", "-f", "--prune", "--", r.url,
+		"+refs/heads/*:refs/remotes/origin/*",
+		"+refs/tags/*:refs/tags/*",
+		"+HEAD:"+headRef,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "fetching %s", r.url)
+	}
+
+	r.fetched = time.Now()
+	return nil
+}
+
+// RunGit runs a git command in dir and returns its standard output.
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "running git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// directModule is a module in a [gitRepo].
+type directModule struct {
+	path      string
+	repo      *gitRepo
+	codeDir   string // the module's directory in the repository, "" for the root
+	pathMajor string // the module path's major-version suffix, e.g. "/v2", or ""
+}
+
+// Module finds the repository for a module and fetches it.
+// Note, modpath is escaped.
+func (d *direct) module(ctx context.Context, modpath string) (*directModule, error) {
+	mod, err := module.UnescapePath(modpath)
+	if err != nil {
+		return nil, errors.Wrap(err, "unescaping module path")
+	}
+
+	imp, err := goImport(ctx, d.client, mod)
+	if err != nil {
+		return nil, mid.CodeErr{C: http.StatusNotFound, Err: errors.Wrapf(err, "finding repository for %s", mod)}
+	}
+	if !d.policy.Allowed(mod, imp.vcs) {
+		return nil, fmt.Errorf("GOVCS disallows using %s for %s", imp.vcs, mod)
+	}
+	if imp.vcs != "git" {
+		return nil, fmt.Errorf("cannot fetch %s from %s repository %s (only git is supported)", mod, imp.vcs, imp.repo)
+	}
+	if err := checkRepoURL(imp.repo, d.insecure.Match(mod)); err != nil {
+		return nil, errors.Wrapf(err, "finding repository for %s", mod)
+	}
+
+	prefix, pathMajor, ok := module.SplitPathVersion(mod)
+	if !ok {
+		return nil, fmt.Errorf("invalid module path %s", mod)
+	}
+	var rel string
+	if strings.HasPrefix(prefix, imp.prefix+"/") {
+		rel = prefix[len(imp.prefix)+1:]
+	}
+
+	m := &directModule{
+		path:      mod,
+		repo:      d.repo(imp.repo),
+		codeDir:   path.Join(imp.subdir, rel),
+		pathMajor: pathMajor,
+	}
+	return m, m.repo.fetch(ctx)
+}
+
+func (m *directModule) tagPrefix() string {
+	if m.codeDir == "" {
+		return ""
+	}
+	return m.codeDir + "/"
+}
+
+// TagVersion returns the module version named by a tag,
+// if it names one.
+func (m *directModule) tagVersion(tag string) (string, bool) {
+	v, ok := strings.CutPrefix(tag, m.tagPrefix())
+	if !ok || v == "" || semver.Canonical(v) != v || module.IsPseudoVersion(v) {
+		return "", false
+	}
+	if module.CheckPathMajor(v, m.pathMajor) != nil {
+		return "", false
+	}
+	return v, true
+}
+
+// Tags returns the versions named by the repository's tags,
+// in semver order.
+// Extra arguments to git tag,
+// such as --merged,
+// may be given.
+func (m *directModule) tags(ctx context.Context, args ...string) ([]string, error) {
+	args = append([]string{"tag", "-l"}, args...)
+	args = append(args, m.tagPrefix()+"v*")
+	out, err := runGit(ctx, m.repo.dir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, tag := range strings.Fields(string(out)) {
+		if v, ok := m.tagVersion(tag); ok {
+			versions = append(versions, v)
+		}
+	}
+	semver.Sort(versions)
+	return versions, nil
+}
+
+// Commit returns the hash and commit time of a revision.
+func (m *directModule) commit(ctx context.Context, rev string) (string, time.Time, error) {
+	out, err := runGit(ctx, m.repo.dir, "log", "-1", "--format=%H %ct", rev+"^{commit}", "--")
+	if err != nil {
+		return "", time.Time{}, mid.CodeErr{C: http.StatusNotFound, Err: errors.Wrapf(err, "unknown revision %s of %s", rev, m.path)}
+	}
+	hash, secs, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+	n, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return "", time.Time{}, errors.Wrapf(err, "parsing commit time of %s", rev)
+	}
+	return hash, time.Unix(n, 0).UTC(), nil
+}
+
+// directRev is a revision of a [directModule] resolved to a version.
+type directRev struct {
+	version, ref, hash string
+	time               time.Time
+}
+
+// Stat resolves a revision
+// (a version, branch name, tag, commit hash, or "HEAD")
+// to a canonical version,
+// which is a pseudo-version if no version tag names the commit.
+func (m *directModule) stat(ctx context.Context, rev string) (directRev, error) {
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return directRev{}, fmt.Errorf("invalid revision %q", rev)
+	}
+
+	if v, ok := m.tagVersion(m.tagPrefix() + rev); ok && v == rev {
+		ref := "refs/tags/" + m.tagPrefix() + rev
+		if hash, t, err := m.commit(ctx, ref); err == nil {
+			return directRev{version: rev, ref: ref, hash: hash, time: t}, nil
+		}
+	}
+
+	if module.IsPseudoVersion(rev) {
+		short, err := module.PseudoVersionRev(rev)
+		if err != nil {
+			return directRev{}, errors.Wrapf(err, "parsing pseudo-version %s", rev)
+		}
+		hash, t, err := m.commit(ctx, short)
+		if err != nil {
+			return directRev{}, err
+		}
+		return directRev{version: rev, hash: hash, time: t}, nil
+	}
+
+	var (
+		ref, hash string
+		t         time.Time
+		err       error
+	)
+	candidates := []string{"refs/remotes/origin/" + rev, "refs/tags/" + rev, rev}
+	if rev == "HEAD" {
+		candidates = []string{headRef}
+	}
+	for _, ref = range candidates {
+		hash, t, err = m.commit(ctx, ref)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return directRev{}, err
+	}
+	switch {
+	case strings.HasPrefix(ref, "refs/remotes/origin/"):
+		ref = "refs/heads/" + rev
+	case ref == headRef:
+		ref = "HEAD"
+	case ref == rev:
+		ref = ""
+	}
+
+	// Use a version tag on the commit if there is one,
+	// otherwise a pseudo-version based on the highest earlier version tag.
+	if versions, err := m.tags(ctx, "--points-at", hash); err != nil {
+		return directRev{}, err
+	} else if len(versions) > 0 {
+		v := versions[len(versions)-1]
+		return directRev{version: v, ref: "refs/tags/" + m.tagPrefix() + v, hash: hash, time: t}, nil
+	}
+	versions, err := m.tags(ctx, "--merged", hash)
+	if err != nil {
+		return directRev{}, err
+	}
+	var older string
+	if len(versions) > 0 {
+		older = versions[len(versions)-1]
+	}
+	major := module.PathMajorPrefix(m.pathMajor)
+	if older != "" {
+		major = semver.Major(older)
+	}
+	v := module.PseudoVersion(major, older, t, hash[:12])
+	return directRev{version: v, ref: ref, hash: hash, time: t}, nil
+}
+
+// Subdir returns the module's directory in the repository at the given commit.
+// For a module path with a major-version suffix such as /v2,
+// that is the v2 subdirectory of the module's code directory if it has a go.mod file,
+// otherwise the code directory itself.
+func (m *directModule) subdir(ctx context.Context, hash string) string {
+	if strings.HasPrefix(m.pathMajor, "/") {
+		dir := path.Join(m.codeDir, m.pathMajor[1:])
+		if _, err := runGit(ctx, m.repo.dir, "cat-file", "-e", hash+":"+path.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+	}
+	return m.codeDir
+}
+
+// Note, modpath is already escaped.
+func (d *direct) list(ctx context.Context, modpath string) ([]string, error) {
+	m, err := d.module(ctx, modpath)
+	if err != nil {
+		return nil, err
+	}
+	return m.tags(ctx)
+}
+
+// Note, modpath and version are already escaped.
+func (d *direct) info(ctx context.Context, modpath, version string) (string, time.Time, map[string]json.RawMessage, error) {
+	m, rev, err := d.stat(ctx, modpath, version)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	return m.info(ctx, rev)
+}
+
+// Latest chooses the highest release version,
+// or if there is none the highest prerelease version,
+// or if there is none a pseudo-version for the repository's HEAD,
+// as the go command does.
+// Note, modpath is already escaped.
+func (d *direct) latest(ctx context.Context, modpath string) (string, time.Time, map[string]json.RawMessage, error) {
+	m, err := d.module(ctx, modpath)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	versions, err := m.tags(ctx)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+
+	latest := "HEAD"
+	if len(versions) > 0 {
+		latest = versions[len(versions)-1]
+		for i := len(versions) - 1; i >= 0; i-- {
+			if semver.Prerelease(versions[i]) == "" {
+				latest = versions[i]
+				break
+			}
+		}
+	}
+
+	rev, err := m.stat(ctx, latest)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	return m.info(ctx, rev)
+}
+
+func (d *direct) stat(ctx context.Context, modpath, version string) (*directModule, directRev, error) {
+	m, err := d.module(ctx, modpath)
+	if err != nil {
+		return nil, directRev{}, err
+	}
+	version, err = module.UnescapeVersion(version)
+	if err != nil {
+		return nil, directRev{}, errors.Wrap(err, "unescaping module version")
+	}
+	rev, err := m.stat(ctx, version)
+	return m, rev, err
+}
+
+// Info produces the .info response for a revision,
+// including an Origin object as proxy.golang.org does.
+func (m *directModule) info(ctx context.Context, rev directRev) (string, time.Time, map[string]json.RawMessage, error) {
+	body, err := json.Marshal(struct {
+		Version string
+		Time    time.Time
+		Origin  Origin
+	}{
+		Version: rev.version,
+		Time:    rev.time,
+		Origin: Origin{
+			VCS:    "git",
+			URL:    m.repo.url,
+			Subdir: m.subdir(ctx, rev.hash),
+			Ref:    rev.ref,
+			Hash:   rev.hash,
+		},
+	})
+	if err != nil {
+		return "", time.Time{}, nil, errors.Wrap(err, "encoding info")
+	}
+	return protocol.ParseInfo(body)
+}
+
+// Mod produces the go.mod file for a module version,
+// or a synthesized one containing only a module directive
+// if the module has none,
+// as the go command does.
+// Note, modpath and version are already escaped.
+func (d *direct) mod(ctx context.Context, modpath, version string) (io.ReadCloser, error) {
+	m, rev, err := d.stat(ctx, modpath, version)
+	if err != nil {
+		return nil, err
+	}
+	data, err := runGit(ctx, m.repo.dir, "cat-file", "blob", rev.hash+":"+path.Join(m.subdir(ctx, rev.hash), "go.mod"))
+	if err != nil {
+		data = []byte(fmt.Sprintf("module %s\n", modfile.AutoQuote(m.path)))
+	}
+	return sizedBody{ReadCloser: io.NopCloser(bytes.NewReader(data)), size: int64(len(data)), proxy: "direct", url: m.repo.url}, nil
+}
+
+// Zip produces the zip file for a module version
+// in a temporary file,
+// which is removed when the result is closed.
+// Note, modpath and version are already escaped.
+func (d *direct) zip(ctx context.Context, modpath, version string) (io.ReadCloser, error) {
+	m, rev, err := d.stat(ctx, modpath, version)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp("", "goproxyclient-*.zip")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating temp file")
+	}
+	tf := tempFile{File: f}
+
+	mv := module.Version{Path: m.path, Version: rev.version}
+	if err := modzip.CreateFromVCS(f, mv, m.repo.dir, rev.hash, m.subdir(ctx, rev.hash)); err != nil {
+		tf.Close()
+		return nil, errors.Wrapf(err, "creating zip for %s", mv)
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		tf.Close()
+		return nil, errors.Wrap(err, "getting zip size")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		tf.Close()
+		return nil, errors.Wrap(err, "rewinding zip")
+	}
+
+	return sizedBody{ReadCloser: tf, size: size, proxy: "direct", url: m.repo.url}, nil
+}
+
+// ZipSize reports -1
+// (unknown)
+// for a module version that exists,
+// since producing the zip file to measure it is as costly as fetching it.
+// Note, modpath and version are already escaped.
+func (d *direct) zipSize(ctx context.Context, modpath, version string) (int64, error) {
+	if _, _, err := d.stat(ctx, modpath, version); err != nil {
+		return 0, err
+	}
+	return -1, nil
+}
+
+// tempFile is a temporary file that is removed when it is closed.
+type tempFile struct {
+	*os.File
+}
+
+func (t tempFile) Close() error {
+	err := t.File.Close()
+	os.Remove(t.Name())
+	return err
+}
@@ -0,0 +1,328 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bobg/goproxyclient/goproxytest"
+)
+
+// goImportTransport answers go-get=1 requests for example.com
+// with a go-import meta tag pointing at a local git repository,
+// and passes other requests through.
+type goImportTransport struct {
+	repoDir string
+}
+
+func (t goImportTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != "example.com" {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	rec := httptest.NewRecorder()
+	fmt.Fprintf(rec, `<html><head><meta name="go-import" content="example.com/repo git file://%s"></head></html>`, t.repoDir)
+	return rec.Result(), nil
+}
+
+// makeGitRepo creates a git repository for the module example.com/repo,
+// tagged v1.0.0 and v1.1.0,
+// with one more commit on its main branch,
+// and a nested module example.com/repo/sub tagged sub/v0.1.0.
+// It permits file: repository URLs until the end of the test.
+func makeGitRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	allowFileRepos = true
+	t.Cleanup(func() { allowFileRepos = false })
+
+	dir := t.TempDir()
+	date := 1704067200 // 2024-01-01T00:00:00Z
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+			fmt.Sprintf("GIT_AUTHOR_DATE=@%d +0000", date),
+			fmt.Sprintf("GIT_COMMITTER_DATE=@%d +0000", date),
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit := func(msg string) {
+		t.Helper()
+		git("add", "-A")
+		git("commit", "-q", "-m", msg)
+		date += 86400
+	}
+
+	git("init", "-q", "-b", "main")
+	write("go.mod", "module example.com/repo\n")
+	write("a.go", "package repo\n")
+	write("LICENSE", "MIT License\n")
+	commit("first")
+	git("tag", "v1.0.0")
+
+	write("b.go", "package repo\n")
+	write("sub/go.mod", "module example.com/repo/sub\n")
+	write("sub/s.go", "package sub\n")
+	commit("second")
+	git("tag", "v1.1.0")
+	git("tag", "sub/v0.1.0")
+	git("tag", "not-a-version")
+
+	write("c.go", "package repo\n")
+	commit("third")
+
+	return dir
+}
+
+func TestDirect(t *testing.T) {
+	ctx := context.Background()
+
+	repoDir := makeGitRepo(t)
+	hc := &http.Client{Transport: goImportTransport{repoDir: repoDir}}
+
+	// A proxy that has nothing,
+	// so requests fall back to direct.
+	s := httptest.NewServer(goproxytest.New(fstest.MapFS{}))
+	defer s.Close()
+
+	cl := New(s.URL+",direct", hc, WithVCSCacheDir(t.TempDir()))
+
+	versions, err := cl.List(ctx, "example.com/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v1.0.0", "v1.1.0"}; !slices.Equal(versions, want) {
+		t.Errorf("got versions %v, want %v", versions, want)
+	}
+
+	versions, err = cl.List(ctx, "example.com/repo/sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v0.1.0"}; !slices.Equal(versions, want) {
+		t.Errorf("got sub versions %v, want %v", versions, want)
+	}
+
+	latest, err := cl.LatestInfo(ctx, "example.com/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Version != "v1.1.0" {
+		t.Errorf("got latest %s, want v1.1.0", latest.Version)
+	}
+	if latest.Origin == nil || latest.Origin.VCS != "git" || latest.Origin.Ref != "refs/tags/v1.1.0" || latest.Origin.Hash == "" {
+		t.Errorf("got origin %+v", latest.Origin)
+	}
+
+	info, err := cl.VersionInfo(ctx, "example.com/repo", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "v1.1.1-0.20240103000000-" + info.Origin.Hash[:12]; info.Version != want {
+		t.Errorf("got version %s for main, want %s", info.Version, want)
+	}
+
+	ver, _, _, err := cl.Info(ctx, "example.com/repo", info.Origin.Hash[:8])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver != info.Version {
+		t.Errorf("got version %s for commit hash, want %s", ver, info.Version)
+	}
+
+	ver, _, _, err = cl.Info(ctx, "example.com/repo", info.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver != info.Version {
+		t.Errorf("got version %s for pseudo-version, want %s", ver, info.Version)
+	}
+
+	if _, _, _, err := cl.Info(ctx, "example.com/repo", "v1.2.0"); !IsNotFound(err) {
+		t.Errorf("got %v for nonexistent version, want not-found error", err)
+	}
+
+	rc, err := cl.Mod(ctx, "example.com/repo/sub", "v0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(mod) != "module example.com/repo/sub\n" {
+		t.Errorf("got go.mod %q", mod)
+	}
+
+	rc, err = cl.Zip(ctx, "example.com/repo", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	slices.Sort(names)
+	want := []string{"example.com/repo@v1.0.0/LICENSE", "example.com/repo@v1.0.0/a.go", "example.com/repo@v1.0.0/go.mod"}
+	if !slices.Equal(names, want) {
+		t.Errorf("got zip files %v, want %v", names, want)
+	}
+
+	// The sub module is not part of example.com/repo's zip file,
+	// but the repository's LICENSE is part of the sub module's.
+	rc, err = cl.Zip(ctx, "example.com/repo/sub", "v0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names = nil
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	slices.Sort(names)
+	want = []string{"example.com/repo/sub@v0.1.0/LICENSE", "example.com/repo/sub@v0.1.0/go.mod", "example.com/repo/sub@v0.1.0/s.go"}
+	if !slices.Equal(names, want) {
+		t.Errorf("got sub zip files %v, want %v", names, want)
+	}
+}
+
+func TestDirectGOVCS(t *testing.T) {
+	repoDir := makeGitRepo(t)
+	hc := &http.Client{Transport: goImportTransport{repoDir: repoDir}}
+
+	policy, err := ParseGOVCS("example.com:off", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl := New("direct", hc, WithVCSCacheDir(t.TempDir()), WithVCSPolicy(policy))
+
+	_, err = cl.List(context.Background(), "example.com/repo")
+	if err == nil || !strings.Contains(err.Error(), "GOVCS disallows") {
+		t.Errorf("got %v, want GOVCS error", err)
+	}
+}
+
+// metaTransport answers go-get=1 requests for example.com
+// with a go-import meta tag for example.com/repo naming the given repository.
+type metaTransport string
+
+func (t metaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	fmt.Fprintf(rec, `<html><head><meta name="go-import" content="example.com/repo git %s"></head></html>`, string(t))
+	return rec.Result(), nil
+}
+
+func TestDirectHostileRepo(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "pwned")
+	script := filepath.Join(dir, "evil.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ntouch "+marker+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	repos := []string{
+		"--upload-pack=" + script,
+		"ext::" + script,
+		"file://" + dir,
+		dir,
+		"http://example.com/repo",
+	}
+	for _, repo := range repos {
+		t.Run(repo, func(t *testing.T) {
+			hc := &http.Client{Transport: metaTransport(repo)}
+			cl := New("direct", hc, WithVCSCacheDir(t.TempDir()))
+			if _, err := cl.List(ctx, "example.com/repo"); err == nil {
+				t.Error("got no error")
+			}
+			if _, err := os.Stat(marker); err == nil {
+				t.Fatal("git ran the command in the repository URL")
+			}
+			if _, err := cl.Repo(ctx, "example.com/repo"); err == nil {
+				t.Error("got no error from Repo")
+			}
+		})
+	}
+}
+
+func TestCheckRepoURL(t *testing.T) {
+	cases := []struct {
+		repo     string
+		insecure bool
+		wantErr  bool
+	}{
+		{"https://github.com/example/foo", false, false},
+		{"ssh://git@github.com/example/foo", false, false},
+		{"git+ssh://git@github.com/example/foo", false, false},
+		{"http://example.com/foo", false, true},
+		{"http://example.com/foo", true, false},
+		{"git://example.com/foo", false, true},
+		{"git://example.com/foo", true, false},
+		{"--upload-pack=touch", false, true},
+		{"ext::sh -c touch", true, true},
+		{"file:///tmp/foo", true, true},
+		{"/tmp/foo", true, true},
+		{"ssh://-oProxyCommand=touch/foo", false, true},
+		{"ftp://example.com/foo", true, true},
+	}
+	for _, c := range cases {
+		err := checkRepoURL(c.repo, c.insecure)
+		if (err != nil) != c.wantErr {
+			t.Errorf("checkRepoURL(%q, %v): got error %v, want error %v", c.repo, c.insecure, err, c.wantErr)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package goproxyclient
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// InsufficientSpaceError is the type of error returned when a download or extraction
+// would need more disk space than is available.
+type InsufficientSpaceError struct {
+	// Dir is the directory that lacks space.
+	Dir string
+
+	// Need is the number of bytes needed.
+	Need int64
+
+	// Avail is the number of bytes available.
+	Avail int64
+}
+
+func (e InsufficientSpaceError) Error() string {
+	return fmt.Sprintf("insufficient disk space in %s: need %d bytes, have %d", e.Dir, e.Need, e.Avail)
+}
+
+// checkDiskSpace returns an [InsufficientSpaceError] if dir
+// (or its nearest existing ancestor)
+// lacks need bytes of free space.
+// If need is negative (unknown),
+// or free space cannot be determined on this platform,
+// it returns nil.
+func checkDiskSpace(dir string, need int64) error {
+	if need < 0 {
+		return nil
+	}
+	avail, ok := diskFree(existingAncestor(dir))
+	if !ok || avail >= need {
+		return nil
+	}
+	return InsufficientSpaceError{Dir: dir, Need: need, Avail: avail}
+}
+
+func existingAncestor(dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// sizedBody is the io.ReadCloser returned by [Client.Mod] and [Client.Zip].
+// It remembers the Content-Length of the response,
+// and which proxy and URL it came from.
+type sizedBody struct {
+	io.ReadCloser
+	size  int64 // -1 if unknown
+	proxy string
+	url   string
+}
+
+// contentLength returns the Content-Length recorded in a [sizedBody],
+// or -1 if rc is not one or the length is unknown.
+func contentLength(rc io.ReadCloser) int64 {
+	if b, ok := rc.(sizedBody); ok {
+		return b.size
+	}
+	return -1
+}
+
+// source returns the proxy base URL and request URL recorded in a [sizedBody],
+// or empty strings if rc is not one.
+func source(rc io.ReadCloser) (proxy, url string) {
+	if b, ok := rc.(sizedBody); ok {
+		return b.proxy, b.url
+	}
+	return "", ""
+}
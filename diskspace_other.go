@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd)
+
+package goproxyclient
+
+// diskFree cannot determine free space on this platform.
+func diskFree(string) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,31 @@
+package goproxyclient
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckDiskSpace(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "not", "yet", "created")
+
+	if _, ok := diskFree(existingAncestor(dir)); !ok {
+		t.Skip("cannot determine free disk space on this platform")
+	}
+
+	if err := checkDiskSpace(dir, 1); err != nil {
+		t.Errorf("checking for 1 byte: %s", err)
+	}
+	if err := checkDiskSpace(dir, -1); err != nil {
+		t.Errorf("checking for unknown size: %s", err)
+	}
+
+	err := checkDiskSpace(dir, 1<<62)
+	var e InsufficientSpaceError
+	if !errors.As(err, &e) {
+		t.Fatalf("got %v, want InsufficientSpaceError", err)
+	}
+	if e.Need != 1<<62 {
+		t.Errorf("got Need %d, want %d", e.Need, int64(1<<62))
+	}
+}
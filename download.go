@@ -0,0 +1,104 @@
+package goproxyclient
+
+import (
+	"context"
+	"io"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// Download describes a module version fetched by [Client.Download].
+// Its fields have the same names and meanings
+// as those in the output of "go mod download -json,"
+// so it can be used in place of that output.
+type Download struct {
+	// Path is the module path.
+	Path string `json:",omitempty"`
+
+	// Version is the canonical module version.
+	Version string `json:",omitempty"`
+
+	// Info, GoMod, and Zip are the absolute paths of the version's info, go.mod, and zip files
+	// in the client's cache
+	// (see [WithCache]).
+	// They are empty if the client has no cache
+	// (or the files could not be written to it).
+	Info  string `json:",omitempty"`
+	GoMod string `json:",omitempty"`
+	Zip   string `json:",omitempty"`
+
+	// Sum and GoModSum are the hashes of the zip file and the go.mod file
+	// in the form used in go.sum files.
+	Sum      string `json:",omitempty"`
+	GoModSum string `json:",omitempty"`
+}
+
+// Download fetches the info, go.mod, and zip files for a version of a module,
+// as "go mod download" does,
+// and describes them.
+// The version need not be canonical,
+// as for [Client.Info].
+//
+// The files are checked as [Client.Mod] and [Client.Zip] check them
+// (for example, against the client's [ChecksumDB]),
+// and an attestation is written for the zip file if the client is configured to
+// (see [WithAttestations]).
+func (cl Client) Download(ctx context.Context, mod, ver string) (*Download, error) {
+	canonicalVer, _, _, err := cl.Info(ctx, mod, ver)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting info for %s@%s", mod, ver)
+	}
+	if canonicalVer != ver {
+		// Get the info again,
+		// so that it's cached under the canonical version.
+		if _, _, _, err := cl.Info(ctx, mod, canonicalVer); err != nil {
+			return nil, errors.Wrapf(err, "getting info for %s@%s", mod, canonicalVer)
+		}
+	}
+	ver = canonicalVer
+
+	rc, err := cl.Mod(ctx, mod, ver)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting go.mod for %s@%s", mod, ver)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading go.mod for %s@%s", mod, ver)
+	}
+	goModSum, err := hashModFile(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "hashing go.mod for %s@%s", mod, ver)
+	}
+
+	d, err := cl.zipFile(ctx, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+	defer d.remove()
+
+	if err := cl.checkDownload(ctx, d, mod, ver); err != nil {
+		return nil, err
+	}
+	sum, err := dirhash.HashZip(d.Name(), dirhash.Hash1)
+	if err != nil {
+		return nil, errors.Wrapf(err, "hashing zip for %s@%s", mod, ver)
+	}
+
+	result := &Download{
+		Path:     mod,
+		Version:  ver,
+		Sum:      sum,
+		GoModSum: goModSum,
+	}
+	if escMod, err := module.EscapePath(mod); err == nil {
+		if escVer, err := module.EscapeVersion(ver); err == nil {
+			result.Info = cl.conf.cache.file(cl.cacheKey(ctx, cacheName(escMod, escVer, "info")))
+			result.GoMod = cl.conf.cache.file(cl.cacheKey(ctx, cacheName(escMod, escVer, "mod")))
+			result.Zip = cl.conf.cache.file(cl.cacheKey(ctx, cacheName(escMod, escVer, "zip")))
+		}
+	}
+	return result, nil
+}
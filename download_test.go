@@ -0,0 +1,98 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/mod/sumdb/dirhash"
+
+	"github.com/bobg/goproxyclient/internal/protocol"
+)
+
+func TestDownload(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	wantSum, err := dirhash.HashZip(filepath.Join("testdata", "example.com", "multi", "@v", "v1.0.0.zip"), dirhash.Hash1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modData, err := fs.ReadFile(testdata, "testdata/example.com/multi/@v/v1.0.0.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantGoModSum, err := hashModFile(modData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("cache", func(t *testing.T) {
+		cl := New(s.URL, nil, WithCache(t.TempDir(), 0))
+
+		d, err := cl.Download(ctx, "example.com/multi", "v1.0.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Path != "example.com/multi" || d.Version != "v1.0.0" {
+			t.Errorf("got %s@%s, want example.com/multi@v1.0.0", d.Path, d.Version)
+		}
+		if d.Sum != wantSum {
+			t.Errorf("got sum %s, want %s", d.Sum, wantSum)
+		}
+		if d.GoModSum != wantGoModSum {
+			t.Errorf("got go.mod sum %s, want %s", d.GoModSum, wantGoModSum)
+		}
+
+		info, err := os.ReadFile(d.Info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ver, _, _, err := protocol.ParseInfo(info); err != nil || ver != "v1.0.0" {
+			t.Errorf("got version %q, error %v from cached info file", ver, err)
+		}
+
+		for _, file := range []struct{ path, name string }{{d.GoMod, "v1.0.0.mod"}, {d.Zip, "v1.0.0.zip"}} {
+			if !filepath.IsAbs(file.path) {
+				t.Errorf("got non-absolute path %q for %s", file.path, file.name)
+				continue
+			}
+			got, err := os.ReadFile(file.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := fs.ReadFile(testdata, "testdata/example.com/multi/@v/"+file.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("wrong contents in %s", file.path)
+			}
+		}
+	})
+
+	t.Run("no_cache", func(t *testing.T) {
+		cl := New(s.URL, nil)
+
+		d, err := cl.Download(ctx, "example.com/multi", "v1.0.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Info != "" || d.GoMod != "" || d.Zip != "" {
+			t.Errorf("got file paths %q, %q, %q, want none", d.Info, d.GoMod, d.Zip)
+		}
+		if d.Sum != wantSum || d.GoModSum != wantGoModSum {
+			t.Errorf("got sums %s, %s, want %s, %s", d.Sum, d.GoModSum, wantSum, wantGoModSum)
+		}
+
+		if _, err := cl.Download(ctx, "example.com/nonexistent", "v1.0.0"); !IsNotFound(err) {
+			t.Errorf("got %v, want a not-found error", err)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package goproxyclient
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+)
+
+// ZipSizeEstimate is the result of [Client.EstimateZipSizes].
+type ZipSizeEstimate struct {
+	// Sizes are the sizes in bytes of the zip files,
+	// by module version.
+	// A size is -1 if the proxy did not report it.
+	Sizes map[module.Version]int64
+
+	// Total is the sum of the known sizes.
+	Total int64
+
+	// Unknown is the number of module versions whose size is unknown,
+	// and which are not counted in Total.
+	Unknown int
+}
+
+// estimateConcurrency is the number of zip sizes [Client.EstimateZipSizes] requests at once.
+const estimateConcurrency = 8
+
+// EstimateZipSizes reports the sizes of the zip files for the given module versions,
+// such as a build list from [ParseBuildList],
+// and their total,
+// without downloading them
+// (see [Client.ZipSize]).
+// This helps in planning for the disk space and bandwidth
+// that downloading or mirroring them will need.
+//
+// Module versions with no version
+// (such as the main module in a build list)
+// are skipped,
+// as are duplicates.
+// The versions need not be canonical.
+func (cl Client) EstimateZipSizes(ctx context.Context, versions []module.Version) (*ZipSizeEstimate, error) {
+	var (
+		wg     sync.WaitGroup
+		sem    = make(chan struct{}, estimateConcurrency)
+		mu     sync.Mutex
+		result = &ZipSizeEstimate{Sizes: make(map[module.Version]int64)}
+		seen   = make(map[module.Version]bool)
+		errs   []error
+	)
+
+	for _, mv := range versions {
+		if mv.Version == "" || seen[mv] {
+			continue
+		}
+		seen[mv] = true
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			size, err := cl.ZipSize(ctx, mv.Path, mv.Version)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "getting zip size for %s@%s", mv.Path, mv.Version))
+				return
+			}
+			result.Sizes[mv] = size
+			if size < 0 {
+				result.Unknown++
+			} else {
+				result.Total += size
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return result, nil
+}
+
+// ParseBuildList parses a build list,
+// such as the output of "go list -m all",
+// returning the module versions in it.
+//
+// Each line is "MODPATH VERSION" or MODPATH@VERSION.
+// A line with only a module path
+// (a main module, which has no version)
+// is skipped.
+// A line with a replacement
+// ("MODPATH VERSION => REPLACEMENT [VERSION]")
+// produces the replacement if it has a version,
+// and is otherwise skipped,
+// since a replacement with no version is a local directory.
+// Blank lines and lines beginning with # are ignored.
+func ParseBuildList(r io.Reader) ([]module.Version, error) {
+	var (
+		sc       = bufio.NewScanner(r)
+		versions []module.Version
+		lineno   int
+	)
+	for sc.Scan() {
+		lineno++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, repl, ok := strings.Cut(line, "=>"); ok {
+			line = strings.TrimSpace(repl)
+		}
+
+		var mv module.Version
+		if p, v, ok := strings.Cut(line, "@"); ok {
+			mv = module.Version{Path: p, Version: v}
+		} else {
+			switch fields := strings.Fields(line); len(fields) {
+			case 1:
+				continue
+			case 2:
+				mv = module.Version{Path: fields[0], Version: fields[1]}
+			}
+		}
+		if mv.Path == "" || mv.Version == "" {
+			return nil, fmt.Errorf("line %d: malformed module version", lineno)
+		}
+		versions = append(versions, mv)
+	}
+	return versions, errors.Wrap(sc.Err(), "reading build list")
+}
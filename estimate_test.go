@@ -0,0 +1,107 @@
+package goproxyclient
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/module"
+)
+
+func TestEstimateZipSizes(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+	ctx := context.Background()
+
+	multi := module.Version{Path: "example.com/multi", Version: "v1.0.0"}
+	errs := module.Version{Path: "github.com/bobg/errors", Version: "v1.1.0"}
+
+	est, err := cl.EstimateZipSizes(ctx, []module.Version{
+		{Path: "example.com/main"},
+		multi,
+		errs,
+		multi,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(est.Sizes) != 2 {
+		t.Fatalf("got %d sizes, want 2", len(est.Sizes))
+	}
+
+	var want int64
+	for _, mv := range []module.Version{multi, errs} {
+		data, err := fs.ReadFile(testdata, "testdata/"+mv.Path+"/@v/"+mv.Version+".zip")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := est.Sizes[mv]; got != int64(len(data)) {
+			t.Errorf("%s: got size %d, want %d", mv, got, len(data))
+		}
+		want += int64(len(data))
+	}
+	if est.Total != want {
+		t.Errorf("got total %d, want %d", est.Total, want)
+	}
+	if est.Unknown != 0 {
+		t.Errorf("got %d unknown, want 0", est.Unknown)
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := cl.EstimateZipSizes(ctx, []module.Version{multi, {Path: "example.com/nonexistent", Version: "v1.0.0"}})
+		if !IsNotFound(err) {
+			t.Errorf("got error %v, want not found", err)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		// A proxy that does not report Content-Length.
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Transfer-Encoding", "chunked")
+			w.Write([]byte("PK"))
+			w.(http.Flusher).Flush()
+		}))
+		defer s.Close()
+
+		est, err := New(s.URL, nil).EstimateZipSizes(ctx, []module.Version{multi})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if est.Total != 0 || est.Unknown != 1 || est.Sizes[multi] != -1 {
+			t.Errorf("got total %d, unknown %d, size %d; want 0, 1, -1", est.Total, est.Unknown, est.Sizes[multi])
+		}
+	})
+}
+
+func TestParseBuildList(t *testing.T) {
+	const input = `
+example.com/main
+# A comment.
+example.com/a v1.0.0
+example.com/b@v1.2.3
+example.com/c v1.0.0 => example.com/d v1.1.0
+example.com/e v1.0.0 => ../e
+`
+	got, err := ParseBuildList(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []module.Version{
+		{Path: "example.com/a", Version: "v1.0.0"},
+		{Path: "example.com/b", Version: "v1.2.3"},
+		{Path: "example.com/d", Version: "v1.1.0"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParseBuildList(strings.NewReader("example.com/a v1.0.0 extra\n")); err == nil {
+		t.Error("got no error for malformed line")
+	}
+}
@@ -0,0 +1,367 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bobg/errors"
+)
+
+// Extract fetches the zip file for a specific version of a Go module
+// and extracts its contents into dir,
+// which is created if necessary.
+// The files are placed directly in dir,
+// without the MODPATH@VERSION prefix used inside module zip files.
+//
+// If the client has a [VerifiedDB] (see [WithVerifiedDB])
+// with a hash for the module version,
+// the zip file is checked against it before anything is extracted.
+func (cl Client) Extract(ctx context.Context, mod, ver, dir string) error {
+	return cl.ExtractFiltered(ctx, mod, ver, dir, ExtractFilter{})
+}
+
+// ExtractFiltered is like [Client.Extract]
+// but extracts only the files selected by filter.
+// The [ExtractLimits] and the check for free space in dir
+// apply only to the selected files.
+func (cl Client) ExtractFiltered(ctx context.Context, mod, ver, dir string, filter ExtractFilter) error {
+	if err := filter.check(); err != nil {
+		return err
+	}
+
+	d, err := cl.zipFile(ctx, mod, ver)
+	if err != nil {
+		return err
+	}
+	defer d.remove()
+
+	if err := cl.checkDownload(ctx, d, mod, ver); err != nil {
+		return err
+	}
+
+	zr, err := zip.NewReader(d, d.size)
+	if err != nil {
+		return errors.Wrapf(err, "parsing zip for %s@%s", mod, ver)
+	}
+	return cl.extractZip(zr, mod+"@"+ver+"/", dir, filter, nil)
+}
+
+// ExtractFilter selects the files to extract from a module zip file
+// (see [Client.ExtractFiltered]).
+// The zero ExtractFilter selects all files.
+//
+// Each pattern is a glob in the syntax of [path.Match].
+// A pattern containing no slash matches a file
+// if it matches any element of the file's slash-separated name,
+// so "testdata" matches every file in every testdata directory
+// and "*.md" matches every Markdown file.
+// A pattern containing a slash matches a file
+// if it matches a prefix of the file's name
+// consisting of the same number of path elements,
+// so "docs" and "docs/*" both match every file beneath the module's top-level docs directory
+// (but "docs/*" would also match a top-level file named docs).
+type ExtractFilter struct {
+	// Include, if non-empty, limits extraction to files matching at least one of these patterns.
+	Include []string
+
+	// Exclude prevents extraction of files matching any of these patterns,
+	// even if they match an Include pattern.
+	Exclude []string
+}
+
+// ParseExtractFilter creates an [ExtractFilter]
+// from comma-separated lists of include and exclude patterns.
+// Empty patterns and trailing slashes are ignored.
+// It returns an error if any pattern is malformed.
+func ParseExtractFilter(include, exclude string) (ExtractFilter, error) {
+	split := func(list string) []string {
+		var result []string
+		for _, pat := range strings.Split(list, ",") {
+			if pat = strings.TrimSuffix(pat, "/"); pat != "" {
+				result = append(result, pat)
+			}
+		}
+		return result
+	}
+	f := ExtractFilter{Include: split(include), Exclude: split(exclude)}
+	return f, f.check()
+}
+
+// Match tells whether the filter selects the file with the given name,
+// which is slash-separated and relative to the module root.
+func (f ExtractFilter) Match(name string) bool {
+	if len(f.Include) > 0 && !matchAnyExtractPattern(f.Include, name) {
+		return false
+	}
+	return !matchAnyExtractPattern(f.Exclude, name)
+}
+
+// Check reports an error if any of f's patterns is malformed.
+func (f ExtractFilter) check() error {
+	for _, pat := range slices.Concat(f.Include, f.Exclude) {
+		if _, err := path.Match(pat, ""); err != nil {
+			return errors.Wrapf(err, "malformed pattern %q", pat)
+		}
+	}
+	return nil
+}
+
+func matchAnyExtractPattern(pats []string, name string) bool {
+	return slices.ContainsFunc(pats, func(pat string) bool {
+		return matchExtractPattern(pat, name)
+	})
+}
+
+func matchExtractPattern(pat, name string) bool {
+	elems := strings.Split(name, "/")
+	if !strings.Contains(pat, "/") {
+		return slices.ContainsFunc(elems, func(elem string) bool {
+			ok, _ := path.Match(pat, elem)
+			return ok
+		})
+	}
+	n := strings.Count(pat, "/") + 1
+	if n > len(elems) {
+		return false
+	}
+	ok, _ := path.Match(pat, strings.Join(elems[:n], "/"))
+	return ok
+}
+
+// downloadedZip is a module zip file downloaded into a temporary file by [Client.zipFile].
+type downloadedZip struct {
+	*os.File
+	size int64
+
+	proxy, url string // where it came from
+	time       time.Time
+	sha256     []byte
+}
+
+// Remove closes and removes the temporary file.
+func (d *downloadedZip) remove() {
+	d.Close()
+	os.Remove(d.Name())
+}
+
+// ZipFile fetches the zip file for a module version into a temporary file,
+// without scanning it.
+// The caller must remove it.
+func (cl Client) zipFile(ctx context.Context, mod, ver string) (*downloadedZip, error) {
+	rc, err := cl.fetchZip(ctx, mod, ver)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting zip for %s@%s", mod, ver)
+	}
+	defer rc.Close()
+
+	if err := checkDiskSpace(os.TempDir(), contentLength(rc)); err != nil {
+		return nil, errors.Wrapf(err, "downloading zip for %s@%s", mod, ver)
+	}
+
+	f, err := os.CreateTemp("", "goproxyclient-*.zip")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating temp file")
+	}
+	d := &downloadedZip{File: f, time: time.Now()}
+	d.proxy, d.url = source(rc)
+
+	h := sha256.New()
+	d.size, err = io.Copy(io.MultiWriter(f, h), rc)
+	if err != nil {
+		d.remove()
+		return nil, errors.Wrapf(err, "downloading zip for %s@%s", mod, ver)
+	}
+	d.sha256 = h.Sum(nil)
+
+	return d, nil
+}
+
+// CheckDownload checks a downloaded zip file for a module version
+// (see [Client.inspectZip])
+// and writes an attestation for it if the client is configured to
+// (see [WithAttestations]).
+func (cl Client) checkDownload(ctx context.Context, d *downloadedZip, mod, ver string) error {
+	verified, err := cl.inspectZip(ctx, d, mod, ver)
+	if err != nil {
+		return err
+	}
+	return cl.attest(d, mod, ver, verified)
+}
+
+// InspectZip checks a downloaded zip file for a module version
+// against the client's [VerifiedDB], if any
+// (see [Client.verifyZipFile]),
+// passes it to the client's [Scanner]s, if any,
+// and checks it against the client's [Policy], if any.
+// It reports whether the file was verified.
+// A file that fails any of these checks is removed from the client's cache.
+func (cl Client) inspectZip(ctx context.Context, d *downloadedZip, mod, ver string) (verified bool, err error) {
+	defer func() {
+		if err != nil {
+			cl.uncache(ctx, mod, ver, "zip")
+		}
+	}()
+
+	verified, err = cl.verifyZipFile(d.Name(), mod, ver)
+	if cl.hasHashes() {
+		traceFrom(ctx).verified(mod, ver, "zip", verified, err)
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := cl.scanZipFile(ctx, d, mod, ver); err != nil {
+		return false, err
+	}
+	return verified, cl.enforceZipPolicy(ctx, d, mod, ver, verified)
+}
+
+// ExtractZip writes the files in zr whose names begin with prefix
+// and are selected by filter
+// (identified by their names without prefix)
+// into dir,
+// with prefix removed.
+// Each file is written atomically (see [writeFileAtomic]).
+// If modeFn is non-nil,
+// it gives the permission bits for each file
+// (identified by its slash-separated name without prefix);
+// otherwise all files get mode 0644.
+//
+// Before writing anything,
+// it checks the selected files against the client's [ExtractLimits],
+// returning an [*ExtractLimitError] if any is exceeded
+// or an [*UnsafePathError] if any file has an unsafe name,
+// and checks that dir has enough free space for the uncompressed contents,
+// returning an [InsufficientSpaceError] if not.
+func (cl Client) extractZip(zr *zip.Reader, prefix, dir string, filter ExtractFilter, modeFn func(string) os.FileMode) error {
+	var files []*zip.File
+	for _, f := range zr.File {
+		if err := checkZipEntry(f, prefix); err != nil {
+			return err
+		}
+		if filter.Match(strings.TrimPrefix(f.Name, prefix)) {
+			files = append(files, f)
+		}
+	}
+
+	lim := cl.conf.getExtractLimits()
+	if lim.MaxFiles > 0 && len(files) > lim.MaxFiles {
+		return &ExtractLimitError{Limit: "file count", Value: float64(len(files)), Max: float64(lim.MaxFiles)}
+	}
+
+	var total int64
+	for _, f := range files {
+		size := f.UncompressedSize64
+		if size > math.MaxInt64-uint64(total) {
+			return errors.Newf("zip contents too large (file %s claims %d bytes)", f.Name, size)
+		}
+		if lim.MaxFileSize > 0 && size > uint64(lim.MaxFileSize) {
+			return &ExtractLimitError{File: f.Name, Limit: "file size", Value: float64(size), Max: float64(lim.MaxFileSize)}
+		}
+		if lim.MaxRatio > 0 && size > ratioMinSize {
+			if ratio := float64(size) / float64(f.CompressedSize64); ratio > lim.MaxRatio {
+				return &ExtractLimitError{File: f.Name, Limit: "compression ratio", Value: ratio, Max: lim.MaxRatio}
+			}
+		}
+		total += int64(size)
+		if lim.MaxTotalSize > 0 && total > lim.MaxTotalSize {
+			return &ExtractLimitError{Limit: "total size", Value: float64(total), Max: float64(lim.MaxTotalSize)}
+		}
+	}
+	if err := checkDiskSpace(dir, total); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		name := strings.TrimPrefix(f.Name, prefix)
+		if name == "" || strings.HasSuffix(name, "/") {
+			continue
+		}
+
+		mode := os.FileMode(0644)
+		if modeFn != nil {
+			mode = modeFn(name)
+		}
+
+		dest := filepath.Join(dir, filepath.FromSlash(name))
+		if err := extractFile(f, dest, mode, cl.conf.syncWrites); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckZipEntry checks that f has the given prefix
+// and that the rest of its name is safe to extract
+// (see [checkZipName]).
+func checkZipEntry(f *zip.File, prefix string) error {
+	name, ok := strings.CutPrefix(f.Name, prefix)
+	if !ok {
+		return &UnsafePathError{File: f.Name, Err: errors.Newf("lacks the expected prefix %s", prefix)}
+	}
+	if name == "" || strings.HasSuffix(name, "/") {
+		return nil
+	}
+	if err := checkZipName(name); err != nil {
+		return &UnsafePathError{File: f.Name, Err: err}
+	}
+	return nil
+}
+
+// CheckZipName reports an error if name,
+// the slash-separated name of a file in a zip without its prefix,
+// is not safe to extract:
+// if it is not valid UTF-8,
+// is absolute,
+// is not clean,
+// or contains backslashes, NUL bytes, or ".." elements.
+func checkZipName(name string) error {
+	switch {
+	case !utf8.ValidString(name):
+		return errors.New("invalid UTF-8 in name")
+	case strings.ContainsAny(name, "\\\x00"):
+		return errors.New("invalid character in name")
+	case path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "":
+		return errors.New("absolute name")
+	case path.Clean(name) != name:
+		return errors.New("name is not clean")
+	case name == ".." || strings.HasPrefix(name, "../"):
+		return errors.New("name escapes the destination directory")
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, dest string, mode os.FileMode, doSync bool) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return errors.Wrapf(err, "creating directory for %s", dest)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return errors.Wrapf(err, "opening %s in zip", f.Name)
+	}
+	defer rc.Close()
+
+	// The zip package also checks this,
+	// but don't depend on it to stop a file that lies about its size.
+	size := f.UncompressedSize64
+	return writeFileAtomic(dest, mode, doSync, func(w io.Writer) error {
+		n, err := io.Copy(w, io.LimitReader(rc, int64(size)+1))
+		if err != nil {
+			return err
+		}
+		if uint64(n) > size {
+			return &ExtractLimitError{File: f.Name, Limit: "declared size", Value: float64(n), Max: float64(size)}
+		}
+		return nil
+	})
+}
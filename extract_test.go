@@ -0,0 +1,95 @@
+package goproxyclient
+
+import (
+	"context"
+	"io/fs"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExtractFilter(t *testing.T) {
+	cases := []struct {
+		include, exclude string
+		want             []string
+	}{{
+		want: []string{
+			"LICENSE",
+			"_ignored/ignored.go",
+			"go.mod",
+			"multi.go",
+			"multi_test.go",
+			"sub/sub.go",
+			"testdata/ignored.go",
+			"testonly/x_test.go",
+			"winonly/winonly_windows.go",
+		},
+	}, {
+		exclude: "testdata/,*_test.go,_*",
+		want: []string{
+			"LICENSE",
+			"go.mod",
+			"multi.go",
+			"sub/sub.go",
+			"winonly/winonly_windows.go",
+		},
+	}, {
+		include: "*.go",
+		exclude: "winonly",
+		want: []string{
+			"_ignored/ignored.go",
+			"multi.go",
+			"multi_test.go",
+			"sub/sub.go",
+			"testdata/ignored.go",
+			"testonly/x_test.go",
+		},
+	}, {
+		include: "sub/*,go.mod",
+		want:    []string{"go.mod", "sub/sub.go"},
+	}}
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+
+	for _, tc := range cases {
+		t.Run(tc.include+"_"+tc.exclude, func(t *testing.T) {
+			filter, err := ParseExtractFilter(tc.include, tc.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dir := t.TempDir()
+			if err := cl.ExtractFiltered(context.Background(), "example.com/multi", "v1.0.0", dir, filter); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				rel, err := filepath.Rel(dir, path)
+				got = append(got, filepath.ToSlash(rel))
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := ParseExtractFilter("[", ""); err == nil {
+		t.Error("got no error for malformed pattern")
+	}
+}
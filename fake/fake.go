@@ -0,0 +1,200 @@
+// Package fake provides an in-memory implementation of [goproxyclient.Clientish]
+// for use in tests.
+package fake
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/mid"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"github.com/bobg/goproxyclient"
+)
+
+// Client is an in-memory implementation of [goproxyclient.Clientish].
+// Seed it with module versions using [Client.Add].
+// Requests for modules or versions it does not have
+// fail with errors for which [goproxyclient.IsNotFound] is true.
+//
+// A Client is safe for concurrent use by multiple goroutines.
+// Create one with [New].
+type Client struct {
+	mu   sync.Mutex
+	mods map[string]map[string]*version // module path -> version -> data
+}
+
+var _ goproxyclient.Clientish = (*Client)(nil)
+
+type version struct {
+	tm    time.Time
+	gomod []byte
+	zip   []byte
+}
+
+// New creates a new, empty [Client].
+func New() *Client {
+	return &Client{mods: make(map[string]map[string]*version)}
+}
+
+// Add adds a version of a module to the client.
+// The version must be canonical (e.g. v1.2.3, not 1.2.3 or v1.2).
+//
+// If gomod is nil,
+// a minimal go.mod file containing only a module directive is used.
+// The files map gives the contents of the module's zip file,
+// keyed by slash-separated path relative to the module root.
+// A go.mod file is added to it if not present.
+func (c *Client) Add(mod, ver string, tm time.Time, gomod []byte, files map[string]string) error {
+	if err := module.Check(mod, ver); err != nil {
+		return err
+	}
+	if semver.Canonical(ver) != ver {
+		return fmt.Errorf("version %s is not canonical", ver)
+	}
+
+	if gomod == nil {
+		gomod = fmt.Appendf(nil, "module %s\n", mod)
+	}
+
+	var (
+		buf    bytes.Buffer
+		zw     = zip.NewWriter(&buf)
+		prefix = mod + "@" + ver + "/"
+	)
+	if _, ok := files["go.mod"]; !ok {
+		if err := addZipFile(zw, prefix+"go.mod", gomod); err != nil {
+			return err
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		if err := addZipFile(zw, prefix+name, []byte(files[name])); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return errors.Wrap(err, "finishing zip")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	versions := c.mods[mod]
+	if versions == nil {
+		versions = make(map[string]*version)
+		c.mods[mod] = versions
+	}
+	versions[ver] = &version{tm: tm, gomod: gomod, zip: buf.Bytes()}
+
+	return nil
+}
+
+func addZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return errors.Wrapf(err, "adding %s to zip", name)
+	}
+	_, err = w.Write(data)
+	return errors.Wrapf(err, "writing %s to zip", name)
+}
+
+func notFound(format string, args ...any) error {
+	return mid.CodeErr{C: http.StatusNotFound, Err: fmt.Errorf(format, args...)}
+}
+
+func (c *Client) lookup(mod, ver string) (*version, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.mods[mod][ver]
+	if !ok {
+		return nil, notFound("%s@%s not found", mod, ver)
+	}
+	return v, nil
+}
+
+func infoMap(ver string, tm time.Time) (map[string]json.RawMessage, error) {
+	j, err := json.Marshal(struct {
+		Version string
+		Time    time.Time
+	}{Version: ver, Time: tm})
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	err = json.Unmarshal(j, &m)
+	return m, err
+}
+
+// Info implements [goproxyclient.Clientish].
+// Only canonical versions are recognized.
+func (c *Client) Info(_ context.Context, mod, ver string) (string, time.Time, map[string]json.RawMessage, error) {
+	v, err := c.lookup(mod, ver)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	m, err := infoMap(ver, v.tm)
+	return ver, v.tm, m, err
+}
+
+// Latest implements [goproxyclient.Clientish].
+// The latest version is the highest release version if there is one,
+// otherwise the highest prerelease version.
+func (c *Client) Latest(ctx context.Context, mod string) (string, time.Time, map[string]json.RawMessage, error) {
+	versions, err := c.List(ctx, mod)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+
+	latest := versions[len(versions)-1]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if semver.Prerelease(versions[i]) == "" {
+			latest = versions[i]
+			break
+		}
+	}
+	return c.Info(ctx, mod, latest)
+}
+
+// List implements [goproxyclient.Clientish].
+func (c *Client) List(_ context.Context, mod string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	versions, ok := c.mods[mod]
+	if !ok {
+		return nil, notFound("module %s not found", mod)
+	}
+	result := slices.Collect(maps.Keys(versions))
+	semver.Sort(result)
+	return result, nil
+}
+
+// Mod implements [goproxyclient.Clientish].
+func (c *Client) Mod(_ context.Context, mod, ver string) (io.ReadCloser, error) {
+	v, err := c.lookup(mod, ver)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(v.gomod)), nil
+}
+
+// Zip implements [goproxyclient.Clientish].
+func (c *Client) Zip(_ context.Context, mod, ver string) (io.ReadCloser, error) {
+	v, err := c.lookup(mod, ver)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(v.zip)), nil
+}
@@ -0,0 +1,128 @@
+package fake
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bobg/goproxyclient"
+)
+
+func TestClient(t *testing.T) {
+	var (
+		ctx = context.Background()
+		cl  = New()
+		t1  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		t2  = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+		t3  = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	if err := cl.Add("example.com/foo", "v1.0.0", t1, nil, map[string]string{"foo.go": "package foo\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.Add("example.com/foo", "v1.1.0", t2, []byte("module example.com/foo\n\ngo 1.22\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.Add("example.com/foo", "v1.2.0-pre", t3, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.Add("example.com/foo", "1.3", t3, nil, nil); err == nil {
+		t.Error("adding non-canonical version: got nil, want error")
+	}
+
+	versions, err := cl.List(ctx, "example.com/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v1.0.0", "v1.1.0", "v1.2.0-pre"}; !slices.Equal(versions, want) {
+		t.Errorf("got %v, want %v", versions, want)
+	}
+
+	ver, tm, _, err := cl.Latest(ctx, "example.com/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver != "v1.1.0" || !tm.Equal(t2) {
+		t.Errorf("got latest %s %s, want v1.1.0 %s", ver, tm, t2)
+	}
+
+	rc, err := cl.Mod(ctx, "example.com/foo", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gomod, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(gomod) != "module example.com/foo\n" {
+		t.Errorf("got go.mod %q", gomod)
+	}
+
+	rc, err = cl.Zip(ctx, "example.com/foo", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if want := []string{"example.com/foo@v1.0.0/go.mod", "example.com/foo@v1.0.0/foo.go"}; !slices.Equal(names, want) {
+		t.Errorf("got zip files %v, want %v", names, want)
+	}
+
+	if _, _, _, err := cl.Info(ctx, "example.com/foo", "v9.9.9"); !goproxyclient.IsNotFound(err) {
+		t.Errorf("got %v, want not-found error", err)
+	}
+	if _, err := cl.List(ctx, "example.com/bar"); !goproxyclient.IsNotFound(err) {
+		t.Errorf("got %v, want not-found error", err)
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	var (
+		ctx = context.Background()
+		cl  = New()
+		tm  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		wg  sync.WaitGroup
+	)
+
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			ver := fmt.Sprintf("v1.%d.0", i)
+			if err := cl.Add("example.com/foo", ver, tm, nil, map[string]string{"foo.go": "package foo\n"}); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			// These may or may not find anything, depending on timing.
+			cl.List(ctx, "example.com/foo")
+			cl.Latest(ctx, "example.com/foo")
+			if rc, err := cl.Zip(ctx, "example.com/foo", "v1.0.0"); err == nil {
+				io.Copy(io.Discard, rc)
+				rc.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	versions, err := cl.List(ctx, "example.com/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 8 {
+		t.Errorf("got %d versions, want 8", len(versions))
+	}
+}
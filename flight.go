@@ -0,0 +1,151 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/bobg/errors"
+)
+
+// flightGroup coalesces concurrent identical requests,
+// so that when many goroutines ask a [Client] for the same thing at once
+// (as graph walkers often do),
+// only one request is sent to the proxies
+// and all of the callers share its result.
+// Its zero value is ready to use.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done  chan struct{} // closed when val, err, and proxy are set
+	val   any
+	err   error
+	proxy string // for Provenance
+
+	waiters int // protected by the flightGroup's mu
+	cancel  context.CancelFunc
+}
+
+// Coalesce calls f and returns its result,
+// unless a call with the same key is already in progress,
+// in which case it waits for that one and returns its result instead.
+// Callers sharing a result must not modify it,
+// so share copies in place of maps, slices, and readers.
+//
+// The call runs with a context that has the values of the first caller's ctx
+// (such as its request ID and [Trace])
+// but not its cancellation:
+// the call is canceled only when every caller waiting for it has given up.
+// Each caller's [Provenance] records where the shared result came from.
+//
+// Each caller counts as an operation in progress for [Client.Shutdown],
+// so after shutdown no caller can join a call already in progress.
+//
+// If requests to the client's proxies depend on the tenant of the operation
+// (see [WithTenant]),
+// only callers with the same tenant share a call.
+// If the client has a scheduler
+// (see [WithScheduler]),
+// only callers whose priorities are scheduled alike share a call,
+// so that an interactive caller does not wait behind background work.
+func coalesce[T any](ctx context.Context, cl Client, key string, f func(context.Context) (T, error)) (T, error) {
+	var zero T
+
+	if cl.chain().byTenant {
+		if tenant := Tenant(ctx); tenant != "" {
+			key += " tenant=" + tenant
+		}
+	}
+	if cl.conf.scheduler != nil {
+		key += " priority=" + strconv.Itoa(schedLevel(RequestPriority(ctx)))
+	}
+
+	done, err := cl.work().begin()
+	if err != nil {
+		return zero, err
+	}
+	defer done()
+
+	g := &cl.conf.flights
+	g.mu.Lock()
+	fl, ok := g.flights[key]
+	if ok {
+		fl.waiters++
+	} else {
+		fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		fl = &flight{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		if g.flights == nil {
+			g.flights = make(map[string]*flight)
+		}
+		g.flights[key] = fl
+
+		go func() {
+			defer cancel()
+
+			var p Provenance
+			fl.val, fl.err = f(WithProvenance(fctx, &p))
+			fl.proxy = p.Proxy()
+
+			g.mu.Lock()
+			if g.flights[key] == fl {
+				delete(g.flights, key)
+			}
+			g.mu.Unlock()
+
+			close(fl.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-fl.done:
+		if fl.err != nil {
+			return zero, fl.err
+		}
+		provenanceFrom(ctx).set(fl.proxy)
+		return fl.val.(T), nil
+
+	case <-ctx.Done():
+		g.mu.Lock()
+		fl.waiters--
+		if fl.waiters == 0 {
+			// Nobody wants the result any more.
+			// Cancel the call,
+			// and let later callers start a new one.
+			fl.cancel()
+			if g.flights[key] == fl {
+				delete(g.flights, key)
+			}
+		}
+		g.mu.Unlock()
+		return zero, ctx.Err()
+	}
+}
+
+// SharedBody is a small response body (such as a go.mod file) read into memory,
+// so that callers sharing it can each have their own reader.
+type sharedBody struct {
+	data       []byte
+	proxy, url string // see sizedBody
+}
+
+// ReadShared reads rc into a [sharedBody] and closes it.
+func readShared(rc io.ReadCloser) (sharedBody, error) {
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return sharedBody{}, errors.Wrap(err, "reading response body")
+	}
+	proxy, url := source(rc)
+	return sharedBody{data: data, proxy: proxy, url: url}, nil
+}
+
+func (b sharedBody) reader() io.ReadCloser {
+	return sizedBody{ReadCloser: io.NopCloser(bytes.NewReader(b.data)), size: int64(len(b.data)), proxy: b.proxy, url: b.url}
+}
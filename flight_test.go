@@ -0,0 +1,179 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesce(t *testing.T) {
+	ctx := context.Background()
+
+	// NewServer starts a proxy that counts its requests
+	// and doesn't answer them until release is closed.
+	type server struct {
+		cl                 Client
+		requests, canceled atomic.Int64
+		release            chan struct{}
+	}
+	newServer := func(t *testing.T) *server {
+		srv := &server{release: make(chan struct{})}
+		h := testHandler(nil)
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			srv.requests.Add(1)
+			select {
+			case <-srv.release:
+				h.ServeHTTP(w, req)
+			case <-req.Context().Done():
+				srv.canceled.Add(1)
+			}
+		}))
+		t.Cleanup(s.Close)
+		srv.cl = New(s.URL, nil)
+		return srv
+	}
+
+	// WaitFor waits until n callers are waiting for the call with the given key.
+	waitFor := func(t *testing.T, cl Client, key string, n int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			g := &cl.conf.flights
+			g.mu.Lock()
+			var waiters int
+			if fl := g.flights[key]; fl != nil {
+				waiters = fl.waiters
+			}
+			g.mu.Unlock()
+			if waiters == n {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("got %d waiters for %s, want %d", waiters, key, n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	const n = 10
+
+	t.Run("mod", func(t *testing.T) {
+		srv := newServer(t)
+
+		var (
+			wg   sync.WaitGroup
+			mods [n]string
+			errs [n]error
+		)
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				rc, err := srv.cl.Mod(ctx, "example.com/multi", "v1.0.0")
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				defer rc.Close()
+				data, err := io.ReadAll(rc)
+				mods[i], errs[i] = string(data), err
+			}()
+		}
+		waitFor(t, srv.cl, "mod example.com/multi@v1.0.0", n)
+		close(srv.release)
+		wg.Wait()
+
+		if got := srv.requests.Load(); got != 1 {
+			t.Errorf("got %d requests, want 1", got)
+		}
+		for i := range n {
+			if errs[i] != nil {
+				t.Fatalf("caller %d: %s", i, errs[i])
+			}
+			if mods[i] != mods[0] || mods[i] == "" {
+				t.Errorf("caller %d got go.mod %q, want %q", i, mods[i], mods[0])
+			}
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		srv := newServer(t)
+
+		var (
+			wg       sync.WaitGroup
+			versions [n][]string
+		)
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				vs, err := srv.cl.List(ctx, "example.com/multi")
+				if err != nil {
+					t.Error(err)
+				}
+				versions[i] = vs
+			}()
+		}
+		waitFor(t, srv.cl, "list example.com/multi", n)
+		close(srv.release)
+		wg.Wait()
+
+		if got := srv.requests.Load(); got != 1 {
+			t.Errorf("got %d requests, want 1", got)
+		}
+
+		// Each caller has its own copy.
+		versions[0][0] = "changed"
+		if versions[1][0] == "changed" {
+			t.Error("callers share a version list")
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		srv := newServer(t)
+		defer close(srv.release)
+
+		ctx1, cancel1 := context.WithCancel(ctx)
+		ctx2, cancel2 := context.WithCancel(ctx)
+
+		errch1, errch2 := make(chan error, 1), make(chan error, 1)
+		go func() {
+			_, _, _, err := srv.cl.Info(ctx1, "example.com/multi", "v1.0.0")
+			errch1 <- err
+		}()
+		go func() {
+			_, _, _, err := srv.cl.Info(ctx2, "example.com/multi", "v1.0.0")
+			errch2 <- err
+		}()
+		waitFor(t, srv.cl, "info example.com/multi@v1.0.0", 2)
+
+		// Canceling one caller doesn't cancel the request.
+		cancel1()
+		if err := <-errch1; !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+		waitFor(t, srv.cl, "info example.com/multi@v1.0.0", 1)
+		if got := srv.canceled.Load(); got != 0 {
+			t.Errorf("got %d canceled requests, want 0", got)
+		}
+
+		// Canceling the last one does.
+		cancel2()
+		if err := <-errch2; !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for srv.canceled.Load() == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("request not canceled")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func FuzzExtractZip(f *testing.F) {
+	const prefix = "example.com/multi@v1.0.0/"
+
+	data, err := fs.ReadFile(testdata, "testdata/example.com/multi/@v/v1.0.0.zip")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Add(makeZip(f, prefix+"../../escape.txt", prefix+"ok.txt"))
+	f.Add(makeZip(f, prefix+"/abs.txt", prefix+"a\\b.txt", prefix+"a//b.txt"))
+	f.Add([]byte("PK\x05\x06"))
+
+	cl := New("", nil)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return
+		}
+
+		dir := t.TempDir()
+		if err := cl.extractZip(zr, prefix, dir, ExtractFilter{}, nil); err != nil {
+			return
+		}
+
+		for _, zf := range zr.File {
+			name := strings.TrimPrefix(zf.Name, prefix)
+			if name == "" || strings.HasSuffix(name, "/") {
+				continue
+			}
+			if !filepath.IsLocal(filepath.FromSlash(name)) {
+				t.Errorf("extracted non-local file %s", zf.Name)
+			}
+		}
+	})
+}
+
+func makeZip(f *testing.F, names ...string) []byte {
+	var (
+		buf bytes.Buffer
+		zw  = zip.NewWriter(&buf)
+	)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		if _, err := w.Write([]byte("x")); err != nil {
+			f.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		f.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCheckZipName(t *testing.T) {
+	cases := []struct {
+		name string
+		ok   bool
+	}{
+		{"go.mod", true},
+		{"sub/dir/file.go", true},
+		{"..foo/bar", true},
+		{"../escape", false},
+		{"a/../../escape", false},
+		{"..", false},
+		{"/abs", false},
+		{"a//b", false},
+		{"a/./b", false},
+		{"a\\b", false},
+		{"a\x00b", false},
+		{"bad\xff", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkZipName(tc.name)
+			if tc.ok && err != nil {
+				t.Errorf("got %v, want nil", err)
+			}
+			if !tc.ok && err == nil {
+				t.Error("got nil, want error")
+			}
+		})
+	}
+}
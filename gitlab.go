@@ -0,0 +1,55 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/bobg/errors"
+)
+
+// GitLabAuth returns an [Authenticator] for GitLab's Go module proxy,
+// whose URLs have the form https://HOST/api/v4/projects/ID/packages/go.
+//
+// It applies to requests whose hostname matches hostPattern
+// (using the syntax of [path.Match], e.g. "gitlab.example.com" or "*.gitlab.example.com")
+// and whose path begins with /api/v4/.
+// If hostPattern is empty,
+// the value of $CI_SERVER_HOST is used if set,
+// otherwise gitlab.com.
+//
+// If token is non-empty,
+// it is sent as a personal, project, or group access token in the PRIVATE-TOKEN header.
+// Otherwise the value of $CI_JOB_TOKEN is sent in the JOB-TOKEN header,
+// so that within a GitLab CI job no further configuration is needed.
+// The environment is consulted at request time, not when GitLabAuth is called.
+func GitLabAuth(hostPattern, token string) Authenticator {
+	return AuthFunc(func(_ context.Context, req *http.Request) error {
+		pattern := hostPattern
+		if pattern == "" {
+			pattern = os.Getenv("CI_SERVER_HOST")
+		}
+		if pattern == "" {
+			pattern = "gitlab.com"
+		}
+
+		ok, err := path.Match(pattern, strings.ToLower(req.URL.Hostname()))
+		if err != nil {
+			return errors.Wrapf(err, "matching host pattern %s", pattern)
+		}
+		if !ok || !strings.HasPrefix(req.URL.Path, "/api/v4/") {
+			return nil
+		}
+
+		if token != "" {
+			req.Header.Set("PRIVATE-TOKEN", token)
+			return nil
+		}
+		if jobToken := os.Getenv("CI_JOB_TOKEN"); jobToken != "" {
+			req.Header.Set("JOB-TOKEN", jobToken)
+		}
+		return nil
+	})
+}
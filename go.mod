@@ -10,6 +10,11 @@ require (
 	github.com/bobg/subcmd/v2 v2.3.0
 	github.com/google/go-cmp v0.5.6
 	golang.org/x/mod v0.24.0
+	golang.org/x/oauth2 v0.30.0
+	golang.org/x/time v0.11.0
 )
 
-require github.com/pkg/errors v0.9.1 // indirect
+require (
+	cloud.google.com/go/compute/metadata v0.3.0 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+)
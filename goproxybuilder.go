@@ -0,0 +1,114 @@
+package goproxyclient
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bobg/errors"
+)
+
+// GOPROXYBuilder constructs GOPROXY strings,
+// the inverse of [Parse].
+// The zero value is an empty builder ready to use.
+//
+// Example:
+//
+//	var b GOPROXYBuilder
+//	b.Add("https://goproxy.corp.example.com")
+//	b.AddAfterAnyErr("https://proxy.golang.org")
+//	b.Add("direct")
+//	goproxy, err := b.Build() // "https://goproxy.corp.example.com|https://proxy.golang.org,direct"
+type GOPROXYBuilder struct {
+	buf strings.Builder
+}
+
+// Add appends an entry to the GOPROXY string:
+// a proxy URL, "direct," or "off."
+// The entry is tried only if the preceding one fails with a 404 (Not Found) or 410 (Gone) error.
+// That is, it is preceded by a comma.
+func (b *GOPROXYBuilder) Add(entry string) *GOPROXYBuilder {
+	return b.add(",", entry)
+}
+
+// AddAfterAnyErr appends an entry to the GOPROXY string:
+// a proxy URL, "direct," or "off."
+// The entry is tried if the preceding one fails with any error.
+// That is, it is preceded by a pipe.
+func (b *GOPROXYBuilder) AddAfterAnyErr(entry string) *GOPROXYBuilder {
+	return b.add("|", entry)
+}
+
+func (b *GOPROXYBuilder) add(sep, entry string) *GOPROXYBuilder {
+	if b.buf.Len() > 0 {
+		b.buf.WriteString(sep)
+	}
+	b.buf.WriteString(entry)
+	return b
+}
+
+// String returns the GOPROXY string built so far,
+// without validating it.
+func (b *GOPROXYBuilder) String() string {
+	return b.buf.String()
+}
+
+// Build returns the GOPROXY string built so far,
+// or an error if [ValidateGOPROXY] rejects it.
+func (b *GOPROXYBuilder) Build() (string, error) {
+	s := b.String()
+	return s, ValidateGOPROXY(s)
+}
+
+// ValidateGOPROXY checks a GOPROXY string the way the go command does,
+// returning an error describing the first invalid entry if there is one.
+//
+// Valid entries are "direct," "off,"
+// and URLs with the scheme http, https, or file.
+// As in the go command,
+// an entry with no scheme that contains a dot, colon, or slash
+// (and is not an absolute file path)
+// is taken to be an https URL.
+// Empty entries are ignored.
+func ValidateGOPROXY(goproxy string) error {
+	_, err := ParseConfig(goproxy)
+	return err
+}
+
+// NormalizeProxyEntry checks a single GOPROXY entry
+// and returns it in the form the go command would use:
+// unchanged for "direct," "off," and the empty string,
+// and with "https://" prepended for URLs that lack a scheme.
+func normalizeProxyEntry(entry string) (string, error) {
+	switch entry {
+	case "", "direct", "off":
+		return entry, nil
+	}
+
+	// This logic follows cmd/go/internal/modfetch.proxyList.
+	if strings.ContainsAny(entry, ".:/") && !strings.Contains(entry, ":/") && !filepath.IsAbs(entry) && !path.IsAbs(entry) {
+		entry = "https://" + entry
+	}
+
+	u, err := url.Parse(entry)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid proxy URL %q", entry)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return "", fmt.Errorf("invalid proxy URL %q: missing host", entry)
+		}
+	case "file":
+		if u.Path == "" && u.Opaque == "" {
+			return "", fmt.Errorf("invalid proxy URL %q: missing path", entry)
+		}
+	case "":
+		return "", fmt.Errorf("invalid proxy URL %q: missing scheme", entry)
+	default:
+		return "", fmt.Errorf("invalid proxy URL %q: unsupported scheme %q", entry, u.Scheme)
+	}
+	return entry, nil
+}
@@ -0,0 +1,55 @@
+package goproxyclient
+
+import "testing"
+
+func TestGOPROXYBuilder(t *testing.T) {
+	var b GOPROXYBuilder
+	b.Add("https://goproxy.corp.example.com").AddAfterAnyErr("proxy.golang.org").Add("direct")
+
+	got, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "https://goproxy.corp.example.com|proxy.golang.org,direct"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	b.Add("ftp://example.com")
+	if _, err := b.Build(); err == nil {
+		t.Error("got nil error for ftp URL")
+	}
+}
+
+func TestValidateGOPROXY(t *testing.T) {
+	cases := []struct {
+		goproxy string
+		wantErr bool
+	}{
+		{"", false},
+		{"off", false},
+		{"direct", false},
+		{"https://proxy.golang.org,direct", false},
+		{"proxy.golang.org", false},
+		{"localhost:3000", false},
+		{"http://localhost:3000|off", false},
+		{"file:///var/goproxy", false},
+		{"https://a.example.com,,https://b.example.com", false},
+		{"noproxy", true},
+		{"ftp://example.com", true},
+		{"https://", true},
+		{"http://[::1", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.goproxy, func(t *testing.T) {
+			err := ValidateGOPROXY(tc.goproxy)
+			if tc.wantErr && err == nil {
+				t.Error("got nil error")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("got error %v", err)
+			}
+		})
+	}
+}
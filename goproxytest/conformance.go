@@ -0,0 +1,106 @@
+package goproxytest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/mid"
+	"golang.org/x/mod/module"
+
+	"github.com/bobg/goproxyclient/internal/protocol"
+)
+
+// ConformanceOption is the type of an option that can be passed to [Conformance].
+type ConformanceOption func(*conformance)
+
+type conformance struct {
+	probe module.Version
+	hc    *http.Client
+}
+
+// WithProbe sets the module version that [Conformance] requests.
+// If mv.Version is empty,
+// the highest release version in the module's version list is used.
+// The default is rsc.io/quote v1.5.2,
+// so a server that does not have that module needs this option.
+func WithProbe(mv module.Version) ConformanceOption {
+	return func(c *conformance) {
+		c.probe = mv
+	}
+}
+
+// WithHTTPClient sets the HTTP client that [Conformance] uses.
+// The default is [http.DefaultClient].
+func WithHTTPClient(hc *http.Client) ConformanceOption {
+	return func(c *conformance) {
+		c.hc = hc
+	}
+}
+
+// Conformance tests that the Go module proxy at baseURL
+// implements the [GOPROXY protocol] correctly,
+// making the same checks as github.com/bobg/goproxyclient.CheckProxy
+// (and the check-proxy command).
+// It is meant for people writing their own proxy servers
+// to run in their tests:
+//
+//	func TestConformance(t *testing.T) {
+//		s := httptest.NewServer(myProxyHandler)
+//		defer s.Close()
+//		goproxytest.Conformance(t, s.URL, goproxytest.WithProbe(module.Version{Path: "example.com/foo"}))
+//	}
+//
+// Each check runs as a subtest,
+// which fails if the check does.
+// Checks of behavior that the protocol does not require,
+// such as the @latest endpoint,
+// are skipped instead of failing.
+//
+// [GOPROXY protocol]: https://go.dev/ref/mod#goproxy-protocol
+func Conformance(t *testing.T, baseURL string, opts ...ConformanceOption) {
+	t.Helper()
+
+	c := conformance{hc: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	probe, results, err := protocol.Check(context.Background(), baseURL, c.probe, c.get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("probe module: %s", probe)
+
+	for _, r := range results {
+		t.Run(r.Name, func(t *testing.T) {
+			if r.Err == nil {
+				return
+			}
+			if r.Optional {
+				t.Skipf("GET %s: %s (optional)", r.URL, r.Err)
+			}
+			t.Errorf("GET %s: %s", r.URL, r.Err)
+		})
+	}
+}
+
+// Get implements [protocol.Getter].
+func (c conformance) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating GET %s request", url)
+	}
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "in GET %s", url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, mid.CodeErr{C: resp.StatusCode, Err: fmt.Errorf("GET %s: %s", url, resp.Status)}
+	}
+	return resp.Body, nil
+}
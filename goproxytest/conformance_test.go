@@ -0,0 +1,15 @@
+package goproxytest
+
+import (
+	"os"
+	"testing"
+
+	"golang.org/x/mod/module"
+)
+
+func TestConformance(t *testing.T) {
+	s := NewServer(os.DirFS("../testdata"))
+	defer s.Close()
+
+	Conformance(t, s.URL, WithProbe(module.Version{Path: "example.com/multi", Version: "v1.0.0"}))
+}
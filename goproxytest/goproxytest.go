@@ -0,0 +1,156 @@
+// Package goproxytest provides a Go module proxy server for use in tests.
+//
+// The server serves files from an [fs.FS],
+// such as a directory (via [os.DirFS]),
+// an embedded filesystem,
+// or an [fstest.MapFS].
+// Paths in the filesystem are the request paths of the [GOPROXY protocol],
+// e.g. github.com/bobg/errors/@v/list
+// or github.com/bobg/errors/@v/v1.1.0.info.
+//
+// Options simulate slow, broken, and flaky proxies.
+//
+// Going the other way,
+// [Conformance] tests that a proxy server,
+// such as one under development,
+// implements the protocol correctly.
+//
+// [GOPROXY protocol]: https://go.dev/ref/mod#goproxy-protocol
+package goproxytest
+
+import (
+	"io/fs"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bobg/mid"
+)
+
+// Handler is an [http.Handler] that acts as a Go module proxy.
+// Create one with [New].
+type Handler struct {
+	fsys    fs.FS
+	latency time.Duration
+	errs    []injectedErr
+
+	flakiness float64
+	flakeCode int
+	mu        sync.Mutex // protects rng
+	rng       *rand.Rand
+
+	requests atomic.Int64
+}
+
+type injectedErr struct {
+	prefix string
+	code   int
+}
+
+// Option is the type of an option that can be passed to [New] and [NewServer].
+type Option func(*Handler)
+
+// WithLatency causes the handler to wait for d before responding to each request.
+// The wait is cut short if the request's context is canceled.
+func WithLatency(d time.Duration) Option {
+	return func(h *Handler) {
+		h.latency = d
+	}
+}
+
+// WithError causes the handler to respond with the given HTTP status code
+// to any request whose path
+// (without the leading slash)
+// begins with prefix.
+// Multiple WithError options may be given;
+// the first matching one applies.
+func WithError(prefix string, code int) Option {
+	return func(h *Handler) {
+		h.errs = append(h.errs, injectedErr{prefix: prefix, code: code})
+	}
+}
+
+// WithFlakiness causes the handler to fail a random fraction p of requests
+// (0 <= p <= 1)
+// with the given HTTP status code.
+// The seed makes the sequence of failures reproducible.
+func WithFlakiness(p float64, code int, seed uint64) Option {
+	return func(h *Handler) {
+		h.flakiness = p
+		h.flakeCode = code
+		h.rng = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// New creates a new [Handler] serving the files in fsys.
+func New(fsys fs.FS, opts ...Option) *Handler {
+	h := &Handler{fsys: fsys}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// NewServer starts and returns a new [httptest.Server]
+// running a [Handler] that serves the files in fsys.
+// The caller should call Close when finished, to shut it down.
+func NewServer(fsys fs.FS, opts ...Option) *httptest.Server {
+	return httptest.NewServer(New(fsys, opts...))
+}
+
+// Requests tells the number of requests the handler has received.
+func (h *Handler) Requests() int64 {
+	return h.requests.Load()
+}
+
+// ServeHTTP implements [http.Handler].
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	mid.Err(h.serve).ServeHTTP(w, req)
+}
+
+func (h *Handler) serve(w http.ResponseWriter, req *http.Request) error {
+	h.requests.Add(1)
+
+	if h.latency > 0 {
+		timer := time.NewTimer(h.latency)
+		defer timer.Stop()
+
+		select {
+		case <-req.Context().Done():
+			return req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	reqPath := strings.Trim(req.URL.Path, "/")
+	for _, e := range h.errs {
+		if strings.HasPrefix(reqPath, e.prefix) {
+			return mid.CodeErr{C: e.code}
+		}
+	}
+
+	if h.flaky() {
+		return mid.CodeErr{C: h.flakeCode}
+	}
+
+	if reqPath == "" {
+		reqPath = "."
+	}
+	http.ServeFileFS(w, req, h.fsys, reqPath)
+	return nil
+}
+
+func (h *Handler) flaky() bool {
+	if h.rng == nil {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.rng.Float64() < h.flakiness
+}
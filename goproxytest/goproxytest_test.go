@@ -0,0 +1,113 @@
+package goproxytest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testFS = fstest.MapFS{
+	"example.com/foo/@v/list":        {Data: []byte("v1.0.0\n")},
+	"example.com/foo/@v/v1.0.0.mod":  {Data: []byte("module example.com/foo\n")},
+	"example.com/foo/@v/v1.0.0.info": {Data: []byte(`{"Version":"v1.0.0"}`)},
+}
+
+func get(t *testing.T, ctx context.Context, hc *http.Client, url string) (int, string, error) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), err
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	s := NewServer(testFS, WithError("example.com/bar", http.StatusGone))
+	defer s.Close()
+
+	cases := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{path: "/example.com/foo/@v/list", wantCode: http.StatusOK, wantBody: "v1.0.0\n"},
+		{path: "/example.com/foo/@v/v1.0.0.mod", wantCode: http.StatusOK, wantBody: "module example.com/foo\n"},
+		{path: "/example.com/foo/@v/v2.0.0.mod", wantCode: http.StatusNotFound},
+		{path: "/example.com/bar/@v/list", wantCode: http.StatusGone},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			code, body, err := get(t, ctx, http.DefaultClient, s.URL+tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != tc.wantCode {
+				t.Errorf("got status %d, want %d", code, tc.wantCode)
+			}
+			if tc.wantBody != "" && body != tc.wantBody {
+				t.Errorf("got body %q, want %q", body, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestLatency(t *testing.T) {
+	s := NewServer(testFS, WithLatency(time.Minute))
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, _, err := get(t, ctx, http.DefaultClient, s.URL+"/example.com/foo/@v/list"); err == nil {
+		t.Error("got nil error, want timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("request took %s", elapsed)
+	}
+}
+
+func TestFlakiness(t *testing.T) {
+	ctx := context.Background()
+
+	h := New(testFS, WithFlakiness(0.5, http.StatusServiceUnavailable, 1))
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	var ok, failed int
+	for range 100 {
+		code, _, err := get(t, ctx, http.DefaultClient, s.URL+"/example.com/foo/@v/list")
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch code {
+		case http.StatusOK:
+			ok++
+		case http.StatusServiceUnavailable:
+			failed++
+		default:
+			t.Fatalf("unexpected status %d", code)
+		}
+	}
+	if ok == 0 || failed == 0 {
+		t.Errorf("got %d successes and %d failures, want some of each", ok, failed)
+	}
+	if n := h.Requests(); n != 100 {
+		t.Errorf("got %d requests, want 100", n)
+	}
+}
@@ -0,0 +1,176 @@
+package goproxytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/bobg/errors"
+)
+
+// Mode is the mode of operation of a [Recorder].
+type Mode int
+
+const (
+	// Replay mode serves responses only from previous recordings.
+	// A request with no recording fails with [ErrNotRecorded].
+	Replay Mode = iota
+
+	// Record mode sends every request to the underlying transport
+	// and records the response,
+	// replacing any previous recording.
+	Record
+
+	// ReplayOrRecord mode serves recorded responses when they exist,
+	// and sends the request to the underlying transport
+	// (recording the response)
+	// when they don't.
+	ReplayOrRecord
+)
+
+// ErrNotRecorded is the error produced by a [Recorder] in [Replay] mode
+// for a request that has no recorded response.
+var ErrNotRecorded = errors.New("no recorded response")
+
+// Recorder is an [http.RoundTripper] that records proxy responses to disk
+// and replays them later,
+// making tests that talk to a real proxy such as proxy.golang.org hermetic.
+// Use it as the Transport of the [http.Client] passed to goproxyclient.New.
+//
+// Responses are keyed by request path alone,
+// so recordings made against one proxy host
+// can be replayed for another.
+// Each response is stored as two files in Dir:
+// PATH.resp, holding the status code and headers as JSON,
+// and PATH.body, holding the body.
+//
+// Only GET and HEAD requests are supported.
+type Recorder struct {
+	// Dir is the directory holding the recordings.
+	Dir string
+
+	// Mode is the mode of operation.
+	Mode Mode
+
+	// Transport is the underlying transport used in [Record] and [ReplayOrRecord] modes.
+	// If nil, [http.DefaultTransport] is used.
+	Transport http.RoundTripper
+}
+
+var _ http.RoundTripper = &Recorder{}
+
+type recordedResponse struct {
+	StatusCode int
+	Header     http.Header
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return nil, fmt.Errorf("recorder: unsupported method %s", req.Method)
+	}
+
+	key := r.key(req)
+
+	if r.Mode != Record {
+		resp, err := r.replay(req, key)
+		if err == nil || !errors.Is(err, ErrNotRecorded) || r.Mode == Replay {
+			return resp, err
+		}
+	}
+
+	return r.record(req, key)
+}
+
+func (r *Recorder) key(req *http.Request) string {
+	p := path.Clean("/" + req.URL.Path)
+	if p == "/" {
+		p = "/_root"
+	}
+	if req.Method == http.MethodHead {
+		p += ".head"
+	}
+	return filepath.Join(r.Dir, filepath.FromSlash(p[1:]))
+}
+
+func (r *Recorder) replay(req *http.Request, key string) (*http.Response, error) {
+	j, err := os.ReadFile(key + ".resp")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Wrapf(ErrNotRecorded, "GET %s", req.URL.Path)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading recorded response")
+	}
+
+	var rec recordedResponse
+	if err := json.Unmarshal(j, &rec); err != nil {
+		return nil, errors.Wrapf(err, "decoding recorded response %s.resp", key)
+	}
+
+	body, err := os.ReadFile(key + ".body")
+	if err != nil {
+		return nil, errors.Wrap(err, "reading recorded body")
+	}
+
+	return newResponse(req, rec, body), nil
+}
+
+func (r *Recorder) record(req *http.Request, key string) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading response body")
+	}
+
+	rec := recordedResponse{StatusCode: resp.StatusCode, Header: resp.Header}
+	j, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding response")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(key), 0755); err != nil {
+		return nil, errors.Wrap(err, "creating recording directory")
+	}
+	// Write the body first,
+	// so that a .resp file is never present without its .body.
+	if err := os.WriteFile(key+".body", body, 0644); err != nil {
+		return nil, errors.Wrap(err, "writing recorded body")
+	}
+	if err := os.WriteFile(key+".resp", j, 0644); err != nil {
+		return nil, errors.Wrap(err, "writing recorded response")
+	}
+
+	return newResponse(req, rec, body), nil
+}
+
+func newResponse(req *http.Request, rec recordedResponse, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
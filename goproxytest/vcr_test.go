@@ -0,0 +1,56 @@
+package goproxytest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	var (
+		ctx = context.Background()
+		dir = t.TempDir()
+	)
+
+	s := NewServer(testFS)
+
+	rec := &Recorder{Dir: dir, Mode: Record}
+	hc := &http.Client{Transport: rec}
+
+	code, body, err := get(t, ctx, hc, s.URL+"/example.com/foo/@v/v1.0.0.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != http.StatusOK || body != "module example.com/foo\n" {
+		t.Fatalf("recording: got %d %q", code, body)
+	}
+	if _, _, err := get(t, ctx, hc, s.URL+"/example.com/foo/@v/v2.0.0.mod"); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Close()
+
+	rec.Mode = Replay
+
+	code, body, err = get(t, ctx, hc, s.URL+"/example.com/foo/@v/v1.0.0.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != http.StatusOK || body != "module example.com/foo\n" {
+		t.Errorf("replaying: got %d %q", code, body)
+	}
+
+	code, _, err = get(t, ctx, hc, s.URL+"/example.com/foo/@v/v2.0.0.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != http.StatusNotFound {
+		t.Errorf("replaying: got %d, want %d", code, http.StatusNotFound)
+	}
+
+	_, _, err = get(t, ctx, hc, s.URL+"/example.com/foo/@v/list")
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("got error %v, want %v", err, ErrNotRecorded)
+	}
+}
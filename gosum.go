@@ -0,0 +1,206 @@
+package goproxyclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bobg/errors"
+)
+
+// GoSum is the set of module hashes in a go.sum file,
+// against which a client can verify what it downloads
+// (see [WithGoSum]).
+// Create one with [ParseGoSum] or [ReadGoSum].
+//
+// Unlike a [VerifiedDB],
+// a GoSum is never added to.
+type GoSum struct {
+	m map[string][]string // "modpath version" -> hashes
+}
+
+// ParseGoSum parses the contents of a go.sum file:
+// one "MODPATH VERSION HASH" line per entry,
+// where VERSION has the suffix /go.mod for the hash of a go.mod file.
+func ParseGoSum(data []byte) (*GoSum, error) {
+	g := &GoSum{m: make(map[string][]string)}
+
+	var (
+		sc     = bufio.NewScanner(bytes.NewReader(data))
+		lineno int
+	)
+	for sc.Scan() {
+		lineno++
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: malformed line", lineno)
+		}
+		key := fields[0] + " " + fields[1]
+		g.m[key] = append(g.m[key], fields[2])
+	}
+	return g, errors.Wrap(sc.Err(), "reading go.sum")
+}
+
+// ReadGoSum reads and parses the go.sum file at path
+// (see [ParseGoSum]).
+func ReadGoSum(path string) (*GoSum, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", path)
+	}
+	g, err := ParseGoSum(data)
+	return g, errors.Wrapf(err, "parsing %s", path)
+}
+
+// Lookup returns the hashes for the given module path and version
+// (which should have the suffix /go.mod to look up the hash of a go.mod file),
+// or nil if there are none.
+func (g *GoSum) Lookup(mod, ver string) []string {
+	return g.m[mod+" "+ver]
+}
+
+// WithGoSum is an [Option] that makes the client verify the go.mod and zip files it fetches
+// (with [Client.Mod] and [Client.Zip],
+// and in operations built on them)
+// against the hashes in g,
+// as the go command does with a module's go.sum file.
+// A file that does not match fails with a [*HashMismatchError].
+// Module versions not in g are not verified
+// (except by any other source of hashes the client has).
+//
+// The hashes in g take precedence over those in the client's [VerifiedDB] and [ChecksumDB],
+// if it has them,
+// and are not recorded in the VerifiedDB.
+// If g has more than one hash for a file,
+// the first one that the client can check is used.
+//
+// Unless the client has other reasons to store a zip file before returning it
+// (see [Client.Zip]),
+// the zip file is verified as it is read,
+// so that it need not be stored first.
+// A mismatch is then reported by the read that reaches the end of the file,
+// and callers must not use the contents until they have read all of it without error.
+func WithGoSum(g *GoSum) Option {
+	return func(c *config) {
+		c.goSum = g
+	}
+}
+
+// GoSumHash returns the first hash in the client's [GoSum]
+// (if it has one)
+// for the given module path and version
+// that the client can check.
+// The boolean result is false if there is no such hash.
+func (cl Client) goSumHash(mod, ver string) (string, bool) {
+	if cl.conf.goSum == nil {
+		return "", false
+	}
+	for _, h := range cl.conf.goSum.Lookup(mod, ver) {
+		alg, _, _ := strings.Cut(h, ":")
+		if strings.HasSuffix(ver, "/go.mod") && alg != "h1" {
+			continue
+		}
+		if cl.conf.hasher(alg) != nil {
+			return h, true
+		}
+	}
+	return "", false
+}
+
+// StreamVerifiedZip is [Client.Zip] for a client whose [GoSum] has an h1: hash for the zip file,
+// and which has no other reason to store the zip file before returning it.
+// The zip file is verified as it is read.
+func (cl Client) streamVerifiedZip(ctx context.Context, mod, ver, want string) (io.ReadCloser, error) {
+	rc, err := cl.fetchZip(ctx, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+	v := &verifyingZip{
+		ReadCloser: rc,
+		ctx:        ctx,
+		cl:         cl,
+		mod:        mod,
+		ver:        ver,
+		want:       want,
+		w:          NewZipHashWriter(mod, ver),
+	}
+	if b, ok := rc.(sizedBody); ok {
+		b.ReadCloser = v
+		return b, nil
+	}
+	return v, nil
+}
+
+// verifyingZip is a zip file that checks its hash when it has been read to the end.
+type verifyingZip struct {
+	io.ReadCloser
+	ctx          context.Context
+	cl           Client
+	mod, ver     string
+	want         string
+	w            *ZipHashWriter
+	err          error // returned by all reads once set
+	closedWriter bool
+}
+
+func (v *verifyingZip) Read(p []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+
+	n, err := v.ReadCloser.Read(p)
+	if n > 0 {
+		if _, werr := v.w.Write(p[:n]); werr != nil {
+			// The zip file can't be parsed,
+			// so can't match its hash.
+			v.err = v.fail(werr)
+			return n, v.err
+		}
+	}
+	if err == io.EOF {
+		v.err = v.finish()
+		return n, v.err
+	}
+	return n, err
+}
+
+// Finish checks the hash of the zip file once it has been read,
+// returning io.EOF if it matches.
+func (v *verifyingZip) finish() error {
+	v.closedWriter = true
+	if err := v.w.Close(); err != nil {
+		return v.fail(err)
+	}
+	got, _ := v.w.Sums()
+	if got != v.want {
+		mismatch := &HashMismatchError{Module: v.mod, Version: v.ver, Want: v.want, Got: got}
+		v.cl.uncache(v.ctx, v.mod, v.ver, "zip")
+		traceFrom(v.ctx).verified(v.mod, v.ver, "zip", false, mismatch)
+		return mismatch
+	}
+	traceFrom(v.ctx).verified(v.mod, v.ver, "zip", true, nil)
+	return io.EOF
+}
+
+// Fail reports a zip file that could not be hashed.
+func (v *verifyingZip) fail(err error) error {
+	err = errors.Wrapf(err, "hashing zip for %s@%s", v.mod, v.ver)
+	v.cl.uncache(v.ctx, v.mod, v.ver, "zip")
+	traceFrom(v.ctx).verified(v.mod, v.ver, "zip", false, err)
+	return err
+}
+
+func (v *verifyingZip) Close() error {
+	if !v.closedWriter {
+		v.closedWriter = true
+		v.w.Close()
+	}
+	return v.ReadCloser.Close()
+}
@@ -0,0 +1,140 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+func TestGoSum(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	ctx := context.Background()
+
+	zipHash, err := dirhash.HashZip(filepath.Join("testdata", "example.com", "multi", "@v", "v1.0.0.zip"), dirhash.Hash1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modData, err := os.ReadFile(filepath.Join("testdata", "example.com", "multi", "@v", "v1.0.0.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modHash, err := hashModFile(modData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const bogus = "h1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+	parse := func(zipHash, modHash string) *GoSum {
+		t.Helper()
+		g, err := ParseGoSum([]byte("example.com/multi v1.0.0 " + zipHash + "\nexample.com/multi v1.0.0/go.mod " + modHash + "\n\nexample.com/other v1.2.3/go.mod " + bogus + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+
+	fetch := func(cl Client) (modErr, zipErr error) {
+		rc, err := cl.Mod(ctx, "example.com/multi", "v1.0.0")
+		if err == nil {
+			_, err = io.ReadAll(rc)
+			rc.Close()
+		}
+		modErr = err
+
+		rc, err = cl.Zip(ctx, "example.com/multi", "v1.0.0")
+		if err == nil {
+			_, err = io.ReadAll(rc)
+			rc.Close()
+		}
+		return modErr, err
+	}
+
+	t.Run("match", func(t *testing.T) {
+		cl := New(s.URL, nil, WithGoSum(parse(zipHash, modHash)))
+		modErr, zipErr := fetch(cl)
+		if modErr != nil {
+			t.Fatal(modErr)
+		}
+		if zipErr != nil {
+			t.Fatal(zipErr)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		cl := New(s.URL, nil, WithGoSum(parse(bogus, bogus)), WithCache(cacheDir, 0))
+
+		modErr, zipErr := fetch(cl)
+		var mismatch *HashMismatchError
+		if !errors.As(modErr, &mismatch) || mismatch.Version != "v1.0.0/go.mod" {
+			t.Errorf("got %v for go.mod, want hash mismatch", modErr)
+		}
+		if !errors.As(zipErr, &mismatch) || mismatch.Version != "v1.0.0" || mismatch.Got != zipHash {
+			t.Errorf("got %v for zip, want hash mismatch", zipErr)
+		}
+
+		// The tampered zip file is not left in the cache.
+		if _, err := os.Stat(filepath.Join(cacheDir, cacheName("example.com/multi", "v1.0.0", "zip"))); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got %v for cached zip file, want %v", err, os.ErrNotExist)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		// Module versions not in the go.sum file are not verified.
+		g, err := ParseGoSum([]byte("example.com/other v1.2.3 " + bogus + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		modErr, zipErr := fetch(New(s.URL, nil, WithGoSum(g)))
+		if modErr != nil {
+			t.Fatal(modErr)
+		}
+		if zipErr != nil {
+			t.Fatal(zipErr)
+		}
+	})
+
+	t.Run("malformed_zip", func(t *testing.T) {
+		// A proxy serving a zip file with a hostile Zip64 end of directory record.
+		data, err := fs.ReadFile(testdata, "testdata/example.com/multi/@v/v1.0.0.zip")
+		if err != nil {
+			t.Fatal(err)
+		}
+		bad := withZip64End(data, 0xffffffffffffff00)
+		h := testHandler(nil)
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if strings.HasSuffix(req.URL.Path, ".zip") {
+				w.Write(bad)
+				return
+			}
+			h.ServeHTTP(w, req)
+		}))
+		defer s.Close()
+
+		cacheDir := t.TempDir()
+		cl := New(s.URL, nil, WithGoSum(parse(zipHash, modHash)), WithCache(cacheDir, 0))
+		if _, zipErr := fetch(cl); zipErr == nil {
+			t.Error("got no error for zip")
+		}
+		if _, err := os.Stat(filepath.Join(cacheDir, cacheName("example.com/multi", "v1.0.0", "zip"))); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got %v for cached zip file, want %v", err, os.ErrNotExist)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		if _, err := ParseGoSum([]byte("example.com/multi v1.0.0\n")); err == nil {
+			t.Error("got no error")
+		}
+	})
+}
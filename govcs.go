@@ -0,0 +1,117 @@
+package goproxyclient
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// VCSPolicy says which version control systems may be used to fetch which modules directly from their origins
+// (i.e., in "direct" mode).
+// It implements the rules of the go command's GOVCS environment variable,
+// described at https://go.dev/ref/mod#vcs-govcs.
+// Create one with [ParseGOVCS].
+//
+// The zero VCSPolicy applies the go command's default policy
+// of allowing only git and hg for public modules,
+// and any VCS for private ones
+// (though with no GOPRIVATE patterns, no modules are private).
+type VCSPolicy struct {
+	rules     []vcsRule
+	goprivate string
+}
+
+type vcsRule struct {
+	pattern string
+	allowed []string // nil means all are allowed; empty (non-nil) means none
+}
+
+// knownVCS is the set of version control systems known to the go command.
+var knownVCS = []string{"bzr", "fossil", "git", "hg", "svn"}
+
+// ParseGOVCS parses a GOVCS value:
+// a comma-separated list of pattern:vcslist rules.
+// Each pattern is a module path prefix glob
+// (as in GOPRIVATE)
+// or one of the special words "public" or "private".
+// Each vcslist is a pipe-separated list of VCS names,
+// or "all" or "off".
+//
+// The goprivate argument is the value of GOPRIVATE,
+// which determines which modules the pattern "private" matches.
+func ParseGOVCS(govcs, goprivate string) (VCSPolicy, error) {
+	p := VCSPolicy{goprivate: goprivate}
+
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(govcs, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, list, ok := strings.Cut(item, ":")
+		if !ok {
+			return VCSPolicy{}, fmt.Errorf("malformed entry in GOVCS (missing colon): %q", item)
+		}
+		pattern, list = strings.TrimSpace(pattern), strings.TrimSpace(list)
+		if pattern == "" {
+			return VCSPolicy{}, fmt.Errorf("empty pattern in GOVCS: %q", item)
+		}
+		if list == "" {
+			return VCSPolicy{}, fmt.Errorf("empty VCS list in GOVCS: %q", item)
+		}
+		if seen[pattern] {
+			return VCSPolicy{}, fmt.Errorf("unreachable pattern in GOVCS: %q after earlier %q", item, pattern)
+		}
+		seen[pattern] = true
+
+		rule := vcsRule{pattern: pattern}
+		switch list {
+		case "all":
+			// rule.allowed stays nil
+
+		case "off":
+			rule.allowed = []string{}
+
+		default:
+			for _, vcs := range strings.Split(list, "|") {
+				if !slices.Contains(knownVCS, vcs) {
+					return VCSPolicy{}, fmt.Errorf("unknown VCS %q in GOVCS entry %q", vcs, item)
+				}
+				rule.allowed = append(rule.allowed, vcs)
+			}
+		}
+		p.rules = append(p.rules, rule)
+	}
+
+	return p, nil
+}
+
+// Allowed tells whether the given version control system
+// (e.g. "git")
+// may be used to fetch the module with the given path.
+func (p VCSPolicy) Allowed(modpath, vcs string) bool {
+	private := MatchPathPatterns(p.goprivate, modpath)
+
+	for _, rule := range p.rules {
+		if rule.matches(modpath, private) {
+			return rule.allowed == nil || slices.Contains(rule.allowed, vcs)
+		}
+	}
+
+	// The default rules.
+	if private {
+		return true
+	}
+	return vcs == "git" || vcs == "hg"
+}
+
+func (r vcsRule) matches(modpath string, private bool) bool {
+	switch r.pattern {
+	case "public":
+		return !private
+	case "private":
+		return private
+	default:
+		return MatchPathPatterns(r.pattern, modpath)
+	}
+}
@@ -0,0 +1,38 @@
+package goproxyclient
+
+import "testing"
+
+func TestVCSPolicy(t *testing.T) {
+	cases := []struct {
+		govcs, goprivate string
+		modpath, vcs     string
+		want             bool
+	}{
+		{"", "", "github.com/foo/bar", "git", true},
+		{"", "", "github.com/foo/bar", "svn", false},
+		{"", "corp.example.com", "corp.example.com/foo", "svn", true},
+		{"github.com:git,evil.com:off,*:git|hg", "", "github.com/foo/bar", "git", true},
+		{"github.com:git,evil.com:off,*:git|hg", "", "github.com/foo/bar", "hg", false},
+		{"github.com:git,evil.com:off,*:git|hg", "", "evil.com/foo", "git", false},
+		{"github.com:git,evil.com:off,*:git|hg", "", "example.com/foo", "hg", true},
+		{"private:all,public:git", "corp.example.com", "corp.example.com/foo", "fossil", true},
+		{"private:all,public:git", "corp.example.com", "github.com/foo", "hg", false},
+		{"public:off", "", "github.com/foo", "git", false},
+	}
+
+	for _, tc := range cases {
+		p, err := ParseGOVCS(tc.govcs, tc.goprivate)
+		if err != nil {
+			t.Fatalf("GOVCS=%q: %s", tc.govcs, err)
+		}
+		if got := p.Allowed(tc.modpath, tc.vcs); got != tc.want {
+			t.Errorf("GOVCS=%q GOPRIVATE=%q: Allowed(%s, %s) = %v, want %v", tc.govcs, tc.goprivate, tc.modpath, tc.vcs, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"github.com", ":git", "github.com:", "github.com:cvs", "a:git,a:hg"} {
+		if _, err := ParseGOVCS(bad, ""); err == nil {
+			t.Errorf("GOVCS=%q: got nil, want error", bad)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package goproxyclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// Hasher computes a hash of a module zip file.
+//
+// The client always uses [H1],
+// the go command's standard hash.
+// Others can be added with [WithHashers],
+// e.g. to support a future dirhash version
+// or an organization's own hash requirements.
+type Hasher interface {
+	// Name is the name of the hash algorithm,
+	// which is also the prefix (before the colon) of the hashes it produces.
+	Name() string
+
+	// HashZip hashes the module zip file at path.
+	// The result is in the form NAME:VALUE,
+	// as in go.sum files.
+	HashZip(path string) (string, error)
+}
+
+// DirHasher is a [Hasher] that uses a [dirhash.Hash] function,
+// which hashes the files in the zip file
+// (but not the zip file itself).
+type DirHasher struct {
+	Alg  string
+	Hash dirhash.Hash
+}
+
+// H1 is the go command's h1: hash of a module,
+// as in go.sum files and the checksum database.
+var H1 = DirHasher{Alg: "h1", Hash: dirhash.Hash1}
+
+func (h DirHasher) Name() string { return h.Alg }
+
+func (h DirHasher) HashZip(path string) (string, error) {
+	return dirhash.HashZip(path, h.Hash)
+}
+
+// ZipSHA256 is a [Hasher] that produces the hex-encoded SHA-256 hash
+// of the bytes of a zip file,
+// prefixed with "sha256:".
+var ZipSHA256 Hasher = zipSHA256{}
+
+type zipSHA256 struct{}
+
+func (zipSHA256) Name() string { return "sha256" }
+
+func (zipSHA256) HashZip(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "opening %s", path)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "reading %s", path)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WithHashers is an [Option] that adds hashers to the client's default of [H1].
+// A hasher with the same name as an earlier one replaces it.
+//
+// Hashes recorded in a [VerifiedDB] (see [WithVerifiedDB])
+// are checked with the hasher whose name matches their prefix,
+// and attestations (see [WithAttestations])
+// include a digest from each hasher.
+func WithHashers(hs ...Hasher) Option {
+	return func(c *config) {
+		c.hashers = append(c.hashers, hs...)
+	}
+}
+
+// Hasher returns the client's hasher with the given name,
+// or nil if there is none.
+func (c *config) hasher(name string) Hasher {
+	for _, h := range c.allHashers() {
+		if h.Name() == name {
+			return h
+		}
+	}
+	return nil
+}
+
+// AllHashers returns [H1] followed by the hashers added with [WithHashers],
+// with later ones replacing earlier ones of the same name.
+func (c *config) allHashers() []Hasher {
+	result := []Hasher{H1}
+	if c == nil {
+		return result
+	}
+outer:
+	for _, h := range c.hashers {
+		for i, prev := range result {
+			if prev.Name() == h.Name() {
+				result[i] = h
+				continue outer
+			}
+		}
+		result = append(result, h)
+	}
+	return result
+}
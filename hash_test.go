@@ -0,0 +1,86 @@
+package goproxyclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type constHasher string
+
+func (h constHasher) Name() string                   { return "const" }
+func (h constHasher) HashZip(string) (string, error) { return "const:" + string(h), nil }
+
+func TestHashers(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	db, err := OpenVerifiedDB(filepath.Join(t.TempDir(), "verified"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add("example.com/multi", "v1.0.0", "const:foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a hasher for the recorded hash, verification fails.
+	cl := New(s.URL, nil, WithVerifiedDB(db))
+	err = cl.Extract(ctx, "example.com/multi", "v1.0.0", t.TempDir())
+	if err == nil {
+		t.Fatal("got no error")
+	}
+
+	cl = New(s.URL, nil, WithVerifiedDB(db), WithHashers(constHasher("bar")))
+	err = cl.Extract(ctx, "example.com/multi", "v1.0.0", t.TempDir())
+	var mismatch *HashMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("got error %v, want HashMismatchError", err)
+	}
+	if mismatch.Got != "const:bar" {
+		t.Errorf("got hash %s, want const:bar", mismatch.Got)
+	}
+
+	// A later hasher replaces an earlier one of the same name.
+	adir := t.TempDir()
+	cl = New(s.URL, nil, WithVerifiedDB(db), WithAttestations(adir, nil), WithHashers(constHasher("bar"), constHasher("foo"), ZipSHA256))
+	if err := cl.Extract(ctx, "example.com/multi", "v1.0.0", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(adir, "example.com_multi@v1.0.0.intoto.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a Attestation
+	if err := json.Unmarshal(data, &a); err != nil {
+		t.Fatal(err)
+	}
+	d := a.Subject[0].Digest
+	if d["const"] != "const:foo" || len(d["sha256"]) != 64 || d["h1"] == "" {
+		t.Errorf("got digest %v", d)
+	}
+	if a.Predicate.Verification != "verified" {
+		t.Errorf("got verification %s", a.Predicate.Verification)
+	}
+}
+
+func TestZipSHA256(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.zip")
+	if err := os.WriteFile(path, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ZipSHA256.HashZip(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "sha256:5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
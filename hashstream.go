@@ -0,0 +1,581 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/bobg/errors"
+	modzip "golang.org/x/mod/zip"
+)
+
+// HashZipStream computes the h1: hash of a module zip file
+// and of the go.mod file in it
+// as the zip file is read from r,
+// without storing it first.
+// The hashes are in the form used in go.sum files:
+// they are the same as [H1] and the go command produce for the zip file,
+// and for the module's go.mod file if it has one.
+// If the zip file contains no go.mod file for module mod at version ver,
+// goModSum is empty.
+//
+// The zip file is read to the end,
+// so r may be an [io.TeeReader] copying it elsewhere:
+// to a file, for instance,
+// or to the response of a server that is relaying it.
+// See also [ZipHashWriter].
+//
+// The entries in a zip file are described twice:
+// once before the contents of each file,
+// and again in a directory at the end.
+// The go command uses the directory,
+// which cannot be read until the whole zip file has been,
+// so HashZipStream uses the descriptions before the files
+// and fails if they do not match the directory.
+// It also fails for zip files that the go command rejects anyway,
+// such as ones with duplicate file names or encrypted files,
+// and with an [*ExtractLimitError] for zip files whose contents
+// (uncompressed)
+// are larger than the go command's limit of 500 MiB.
+func HashZipStream(r io.Reader, mod, ver string) (sum, goModSum string, err error) {
+	return hashZipStream(r, mod, ver, modzip.MaxZipFile)
+}
+
+// HashZipStream is [HashZipStream]
+// with maxSize as the limit on the uncompressed size of the zip file's contents.
+func hashZipStream(r io.Reader, mod, ver string, maxSize int64) (sum, goModSum string, err error) {
+	zs := &zipStream{br: bufio.NewReader(r), crc: crc32.NewIEEE(), sha: sha256.New(), maxSize: maxSize}
+	goModName := mod + "@" + ver + "/go.mod"
+
+	var (
+		entries []zipStreamEntry
+		names   = make(map[string]bool)
+	)
+	for {
+		sig, err := zs.peekUint32()
+		if err != nil {
+			return "", "", errors.Wrap(err, "reading zip file")
+		}
+		if sig != zipLocalHeaderSig {
+			break
+		}
+		e, err := zs.readEntry()
+		if err != nil {
+			return "", "", errors.Wrapf(err, "reading entry %d of zip file", len(entries)+1)
+		}
+		if names[e.name] {
+			return "", "", fmt.Errorf("duplicate file %s in zip file", e.name)
+		}
+		names[e.name] = true
+		entries = append(entries, e)
+	}
+
+	if err := zs.checkDirectory(entries); err != nil {
+		return "", "", err
+	}
+
+	// Consume the rest of the input
+	// (the zip file's comment, if any).
+	if _, err := io.Copy(io.Discard, zs.br); err != nil {
+		return "", "", errors.Wrap(err, "reading zip file")
+	}
+
+	// This is the computation in [dirhash.Hash1].
+	slices.SortFunc(entries, func(a, b zipStreamEntry) int { return strings.Compare(a.name, b.name) })
+	h := sha256.New()
+	for _, e := range entries {
+		if strings.Contains(e.name, "\n") {
+			return "", "", fmt.Errorf("file name %q in zip file contains a newline", e.name)
+		}
+		fmt.Fprintf(h, "%x  %s\n", e.sha, e.name)
+		if e.name == goModName {
+			gh := sha256.New()
+			fmt.Fprintf(gh, "%x  go.mod\n", e.sha)
+			goModSum = "h1:" + base64.StdEncoding.EncodeToString(gh.Sum(nil))
+		}
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(h.Sum(nil)), goModSum, nil
+}
+
+// ZipHashWriter is an [io.WriteCloser] that computes the hashes of a module zip file written to it,
+// as [HashZipStream] does.
+// It can be combined with another writer using [io.MultiWriter],
+// e.g. to hash a zip file as [Client.ZipTo] writes it.
+//
+// Once the zip file has been parsed,
+// writes of the rest of it succeed without effect.
+// If it can't be parsed,
+// writes fail with the error.
+type ZipHashWriter struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+
+	// These are set when done is closed.
+	sum, goModSum string
+	err           error
+}
+
+// NewZipHashWriter creates a [ZipHashWriter] for the zip file of version ver of module mod.
+// The caller must close it.
+func NewZipHashWriter(mod, ver string) *ZipHashWriter {
+	pr, pw := io.Pipe()
+	w := &ZipHashWriter{pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		w.sum, w.goModSum, w.err = HashZipStream(pr, mod, ver)
+		pr.CloseWithError(w.err)
+	}()
+	return w
+}
+
+func (w *ZipHashWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+// Close signals the end of the zip file
+// and waits for its hashes to be computed,
+// returning any error from parsing it.
+func (w *ZipHashWriter) Close() error {
+	w.pw.Close()
+	<-w.done
+	return w.err
+}
+
+// Sums returns the hashes of the zip file and its go.mod file
+// (see [HashZipStream]).
+// It must be called after [ZipHashWriter.Close].
+func (w *ZipHashWriter) Sums() (sum, goModSum string) {
+	return w.sum, w.goModSum
+}
+
+const (
+	zipLocalHeaderSig     = 0x04034b50
+	zipDataDescriptorSig  = 0x08074b50
+	zipDirectoryHeaderSig = 0x02014b50
+	zip64EndSig           = 0x06064b50
+	zip64LocatorSig       = 0x07064b50
+	zipEndSig             = 0x06054b50
+
+	zipFlagEncrypted      = 0x1
+	zipFlagDataDescriptor = 0x8
+
+	zip64ExtraID = 0x0001
+
+	// zip64EndMaxSize is the largest Zip64 end of directory record accepted
+	// (not counting its signature and size),
+	// which is 44 bytes plus any "extensible data."
+	zip64EndMaxSize = 64 << 10
+)
+
+// zipStream reads a zip file sequentially
+// (see [HashZipStream]).
+type zipStream struct {
+	br  *bufio.Reader
+	off int64 // the offset in the zip file of the next byte in br
+
+	maxSize int64 // the limit on the uncompressed size of the contents
+	size    int64 // the uncompressed size of the contents so far
+
+	// These are reused for each entry.
+	crc hash.Hash32
+	sha hash.Hash
+}
+
+// zipStreamEntry is an entry read from the body of a zip file,
+// to be checked against the zip file's directory.
+type zipStreamEntry struct {
+	name         string
+	off          int64 // of the entry's local header
+	crc          uint32
+	csize, usize uint64
+	sha          []byte // hash of the file's contents
+}
+
+// Read and ReadByte make zipStream a [flate.Reader],
+// so that decompression does not read past the end of the compressed data.
+
+func (zs *zipStream) Read(p []byte) (int, error) {
+	n, err := zs.br.Read(p)
+	zs.off += int64(n)
+	return n, err
+}
+
+func (zs *zipStream) ReadByte() (byte, error) {
+	b, err := zs.br.ReadByte()
+	if err == nil {
+		zs.off++
+	}
+	return b, err
+}
+
+func (zs *zipStream) next(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(zs, buf); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return buf, nil
+}
+
+func (zs *zipStream) peekUint32() (uint32, error) {
+	buf, err := zs.br.Peek(4)
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	return binary.LittleEndian.Uint32(buf), nil
+}
+
+// ReadEntry reads a local file header and the file's contents,
+// and the data descriptor following them if there is one.
+func (zs *zipStream) readEntry() (zipStreamEntry, error) {
+	e := zipStreamEntry{off: zs.off}
+
+	hdr, err := zs.next(30)
+	if err != nil {
+		return e, errors.Wrap(err, "reading header")
+	}
+	var (
+		flags    = binary.LittleEndian.Uint16(hdr[6:])
+		method   = binary.LittleEndian.Uint16(hdr[8:])
+		nameLen  = int(binary.LittleEndian.Uint16(hdr[26:]))
+		extraLen = int(binary.LittleEndian.Uint16(hdr[28:]))
+	)
+	e.crc = binary.LittleEndian.Uint32(hdr[14:])
+	e.csize = uint64(binary.LittleEndian.Uint32(hdr[18:]))
+	e.usize = uint64(binary.LittleEndian.Uint32(hdr[22:]))
+
+	nameExtra, err := zs.next(nameLen + extraLen)
+	if err != nil {
+		return e, errors.Wrap(err, "reading header")
+	}
+	e.name = string(nameExtra[:nameLen])
+	zip64 := readZip64Extra(nameExtra[nameLen:], &e.usize, &e.csize, nil)
+
+	if flags&zipFlagEncrypted != 0 {
+		return e, fmt.Errorf("file %s is encrypted", e.name)
+	}
+	if method != zip.Store && method != zip.Deflate {
+		return e, fmt.Errorf("file %s has unsupported compression method %d", e.name, method)
+	}
+	if flags&zipFlagDataDescriptor == 0 && e.usize > uint64(zs.maxSize-zs.size) {
+		return e, &ExtractLimitError{File: e.name, Limit: "total size", Value: float64(zs.size) + float64(e.usize), Max: float64(zs.maxSize)}
+	}
+
+	zs.crc.Reset()
+	zs.sha.Reset()
+	dest := &zipStreamWriter{w: io.MultiWriter(zs.crc, zs.sha), zs: zs, name: e.name}
+
+	var (
+		start      = zs.off
+		usize      int64
+		descriptor = flags&zipFlagDataDescriptor != 0
+	)
+	switch {
+	case !descriptor && method == zip.Store:
+		usize, err = io.CopyN(dest, zs, int64(e.csize))
+		err = unexpectedEOF(err)
+
+	case !descriptor:
+		src := io.LimitReader(zs, int64(e.csize))
+		fr := flate.NewReader(src)
+		usize, err = io.Copy(dest, fr)
+		fr.Close()
+		if err == nil {
+			// Skip any padding after the compressed data.
+			_, err = io.Copy(io.Discard, src)
+		}
+
+	case method == zip.Store:
+		// The end of the file is not marked,
+		// so look for a data descriptor matching the data before it.
+		usize, err = zs.copyToDescriptor(dest, zip64)
+
+	default:
+		fr := flate.NewReader(zs)
+		usize, err = io.Copy(dest, fr)
+		fr.Close()
+	}
+	if err != nil {
+		return e, errors.Wrapf(err, "reading file %s", e.name)
+	}
+
+	csize := uint64(zs.off - start)
+	if descriptor {
+		if err := zs.readDescriptor(&e, zip64); err != nil {
+			return e, errors.Wrapf(err, "reading data descriptor for %s", e.name)
+		}
+	}
+	if csize != e.csize {
+		return e, fmt.Errorf("file %s has %d bytes of data, but the zip file says %d", e.name, csize, e.csize)
+	}
+	if uint64(usize) != e.usize {
+		return e, fmt.Errorf("file %s has size %d, but the zip file says %d", e.name, usize, e.usize)
+	}
+	if crc := zs.crc.Sum32(); crc != e.crc {
+		return e, fmt.Errorf("file %s has CRC-32 %08x, but the zip file says %08x", e.name, crc, e.crc)
+	}
+
+	e.sha = zs.sha.Sum(nil)
+	return e, nil
+}
+
+// zipStreamWriter receives the uncompressed contents of a file in a [zipStream],
+// failing once they exceed the stream's size limit.
+type zipStreamWriter struct {
+	w    io.Writer
+	zs   *zipStream
+	name string
+}
+
+func (w *zipStreamWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > w.zs.maxSize-w.zs.size {
+		return 0, &ExtractLimitError{File: w.name, Limit: "total size", Value: float64(w.zs.size) + float64(len(p)), Max: float64(w.zs.maxSize)}
+	}
+	w.zs.size += int64(len(p))
+	return w.w.Write(p)
+}
+
+// ReadDescriptor reads the data descriptor following the contents of e,
+// which replaces the sizes and CRC-32 in its local header.
+func (zs *zipStream) readDescriptor(e *zipStreamEntry, zip64 bool) error {
+	// The signature is optional.
+	if sig, err := zs.peekUint32(); err != nil {
+		return err
+	} else if sig == zipDataDescriptorSig {
+		if _, err := zs.next(4); err != nil {
+			return err
+		}
+	}
+
+	sizeLen := 4
+	if zip64 {
+		sizeLen = 8
+	}
+	buf, err := zs.next(4 + 2*sizeLen)
+	if err != nil {
+		return err
+	}
+	e.crc = binary.LittleEndian.Uint32(buf)
+	if zip64 {
+		e.csize = binary.LittleEndian.Uint64(buf[4:])
+		e.usize = binary.LittleEndian.Uint64(buf[12:])
+	} else {
+		e.csize = uint64(binary.LittleEndian.Uint32(buf[4:]))
+		e.usize = uint64(binary.LittleEndian.Uint32(buf[8:]))
+	}
+	return nil
+}
+
+// CopyToDescriptor copies the contents of an uncompressed file
+// whose size is given only in the data descriptor following it
+// to dest,
+// stopping at the first data descriptor that matches the data before it
+// and is followed by the next entry or the directory.
+// The descriptor is left to be read.
+//
+// Contents that happen to contain such a descriptor
+// (or are crafted to)
+// are misread,
+// but then the entries read do not match the directory,
+// so the result is an error rather than a wrong hash.
+func (zs *zipStream) copyToDescriptor(dest io.Writer, zip64 bool) (int64, error) {
+	sig := binary.LittleEndian.AppendUint32(nil, zipDataDescriptorSig)
+	descLen := 12
+	if zip64 {
+		descLen = 20
+	}
+	need := len(sig) + descLen + 4 // the descriptor and the next signature
+
+	var n int64
+	for {
+		window, err := zs.br.Peek(zs.br.Size())
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+
+		i := bytes.Index(window, sig)
+		switch {
+		case i < 0 && err == io.EOF:
+			return n, io.ErrUnexpectedEOF
+		case i < 0:
+			// Keep enough to recognize a signature split across windows.
+			i = len(window) - len(sig) + 1
+		case len(window) < i+need && err == io.EOF:
+			return n, io.ErrUnexpectedEOF
+		case len(window) < i+need:
+			// Read the descriptor into the window before checking it.
+		case zs.descriptorMatches(window[i+len(sig):i+need], window[:i], n+int64(i), zip64):
+			m, err := io.CopyN(dest, zs, int64(i))
+			return n + m, err
+		default:
+			// The signature is part of the data.
+			i++
+		}
+
+		m, err := io.CopyN(dest, zs, int64(i))
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// DescriptorMatches tells whether desc is a data descriptor
+// (without its signature)
+// for the contents hashed in zs.crc followed by pending,
+// n bytes in all,
+// followed by the signature of a local header or directory header.
+func (zs *zipStream) descriptorMatches(desc, pending []byte, n int64, zip64 bool) bool {
+	switch binary.LittleEndian.Uint32(desc[len(desc)-4:]) {
+	case zipLocalHeaderSig, zipDirectoryHeaderSig:
+	default:
+		return false
+	}
+
+	var csize, usize uint64
+	if zip64 {
+		csize, usize = binary.LittleEndian.Uint64(desc[4:]), binary.LittleEndian.Uint64(desc[12:])
+	} else {
+		csize, usize = uint64(binary.LittleEndian.Uint32(desc[4:])), uint64(binary.LittleEndian.Uint32(desc[8:]))
+	}
+	if csize != uint64(n) || usize != uint64(n) {
+		return false
+	}
+
+	crc := crc32.Update(zs.crc.Sum32(), crc32.IEEETable, pending)
+	return crc == binary.LittleEndian.Uint32(desc)
+}
+
+// CheckDirectory reads the directory at the end of a zip file
+// and checks that it describes the entries read before it.
+func (zs *zipStream) checkDirectory(entries []zipStreamEntry) error {
+	for i := 0; ; i++ {
+		sig, err := zs.peekUint32()
+		if err != nil {
+			return errors.Wrap(err, "reading zip directory")
+		}
+		if sig != zipDirectoryHeaderSig {
+			if i != len(entries) {
+				return fmt.Errorf("zip directory has %d entries, but zip file has %d", i, len(entries))
+			}
+			break
+		}
+		if i >= len(entries) {
+			return fmt.Errorf("zip directory has more than %d entries", len(entries))
+		}
+
+		hdr, err := zs.next(46)
+		if err != nil {
+			return errors.Wrap(err, "reading zip directory")
+		}
+		var (
+			crc        = binary.LittleEndian.Uint32(hdr[16:])
+			csize      = uint64(binary.LittleEndian.Uint32(hdr[20:]))
+			usize      = uint64(binary.LittleEndian.Uint32(hdr[24:]))
+			nameLen    = int(binary.LittleEndian.Uint16(hdr[28:]))
+			extraLen   = int(binary.LittleEndian.Uint16(hdr[30:]))
+			commentLen = int(binary.LittleEndian.Uint16(hdr[32:]))
+			off        = uint64(binary.LittleEndian.Uint32(hdr[42:]))
+		)
+		rest, err := zs.next(nameLen + extraLen + commentLen)
+		if err != nil {
+			return errors.Wrap(err, "reading zip directory")
+		}
+		name := string(rest[:nameLen])
+		readZip64Extra(rest[nameLen:nameLen+extraLen], &usize, &csize, &off)
+
+		e := entries[i]
+		if name != e.name || crc != e.crc || csize != e.csize || usize != e.usize || int64(off) != e.off {
+			return fmt.Errorf("zip directory entry %d (%s) does not match file %s", i+1, name, e.name)
+		}
+	}
+
+	// Skip the Zip64 end of directory record and locator, if present.
+	for _, want := range []uint32{zip64EndSig, zip64LocatorSig} {
+		sig, err := zs.peekUint32()
+		if err != nil {
+			return errors.Wrap(err, "reading end of zip directory")
+		}
+		if sig != want {
+			continue
+		}
+		var n uint64
+		switch sig {
+		case zip64EndSig:
+			buf, err := zs.next(12)
+			if err != nil {
+				return errors.Wrap(err, "reading end of zip directory")
+			}
+			n = binary.LittleEndian.Uint64(buf[4:])
+			if n < 44 || n > zip64EndMaxSize {
+				return fmt.Errorf("zip64 end of directory record has invalid size %d", n)
+			}
+		case zip64LocatorSig:
+			n = 20
+		}
+		if _, err := io.CopyN(io.Discard, zs, int64(n)); err != nil {
+			return errors.Wrap(unexpectedEOF(err), "reading end of zip directory")
+		}
+	}
+
+	hdr, err := zs.next(22)
+	if err != nil {
+		return errors.Wrap(err, "reading end of zip directory")
+	}
+	if sig := binary.LittleEndian.Uint32(hdr); sig != zipEndSig {
+		return fmt.Errorf("unexpected record %08x at end of zip directory", sig)
+	}
+	if n := binary.LittleEndian.Uint16(hdr[10:]); n != 0xffff && int(n) != len(entries) {
+		return fmt.Errorf("end of zip directory says %d entries, but zip file has %d", n, len(entries))
+	}
+	return nil
+}
+
+// ReadZip64Extra looks for a Zip64 field in extra,
+// the extra fields of a local or directory header.
+// If there is one,
+// it replaces those of the header's 32-bit values
+// usize, csize, and (for a directory header) off
+// that are 0xffffffff,
+// in that order,
+// and readZip64Extra returns true.
+func readZip64Extra(extra []byte, usize, csize, off *uint64) bool {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			return false
+		}
+		field := extra[:size]
+		extra = extra[size:]
+		if id != zip64ExtraID {
+			continue
+		}
+		for _, v := range []*uint64{usize, csize, off} {
+			if v == nil || *v != 0xffffffff || len(field) < 8 {
+				continue
+			}
+			*v = binary.LittleEndian.Uint64(field)
+			field = field[8:]
+		}
+		return true
+	}
+	return false
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,356 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+func TestHashZipStream(t *testing.T) {
+	err := fs.WalkDir(testdata, "testdata", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !strings.HasSuffix(path, ".zip") {
+			return err
+		}
+		escMod, file, ok := strings.Cut(strings.TrimPrefix(path, "testdata/"), "/@v/")
+		if !ok {
+			return nil
+		}
+		mod, err := module.UnescapePath(escMod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ver := strings.TrimSuffix(file, ".zip")
+
+		t.Run(mod+"@"+ver, func(t *testing.T) {
+			data, err := fs.ReadFile(testdata, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkHashZipStream(t, data, mod, ver)
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := []struct {
+		name, content string
+		method        uint16
+	}{
+		{name: "go.mod", content: "module example.com/m\n", method: zip.Deflate},
+		{name: "a.go", content: "package m\n", method: zip.Store},
+		{name: "empty", method: zip.Store},
+		{name: "dir/", method: zip.Store},
+		{name: "b.go", content: "package m\n\n// " + strings.Repeat("x", 10000), method: zip.Deflate},
+	}
+
+	// Stored files with data descriptors,
+	// including one whose contents look like a descriptor.
+	var fake []byte
+	fake = binary.LittleEndian.AppendUint32(fake, zipDataDescriptorSig)
+	fake = binary.LittleEndian.AppendUint32(fake, crc32.ChecksumIEEE([]byte("abc")))
+	fake = binary.LittleEndian.AppendUint32(fake, 3)
+	fake = binary.LittleEndian.AppendUint32(fake, 3)
+	fakeContent := "abc" + string(fake) + strings.Repeat("y", 5000) + string(fake)
+
+	t.Run("writer", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		zw := zip.NewWriter(buf)
+		for _, f := range files {
+			w, err := zw.CreateHeader(&zip.FileHeader{Name: "example.com/m@v1.0.0/" + f.name, Method: f.method})
+			if err != nil {
+				t.Fatal(err)
+			}
+			io.WriteString(w, f.content)
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: "example.com/m@v1.0.0/fake", Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, fakeContent)
+		zw.SetComment("comment")
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		checkHashZipStream(t, buf.Bytes(), "example.com/m", "v1.0.0")
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		// Sizes in the local headers,
+		// and no data descriptors.
+		buf := new(bytes.Buffer)
+		zw := zip.NewWriter(buf)
+		for _, f := range files {
+			w, err := zw.CreateRaw(&zip.FileHeader{
+				Name:               "example.com/m@v1.0.0/" + f.name,
+				Method:             zip.Store,
+				CRC32:              crc32.ChecksumIEEE([]byte(f.content)),
+				CompressedSize64:   uint64(len(f.content)),
+				UncompressedSize64: uint64(len(f.content)),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			io.WriteString(w, f.content)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		checkHashZipStream(t, buf.Bytes(), "example.com/m", "v1.0.0")
+	})
+
+	t.Run("bad", func(t *testing.T) {
+		data, err := fs.ReadFile(testdata, "testdata/example.com/multi/@v/v1.0.0.zip")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// A name in the directory that differs from the one before the file.
+		renamed := bytes.Clone(data)
+		i := bytes.LastIndex(renamed, []byte("go.mod"))
+		copy(renamed[i:], "go.mud")
+
+		// Garbled contents.
+		garbled := bytes.Clone(data)
+		garbled[100] ^= 0xff
+
+		// Duplicate files.
+		buf := new(bytes.Buffer)
+		zw := zip.NewWriter(buf)
+		for range 2 {
+			w, err := zw.Create("example.com/m@v1.0.0/go.mod")
+			if err != nil {
+				t.Fatal(err)
+			}
+			io.WriteString(w, "module example.com/m\n")
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		cases := map[string][]byte{
+			"renamed":   renamed,
+			"garbled":   garbled,
+			"truncated": data[:len(data)-10],
+			"duplicate": buf.Bytes(),
+			"empty":     nil,
+		}
+		for name, data := range cases {
+			if _, _, err := HashZipStream(bytes.NewReader(data), "example.com/multi", "v1.0.0"); err == nil {
+				t.Errorf("%s: got no error", name)
+			}
+		}
+	})
+}
+
+// CheckHashZipStream checks the result of HashZipStream on the zip file in data
+// against those of dirhash.HashZip and hashModFile.
+func checkHashZipStream(t *testing.T, data []byte, mod, ver string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "x.zip")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	wantSum, err := dirhash.HashZip(path, dirhash.Hash1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wantGoModSum string
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, err := zr.Open(mod + "@" + ver + "/go.mod"); err == nil {
+		goMod, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wantGoModSum, err = hashModFile(goMod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Read a byte at a time to exercise the handling of short reads.
+	var r io.Reader = bytes.NewReader(data)
+	if len(data) < 100000 {
+		r = &oneByteReader{r: r}
+	}
+	sum, goModSum, err := HashZipStream(r, mod, ver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != wantSum {
+		t.Errorf("got sum %s, want %s", sum, wantSum)
+	}
+	if goModSum != wantGoModSum {
+		t.Errorf("got go.mod sum %q, want %q", goModSum, wantGoModSum)
+	}
+}
+
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (r *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return r.r.Read(p[:1])
+}
+
+// WithZip64End inserts a Zip64 end of directory record with the given size field,
+// followed by that many zero bytes (up to 1 KiB),
+// before the end of directory record of the zip file in data,
+// which must have no comment.
+func withZip64End(data []byte, size uint64) []byte {
+	var rec []byte
+	rec = binary.LittleEndian.AppendUint32(rec, zip64EndSig)
+	rec = binary.LittleEndian.AppendUint64(rec, size)
+	rec = append(rec, make([]byte, min(size, 1024))...)
+
+	i := len(data) - 22
+	return append(append(bytes.Clone(data[:i]), rec...), data[i:]...)
+}
+
+func TestHashZipStreamZip64End(t *testing.T) {
+	data, err := fs.ReadFile(testdata, "testdata/example.com/multi/@v/v1.0.0.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := HashZipStream(bytes.NewReader(withZip64End(data, 44)), "example.com/multi", "v1.0.0"); err != nil {
+		t.Errorf("valid record: %v", err)
+	}
+	for _, size := range []uint64{0xffffffffffffff00, 1 << 40, 1 << 20, 10} {
+		if _, _, err := HashZipStream(bytes.NewReader(withZip64End(data, size)), "example.com/multi", "v1.0.0"); err == nil {
+			t.Errorf("size %d: got no error", size)
+		}
+	}
+
+	// The same through a ZipHashWriter,
+	// whose parsing goroutine must not panic.
+	w := NewZipHashWriter("example.com/multi", "v1.0.0")
+	w.Write(withZip64End(data, 0xffffffffffffff00))
+	if err := w.Close(); err == nil {
+		t.Error("got no error from ZipHashWriter")
+	}
+}
+
+func TestHashZipStreamSizeLimit(t *testing.T) {
+	const limit = 64 << 10
+
+	zeros := make([]byte, 40<<10)
+	makeZip := func(t *testing.T, sizes ...int) []byte {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		zw := zip.NewWriter(buf)
+		for i, size := range sizes {
+			w, err := zw.Create(fmt.Sprintf("example.com/m@v1.0.0/f%d", i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for size > 0 {
+				n, _ := w.Write(zeros[:min(size, len(zeros))])
+				size -= n
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	cases := []struct {
+		name    string
+		sizes   []int
+		wantErr bool
+	}{
+		{"small", []int{1000, 1000}, false},
+		{"bomb", []int{10 << 20}, true},
+		{"total", []int{40 << 10, 40 << 10}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, _, err := hashZipStream(bytes.NewReader(makeZip(t, c.sizes...)), "example.com/m", "v1.0.0", limit)
+			var limitErr *ExtractLimitError
+			if got := errors.As(err, &limitErr); got != c.wantErr {
+				t.Errorf("got error %v, want size-limit error: %v", err, c.wantErr)
+			}
+		})
+	}
+
+	// A declared size over the limit fails before the contents are read.
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "example.com/m@v1.0.0/f",
+		Method:             zip.Deflate,
+		CompressedSize64:   10,
+		UncompressedSize64: 1 << 30,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(make([]byte, 10))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = hashZipStream(bytes.NewReader(buf.Bytes()), "example.com/m", "v1.0.0", limit)
+	var limitErr *ExtractLimitError
+	if !errors.As(err, &limitErr) {
+		t.Errorf("got error %v for declared size, want size-limit error", err)
+	}
+}
+
+func TestZipHashWriter(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+
+	buf := new(bytes.Buffer)
+	w := NewZipHashWriter("example.com/multi", "v1.0.0")
+	if _, err := cl.ZipTo(ctx, "example.com/multi", "v1.0.0", io.MultiWriter(buf, w)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sum, goModSum := w.Sums()
+
+	wantSum, wantGoModSum, err := HashZipStream(bytes.NewReader(buf.Bytes()), "example.com/multi", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != wantSum || goModSum != wantGoModSum {
+		t.Errorf("got %s, %s, want %s, %s", sum, goModSum, wantSum, wantGoModSum)
+	}
+
+	// A writer for something other than a zip file fails.
+	w = NewZipHashWriter("example.com/multi", "v1.0.0")
+	if _, err := io.WriteString(w, strings.Repeat("not a zip file\n", 1000)); err == nil {
+		t.Error("got no error from write")
+	}
+	if err := w.Close(); err == nil {
+		t.Error("got no error from close")
+	}
+}
@@ -0,0 +1,150 @@
+package goproxyclient
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+)
+
+// headSupport records whether a proxy supports HEAD requests,
+// which the GOPROXY protocol does not require.
+// It is shared by the copies of a [single].
+type headSupport struct {
+	state atomic.Int32 // headUnknown, headYes, or headNo
+}
+
+const (
+	headUnknown int32 = iota
+	headYes
+	headNo
+)
+
+// Probe sends a HEAD request for q if the proxy is not known not to support them,
+// for checking that a file exists or getting its size
+// without downloading it.
+// If the proxy rejects the HEAD request as unsupported,
+// or fails it before ever having answered one,
+// or (when needSize is true) does not report the file's size,
+// probe falls back to a GET request.
+// Either way,
+// the response body is closed.
+func (s single) probe(ctx context.Context, q string, needSize bool) (*http.Response, error) {
+	state := s.head.state.Load()
+	if state != headNo {
+		resp, err := s.do(ctx, http.MethodHead, q)
+		switch {
+		case err == nil:
+			s.head.state.Store(headYes)
+			resp.Body.Close()
+			if !needSize || resp.ContentLength >= 0 {
+				return resp, nil
+			}
+
+		case headUnsupported(err):
+			s.head.state.Store(headNo)
+			s.log(ctx, "HEAD not supported, using GET", slog.String("url", q), slog.String("request_id", RequestID(ctx)), slog.Any("error", err))
+
+		case state == headYes || ctx.Err() != nil:
+			// The proxy handles HEAD requests,
+			// so trust its answer.
+			return nil, err
+		}
+	}
+
+	resp, err := s.get(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if state == headUnknown && s.head.state.Load() == headUnknown {
+		// The HEAD request failed but the GET succeeded.
+		s.head.state.Store(headNo)
+	}
+	return resp, nil
+}
+
+// HeadUnsupported tells whether err is from a proxy that does not support HEAD requests.
+func headUnsupported(err error) bool {
+	var codeErr CodeErr
+	if !errors.As(err, &codeErr) {
+		return false
+	}
+	switch codeErr.Code() {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// Exists tells whether a specific version of a Go module exists,
+// checking with HEAD requests where the proxy supports them
+// so as not to download anything.
+// A module version exists if some proxy in the client's list
+// (or the client's cache; see [WithCache])
+// has an info file for it.
+// The version need not be canonical.
+//
+// The result is false with a nil error
+// if the last proxy tried responds with 404 (Not Found) or 410 (Gone).
+// Other failures produce errors.
+func (cl Client) Exists(ctx context.Context, mod, ver string) (bool, error) {
+	var (
+		exists bool
+		err    error
+	)
+
+	if err := cl.checkModulePolicy(mod, ver, "info"); err != nil {
+		return false, err
+	}
+
+	cacheable := immutable(ver)
+
+	mod, err = module.EscapePath(mod)
+	if err != nil {
+		return false, errors.Wrap(err, "escaping module path")
+	}
+	ver, err = module.EscapeVersion(ver)
+	if err != nil {
+		return false, errors.Wrap(err, "escaping module version")
+	}
+
+	if cacheable {
+		if _, ok := cl.conf.cache.size(ctx, cl.cacheKey(ctx, cacheName(mod, ver, "info"))); ok {
+			return true, nil
+		}
+	}
+
+	ctx = ensureRequestID(ctx)
+	exists, err = loop(ctx, cl, func(ctx context.Context, s single) (bool, error) {
+		return s.exists(ctx, mod, ver)
+	})
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return exists, err
+}
+
+// Note, modpath and version are already escaped.
+func (s single) exists(ctx context.Context, modpath, version string) (bool, error) {
+	if s.off {
+		return false, ErrProxyOff
+	}
+	if s.direct != nil {
+		if _, _, _, err := s.direct.info(ctx, modpath, version); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	q := fmt.Sprintf("%s/%s/@v/%s.info", s.baseURL, modpath, version)
+
+	if _, err := s.probe(ctx, q, false); err != nil {
+		return false, err
+	}
+	return true, nil
+}
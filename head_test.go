@@ -0,0 +1,149 @@
+package goproxyclient
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHead(t *testing.T) {
+	ctx := context.Background()
+
+	zip, err := fs.ReadFile(testdata, "testdata/example.com/multi/@v/v1.0.0.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := testHandler(nil)
+
+	// Each case's server records the method of each request it gets
+	// and may handle HEAD requests differently from GET requests.
+	cases := []struct {
+		name string
+		head func(http.ResponseWriter, *http.Request) // nil means handle normally
+
+		// Each step is a call to ZipSize or Exists,
+		// and the methods it must use.
+		steps []headStep
+	}{{
+		name: "supported",
+		steps: []headStep{
+			{op: "size", want: []string{"HEAD"}},
+			{op: "exists", want: []string{"HEAD"}},
+			{op: "missing", want: []string{"HEAD"}},
+		},
+	}, {
+		name: "not_allowed",
+		head: func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		},
+		steps: []headStep{
+			{op: "size", want: []string{"HEAD", "GET"}},
+			{op: "size", want: []string{"GET"}},
+			{op: "exists", want: []string{"GET"}},
+		},
+	}, {
+		name: "not_found",
+		head: http.NotFound,
+		steps: []headStep{
+			// Before the proxy has answered a HEAD request,
+			// a failure is checked with a GET request.
+			{op: "missing", want: []string{"HEAD", "GET"}},
+			{op: "exists", want: []string{"HEAD", "GET"}},
+			{op: "exists", want: []string{"GET"}},
+		},
+	}, {
+		name: "no_length",
+		head: func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+		steps: []headStep{
+			{op: "size", want: []string{"HEAD", "GET"}},
+			{op: "exists", want: []string{"HEAD"}},
+		},
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				methods []string
+			)
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mu.Lock()
+				methods = append(methods, req.Method)
+				mu.Unlock()
+
+				if req.Method == http.MethodHead && tc.head != nil {
+					tc.head(w, req)
+					return
+				}
+				handler.ServeHTTP(w, req)
+			}))
+			defer s.Close()
+
+			cl := New(s.URL, nil)
+
+			for i, step := range tc.steps {
+				methods = nil
+
+				switch step.op {
+				case "size":
+					size, err := cl.ZipSize(ctx, "example.com/multi", "v1.0.0")
+					if err != nil {
+						t.Fatal(err)
+					}
+					if size != int64(len(zip)) {
+						t.Errorf("step %d: got size %d, want %d", i, size, len(zip))
+					}
+
+				case "exists", "missing":
+					mod := "example.com/multi"
+					if step.op == "missing" {
+						mod = "example.com/nonexistent"
+					}
+					exists, err := cl.Exists(ctx, mod, "v1.0.0")
+					if err != nil {
+						t.Fatal(err)
+					}
+					if exists != (step.op == "exists") {
+						t.Errorf("step %d: got exists %v for %s", i, exists, mod)
+					}
+				}
+
+				if diff := cmp.Diff(step.want, slices.Clone(methods)); diff != "" {
+					t.Errorf("step %d (%s) methods mismatch (-want +got):\n%s", i, step.op, diff)
+				}
+			}
+		})
+	}
+}
+
+type headStep struct {
+	op   string // "size", "exists", or "missing"
+	want []string
+}
+
+func TestExistsFallback(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	good := httptest.NewServer(testHandler(nil))
+	defer good.Close()
+
+	cl := New(strings.Join([]string{notFound.URL, good.URL}, ","), nil)
+	exists, err := cl.Exists(context.Background(), "example.com/multi", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Error("got exists false, want true")
+	}
+}
@@ -0,0 +1,158 @@
+package goproxyclient
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bobg/errors"
+)
+
+// WithHedge is an [Option] that makes the client hedge its requests:
+// when a proxy has not answered a request within delay,
+// the client sends the same request to the next proxy in its list as well,
+// without canceling the first,
+// and uses whichever successful response arrives first
+// (canceling the other request).
+// The next proxy is hedged in the same way, and so on.
+// This reduces the latency of operations that happen to reach a slow proxy
+// (or a slow instance of one)
+// while adding requests only for those operations,
+// not for every one as [WithRace] does.
+// A delay around the usual response time of the client's first proxy
+// (for example, 200ms)
+// is a good choice.
+//
+// A proxy that fails falls back to the next one according to the rules described at [Parse],
+// as without hedging.
+// But once a request has been hedged,
+// a successful response from the next proxy is used
+// even if the first proxy fails in a way that does not allow falling back.
+// An operation fails only when all of the proxies it reached have failed,
+// with a [FallbackError] if there was more than one.
+//
+// A delay of zero turns hedging off.
+// Hedging has no effect in race mode.
+func WithHedge(delay time.Duration) Option {
+	return func(c *config) {
+		c.hedge = delay
+	}
+}
+
+// Hedge is the hedged version of [loop]
+// (see [WithHedge]).
+// It calls f on the first proxy in ch,
+// and on each following one
+// (up to and including the first "off" entry)
+// when the one before it either fails in a way that allows falling back
+// or fails to answer within delay.
+// Each call gets its own cancelable copy of ctx.
+// When one succeeds,
+// it cancels the others
+// and waits for them to finish,
+// closing any results they produce anyway.
+func hedge[T any](ctx context.Context, ch *chain, delay time.Duration, f func(context.Context, single) (T, error)) (T, error) {
+	var (
+		entries = ch.reachable()
+		t       = traceFrom(ctx)
+		timer   = time.NewTimer(delay)
+
+		results = make(chan raceResult[T], len(entries))
+		cancels = make([]context.CancelFunc, 0, len(entries)) // one for each proxy started
+		pending int                                           // number of proxies started and not yet done
+		stopped bool                                          // no more proxies may be started
+	)
+	defer timer.Stop()
+
+	start := func() {
+		i := len(cancels)
+		s := entries[i]
+		sctx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		pending++
+
+		t.proxyStart(s.baseURL)
+		go func() {
+			val, err := call(sctx, s, f)
+			results <- raceResult[T]{i: i, val: val, err: err}
+		}()
+		timer.Reset(delay)
+	}
+
+	start()
+
+	var (
+		winner = -1
+		val    T
+		errs   = make([]error, len(entries))
+	)
+	for pending > 0 {
+		var timeout <-chan time.Time
+		if !stopped && len(cancels) < len(entries) {
+			timeout = timer.C
+		}
+
+		select {
+		case <-timeout:
+			s := entries[len(cancels)-1]
+			s.log(ctx, "hedging slow request", slog.String("proxy", s.baseURL), slog.String("next", entries[len(cancels)].baseURL), slog.String("request_id", RequestID(ctx)), slog.Duration("delay", delay))
+			start()
+
+		case r := <-results:
+			pending--
+			s := entries[r.i]
+			switch {
+			case winner >= 0:
+				// Too late.
+				err := r.err
+				if err == nil {
+					closeResult(r.val)
+					err = context.Canceled
+				}
+				t.proxyDone(s.baseURL, err, "")
+
+			case r.err == nil:
+				winner, val, stopped = r.i, r.val, true
+				for i, cancel := range cancels {
+					if i != winner {
+						cancel()
+					}
+				}
+				t.proxyDone(s.baseURL, nil, "")
+
+			default:
+				cancels[r.i]()
+				errs[r.i] = r.err
+
+				// Only a failure of the last proxy started can cause a fallback,
+				// since any after it have already been started by hedging.
+				if r.i < len(cancels)-1 {
+					t.proxyDone(s.baseURL, r.err, "")
+					break
+				}
+				if stopped || r.i+1 >= len(entries) || !(ch.rest[r.i].afterAnyErr || IsNotFound(r.err)) {
+					stopped = true
+					t.proxyDone(s.baseURL, r.err, "")
+					break
+				}
+				t.proxyDone(s.baseURL, r.err, entries[r.i+1].baseURL)
+				start()
+			}
+		}
+	}
+
+	if winner >= 0 {
+		provenanceFrom(ctx).set(entries[winner].baseURL)
+		return releaseWith(val, cancels[winner]), nil
+	}
+
+	var zero T
+	errs = errs[:len(cancels)]
+	if len(errs) == 1 {
+		return zero, errs[0]
+	}
+	for i, err := range errs {
+		errs[i] = errors.Wrapf(err, "proxy %s", entries[i].baseURL)
+	}
+	return zero, FallbackError{Errs: errs}
+}
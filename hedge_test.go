@@ -0,0 +1,129 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	ctx := context.Background()
+
+	// The slow server answers only when its request is canceled.
+	var canceled atomic.Int64
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+			canceled.Add(1)
+		case <-time.After(10 * time.Second):
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer slow.Close()
+
+	var fastRequests atomic.Int64
+	h := testHandler(nil)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fastRequests.Add(1)
+		h.ServeHTTP(w, req)
+	}))
+	defer fast.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	const delay = 50 * time.Millisecond
+
+	t.Run("slow_is_hedged", func(t *testing.T) {
+		canceled.Store(0)
+
+		// Without hedging, the slow proxy's error would prevent fallback.
+		cl := New(slow.URL+","+fast.URL, nil, WithHedge(delay))
+
+		var prov Provenance
+		start := time.Now()
+		versions, err := cl.List(WithProvenance(ctx, &prov), "example.com/multi")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed < delay || elapsed > 5*time.Second {
+			t.Errorf("took %s, want a little more than %s", elapsed, delay)
+		}
+		if len(versions) == 0 {
+			t.Error("got no versions")
+		}
+		if got := prov.Proxy(); got != fast.URL {
+			t.Errorf("got provenance %q, want %q", got, fast.URL)
+		}
+
+		// The server notices the cancellation asynchronously.
+		deadline := time.Now().Add(5 * time.Second)
+		for canceled.Load() == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n := canceled.Load(); n != 1 {
+			t.Errorf("slow proxy saw %d canceled requests, want 1", n)
+		}
+	})
+
+	t.Run("fast_is_not_hedged", func(t *testing.T) {
+		fastRequests.Store(0)
+		canceled.Store(0)
+
+		cl := New(fast.URL+","+slow.URL, nil, WithHedge(time.Second))
+		if _, err := cl.List(ctx, "example.com/multi"); err != nil {
+			t.Fatal(err)
+		}
+		if n := fastRequests.Load(); n != 1 {
+			t.Errorf("got %d requests to the fast proxy, want 1", n)
+		}
+		if n := canceled.Load(); n != 0 {
+			t.Errorf("slow proxy saw %d canceled requests, want 0", n)
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		// A not-found error falls back right away.
+		cl := New(fast.URL+","+fast.URL, nil, WithHedge(time.Minute))
+		_, err := cl.List(ctx, "example.com/nonexistent")
+		var ferr FallbackError
+		if !errors.As(err, &ferr) || len(ferr.Errs) != 2 || !IsNotFound(err) {
+			t.Errorf("got %v, want a not-found FallbackError from two proxies", err)
+		}
+
+		// Another error doesn't fall back after a comma.
+		fastRequests.Store(0)
+		cl = New(broken.URL+","+fast.URL, nil, WithHedge(time.Minute))
+		if _, err := cl.List(ctx, "example.com/multi"); err == nil {
+			t.Error("got no error")
+		}
+		if n := fastRequests.Load(); n != 0 {
+			t.Errorf("got %d requests to the fallback proxy, want 0", n)
+		}
+
+		// But it does after a pipe.
+		cl = New(broken.URL+"|"+fast.URL, nil, WithHedge(time.Minute))
+		if _, err := cl.List(ctx, "example.com/multi"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("body_outlives_hedge", func(t *testing.T) {
+		cl := New(slow.URL+"|"+fast.URL, nil, WithHedge(delay))
+		rc, err := cl.Zip(ctx, "example.com/multi", "v1.0.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		if _, err := io.ReadAll(rc); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"context"
+	"go/parser"
+	"go/token"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bobg/errors"
+)
+
+// ImportGraph describes the imports of the packages in a module version.
+// Create one with [Client.Imports].
+type ImportGraph struct {
+	// Module is the module path.
+	Module string
+
+	// Packages maps the import path of each package in the module
+	// to the sorted, deduplicated import paths it imports.
+	Packages map[string][]string
+}
+
+// Imports parses the .go files in the zip file for a specific version of a Go module
+// to determine which packages each of its packages imports.
+// No build is performed and build constraints are ignored,
+// so the result is the union of imports on all platforms.
+//
+// Directories are treated as in [Client.Packages].
+// If tests is true,
+// the imports of _test.go files are included.
+func (cl Client) Imports(ctx context.Context, mod, ver string, tests bool) (ImportGraph, error) {
+	zr, err := cl.zipReader(ctx, mod, ver)
+	if err != nil {
+		return ImportGraph{}, err
+	}
+
+	var (
+		prefix = mod + "@" + ver + "/"
+		fset   = token.NewFileSet()
+		sets   = make(map[string]map[string]bool) // pkg -> set of imports
+	)
+
+	for _, f := range zr.File {
+		name, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok {
+			return ImportGraph{}, errors.Newf("file %s in zip for %s@%s lacks the expected prefix", f.Name, mod, ver)
+		}
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		isTest := strings.HasSuffix(name, "_test.go")
+		if isTest && !tests {
+			continue
+		}
+
+		dir, file := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+		if ignoredDir(dir) || strings.HasPrefix(file, ".") || strings.HasPrefix(file, "_") {
+			continue
+		}
+
+		src, err := readZipFile(f)
+		if err != nil {
+			return ImportGraph{}, err
+		}
+		parsed, err := parser.ParseFile(fset, f.Name, src, parser.ImportsOnly)
+		if err != nil {
+			return ImportGraph{}, errors.Wrapf(err, "parsing %s", f.Name)
+		}
+
+		pkg := mod
+		if dir != "" {
+			pkg = mod + "/" + dir
+		}
+		set := sets[pkg]
+		if set == nil {
+			set = make(map[string]bool)
+			sets[pkg] = set
+		}
+		for _, imp := range parsed.Imports {
+			p, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				return ImportGraph{}, errors.Wrapf(err, "unquoting import path %s in %s", imp.Path.Value, f.Name)
+			}
+			if p == "C" {
+				continue
+			}
+			set[p] = true
+		}
+	}
+
+	g := ImportGraph{Module: mod, Packages: make(map[string][]string, len(sets))}
+	for pkg, set := range sets {
+		imports := make([]string, 0, len(set))
+		for p := range set {
+			imports = append(imports, p)
+		}
+		slices.Sort(imports)
+		g.Packages[pkg] = imports
+	}
+
+	return g, nil
+}
+
+// External returns the sorted import paths imported by some package in the module
+// that are neither in the module itself nor in the standard library.
+func (g ImportGraph) External() []string {
+	set := make(map[string]bool)
+	for _, imports := range g.Packages {
+		for _, p := range imports {
+			if isStdlib(p) || p == g.Module || strings.HasPrefix(p, g.Module+"/") {
+				continue
+			}
+			set[p] = true
+		}
+	}
+
+	result := make([]string, 0, len(set))
+	for p := range set {
+		result = append(result, p)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// Importers returns the sorted import paths of the packages in the module that import the given package,
+// or any package whose import path begins with pkg+"/" if prefix is true.
+func (g ImportGraph) Importers(pkg string, prefix bool) []string {
+	var result []string
+	for importer, imports := range g.Packages {
+		for _, p := range imports {
+			if p == pkg || (prefix && strings.HasPrefix(p, pkg+"/")) {
+				result = append(result, importer)
+				break
+			}
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// IsStdlib tells whether an import path looks like a standard-library package,
+// using the go command's heuristic:
+// the first path element contains no dot.
+func isStdlib(p string) bool {
+	first, _, _ := strings.Cut(p, "/")
+	return !strings.Contains(first, ".")
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %s", f.Name)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	return data, errors.Wrapf(err, "reading %s", f.Name)
+}
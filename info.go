@@ -0,0 +1,101 @@
+package goproxyclient
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Info is the information about a module version
+// in a proxy's .info or @latest response.
+type Info struct {
+	// Version is the canonical version.
+	Version string
+
+	// Time is the version's commit time.
+	Time time.Time
+
+	// Origin describes where the version came from,
+	// or is nil if the proxy did not say.
+	Origin *Origin
+
+	// Raw holds all the fields of the response,
+	// including any not represented above,
+	// as unparsed JSON.
+	Raw map[string]json.RawMessage
+}
+
+// Origin is the Origin object that some proxies
+// (including proxy.golang.org)
+// include in .info and @latest responses,
+// describing the source of a module version.
+// Fields that do not apply are empty.
+type Origin struct {
+	// VCS is the kind of version control system, e.g. "git".
+	VCS string
+
+	// URL is the URL of the repository.
+	URL string
+
+	// Subdir is the subdirectory of the repository containing the module,
+	// if it is not at the root.
+	Subdir string
+
+	// Ref is the name of the ref from which the version was resolved,
+	// e.g. "refs/tags/v1.2.3" or "refs/heads/main".
+	Ref string
+
+	// Hash is the commit hash.
+	Hash string
+
+	// TagPrefix and TagSum describe the repository's set of tags,
+	// for checking whether a @latest or list result is still current.
+	TagPrefix string `json:",omitempty"`
+	TagSum    string `json:",omitempty"`
+
+	// RepoSum is a hash of the entire repository,
+	// used for the same purpose when the other fields are insufficient.
+	RepoSum string `json:",omitempty"`
+}
+
+// VersionInfo is like [Client.Info]
+// but returns its result as an [Info].
+func (cl Client) VersionInfo(ctx context.Context, mod, ver string) (Info, error) {
+	ver, tm, m, err := cl.Info(ctx, mod, ver)
+	if err != nil {
+		return Info{}, err
+	}
+	return makeInfo(ver, tm, m), nil
+}
+
+// LatestInfo is like [Client.Latest]
+// but returns its result as an [Info].
+func (cl Client) LatestInfo(ctx context.Context, mod string) (Info, error) {
+	ver, tm, m, err := cl.Latest(ctx, mod)
+	if err != nil {
+		return Info{}, err
+	}
+	return makeInfo(ver, tm, m), nil
+}
+
+func makeInfo(ver string, tm time.Time, m map[string]json.RawMessage) Info {
+	info := Info{Version: ver, Time: tm, Raw: m}
+	if o, ok := parseOrigin(m); ok {
+		info.Origin = &o
+	}
+	return info
+}
+
+// ParseOrigin decodes the Origin field of an .info response,
+// reporting false if it is absent or malformed.
+func parseOrigin(m map[string]json.RawMessage) (Origin, bool) {
+	raw, ok := m["Origin"]
+	if !ok {
+		return Origin{}, false
+	}
+	var o Origin
+	if err := json.Unmarshal(raw, &o); err != nil {
+		return Origin{}, false
+	}
+	return o, true
+}
@@ -0,0 +1,47 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLatestInfo(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+
+	info, err := cl.LatestInfo(context.Background(), "github.com/bobg/errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "v1.1.0" {
+		t.Errorf("got version %s, want v1.1.0", info.Version)
+	}
+	if want := time.Date(2024, 5, 15, 17, 43, 47, 0, time.UTC); !info.Time.Equal(want) {
+		t.Errorf("got time %s, want %s", info.Time, want)
+	}
+	want := Origin{
+		VCS:  "git",
+		URL:  "https://github.com/bobg/errors",
+		Ref:  "refs/tags/v1.1.0",
+		Hash: "5f4da70b6f54a67a812f2af5ec8ca9e3291c3d0b",
+	}
+	if info.Origin == nil || *info.Origin != want {
+		t.Errorf("got origin %+v, want %+v", info.Origin, want)
+	}
+	if _, ok := info.Raw["Origin"]; !ok {
+		t.Error("raw fields lack Origin")
+	}
+
+	// No Origin.
+	info, err = cl.VersionInfo(context.Background(), "example.com/multi", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "v1.0.0" || info.Origin != nil {
+		t.Errorf("got %+v", info)
+	}
+}
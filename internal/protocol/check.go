@@ -0,0 +1,232 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	modzip "golang.org/x/mod/zip"
+)
+
+// DefaultProbe is the module version that [Check] requests
+// when no other is given.
+var DefaultProbe = module.Version{Path: "rsc.io/quote", Version: "v1.5.2"}
+
+// Getter fetches a URL.
+// On a 200 (OK) response it returns the response body,
+// which the caller must close.
+// Otherwise the error has a Code method
+// (like [github.com/bobg/mid.CodeErr])
+// reporting the response status,
+// unless there was no response.
+type Getter func(ctx context.Context, url string) (io.ReadCloser, error)
+
+// Result is the result of one of the checks made by [Check].
+// It is converted to github.com/bobg/goproxyclient.ProxyCheck,
+// so the two must have the same fields.
+type Result struct {
+	Name     string
+	URL      string
+	Optional bool
+	Elapsed  time.Duration
+	Err      error
+}
+
+// Check checks that the Go module proxy at baseURL,
+// reached with get,
+// implements the GOPROXY protocol correctly.
+// It requests the version list, info, go.mod file, and zip file for the probe module version
+// (or [DefaultProbe] if probe.Path is empty),
+// and validates each response.
+// If probe.Version is empty,
+// the highest release version in the list is used.
+// It also requests a version and a module that do not exist,
+// which must produce 404 (Not Found) or 410 (Gone) responses.
+//
+// It returns the probe that was used
+// and the results of the checks,
+// in the order they were made.
+func Check(ctx context.Context, baseURL string, probe module.Version, get Getter) (module.Version, []Result, error) {
+	if probe.Path == "" {
+		probe = DefaultProbe
+	}
+	escPath, err := module.EscapePath(probe.Path)
+	if err != nil {
+		return probe, nil, errors.Wrapf(err, "escaping module path %s", probe.Path)
+	}
+
+	c := checker{baseURL: baseURL, get: get}
+
+	c.check(ctx, "list", false, "/"+escPath+"/@v/list", func(body io.Reader) error {
+		versions, err := ParseList(body)
+		if err != nil {
+			return err
+		}
+		if probe.Version == "" {
+			for _, v := range slices.Backward(versions) {
+				if semver.Prerelease(v) == "" {
+					probe.Version = v
+					return nil
+				}
+			}
+			return fmt.Errorf("no release versions of %s listed", probe.Path)
+		}
+		if !slices.Contains(versions, probe.Version) {
+			return fmt.Errorf("%s not listed", probe.Version)
+		}
+		return nil
+	})
+	if probe.Version == "" {
+		// Nothing else can be checked.
+		return probe, c.results, nil
+	}
+
+	escVer, err := module.EscapeVersion(probe.Version)
+	if err != nil {
+		return probe, nil, errors.Wrapf(err, "escaping version %s", probe.Version)
+	}
+	prefix := "/" + escPath + "/@v/" + escVer
+
+	c.check(ctx, "info", false, prefix+".info", func(body io.Reader) error {
+		return checkInfoBody(body, probe.Version)
+	})
+	c.check(ctx, "latest", true, "/"+escPath+"/@latest", func(body io.Reader) error {
+		return checkInfoBody(body, "")
+	})
+	c.check(ctx, "mod", false, prefix+".mod", func(body io.Reader) error {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return errors.Wrap(err, "reading body")
+		}
+		f, err := modfile.ParseLax("go.mod", data, nil)
+		if err != nil {
+			return errors.Wrap(err, "parsing go.mod")
+		}
+		if f.Module == nil {
+			return errors.New("no module directive in go.mod")
+		}
+		if f.Module.Mod.Path != probe.Path {
+			return fmt.Errorf("go.mod declares module %s", f.Module.Mod.Path)
+		}
+		return nil
+	})
+	c.check(ctx, "zip", false, prefix+".zip", func(body io.Reader) error {
+		return checkZipBody(body, probe)
+	})
+
+	c.checkMissing(ctx, "missing version", "/"+escPath+"/@v/v0.0.0-goproxyclient-check.info")
+	c.checkMissing(ctx, "missing module", "/"+escPath+"/goproxyclient-check-missing/@v/list")
+
+	return probe, c.results, nil
+}
+
+// checker accumulates the results of [Check].
+type checker struct {
+	baseURL string
+	get     Getter
+	results []Result
+}
+
+// Check requests the given path from the proxy
+// and, if the response status is 200 (OK),
+// validates the response body with f.
+func (c *checker) check(ctx context.Context, name string, optional bool, path string, f func(io.Reader) error) {
+	var (
+		q     = c.baseURL + path
+		start = time.Now()
+	)
+	body, err := c.get(ctx, q)
+	if err == nil {
+		err = f(body)
+		body.Close()
+	}
+	c.results = append(c.results, Result{
+		Name:     name,
+		URL:      q,
+		Optional: optional,
+		Elapsed:  time.Since(start),
+		Err:      err,
+	})
+}
+
+// CheckMissing requests the given path from the proxy,
+// expecting a 404 (Not Found) or 410 (Gone) response.
+func (c *checker) checkMissing(ctx context.Context, name, path string) {
+	var (
+		q     = c.baseURL + path
+		start = time.Now()
+	)
+	body, err := c.get(ctx, q)
+
+	var codeErr interface{ Code() int }
+	switch {
+	case err == nil:
+		body.Close()
+		err = fmt.Errorf("got status %d, want 404 or 410", http.StatusOK)
+	case !errors.As(err, &codeErr):
+		// No response.
+	case codeErr.Code() == http.StatusNotFound || codeErr.Code() == http.StatusGone:
+		err = nil
+	default:
+		err = fmt.Errorf("got status %d, want 404 or 410", codeErr.Code())
+	}
+	c.results = append(c.results, Result{
+		Name:    name,
+		URL:     q,
+		Elapsed: time.Since(start),
+		Err:     err,
+	})
+}
+
+// CheckInfoBody validates the body of an .info or @latest response.
+// If wantVersion is not empty,
+// the response must be for that version.
+func checkInfoBody(body io.Reader, wantVersion string) error {
+	data, err := io.ReadAll(io.LimitReader(body, MaxInfoSize+1))
+	if err != nil {
+		return errors.Wrap(err, "reading body")
+	}
+	if len(data) > MaxInfoSize {
+		return fmt.Errorf("body exceeds %d bytes", MaxInfoSize)
+	}
+	ver, _, _, err := ParseInfo(data)
+	if err != nil {
+		return err
+	}
+	if wantVersion != "" && ver != wantVersion {
+		return fmt.Errorf("got Version %s, want %s", ver, wantVersion)
+	}
+	return nil
+}
+
+// CheckZipBody validates the body of a .zip response
+// as the go command would when extracting it.
+func checkZipBody(body io.Reader, mv module.Version) error {
+	f, err := os.CreateTemp("", "goproxyclient-*.zip")
+	if err != nil {
+		return errors.Wrap(err, "creating temp file")
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := io.Copy(f, body); err != nil {
+		return errors.Wrap(err, "reading body")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "closing %s", f.Name())
+	}
+
+	cf, err := modzip.CheckZip(mv, f.Name())
+	if err != nil {
+		return err
+	}
+	return cf.Err()
+}
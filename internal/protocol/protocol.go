@@ -0,0 +1,78 @@
+// Package protocol holds the parts of the GOPROXY protocol
+// shared by goproxyclient and goproxytest:
+// parsing responses and checking that a proxy conforms.
+package protocol
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/semver"
+)
+
+// ParseList parses the body of a response to a list request.
+// Following cmd/go,
+// only the first field of each line is used
+// (some proxies append a timestamp),
+// and lines that are not valid semantic versions are ignored.
+func ParseList(r io.Reader) ([]string, error) {
+	var (
+		sc       = bufio.NewScanner(r)
+		versions []string
+	)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || !semver.IsValid(fields[0]) {
+			continue
+		}
+		versions = append(versions, fields[0])
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	semver.Sort(versions)
+	return versions, nil
+}
+
+// MaxInfoSize is the largest .info or @latest response body that will be accepted.
+const MaxInfoSize = 1 << 20
+
+// ParseInfo parses the body of an .info or @latest response.
+// The body must be a valid UTF-8 JSON object
+// with a Version field that is a valid semantic version.
+// The Time field is optional and is zero if absent.
+func ParseInfo(body []byte) (string, time.Time, map[string]json.RawMessage, error) {
+	if !utf8.Valid(body) {
+		return "", time.Time{}, nil, errors.New("invalid UTF-8")
+	}
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return "", time.Time{}, nil, errors.Wrap(err, "unmarshaling")
+	}
+	if m == nil {
+		return "", time.Time{}, nil, errors.New("not a JSON object")
+	}
+
+	var info struct {
+		Version string
+		Time    time.Time
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return "", time.Time{}, nil, errors.Wrap(err, "unmarshaling")
+	}
+	if info.Version == "" {
+		return "", time.Time{}, nil, errors.New("missing Version")
+	}
+	if !semver.IsValid(info.Version) {
+		return "", time.Time{}, nil, fmt.Errorf("invalid Version %q", info.Version)
+	}
+
+	return info.Version, info.Time, m, nil
+}
@@ -0,0 +1,55 @@
+package protocol
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/mod/semver"
+)
+
+func FuzzParseInfo(f *testing.F) {
+	f.Add([]byte(`{"Version":"v1.1.0","Time":"2024-01-01T00:00:00Z"}`))
+	f.Add([]byte(`{"Version":"v1.1.0","Origin":{"VCS":"git","URL":"https://github.com/bobg/errors"}}`))
+	f.Add([]byte(`{"Version":"v1.1.0","Time":"2024-01-01T00:00:00Z","Size":1e999}`))
+	f.Add([]byte(`{"Version":"v1.1.0","X":[[[[[[[[[[{}]]]]]]]]]]}`))
+	f.Add([]byte("{\"Version\":\"v1.\xff\"}"))
+	f.Add([]byte(`null`))
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`{}`))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		ver, _, m, err := ParseInfo(body)
+		if err != nil {
+			return
+		}
+		if !utf8.Valid(body) {
+			t.Errorf("accepted invalid UTF-8")
+		}
+		if !semver.IsValid(ver) {
+			t.Errorf("accepted invalid version %q", ver)
+		}
+		if m == nil {
+			t.Errorf("got nil map with nil error")
+		}
+	})
+}
+
+func FuzzParseList(f *testing.F) {
+	f.Add("v1.0.0\nv1.1.0\n")
+	f.Add("v1.0.0 2024-01-01T00:00:00Z\r\nv1.1.0\r\n")
+	f.Add("\n\nnot-a-version\nv1.2.3-pre\n")
+	f.Add("v1.0.0\x00\xff\n")
+
+	f.Fuzz(func(t *testing.T, body string) {
+		versions, err := ParseList(strings.NewReader(body))
+		if err != nil {
+			return
+		}
+		for _, v := range versions {
+			if !semver.IsValid(v) {
+				t.Errorf("accepted invalid version %q", v)
+			}
+		}
+	})
+}
@@ -0,0 +1,323 @@
+package goproxyclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bobg/errors"
+)
+
+// Journal is an append-only record of the requests a [Client] sends to its proxies,
+// for accounting for their use:
+// for example,
+// to attribute the bandwidth used by a shared service built on the client
+// to the service's users,
+// by way of the tenant IDs or request IDs of their operations
+// (see [WithTenant] and [WithRequestID]).
+// Attach one to a client with [WithJournal].
+//
+// The journal is a file of JSON objects,
+// one [JournalEntry] per line.
+// It may be rotated when it reaches a maximum size
+// (see [OpenJournal]).
+//
+// A Journal is safe for concurrent use,
+// and may be shared by several clients.
+type Journal struct {
+	path    string
+	maxSize int64
+	keep    int
+
+	mu   sync.Mutex
+	f    *os.File // nil after Close
+	size int64
+}
+
+// JournalEntry is one request recorded in a [Journal].
+type JournalEntry struct {
+	// Time is when the request was sent.
+	Time time.Time
+
+	// Proxy is the base URL of the proxy the request was sent to,
+	// and URL is the URL of the request,
+	// both with any password redacted.
+	Proxy, URL string
+
+	// Method is GET or HEAD.
+	Method string
+
+	// Endpoint is the kind of request:
+	// "info," "latest," "list," "mod," or "zip."
+	Endpoint string
+
+	// Status is the status of the response,
+	// or 0 if there was none.
+	Status int `json:",omitempty"`
+
+	// Bytes is the number of bytes of response body received.
+	// It is less than the size of the body
+	// if the caller stopped reading early.
+	Bytes int64
+
+	// Elapsed is the time from sending the request
+	// until its response body was closed
+	// (or until it failed),
+	// in nanoseconds.
+	Elapsed time.Duration
+
+	// RequestID is the request ID of the operation the request was part of
+	// (see [RequestID]).
+	RequestID string `json:",omitempty"`
+
+	// Tenant is the tenant ID of the operation, if it had one
+	// (see [WithTenant]),
+	// and Priority is its priority
+	// (see [WithPriority]).
+	Tenant   string `json:",omitempty"`
+	Priority string
+
+	// Outcome is the [ErrorClass] of the request's error,
+	// as a string,
+	// or "no error" if it succeeded.
+	// Error is the error message, if there was one.
+	Outcome string
+	Error   string `json:",omitempty"`
+}
+
+// OpenJournal opens the journal in the file at path for appending,
+// creating it if necessary.
+//
+// If maxSize is positive,
+// the file is rotated when an entry would make it larger than that many bytes:
+// it is renamed with the suffix ".1"
+// (after any existing ".1" file is renamed ".2," and so on),
+// and a new file is started.
+// At most keep old files are kept;
+// the oldest are removed.
+//
+// The caller should close the journal when done with it.
+func OpenJournal(path string, maxSize int64, keep int) (*Journal, error) {
+	j := &Journal{path: path, maxSize: maxSize, keep: max(keep, 0)}
+	if err := j.open(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *Journal) open() error {
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "opening journal %s", j.path)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrapf(err, "statting journal %s", j.path)
+	}
+	j.f, j.size = f, fi.Size()
+	return nil
+}
+
+// Close closes the journal.
+// Requests after that are not recorded.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Close()
+	j.f = nil
+	return errors.Wrapf(err, "closing journal %s", j.path)
+}
+
+// Write appends e to the journal,
+// rotating it first if necessary.
+func (j *Journal) write(e JournalEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "encoding journal entry")
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.f == nil {
+		return nil
+	}
+	if j.maxSize > 0 && j.size > 0 && j.size+int64(len(line)) > j.maxSize {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := j.f.Write(line)
+	j.size += int64(n)
+	return errors.Wrapf(err, "writing to journal %s", j.path)
+}
+
+func (j *Journal) rotate() error {
+	if err := j.f.Close(); err != nil {
+		return errors.Wrapf(err, "closing journal %s", j.path)
+	}
+
+	name := func(i int) string {
+		if i == 0 {
+			return j.path
+		}
+		return fmt.Sprintf("%s.%d", j.path, i)
+	}
+	if err := os.Remove(name(j.keep)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrapf(err, "removing old journal %s", name(j.keep))
+	}
+	for i := j.keep; i > 0; i-- {
+		if err := os.Rename(name(i-1), name(i)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.Wrapf(err, "renaming journal %s", name(i-1))
+		}
+	}
+
+	return j.open()
+}
+
+// WithJournal is an [Option] that records each request the client sends to its proxies in j
+// (see [Journal]),
+// including each retry and each request abandoned because another proxy answered first.
+// Requests made while fetching modules from their version control repositories
+// (for a "direct" entry in the client's list of proxies)
+// are not recorded.
+//
+// Errors writing to the journal are logged (see [WithLogger])
+// and otherwise ignored.
+func WithJournal(j *Journal) Option {
+	return func(c *config) {
+		c.journal = j
+	}
+}
+
+// JournalRequest records the outcome of a request
+// sent at start
+// and producing resp and err
+// (see [single.doOnce]).
+// If resp is a successful response,
+// the entry is written when its body is closed.
+func (s single) journalRequest(ctx context.Context, method, q string, start time.Time, resp *http.Response, err error) {
+	if s.journal == nil {
+		return
+	}
+
+	e := JournalEntry{
+		Time:      start,
+		Proxy:     redactURL(s.baseURL),
+		URL:       redactURL(q),
+		Method:    method,
+		Endpoint:  endpoint(q),
+		RequestID: RequestID(ctx),
+		Tenant:    Tenant(ctx),
+		Priority:  RequestPriority(ctx).String(),
+	}
+
+	if err != nil {
+		var codeErr CodeErr
+		if errors.As(err, &codeErr) {
+			e.Status = codeErr.Code()
+		}
+		s.writeJournal(ctx, e, start, err)
+		return
+	}
+
+	e.Status = resp.StatusCode
+	resp.Body = &journalBody{ReadCloser: resp.Body, ctx: ctx, s: s, entry: e}
+}
+
+func (s single) writeJournal(ctx context.Context, e JournalEntry, start time.Time, err error) {
+	e.Elapsed = time.Since(start)
+	e.Outcome = Classify(err).String()
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if err := s.journal.write(e); err != nil {
+		s.log(ctx, "writing to journal", slog.Any("error", err))
+	}
+}
+
+// journalBody is a response body that counts the bytes read from it
+// and writes its [JournalEntry] when it is closed.
+type journalBody struct {
+	io.ReadCloser
+	ctx context.Context
+	s   single
+
+	// Close may be called during a read
+	// (see [ctxBody]).
+	mu     sync.Mutex
+	entry  JournalEntry
+	err    error // the first read error other than io.EOF
+	closed bool
+}
+
+func (b *journalBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entry.Bytes += int64(n)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+		if b.ctx.Err() != nil {
+			b.err = context.Cause(b.ctx)
+		}
+	}
+	return n, err
+}
+
+func (b *journalBody) Close() error {
+	err := b.ReadCloser.Close()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.closed {
+		b.closed = true
+		b.s.writeJournal(b.ctx, b.entry, b.entry.Time, b.err)
+	}
+	return err
+}
+
+// Endpoint returns the kind of a request to a proxy for the given URL
+// (see [JournalEntry]).
+func endpoint(q string) string {
+	switch {
+	case strings.HasSuffix(q, "/@v/list"):
+		return "list"
+	case strings.HasSuffix(q, "/@latest"):
+		return "latest"
+	case strings.HasSuffix(q, ".info"):
+		return "info"
+	case strings.HasSuffix(q, ".mod"):
+		return "mod"
+	case strings.HasSuffix(q, ".zip"):
+		return "zip"
+	}
+	return ""
+}
+
+// RedactURL returns s with any password redacted,
+// if it is a URL.
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	return u.Redacted()
+}
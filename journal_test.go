@@ -0,0 +1,157 @@
+package goproxyclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	ctx := WithRequestID(context.Background(), "req-1")
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	path := filepath.Join(t.TempDir(), "journal")
+	j, err := OpenJournal(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	cl := New(s.URL, nil, WithJournal(j))
+
+	rc, err := cl.Mod(ctx, "example.com/multi", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(io.Discard, rc); err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+
+	if _, err := cl.Mod(ctx, "example.com/nonexistent", "v1.0.0"); !IsNotFound(err) {
+		t.Fatalf("got %v, want a not-found error", err)
+	}
+
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := readJournal(t, path)
+	if len(entries) != 2 {
+		t.Fatalf("got %d journal entries, want 2", len(entries))
+	}
+
+	modData, err := fs.ReadFile(testdata, "testdata/example.com/multi/@v/v1.0.0.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := entries[0]
+	if got.Proxy != s.URL || got.URL != s.URL+"/example.com/multi/@v/v1.0.0.mod" {
+		t.Errorf("got proxy %s, URL %s", got.Proxy, got.URL)
+	}
+	if got.Method != "GET" || got.Endpoint != "mod" || got.Status != 200 || got.RequestID != "req-1" {
+		t.Errorf("got method %s, endpoint %s, status %d, request ID %s", got.Method, got.Endpoint, got.Status, got.RequestID)
+	}
+	if got.Bytes != int64(len(modData)) {
+		t.Errorf("got %d bytes, want %d", got.Bytes, len(modData))
+	}
+	if got.Outcome != "no error" || got.Error != "" {
+		t.Errorf("got outcome %s, error %q", got.Outcome, got.Error)
+	}
+
+	got = entries[1]
+	if got.Endpoint != "mod" || got.Status != 404 || got.Bytes != 0 {
+		t.Errorf("got endpoint %s, status %d, %d bytes", got.Endpoint, got.Status, got.Bytes)
+	}
+	if got.Outcome != "not found" || got.Error == "" {
+		t.Errorf("got outcome %s, error %q", got.Outcome, got.Error)
+	}
+}
+
+func TestJournalRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+
+	e := JournalEntry{Endpoint: "zip", Outcome: "no error"}
+	line, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := int64(len(line) + 1)
+
+	j, err := OpenJournal(path, 2*size, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	for range 7 {
+		if err := j.write(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The entries are in path.2 (2), path.1 (2), and path (1),
+	// and the first two were removed with the old path.3.
+	for _, tc := range []struct {
+		suffix string
+		want   int
+	}{{"", 1}, {".1", 2}, {".2", 2}} {
+		if got := len(readJournal(t, path+tc.suffix)); got != tc.want {
+			t.Errorf("got %d entries in journal%s, want %d", got, tc.suffix, tc.want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("got %v statting journal.3, want not-exist", err)
+	}
+
+	// Reopening appends to the existing file.
+	j, err = OpenJournal(path, 2*size, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.write(e); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(readJournal(t, path)); got != 2 {
+		t.Errorf("got %d entries in reopened journal, want 2", got)
+	}
+}
+
+func readJournal(t *testing.T, path string) []JournalEntry {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var entries []JournalEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e JournalEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
@@ -0,0 +1,115 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"io"
+	"path"
+	"slices"
+	"strings"
+)
+
+// licenseFilePrefixes are the (lowercase) name prefixes of files at the root of a module
+// that are examined for license text.
+var licenseFilePrefixes = []string{"license", "licence", "copying", "unlicense"}
+
+// maxLicenseSize is the most of each license file that is read.
+const maxLicenseSize = 64 << 10
+
+// DetectLicenses identifies the licenses of the module in zr,
+// whose files have names beginning with prefix (MODPATH@VERSION/).
+// It examines the files at the root of the module with names like LICENSE and COPYING,
+// and returns the SPDX identifiers (such as "MIT" or "Apache-2.0") of the licenses it recognizes,
+// sorted and without duplicates.
+// A license file whose license is not recognized contributes "unknown".
+//
+// Recognition is by distinctive phrases,
+// not a full comparison with the license text,
+// so the result is a guide and not a legal determination.
+func DetectLicenses(zr *zip.Reader, prefix string) []string {
+	var result []string
+	for _, f := range zr.File {
+		name, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok || strings.Contains(name, "/") || !isLicenseFile(name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		text, err := io.ReadAll(io.LimitReader(rc, maxLicenseSize))
+		rc.Close()
+		if err != nil {
+			continue
+		}
+		result = append(result, identifyLicense(string(text)))
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+// IsLicenseFile tells whether name looks like the name of a license file,
+// such as LICENSE, LICENSE.md, COPYING.txt, or LICENSE-MIT.
+func isLicenseFile(name string) bool {
+	lower := strings.ToLower(name)
+	switch ext := path.Ext(lower); ext {
+	case ".go":
+		return false
+	case ".txt", ".md", ".rst":
+		lower = strings.TrimSuffix(lower, ext)
+	}
+	for _, prefix := range licenseFilePrefixes {
+		if lower == prefix || strings.HasPrefix(lower, prefix+"-") || strings.HasPrefix(lower, prefix+"_") || strings.HasPrefix(lower, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// licensePhrases identifies licenses by phrases in their text.
+// Each entry's phrases must all appear
+// (in the text normalized by normalizeLicense).
+// Earlier entries take precedence,
+// so more specific licenses come before the ones they resemble.
+var licensePhrases = []struct {
+	id      string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"CC0-1.0", []string{"cc0 1.0 universal"}},
+}
+
+func identifyLicense(text string) string {
+	text = normalizeLicense(text)
+	for _, l := range licensePhrases {
+		if allContained(text, l.phrases) {
+			return l.id
+		}
+	}
+	return "unknown"
+}
+
+// NormalizeLicense lowercases text and collapses whitespace,
+// so that phrases match regardless of line wrapping.
+func normalizeLicense(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+func allContained(text string, phrases []string) bool {
+	for _, p := range phrases {
+		if !strings.Contains(text, p) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestDetectLicenses(t *testing.T) {
+	const prefix = "example.com/lic@v1.0.0/"
+
+	files := map[string]string{
+		"LICENSE":         "MIT License\n\nPermission is hereby granted, free of\ncharge, to any person",
+		"COPYING.txt":     "                 Apache License\n           Version 2.0, January 2004",
+		"LICENSE-OTHER":   "All rights reserved.",
+		"license.go":      "package lic",
+		"sub/LICENSE":     "GNU GENERAL PUBLIC LICENSE\nVersion 3",
+		"README.md":       "Permission is hereby granted, free of charge",
+		"UNLICENSE":       "This is free and unencumbered software released into the public domain.",
+		"LICENSE.md":      "Permission is hereby granted, free of charge",
+		"licenses/MIT.md": "Permission is hereby granted, free of charge",
+	}
+
+	var (
+		buf bytes.Buffer
+		zw  = zip.NewWriter(&buf)
+	)
+	for name, content := range files {
+		w, err := zw.Create(prefix + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := DetectLicenses(zr, prefix)
+	want := []string{"Apache-2.0", "MIT", "Unlicense", "unknown"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package goproxyclient
+
+import "fmt"
+
+// ExtractLimits bounds what the client will unpack from a zip file
+// (e.g. in [Client.Extract] and [Client.DownloadToolchain]),
+// so that a hostile zip file cannot exhaust disk space.
+// A zero field means no limit.
+//
+// The limits are checked against the sizes declared in the zip file before anything is written,
+// and the declared sizes are enforced while writing.
+type ExtractLimits struct {
+	// MaxFiles is the maximum number of entries in the zip file.
+	MaxFiles int
+
+	// MaxFileSize is the maximum uncompressed size of a single file.
+	MaxFileSize int64
+
+	// MaxTotalSize is the maximum uncompressed size of all files together.
+	MaxTotalSize int64
+
+	// MaxRatio is the maximum ratio of uncompressed to compressed size for a single file.
+	// It applies only to files whose uncompressed size exceeds 1 MiB,
+	// since small files can have high ratios innocently.
+	MaxRatio float64
+}
+
+// DefaultExtractLimits are the limits used by a client without [WithExtractLimits].
+// The size limits match the go command's limit of 500 MiB for module zip files.
+var DefaultExtractLimits = ExtractLimits{
+	MaxFiles:     100000,
+	MaxFileSize:  500 << 20,
+	MaxTotalSize: 500 << 20,
+	MaxRatio:     100,
+}
+
+// ratioMinSize is the size above which [ExtractLimits.MaxRatio] applies.
+const ratioMinSize = 1 << 20
+
+// WithExtractLimits is an [Option] that sets the limits on what the client will unpack from zip files,
+// in place of [DefaultExtractLimits].
+func WithExtractLimits(lim ExtractLimits) Option {
+	return func(c *config) {
+		c.extractLimits = &lim
+	}
+}
+
+func (c *config) getExtractLimits() ExtractLimits {
+	if c == nil || c.extractLimits == nil {
+		return DefaultExtractLimits
+	}
+	return *c.extractLimits
+}
+
+// ExtractLimitError is the error returned when a zip file exceeds one of the client's [ExtractLimits],
+// or when a file in it is larger than its declared size,
+// or when a zip file is too large for [HashZipStream].
+type ExtractLimitError struct {
+	// File is the name of the offending file in the zip,
+	// or the empty string if the limit applies to the zip as a whole.
+	File string
+
+	// Limit describes the limit that was exceeded:
+	// "file count", "file size", "total size", "compression ratio", or "declared size".
+	Limit string
+
+	// Value is the offending value
+	// and Max is the limit.
+	Value, Max float64
+}
+
+func (e *ExtractLimitError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("zip exceeds %s limit (%g > %g)", e.Limit, e.Value, e.Max)
+	}
+	return fmt.Sprintf("file %s in zip exceeds %s limit (%g > %g)", e.File, e.Limit, e.Value, e.Max)
+}
+
+// UnsafePathError is the error returned when a file in a zip has a name that is not safe to extract,
+// such as one that would escape the destination directory.
+type UnsafePathError struct {
+	File string
+	Err  error
+}
+
+func (e *UnsafePathError) Error() string {
+	return fmt.Sprintf("unsafe file name %q in zip: %s", e.File, e.Err)
+}
+
+func (e *UnsafePathError) Unwrap() error {
+	return e.Err
+}
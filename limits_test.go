@@ -0,0 +1,128 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExtractLimits(t *testing.T) {
+	const prefix = "example.com/bomb@v1.0.0/"
+
+	zeroes := strings.Repeat("\x00", 4<<20)
+
+	cases := []struct {
+		name   string
+		files  map[string]string
+		lim    *ExtractLimits
+		filter ExtractFilter
+		limit  string // "" means no error
+	}{{
+		name:  "ok",
+		files: map[string]string{"a.go": "package a", "b.go": "package b"},
+	}, {
+		name:  "file_count",
+		files: map[string]string{"a.go": "package a", "b.go": "package b"},
+		lim:   &ExtractLimits{MaxFiles: 1},
+		limit: "file count",
+	}, {
+		name:   "file_count_filtered",
+		files:  map[string]string{"a.go": "package a", "b.go": "package b"},
+		lim:    &ExtractLimits{MaxFiles: 1},
+		filter: ExtractFilter{Exclude: []string{"b.go"}},
+	}, {
+		name:  "file_size",
+		files: map[string]string{"a.go": "package a"},
+		lim:   &ExtractLimits{MaxFileSize: 5},
+		limit: "file size",
+	}, {
+		name:  "total_size",
+		files: map[string]string{"a.go": "package a", "b.go": "package b"},
+		lim:   &ExtractLimits{MaxTotalSize: 15},
+		limit: "total size",
+	}, {
+		name:  "ratio",
+		files: map[string]string{"zeroes": zeroes},
+		limit: "compression ratio",
+	}, {
+		name:  "ratio_unlimited",
+		files: map[string]string{"zeroes": zeroes},
+		lim:   &ExtractLimits{},
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				buf bytes.Buffer
+				zw  = zip.NewWriter(&buf)
+			)
+			for name, content := range tc.files {
+				w, err := zw.Create(prefix + name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := w.Write([]byte(content)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := zw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var opts []Option
+			if tc.lim != nil {
+				opts = append(opts, WithExtractLimits(*tc.lim))
+			}
+			cl := New("", nil, opts...)
+
+			err = cl.extractZip(zr, prefix, t.TempDir(), tc.filter, nil)
+			if tc.limit == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var limErr *ExtractLimitError
+			if !errors.As(err, &limErr) {
+				t.Fatalf("got error %v, want ExtractLimitError", err)
+			}
+			if limErr.Limit != tc.limit {
+				t.Errorf("got limit %s, want %s", limErr.Limit, tc.limit)
+			}
+		})
+	}
+}
+
+func TestUnsafePathError(t *testing.T) {
+	const prefix = "example.com/bad@v1.0.0/"
+
+	var (
+		buf bytes.Buffer
+		zw  = zip.NewWriter(&buf)
+	)
+	if _, err := zw.Create(prefix + "../escape"); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = New("", nil).extractZip(zr, prefix, t.TempDir(), ExtractFilter{}, nil)
+	var pathErr *UnsafePathError
+	if !errors.As(err, &pathErr) {
+		t.Fatalf("got error %v, want UnsafePathError", err)
+	}
+	if pathErr.File != prefix+"../escape" {
+		t.Errorf("got file %s", pathErr.File)
+	}
+}
@@ -0,0 +1,98 @@
+package goproxyclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+)
+
+// MajorUpgrade describes the major versions of a module
+// that are newer than the one at a given module path.
+// See [Client.MajorUpgrades].
+type MajorUpgrade struct {
+	// Path is the module path that was checked,
+	// e.g. example.com/foo.
+	Path string
+
+	// Deprecated is the deprecation message
+	// in the go.mod file of the latest version of Path,
+	// or the empty string if it is not deprecated.
+	// Old major versions are often deprecated in favor of new ones.
+	Deprecated string
+
+	// Newer lists the module paths of the higher major versions
+	// (e.g. example.com/foo/v2, example.com/foo/v3)
+	// with the latest version of each,
+	// in increasing order.
+	Newer []module.Version
+}
+
+// Latest returns the highest major version in u.Newer,
+// or the zero module.Version if there is none.
+// This is the upgrade to suggest.
+func (u MajorUpgrade) Latest() module.Version {
+	if len(u.Newer) == 0 {
+		return module.Version{}
+	}
+	return u.Newer[len(u.Newer)-1]
+}
+
+// MajorUpgrades finds the major versions of a module
+// newer than the one at module path mod.
+// In Go,
+// each major version from v2 up is a separate module
+// whose path has a suffix such as /v2
+// (or, for gopkg.in paths, .v2),
+// so these are not among the versions that [Client.List] reports for mod.
+//
+// MajorUpgrades probes successive major versions,
+// starting with the one after mod's own,
+// and stops at the first that the proxy does not have.
+// A candidate counts only if its go.mod file declares its path
+// (see [Client.CheckModulePath]).
+// It also reports whether mod is deprecated
+// (see [Client.Status]).
+func (cl Client) MajorUpgrades(ctx context.Context, mod string) (MajorUpgrade, error) {
+	prefix, pathMajor, ok := module.SplitPathVersion(mod)
+	if !ok {
+		return MajorUpgrade{}, fmt.Errorf("invalid module path %s", mod)
+	}
+
+	u := MajorUpgrade{Path: mod}
+
+	status, err := cl.Status(ctx, mod)
+	if err != nil {
+		return MajorUpgrade{}, errors.Wrapf(err, "getting status of %s", mod)
+	}
+	u.Deprecated = status.Deprecated
+
+	major := 1
+	if pathMajor != "" {
+		major, err = strconv.Atoi(strings.TrimLeft(pathMajor, "/.v"))
+		if err != nil {
+			return MajorUpgrade{}, errors.Wrapf(err, "parsing major version of %s", mod)
+		}
+	}
+
+	sep := "/v"
+	if strings.HasPrefix(mod, "gopkg.in/") {
+		sep = ".v"
+	}
+
+	for n := major + 1; ; n++ {
+		mv, ok, err := cl.probeModule(ctx, fmt.Sprintf("%s%s%d", prefix, sep, n))
+		if err != nil {
+			return MajorUpgrade{}, err
+		}
+		if !ok {
+			break
+		}
+		u.Newer = append(u.Newer, mv)
+	}
+
+	return u, nil
+}
@@ -0,0 +1,77 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/module"
+
+	"github.com/bobg/goproxyclient/goproxytest"
+)
+
+func TestMajorUpgrades(t *testing.T) {
+	fsys := fstest.MapFS{
+		"example.com/m/@latest":       {Data: []byte(`{"Version":"v1.4.0"}`)},
+		"example.com/m/@v/v1.4.0.mod": {Data: []byte("// Deprecated: use example.com/m/v3.\nmodule example.com/m\n")},
+
+		"example.com/m/v2/@latest":       {Data: []byte(`{"Version":"v2.1.0"}`)},
+		"example.com/m/v2/@v/v2.1.0.mod": {Data: []byte("module example.com/m/v2\n")},
+
+		"example.com/m/v3/@latest":       {Data: []byte(`{"Version":"v3.0.2"}`)},
+		"example.com/m/v3/@v/v3.0.2.mod": {Data: []byte("module example.com/m/v3\n")},
+
+		// Not reached, because there is no v4.
+		"example.com/m/v5/@latest":       {Data: []byte(`{"Version":"v5.0.0"}`)},
+		"example.com/m/v5/@v/v5.0.0.mod": {Data: []byte("module example.com/m/v5\n")},
+
+		"gopkg.in/y.v2/@latest":       {Data: []byte(`{"Version":"v2.4.0"}`)},
+		"gopkg.in/y.v2/@v/v2.4.0.mod": {Data: []byte("module gopkg.in/y.v2\n")},
+		"gopkg.in/y.v3/@latest":       {Data: []byte(`{"Version":"v3.0.1"}`)},
+		"gopkg.in/y.v3/@v/v3.0.1.mod": {Data: []byte("module gopkg.in/y.v3\n")},
+	}
+	s := httptest.NewServer(goproxytest.New(fsys))
+	defer s.Close()
+
+	var (
+		ctx = context.Background()
+		cl  = New(s.URL, nil)
+	)
+
+	got, err := cl.MajorUpgrades(ctx, "example.com/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MajorUpgrade{
+		Path:       "example.com/m",
+		Deprecated: "use example.com/m/v3.",
+		Newer: []module.Version{
+			{Path: "example.com/m/v2", Version: "v2.1.0"},
+			{Path: "example.com/m/v3", Version: "v3.0.2"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if latest := got.Latest(); latest.Path != "example.com/m/v3" {
+		t.Errorf("got latest %v, want example.com/m/v3", latest)
+	}
+
+	got, err = cl.MajorUpgrades(ctx, "example.com/m/v3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Newer) != 0 || got.Deprecated != "" {
+		t.Errorf("got %+v for v3, want no upgrades", got)
+	}
+
+	got, err = cl.MajorUpgrades(ctx, "gopkg.in/y.v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]module.Version{{Path: "gopkg.in/y.v3", Version: "v3.0.1"}}, got.Newer); diff != "" {
+		t.Errorf("gopkg.in mismatch (-want +got):\n%s", diff)
+	}
+}
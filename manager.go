@@ -0,0 +1,226 @@
+package goproxyclient
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/bobg/errors"
+)
+
+// Manager maintains a [Client] for each of many tenants,
+// such as the customers of a service that resolves modules on their behalf,
+// each with its own proxies, credentials, cache, and other options.
+// Clients are created on first use
+// and kept for reuse,
+// up to a maximum number;
+// beyond that,
+// the least recently used ones are evicted.
+// Create a Manager with [NewManager].
+//
+// A client evicted from a Manager
+// (or removed with [Manager.Remove])
+// has its idle connections closed,
+// but callers that still hold it may go on using it.
+// The Manager creates a new one the next time the tenant's client is requested.
+//
+// A Manager is safe for concurrent use by multiple goroutines.
+type Manager struct {
+	newClient  func(ctx context.Context, tenant string) (Client, error)
+	maxClients int
+
+	mu       sync.Mutex
+	clients  map[string]*list.Element // values are *managedClient
+	order    *list.List               // most recently used first
+	shutDown bool
+}
+
+var _ Shutdowner = (*Manager)(nil)
+
+type managedClient struct {
+	tenant string
+	ready  chan struct{} // closed when cl and err are set
+	cl     Client
+	err    error
+}
+
+// NewManager creates a [Manager] that keeps up to maxClients clients
+// (or any number, if maxClients is not positive),
+// creating the client for each tenant with newClient.
+func NewManager(maxClients int, newClient func(ctx context.Context, tenant string) (Client, error)) *Manager {
+	return &Manager{
+		newClient:  newClient,
+		maxClients: maxClients,
+		clients:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Client returns the client for the given tenant,
+// creating it if necessary.
+// Concurrent calls for a tenant whose client is being created
+// wait for it
+// and share the result,
+// including any error.
+// Errors are not kept:
+// the next call for the tenant tries again.
+func (m *Manager) Client(ctx context.Context, tenant string) (Client, error) {
+	m.mu.Lock()
+	if m.shutDown {
+		m.mu.Unlock()
+		return Client{}, ErrShutdown
+	}
+	if el, ok := m.clients[tenant]; ok {
+		m.order.MoveToFront(el)
+		m.mu.Unlock()
+
+		mc := el.Value.(*managedClient)
+		select {
+		case <-mc.ready:
+			return mc.cl, mc.err
+		case <-ctx.Done():
+			return Client{}, context.Cause(ctx)
+		}
+	}
+
+	mc := &managedClient{tenant: tenant, ready: make(chan struct{})}
+	m.clients[tenant] = m.order.PushFront(mc)
+	evicted := m.evict()
+	m.mu.Unlock()
+
+	closeManagedClients(evicted)
+
+	cl, err := m.newClient(ctx, tenant)
+	if err != nil {
+		err = errors.Wrapf(err, "creating client for tenant %s", tenant)
+	}
+	mc.cl, mc.err = cl, err
+	close(mc.ready)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.remove(mc)
+		return Client{}, err
+	}
+	if m.shutDown {
+		// The manager was shut down while the client was being created.
+		return Client{}, errors.Join(ErrShutdown, cl.Shutdown(context.WithoutCancel(ctx)))
+	}
+	return cl, nil
+}
+
+// Evict removes the least recently used clients
+// until there are no more than m.maxClients,
+// and returns them.
+// Clients still being created are not removed.
+// The caller must hold m.mu.
+func (m *Manager) evict() []*managedClient {
+	if m.maxClients <= 0 {
+		return nil
+	}
+
+	var result []*managedClient
+	for el := m.order.Back(); el != nil && m.order.Len() > m.maxClients; {
+		prev := el.Prev()
+		mc := el.Value.(*managedClient)
+		select {
+		case <-mc.ready:
+			m.remove(mc)
+			result = append(result, mc)
+		default:
+		}
+		el = prev
+	}
+	return result
+}
+
+// Remove removes mc from m, if it's there.
+// The caller must hold m.mu.
+func (m *Manager) remove(mc *managedClient) {
+	el, ok := m.clients[mc.tenant]
+	if !ok || el.Value != mc {
+		return
+	}
+	m.order.Remove(el)
+	delete(m.clients, mc.tenant)
+}
+
+func closeManagedClients(mcs []*managedClient) {
+	for _, mc := range mcs {
+		if mc.err == nil {
+			mc.cl.chain().closeIdleConnections()
+		}
+	}
+}
+
+// Remove evicts the client for the given tenant, if there is one,
+// e.g. because the tenant's configuration has changed.
+// A client still being created is not affected.
+func (m *Manager) Remove(tenant string) {
+	m.mu.Lock()
+	el, ok := m.clients[tenant]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	mc := el.Value.(*managedClient)
+	select {
+	case <-mc.ready:
+		m.remove(mc)
+	default:
+		mc = nil
+	}
+	m.mu.Unlock()
+
+	if mc != nil {
+		closeManagedClients([]*managedClient{mc})
+	}
+}
+
+// Len returns the number of clients the manager holds,
+// including any being created.
+func (m *Manager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.order.Len()
+}
+
+// Shutdown shuts down the manager and all of its clients
+// (see [Client.Shutdown]).
+// Later calls to [Manager.Client] fail with [ErrShutdown].
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.shutDown = true
+	var clients []*managedClient
+	for el := m.order.Front(); el != nil; el = el.Next() {
+		clients = append(clients, el.Value.(*managedClient))
+	}
+	clear(m.clients)
+	m.order.Init()
+	m.mu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(clients))
+	)
+	for i, mc := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-mc.ready:
+			case <-ctx.Done():
+				errs[i] = context.Cause(ctx)
+				return
+			}
+			if mc.err == nil {
+				errs[i] = errors.Wrapf(mc.cl.Shutdown(ctx), "shutting down client for tenant %s", mc.tenant)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
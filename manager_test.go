@@ -0,0 +1,151 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	var (
+		mu      sync.Mutex
+		created = make(map[string]int)
+		fail    atomic.Bool
+	)
+	m := NewManager(2, func(_ context.Context, tenant string) (Client, error) {
+		if fail.Load() {
+			return Client{}, errors.New("no such tenant")
+		}
+		mu.Lock()
+		created[tenant]++
+		mu.Unlock()
+		return New(s.URL, nil), nil
+	})
+
+	get := func(tenant string) Client {
+		t.Helper()
+		cl, err := m.Client(ctx, tenant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cl
+	}
+
+	a := get("a")
+	if _, _, _, err := a.Info(ctx, "example.com/multi", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if a2 := get("a"); a2 != a {
+		t.Error("got a different client for the same tenant")
+	}
+
+	get("b")
+	get("a") // b is now the least recently used
+	get("c") // evicts b
+	if n := m.Len(); n != 2 {
+		t.Errorf("got %d clients, want 2", n)
+	}
+	get("b") // recreates b, evicts a
+	get("c")
+	get("a") // recreates a, evicts b
+
+	want := map[string]int{"a": 2, "b": 2, "c": 1}
+	for tenant, n := range want {
+		if created[tenant] != n {
+			t.Errorf("tenant %s: got %d clients created, want %d", tenant, created[tenant], n)
+		}
+	}
+
+	// The evicted client is still usable.
+	if _, _, _, err := a.Info(ctx, "example.com/multi", "v1.0.0"); err != nil {
+		t.Errorf("using evicted client: %v", err)
+	}
+
+	// Errors are returned but not kept.
+	fail.Store(true)
+	if _, err := m.Client(ctx, "d"); err == nil {
+		t.Error("got no error")
+	}
+	fail.Store(false)
+	get("d")
+
+	m.Remove("d")
+	get("d")
+	if created["d"] != 2 {
+		t.Errorf("got %d clients created for d, want 2", created["d"])
+	}
+
+	c := get("c")
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Client(ctx, "a"); !errors.Is(err, ErrShutdown) {
+		t.Errorf("got %v from manager after shutdown, want %v", err, ErrShutdown)
+	}
+	if _, _, _, err := c.Info(ctx, "example.com/multi", "v1.0.0"); !errors.Is(err, ErrShutdown) {
+		t.Errorf("got %v from client after shutdown, want %v", err, ErrShutdown)
+	}
+}
+
+func TestManagerConcurrent(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		created atomic.Int32
+		release = make(chan struct{})
+	)
+	m := NewManager(1, func(context.Context, string) (Client, error) {
+		created.Add(1)
+		<-release
+		return New("off", nil), nil
+	})
+
+	const n = 10
+	var (
+		wg      sync.WaitGroup
+		clients = make([]Client, n)
+	)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cl, err := m.Client(ctx, "a")
+			if err != nil {
+				t.Error(err)
+			}
+			clients[i] = cl
+		}()
+	}
+
+	// A waiter can give up.
+	ctx2, cancel := context.WithCancel(ctx)
+	cancel()
+	for m.Len() == 0 {
+		// Wait for the first call to register the tenant.
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := m.Client(ctx2, "a"); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if got := created.Load(); got != 1 {
+		t.Errorf("got %d clients created, want 1", got)
+	}
+	for i, cl := range clients[1:] {
+		if cl != clients[0] {
+			t.Errorf("caller %d got a different client", i+1)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemCache is a [Clientish] that keeps recent responses from another Clientish in memory,
+// so that tools making the same requests over and over
+// (such as dependency analyzers)
+// don't fetch the same data each time.
+// Responses to info, @latest, list, and go.mod requests are cached,
+// each kind in its own least-recently-used cache
+// with its own size and time limits
+// (see [MemCacheConfig]).
+// Zip files are not cached,
+// since they can be large;
+// Zip requests go straight to the underlying Clientish.
+// Errors are not cached.
+//
+// A MemCache is safe for concurrent use by multiple goroutines.
+// Create one with [NewMemCache].
+type MemCache struct {
+	cl Clientish
+
+	info, latest, list, mod *lru
+}
+
+var _ Clientish = (*MemCache)(nil)
+
+// MemCacheConfig is the configuration of a [MemCache].
+type MemCacheConfig struct {
+	// Info is for info requests.
+	// A request for a noncanonical version
+	// (such as a branch name)
+	// uses Latest instead,
+	// since the version it refers to can change.
+	Info MemCacheLimits
+
+	// Latest is for @latest requests.
+	Latest MemCacheLimits
+
+	// List is for list requests.
+	List MemCacheLimits
+
+	// Mod is for go.mod requests.
+	// As with Info,
+	// a request for a noncanonical version uses Latest instead.
+	Mod MemCacheLimits
+}
+
+// MemCacheLimits are the limits on one kind of response in a [MemCache].
+type MemCacheLimits struct {
+	// MaxEntries is the maximum number of responses to keep.
+	// When it is exceeded,
+	// the least recently used one is discarded.
+	// Zero means not to cache responses of this kind.
+	MaxEntries int
+
+	// TTL is how long to keep a response.
+	// Zero means no limit.
+	TTL time.Duration
+}
+
+// DefaultMemCacheConfig is the configuration used by [NewMemCache] when none is given.
+// Info and go.mod responses for canonical versions never change,
+// so they are kept until evicted,
+// while other responses are kept for one minute.
+var DefaultMemCacheConfig = MemCacheConfig{
+	Info:   MemCacheLimits{MaxEntries: 10000},
+	Latest: MemCacheLimits{MaxEntries: 1000, TTL: time.Minute},
+	List:   MemCacheLimits{MaxEntries: 1000, TTL: time.Minute},
+	Mod:    MemCacheLimits{MaxEntries: 10000},
+}
+
+// NewMemCache creates a [MemCache] for cl.
+// If conf is nil,
+// [DefaultMemCacheConfig] is used.
+func NewMemCache(cl Clientish, conf *MemCacheConfig) *MemCache {
+	if conf == nil {
+		conf = &DefaultMemCacheConfig
+	}
+	return &MemCache{
+		cl:     cl,
+		info:   newLRU(conf.Info),
+		latest: newLRU(conf.Latest),
+		list:   newLRU(conf.List),
+		mod:    newLRU(conf.Mod),
+	}
+}
+
+type infoResult struct {
+	ver string
+	tm  time.Time
+	m   map[string]json.RawMessage
+}
+
+func newInfoResult(ver string, tm time.Time, m map[string]json.RawMessage, err error) (infoResult, error) {
+	return infoResult{ver: ver, tm: tm, m: m}, err
+}
+
+// Info implements [Clientish].
+func (c *MemCache) Info(ctx context.Context, mod, ver string) (string, time.Time, map[string]json.RawMessage, error) {
+	cache := c.info
+	if !immutable(ver) {
+		cache = c.latest
+	}
+	key := mod + "@" + ver
+	if v, ok := cache.get(key); ok {
+		r := v.(infoResult)
+		return r.ver, r.tm, maps.Clone(r.m), nil
+	}
+
+	canonicalVer, tm, m, err := c.cl.Info(ctx, mod, ver)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	cache.put(key, infoResult{ver: canonicalVer, tm: tm, m: maps.Clone(m)})
+	return canonicalVer, tm, m, nil
+}
+
+// Latest implements [Clientish].
+func (c *MemCache) Latest(ctx context.Context, mod string) (string, time.Time, map[string]json.RawMessage, error) {
+	key := mod + "@latest"
+	if v, ok := c.latest.get(key); ok {
+		r := v.(infoResult)
+		return r.ver, r.tm, maps.Clone(r.m), nil
+	}
+
+	ver, tm, m, err := c.cl.Latest(ctx, mod)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	c.latest.put(key, infoResult{ver: ver, tm: tm, m: maps.Clone(m)})
+	return ver, tm, m, nil
+}
+
+// List implements [Clientish].
+func (c *MemCache) List(ctx context.Context, mod string) ([]string, error) {
+	if v, ok := c.list.get(mod); ok {
+		return slices.Clone(v.([]string)), nil
+	}
+
+	versions, err := c.cl.List(ctx, mod)
+	if err != nil {
+		return nil, err
+	}
+	c.list.put(mod, slices.Clone(versions))
+	return versions, nil
+}
+
+// Mod implements [Clientish].
+func (c *MemCache) Mod(ctx context.Context, mod, ver string) (io.ReadCloser, error) {
+	cache := c.mod
+	if !immutable(ver) {
+		cache = c.latest
+	}
+	key := mod + "@" + ver + "/go.mod"
+	if v, ok := cache.get(key); ok {
+		return io.NopCloser(bytes.NewReader(v.([]byte))), nil
+	}
+
+	rc, err := c.cl.Mod(ctx, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+	if !cache.enabled() {
+		return rc, nil
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(key, data)
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Zip implements [Clientish].
+// It is not cached.
+func (c *MemCache) Zip(ctx context.Context, mod, ver string) (io.ReadCloser, error) {
+	return c.cl.Zip(ctx, mod, ver)
+}
+
+// Purge empties the cache.
+func (c *MemCache) Purge() {
+	for _, cache := range []*lru{c.info, c.latest, c.list, c.mod} {
+		cache.purge()
+	}
+}
+
+// lru is a least-recently-used cache
+// whose entries may also expire.
+type lru struct {
+	limits MemCacheLimits
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // values are *lruEntry
+	order   *list.List               // most recently used first
+}
+
+type lruEntry struct {
+	key     string
+	val     any
+	expires time.Time // zero if no TTL
+}
+
+func newLRU(limits MemCacheLimits) *lru {
+	return &lru{
+		limits:  limits,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *lru) enabled() bool {
+	return c.limits.MaxEntries > 0
+}
+
+func (c *lru) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*lruEntry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return e.val, true
+}
+
+func (c *lru) put(key string, val any) {
+	if !c.enabled() {
+		return
+	}
+
+	var expires time.Time
+	if c.limits.TTL > 0 {
+		expires = time.Now().Add(c.limits.TTL)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		el.Value = &lruEntry{key: key, val: val, expires: expires}
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, val: val, expires: expires})
+	for c.order.Len() > c.limits.MaxEntries {
+		el := c.order.Back()
+		c.order.Remove(el)
+		delete(c.entries, el.Value.(*lruEntry).key)
+	}
+}
+
+func (c *lru) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.order.Init()
+}
@@ -0,0 +1,128 @@
+package goproxyclient
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bobg/goproxyclient/goproxytest"
+)
+
+func TestMemCache(t *testing.T) {
+	fsys, err := fs.Sub(testdata, "testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := goproxytest.New(fsys)
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	var (
+		ctx = context.Background()
+		cl  = New(s.URL, nil)
+	)
+
+	// Requests tells how many requests reach the server during f.
+	requests := func(t *testing.T, f func() error) int64 {
+		t.Helper()
+
+		before := h.Requests()
+		if err := f(); err != nil {
+			t.Fatal(err)
+		}
+		return h.Requests() - before
+	}
+	info := func(mc *MemCache, mod, ver string) func() error {
+		return func() error {
+			_, _, _, err := mc.Info(ctx, mod, ver)
+			return err
+		}
+	}
+	list := func(mc *MemCache, mod string) func() error {
+		return func() error {
+			_, err := mc.List(ctx, mod)
+			return err
+		}
+	}
+	mod := func(mc *MemCache, mod, ver string) func() error {
+		return func() error {
+			rc, err := mc.Mod(ctx, mod, ver)
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			_, err = io.ReadAll(rc)
+			return err
+		}
+	}
+	zip := func(mc *MemCache, mod, ver string) func() error {
+		return func() error {
+			rc, err := mc.Zip(ctx, mod, ver)
+			if err != nil {
+				return err
+			}
+			return rc.Close()
+		}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		mc := NewMemCache(cl, nil)
+
+		for _, f := range []func() error{
+			info(mc, "example.com/multi", "v1.0.0"),
+			list(mc, "example.com/multi"),
+			mod(mc, "example.com/multi", "v1.0.0"),
+		} {
+			if n := requests(t, f); n != 1 {
+				t.Errorf("got %d requests on first call, want 1", n)
+			}
+			if n := requests(t, f); n != 0 {
+				t.Errorf("got %d requests on second call, want 0", n)
+			}
+		}
+
+		f := zip(mc, "example.com/multi", "v1.0.0")
+		if n := requests(t, f) + requests(t, f); n != 2 {
+			t.Errorf("got %d zip requests, want 2", n)
+		}
+
+		mc.Purge()
+		if n := requests(t, info(mc, "example.com/multi", "v1.0.0")); n != 1 {
+			t.Errorf("got %d requests after purge, want 1", n)
+		}
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		mc := NewMemCache(cl, &MemCacheConfig{
+			Info: MemCacheLimits{MaxEntries: 1},
+			List: MemCacheLimits{MaxEntries: 10, TTL: 10 * time.Millisecond},
+		})
+
+		// Alternating between two versions evicts each in turn.
+		for range 2 {
+			if n := requests(t, info(mc, "example.com/multi", "v1.0.0")); n != 1 {
+				t.Errorf("got %d requests for multi, want 1", n)
+			}
+			if n := requests(t, info(mc, "github.com/bobg/errors", "v1.1.0")); n != 1 {
+				t.Errorf("got %d requests for errors, want 1", n)
+			}
+		}
+
+		if n := requests(t, list(mc, "example.com/multi")) + requests(t, list(mc, "example.com/multi")); n != 1 {
+			t.Errorf("got %d list requests, want 1", n)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if n := requests(t, list(mc, "example.com/multi")); n != 1 {
+			t.Errorf("got %d list requests after expiry, want 1", n)
+		}
+
+		// Mod is not cached at all.
+		f := mod(mc, "example.com/multi", "v1.0.0")
+		if n := requests(t, f) + requests(t, f); n != 2 {
+			t.Errorf("got %d mod requests, want 2", n)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package goproxyclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Mirror copies module versions from a Go module proxy
+// (or anything else satisfying [Clientish])
+// into a [Storage],
+// in the layout of the Go module proxy protocol,
+// so that the storage can itself be served as a proxy.
+// Create one with [NewMirror].
+//
+// A Mirror is safe for concurrent use by multiple goroutines.
+type Mirror struct {
+	cl Clientish
+	st Storage
+
+	mu sync.Mutex // serializes updates to list files
+}
+
+// NewMirror creates a [Mirror] copying from cl into st.
+func NewMirror(cl Clientish, st Storage) *Mirror {
+	return &Mirror{cl: cl, st: st}
+}
+
+// Sync copies all the versions of a module,
+// as reported by [Clientish.List],
+// that are not already in the storage.
+func (m *Mirror) Sync(ctx context.Context, mod string) error {
+	versions, err := m.cl.List(ctx, mod)
+	if err != nil {
+		return errors.Wrapf(err, "listing versions of %s", mod)
+	}
+	for _, ver := range versions {
+		if err := m.SyncVersion(ctx, mod, ver); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncVersion copies the .info, .mod, and .zip files for a single module version into the storage,
+// skipping any that are already there,
+// and adds the version to the module's version list.
+// The version must be canonical.
+func (m *Mirror) SyncVersion(ctx context.Context, mod, ver string) error {
+	if err := module.Check(mod, ver); err != nil {
+		return err
+	}
+	escPath, err := module.EscapePath(mod)
+	if err != nil {
+		return errors.Wrapf(err, "escaping module path %s", mod)
+	}
+	escVer, err := module.EscapeVersion(ver)
+	if err != nil {
+		return errors.Wrapf(err, "escaping version %s", ver)
+	}
+	prefix := escPath + "/@v/" + escVer
+
+	copies := []struct {
+		suffix string
+		fetch  func() (io.ReadCloser, error)
+	}{
+		{".info", func() (io.ReadCloser, error) { return m.info(ctx, mod, ver) }},
+		{".mod", func() (io.ReadCloser, error) { return m.cl.Mod(ctx, mod, ver) }},
+		{".zip", func() (io.ReadCloser, error) { return m.cl.Zip(ctx, mod, ver) }},
+	}
+	for _, c := range copies {
+		name := prefix + c.suffix
+		if err := m.copy(ctx, name, c.fetch); err != nil {
+			return errors.Wrapf(err, "mirroring %s", name)
+		}
+	}
+
+	return m.addToList(ctx, escPath+"/@v/list", ver)
+}
+
+func (m *Mirror) info(ctx context.Context, mod, ver string) (io.ReadCloser, error) {
+	_, _, j, err := m.cl.Info(ctx, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(j)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding info")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *Mirror) copy(ctx context.Context, name string, fetch func() (io.ReadCloser, error)) error {
+	ok, err := m.st.Exists(ctx, name)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+
+	rc, err := fetch()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return m.st.Put(ctx, name, rc)
+}
+
+// AddToList adds ver to the version list in the named file,
+// creating it if necessary.
+func (m *Mirror) addToList(ctx context.Context, name, ver string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return addToStorageList(ctx, m.st, name, ver)
+}
+
+// AddToStorageList adds ver to the version list in the named file in st,
+// creating it if necessary.
+// Callers must serialize calls for the same file.
+func addToStorageList(ctx context.Context, st Storage, name, ver string) error {
+	versions, err := readStorageList(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(versions, ver) {
+		return nil
+	}
+	versions = append(versions, ver)
+	semver.Sort(versions)
+
+	var buf bytes.Buffer
+	for _, v := range versions {
+		buf.WriteString(v)
+		buf.WriteByte('\n')
+	}
+	return errors.Wrapf(st.Put(ctx, name, &buf), "updating %s", name)
+}
+
+func readStorageList(ctx context.Context, st Storage, name string) ([]string, error) {
+	rc, err := st.Get(ctx, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", name)
+	}
+	defer rc.Close()
+
+	var (
+		sc       = bufio.NewScanner(rc)
+		versions []string
+	)
+	for sc.Scan() {
+		if v := strings.TrimSpace(sc.Text()); v != "" {
+			versions = append(versions, v)
+		}
+	}
+	return versions, errors.Wrapf(sc.Err(), "reading %s", name)
+}
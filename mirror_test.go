@@ -0,0 +1,83 @@
+package goproxyclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	var (
+		cl  = New(s.URL, nil)
+		dir = DirStorage(t.TempDir())
+		m   = NewMirror(cl, dir)
+	)
+	if err := m.Sync(ctx, "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+	// Syncing again is a no-op.
+	if err := m.Sync(ctx, "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The mirror can be served as a proxy.
+	mirror := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer mirror.Close()
+	mcl := New(mirror.URL, nil)
+
+	versions, err := mcl.List(ctx, "example.com/multi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v1.0.0"}; !slices.Equal(versions, want) {
+		t.Errorf("got versions %v, want %v", versions, want)
+	}
+
+	ver, _, _, err := mcl.Info(ctx, "example.com/multi", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver != "v1.0.0" {
+		t.Errorf("got version %s, want v1.0.0", ver)
+	}
+
+	type fetchFunc func(context.Context, string, string) (io.ReadCloser, error)
+	pairs := []struct {
+		name           string
+		orig, mirrored fetchFunc
+	}{
+		{"zip", cl.Zip, mcl.Zip},
+		{"go.mod", cl.Mod, mcl.Mod},
+	}
+	for _, p := range pairs {
+		want := readAllFrom(t, ctx, p.orig)
+		got := readAllFrom(t, ctx, p.mirrored)
+		if string(got) != string(want) {
+			t.Errorf("mirrored %s differs from original", p.name)
+		}
+	}
+}
+
+func readAllFrom(t *testing.T, ctx context.Context, fetch func(context.Context, string, string) (io.ReadCloser, error)) []byte {
+	t.Helper()
+
+	rc, err := fetch(ctx, "example.com/multi", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,26 @@
+package goproxyclient
+
+import (
+	"context"
+	"io"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/modfile"
+)
+
+// ModFile gets and parses the go.mod file for a specific version of a Go module.
+func (cl Client) modFile(ctx context.Context, mod, ver string) (*modfile.File, error) {
+	rc, err := cl.Mod(ctx, mod, ver)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting go.mod for %s@%s", mod, ver)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading go.mod for %s@%s", mod, ver)
+	}
+
+	f, err := modfile.ParseLax(mod+"@"+ver+"/go.mod", data, nil)
+	return f, errors.Wrapf(err, "parsing go.mod for %s@%s", mod, ver)
+}
@@ -0,0 +1,92 @@
+package goproxyclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// PathMismatch is a warning that a module's go.mod file
+// declares a different module path from the one it was requested by.
+// This usually means the module is a fork or a mirror served at the wrong path,
+// or was requested with the wrong capitalization or major-version suffix.
+// The go command refuses to use such a module,
+// and proxies such as proxy.golang.org respond to requests for it with confusing 404 (Not Found) errors.
+//
+// PathMismatch implements error,
+// so it can be treated as one.
+type PathMismatch struct {
+	Requested, Declared string
+
+	// Version is the version whose go.mod file was checked.
+	Version string
+
+	// Kind classifies the mismatch:
+	//
+	//   - "case": the paths differ only in capitalization;
+	//   - "major": the paths differ only in their major-version suffixes (e.g. /v2);
+	//   - "missing": the go.mod file has no module directive;
+	//   - "other": the paths are otherwise different,
+	//     as when a fork has not updated its go.mod file.
+	Kind string
+}
+
+func (m *PathMismatch) Error() string {
+	switch m.Kind {
+	case "missing":
+		return fmt.Sprintf("go.mod for %s@%s has no module directive", m.Requested, m.Version)
+	case "case":
+		return fmt.Sprintf("module %s@%s declares its path as %s, which differs in capitalization", m.Requested, m.Version, m.Declared)
+	case "major":
+		return fmt.Sprintf("module %s@%s declares its path as %s, which has a different major-version suffix", m.Requested, m.Version, m.Declared)
+	}
+	return fmt.Sprintf("module %s@%s declares its path as %s (it may be a fork or an alias)", m.Requested, m.Version, m.Declared)
+}
+
+// CheckModulePath compares the module path declared in the go.mod file of mod@ver
+// with mod.
+// It returns a [*PathMismatch] describing any difference,
+// or nil if there is none.
+// The error result is for failures in fetching or parsing the go.mod file.
+func (cl Client) CheckModulePath(ctx context.Context, mod, ver string) (*PathMismatch, error) {
+	f, err := cl.modFile(ctx, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+	var declared string
+	if f.Module != nil {
+		declared = f.Module.Mod.Path
+	}
+	return comparePaths(mod, declared, ver), nil
+}
+
+// ComparePaths compares a requested module path with the one declared in its go.mod file,
+// returning a [*PathMismatch] or nil.
+func comparePaths(requested, declared, ver string) *PathMismatch {
+	if requested == declared {
+		return nil
+	}
+	m := &PathMismatch{Requested: requested, Declared: declared, Version: ver}
+	switch {
+	case declared == "":
+		m.Kind = "missing"
+	case strings.EqualFold(requested, declared):
+		m.Kind = "case"
+	case pathPrefix(requested) == pathPrefix(declared):
+		m.Kind = "major"
+	default:
+		m.Kind = "other"
+	}
+	return m
+}
+
+// PathPrefix returns modpath without its major-version suffix, if any.
+func pathPrefix(modpath string) string {
+	prefix, _, ok := module.SplitPathVersion(modpath)
+	if !ok {
+		return modpath
+	}
+	return prefix
+}
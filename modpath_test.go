@@ -0,0 +1,69 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bobg/goproxyclient/goproxytest"
+)
+
+func TestComparePaths(t *testing.T) {
+	cases := []struct {
+		requested, declared string
+		want                string // "" means no mismatch
+	}{
+		{"github.com/foo/bar", "github.com/foo/bar", ""},
+		{"github.com/Foo/bar", "github.com/foo/bar", "case"},
+		{"github.com/foo/bar", "github.com/foo/bar/v2", "major"},
+		{"github.com/foo/bar/v3", "github.com/foo/bar/v2", "major"},
+		{"gopkg.in/yaml.v3", "gopkg.in/yaml.v2", "major"},
+		{"github.com/fork/bar", "github.com/foo/bar", "other"},
+		{"github.com/foo/bar", "", "missing"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.requested+"_"+tc.declared, func(t *testing.T) {
+			m := comparePaths(tc.requested, tc.declared, "v1.0.0")
+			if tc.want == "" {
+				if m != nil {
+					t.Errorf("got %v, want nil", m)
+				}
+				return
+			}
+			if m == nil {
+				t.Fatalf("got nil, want %s mismatch", tc.want)
+			}
+			if m.Kind != tc.want {
+				t.Errorf("got kind %s, want %s", m.Kind, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckModulePath(t *testing.T) {
+	fsys := fstest.MapFS{
+		"github.com/fork/bar/@v/v1.0.0.mod": {Data: []byte("module github.com/foo/bar\n")},
+		"github.com/foo/bar/@v/v1.0.0.mod":  {Data: []byte("module github.com/foo/bar\n")},
+	}
+	s := httptest.NewServer(goproxytest.New(fsys))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+
+	m, err := cl.CheckModulePath(context.Background(), "github.com/fork/bar", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.Kind != "other" || m.Declared != "github.com/foo/bar" {
+		t.Errorf("got %+v", m)
+	}
+
+	m, err = cl.CheckModulePath(context.Background(), "github.com/foo/bar", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != nil {
+		t.Errorf("got %v, want nil", m)
+	}
+}
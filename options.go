@@ -0,0 +1,152 @@
+package goproxyclient
+
+import (
+	"crypto/ed25519"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Option is the type of an option that can be passed to [New].
+type Option func(*config)
+
+type config struct {
+	auth   []Authenticator
+	compat bool
+
+	bandwidth         *rate.Limiter
+	downloadBandwidth int
+
+	syncWrites bool
+
+	idleTimeout           time.Duration
+	tlsTimeout            time.Duration
+	responseHeaderTimeout time.Duration
+	metadataTimeout       time.Duration
+
+	outboundProxy    *url.URL // nil means connect directly
+	outboundProxySet bool     // if false, use the environment
+	upstreamProxies  map[string]*url.URL
+	upstreams        map[string]upstreamConfig // see WithUpstreamConfig
+
+	dial dialConfig
+
+	rateLimit    rate.Limit
+	rateBurst    int
+	hostLimiters map[string]*rate.Limiter // populated by New
+
+	hostRateLimiters map[string]*rate.Limiter // see WithHostRateLimiter
+
+	retry         RetryPolicy
+	retryAfterMax time.Duration
+
+	breaker *CircuitBreakerPolicy // nil means no circuit breakers
+
+	race          bool
+	hedge         time.Duration // see WithHedge
+	listUnion     bool
+	highestLatest bool
+
+	requestIDHeader string
+	tenantHeader    string
+	logger          *slog.Logger
+	journal         *Journal
+
+	tenantLimiters *tenantLimiters // see WithTenantRateLimit
+	scheduler      *scheduler      // see WithScheduler
+
+	verified      *VerifiedDB
+	checksumDB    ChecksumDB
+	goSum         *GoSum
+	quarantineDir string
+	hashers       []Hasher
+
+	extractLimits *ExtractLimits // nil means DefaultExtractLimits
+
+	scanners []Scanner
+	policy   *Policy
+
+	work workTracker
+
+	chain    atomic.Pointer[chain] // see Client.Reload
+	reloadMu sync.Mutex            // serializes reloads
+	hc       *http.Client          // passed to New, NewFromConfig, or Reload; protected by reloadMu
+
+	flights flightGroup
+
+	versionTimes versionTimeCache
+
+	attestDir string
+	attestKey ed25519.PrivateKey
+
+	vcsPolicy   VCSPolicy
+	insecure    PathPatterns
+	vcsCacheDir string
+	direct      *direct // populated by newChain if there is a "direct" entry
+
+	cacheDir     string
+	cacheMaxSize int64
+	cacheTTL     time.Duration
+	cache        *diskCache // populated by NewFromConfig if there is a cacheDir
+}
+
+// WithAuth adds an [Authenticator] to the client.
+// Each request to a proxy is passed to every Authenticator in the order they were added,
+// giving each a chance to add credentials for the request's host.
+func WithAuth(a Authenticator) Option {
+	return func(c *config) {
+		c.auth = append(c.auth, a)
+	}
+}
+
+// WithCompat is an [Option] that makes the client tolerate known deviations from the Go module proxy protocol
+// in servers such as JFrog Artifactory and Sonatype Nexus:
+//
+//   - A 401 (Unauthorized) response is treated as 404 (Not Found),
+//     since some servers send it for missing modules when the request lacks credentials;
+//   - A 200 (OK) response with an HTML body (an error or login page) is treated as 404;
+//   - When the @latest endpoint fails,
+//     the latest version is determined from the version list instead.
+//
+// The effect is that fallback to the next proxy in the chain works as it would with a conforming server.
+func WithCompat() Option {
+	return func(c *config) {
+		c.compat = true
+	}
+}
+
+// WithVCSPolicy sets the [VCSPolicy] governing which modules
+// may be fetched from which kinds of version control repositories
+// for a "direct" entry in the client's list of proxies.
+// The default is the zero VCSPolicy.
+func WithVCSPolicy(p VCSPolicy) Option {
+	return func(c *config) {
+		c.vcsPolicy = p
+	}
+}
+
+// WithInsecure sets the modules,
+// in the format of the go command's GOINSECURE environment variable,
+// whose repositories may be fetched over insecure schemes (http and git)
+// for a "direct" entry in the client's list of proxies.
+// By default only https, ssh, and git+ssh repositories are used.
+func WithInsecure(p PathPatterns) Option {
+	return func(c *config) {
+		c.insecure = p
+	}
+}
+
+// WithVCSCacheDir sets the directory in which repositories are cached
+// for a "direct" entry in the client's list of proxies.
+// The default is goproxyclient/vcs in the user's cache directory
+// (see [os.UserCacheDir]).
+func WithVCSCacheDir(dir string) Option {
+	return func(c *config) {
+		c.vcsCacheDir = dir
+	}
+}
@@ -0,0 +1,84 @@
+package goproxyclient
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WithOutboundProxy is an [Option] that routes all requests
+// through the outbound proxy at proxyURL,
+// regardless of the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables.
+// The URL scheme may be http or https (for an HTTP CONNECT proxy)
+// or socks5 (for a SOCKS5 proxy),
+// and may include a username and password.
+// A nil proxyURL means to connect directly,
+// again ignoring the environment.
+//
+// See also [WithUpstreamOutboundProxy].
+//
+// This option takes effect only when the [http.Client] passed to [New]
+// is nil or has a Transport that is nil or an [*http.Transport].
+func WithOutboundProxy(proxyURL *url.URL) Option {
+	return func(c *config) {
+		c.outboundProxy = proxyURL
+		c.outboundProxySet = true
+	}
+}
+
+// WithUpstreamOutboundProxy is like [WithOutboundProxy]
+// but applies only to requests for the Go module proxy whose base URL is upstream
+// (one of the URLs in the GOPROXY string passed to [New]).
+// It takes precedence over [WithOutboundProxy] for that upstream.
+func WithUpstreamOutboundProxy(upstream string, proxyURL *url.URL) Option {
+	return func(c *config) {
+		if c.upstreamProxies == nil {
+			c.upstreamProxies = make(map[string]*url.URL)
+		}
+		c.upstreamProxies[strings.TrimRight(upstream, "/")] = proxyURL
+	}
+}
+
+// ClientFor returns the HTTP client to use for the upstream proxy at baseURL.
+// This is hc,
+// modified if necessary to use the configured outbound proxy, dialing options, and timeouts.
+func (c *config) clientFor(baseURL string, hc *http.Client) *http.Client {
+	proxyURL, setProxy := c.upstreamProxies[baseURL]
+	if !setProxy && c.outboundProxySet {
+		proxyURL, setProxy = c.outboundProxy, true
+	}
+	if !setProxy && !c.dial.isSet() && c.tlsTimeout == 0 && c.responseHeaderTimeout == 0 {
+		return hc
+	}
+
+	var transport *http.Transport
+	switch t := hc.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return hc
+	}
+
+	if setProxy {
+		if proxyURL == nil {
+			transport.Proxy = nil
+		} else {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	if c.dial.isSet() {
+		transport.DialContext = c.dial.dialContext
+	}
+	if c.tlsTimeout != 0 {
+		transport.TLSHandshakeTimeout = c.tlsTimeout
+	}
+	if c.responseHeaderTimeout != 0 {
+		transport.ResponseHeaderTimeout = c.responseHeaderTimeout
+	}
+
+	hc2 := *hc
+	hc2.Transport = transport
+	return &hc2
+}
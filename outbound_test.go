@@ -0,0 +1,69 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOutboundProxy(t *testing.T) {
+	ctx := context.Background()
+
+	// The outbound proxy serves every request itself,
+	// whatever host it names.
+	var proxied atomic.Int64
+	h := testHandler(nil)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxied.Add(1)
+		h.ServeHTTP(w, req)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	direct := httptest.NewServer(testHandler(nil))
+	defer direct.Close()
+
+	t.Run("all", func(t *testing.T) {
+		proxied.Store(0)
+
+		cl := New("http://upstream.invalid", nil, WithOutboundProxy(proxyURL))
+		if _, err := cl.List(ctx, "github.com/bobg/errors"); err != nil {
+			t.Fatal(err)
+		}
+		if n := proxied.Load(); n != 1 {
+			t.Errorf("got %d proxied requests, want 1", n)
+		}
+	})
+
+	t.Run("per_upstream", func(t *testing.T) {
+		proxied.Store(0)
+
+		cl := New(
+			direct.URL+"|http://upstream.invalid",
+			nil,
+			WithOutboundProxy(proxyURL),
+			WithUpstreamOutboundProxy(direct.URL, nil),
+		)
+		if _, err := cl.List(ctx, "github.com/bobg/errors"); err != nil {
+			t.Fatal(err)
+		}
+		if n := proxied.Load(); n != 0 {
+			t.Errorf("got %d proxied requests, want 0", n)
+		}
+
+		// Not in the direct server's testdata, so it falls through to the proxied upstream.
+		if _, err := cl.List(ctx, "example.com/nonexistent"); err == nil {
+			t.Fatal("got nil error, want not found")
+		}
+		if n := proxied.Load(); n != 1 {
+			t.Errorf("got %d proxied requests, want 1", n)
+		}
+	})
+}
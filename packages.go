@@ -0,0 +1,119 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"go/build"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/bobg/errors"
+)
+
+// Packages returns the import paths of the packages contained in a specific version of a Go module,
+// sorted.
+// It determines these by fetching the module's zip file and examining its directory structure.
+//
+// A directory counts as a package if it contains at least one .go file that is not a test file.
+// Directories named testdata or vendor, or beginning with . or _, are ignored,
+// as the go command ignores them.
+//
+// If bctx is nil,
+// build constraints are ignored,
+// so the result includes packages that build only on some platforms.
+// Otherwise bctx is used to evaluate build constraints on each .go file
+// (as with [build.Context.MatchFile]),
+// and a directory counts as a package only if some non-test file matches.
+func (cl Client) Packages(ctx context.Context, mod, ver string, bctx *build.Context) ([]string, error) {
+	zr, err := cl.zipReader(ctx, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := mod + "@" + ver + "/"
+
+	var bc build.Context
+	if bctx != nil {
+		bc = *bctx
+		bc.JoinPath = path.Join
+		bc.OpenFile = func(name string) (io.ReadCloser, error) {
+			return zr.Open(prefix + name)
+		}
+	}
+
+	pkgs := make(map[string]bool)
+	for _, f := range zr.File {
+		name, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok {
+			return nil, errors.Newf("file %s in zip for %s@%s lacks the expected prefix", f.Name, mod, ver)
+		}
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		dir, file := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+		if pkgs[dir] || ignoredDir(dir) || strings.HasPrefix(file, ".") || strings.HasPrefix(file, "_") {
+			continue
+		}
+
+		if bctx != nil {
+			match, err := bc.MatchFile(dir, file)
+			if err != nil {
+				return nil, errors.Wrapf(err, "evaluating build constraints for %s", f.Name)
+			}
+			if !match {
+				continue
+			}
+		}
+
+		pkgs[dir] = true
+	}
+
+	result := make([]string, 0, len(pkgs))
+	for dir := range pkgs {
+		if dir == "" {
+			result = append(result, mod)
+		} else {
+			result = append(result, mod+"/"+dir)
+		}
+	}
+	slices.Sort(result)
+
+	return result, nil
+}
+
+// IgnoredDir tells whether the given slash-separated directory path
+// (relative to the module root)
+// contains an element that the go command ignores when looking for packages.
+func ignoredDir(dir string) bool {
+	if dir == "" {
+		return false
+	}
+	for _, elem := range strings.Split(dir, "/") {
+		if elem == "testdata" || elem == "vendor" || strings.HasPrefix(elem, ".") || strings.HasPrefix(elem, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+// ZipReader fetches the zip file for a module version into memory.
+func (cl Client) zipReader(ctx context.Context, mod, ver string) (*zip.Reader, error) {
+	rc, err := cl.Zip(ctx, mod, ver)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting zip for %s@%s", mod, ver)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading zip for %s@%s", mod, ver)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	return zr, errors.Wrapf(err, "parsing zip for %s@%s", mod, ver)
+}
@@ -0,0 +1,88 @@
+package goproxyclient
+
+import (
+	"context"
+	"go/build"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPackages(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	var (
+		ctx = context.Background()
+		cl  = New(s.URL, nil)
+	)
+
+	t.Run("ignore_constraints", func(t *testing.T) {
+		got, err := cl.Packages(ctx, "example.com/multi", "v1.0.0", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"example.com/multi", "example.com/multi/sub", "example.com/multi/winonly"}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("linux", func(t *testing.T) {
+		bctx := build.Default
+		bctx.GOOS, bctx.GOARCH = "linux", "amd64"
+
+		got, err := cl.Packages(ctx, "example.com/multi", "v1.0.0", &bctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"example.com/multi", "example.com/multi/sub"}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestImports(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	var (
+		ctx = context.Background()
+		cl  = New(s.URL, nil)
+	)
+
+	g, err := cl.Imports(ctx, "example.com/multi", "v1.0.0", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"example.com/multi":         {"example.com/multi/sub", "fmt", "github.com/bobg/errors"},
+		"example.com/multi/sub":     {"strings"},
+		"example.com/multi/winonly": {"golang.org/x/sys/windows"},
+	}
+	if diff := cmp.Diff(want, g.Packages); diff != "" {
+		t.Errorf("packages mismatch (-want +got):\n%s", diff)
+	}
+
+	if got, want := g.External(), []string{"github.com/bobg/errors", "golang.org/x/sys/windows"}; !slices.Equal(got, want) {
+		t.Errorf("got external %v, want %v", got, want)
+	}
+	if got, want := g.Importers("golang.org/x/sys", true), []string{"example.com/multi/winonly"}; !slices.Equal(got, want) {
+		t.Errorf("got importers %v, want %v", got, want)
+	}
+	if got := g.Importers("golang.org/x/sys", false); len(got) != 0 {
+		t.Errorf("got importers %v, want none", got)
+	}
+
+	g, err = cl.Imports(ctx, "example.com/multi", "v1.0.0", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.Packages["example.com/multi"], []string{"example.com/multi/sub", "fmt", "github.com/bobg/errors", "testing"}; !slices.Equal(got, want) {
+		t.Errorf("with tests, got %v, want %v", got, want)
+	}
+}
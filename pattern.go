@@ -0,0 +1,110 @@
+package goproxyclient
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// PathPatterns is a list of module path prefix patterns,
+// as used by the go command's GOPRIVATE, GONOPROXY, and GONOSUMDB environment variables
+// (see https://go.dev/ref/mod#private-module-privacy).
+// Create one with [ParsePathPatterns].
+// The zero PathPatterns matches nothing.
+type PathPatterns struct {
+	globs []string
+}
+
+// ParsePathPatterns parses a comma-separated list of module path prefix patterns.
+// Each pattern is a glob in the syntax of [path.Match]
+// that matches a module path if it matches a prefix of it
+// consisting of the same number of path elements.
+// For example, "*.corp.example.com" matches "git.corp.example.com/foo/bar",
+// and "example.com/*/internal" matches "example.com/a/internal/b".
+// Empty patterns and trailing slashes are ignored.
+//
+// Unlike the go command,
+// which silently ignores malformed patterns,
+// this returns an error for them.
+func ParsePathPatterns(list string) (PathPatterns, error) {
+	var p PathPatterns
+	for _, glob := range strings.Split(list, ",") {
+		glob = strings.TrimSuffix(glob, "/")
+		if glob == "" {
+			continue
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			return PathPatterns{}, fmt.Errorf("malformed pattern %q: %w", glob, err)
+		}
+		p.globs = append(p.globs, glob)
+	}
+	return p, nil
+}
+
+// Match tells whether modpath matches any of the patterns in p.
+func (p PathPatterns) Match(modpath string) bool {
+	for _, glob := range p.globs {
+		if matchPathPattern(glob, modpath) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the patterns in p as a comma-separated list.
+func (p PathPatterns) String() string {
+	return strings.Join(p.globs, ",")
+}
+
+// MarshalText implements [encoding.TextMarshaler],
+// producing the same result as [PathPatterns.String].
+func (p PathPatterns) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler]
+// by parsing text with [ParsePathPatterns].
+func (p *PathPatterns) UnmarshalText(text []byte) error {
+	parsed, err := ParsePathPatterns(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// MatchPathPatterns tells whether modpath matches any of the comma-separated module path prefix patterns in list,
+// in the manner of the go command
+// (see [ParsePathPatterns]).
+// Malformed patterns never match.
+func MatchPathPatterns(list, modpath string) bool {
+	for _, glob := range strings.Split(list, ",") {
+		glob = strings.TrimSuffix(glob, "/")
+		if glob != "" && matchPathPattern(glob, modpath) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPathPattern tells whether glob matches the prefix of modpath
+// with the same number of path elements as glob.
+func matchPathPattern(glob, modpath string) bool {
+	n := strings.Count(glob, "/")
+	prefix := modpath
+	for i := 0; i < len(modpath); i++ {
+		if modpath[i] == '/' {
+			if n == 0 {
+				prefix = modpath[:i]
+				break
+			}
+			n--
+		}
+	}
+	if n > 0 {
+		// modpath has fewer elements than glob.
+		return false
+	}
+	matched, _ := path.Match(glob, prefix)
+	return matched
+}
@@ -0,0 +1,57 @@
+package goproxyclient
+
+import (
+	"testing"
+
+	"golang.org/x/mod/module"
+)
+
+func TestPathPatterns(t *testing.T) {
+	cases := []struct {
+		patterns string
+		modpath  string
+		want     bool
+	}{
+		{"", "example.com/foo", false},
+		{"example.com", "example.com", true},
+		{"example.com", "example.com/foo", true},
+		{"example.com", "example.community/foo", false},
+		{"example.com/", "example.com/foo", true},
+		{"example.com/foo", "example.com", false},
+		{"*.corp.example.com", "git.corp.example.com/foo/bar", true},
+		{"*.corp.example.com", "corp.example.com/foo", false},
+		{"example.com/*/internal", "example.com/a/internal/b", true},
+		{"example.com/*/internal", "example.com/a/b/internal", false},
+		{"foo.com,bar.com", "bar.com/x", true},
+		{",,bar.com,", "bar.com/x", true},
+		{"ex?mple.com", "example.com/x", true},
+		{"example.com/[a-c]*", "example.com/bar", true},
+		{"example.com/[a-c]*", "example.com/dar", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.patterns+"_"+tc.modpath, func(t *testing.T) {
+			p, err := ParsePathPatterns(tc.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.Match(tc.modpath); got != tc.want {
+				t.Errorf("Match: got %v, want %v", got, tc.want)
+			}
+			if got := MatchPathPatterns(tc.patterns, tc.modpath); got != tc.want {
+				t.Errorf("MatchPathPatterns: got %v, want %v", got, tc.want)
+			}
+			// Agree with the go command's implementation.
+			if got := module.MatchPrefixPatterns(tc.patterns, tc.modpath); got != tc.want {
+				t.Errorf("module.MatchPrefixPatterns: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := ParsePathPatterns("example.com/[a-"); err == nil {
+		t.Error("got nil error for malformed pattern")
+	}
+	if MatchPathPatterns("example.com/[a-", "example.com/a") {
+		t.Error("malformed pattern matched")
+	}
+}
@@ -0,0 +1,255 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+)
+
+// Policy is a set of rules governing which modules a client may fetch.
+// Add one to a client with [WithPolicy].
+// Violations produce a [*PolicyError] wrapping a [*PolicyViolation].
+//
+// A Policy can be loaded from a JSON file with [LoadPolicy].
+type Policy struct {
+	// Allow, if not empty, lists the only modules that may be fetched.
+	Allow PathPatterns `json:"allow,omitempty"`
+
+	// Deny lists modules that may not be fetched,
+	// even if they match Allow.
+	Deny PathPatterns `json:"deny,omitempty"`
+
+	// MaxAge, if positive,
+	// is the maximum age of a module version
+	// (according to its .info file)
+	// whose zip file may be fetched.
+	// In JSON it is a string such as "8760h".
+	MaxAge time.Duration `json:"-"`
+
+	// Licenses, if not empty,
+	// lists the SPDX identifiers (such as "MIT")
+	// of the licenses a module's zip file may have
+	// (as determined by [DetectLicenses]).
+	// Every license detected must be in the list,
+	// and a module with no detectable license is rejected.
+	Licenses []string `json:"licenses,omitempty"`
+
+	// RequireVerified means that a module's zip file may be fetched
+	// only if it has a hash in the client's [VerifiedDB]
+	// (see [WithVerifiedDB])
+	// and matches it.
+	RequireVerified bool `json:"require_verified,omitempty"`
+}
+
+// PolicyViolation is the error wrapped in a [*PolicyError] when a [Policy] rejects a module.
+type PolicyViolation struct {
+	// Rule is the rule that was violated:
+	// "allow", "deny", "max_age", "licenses", or "require_verified".
+	Rule string
+
+	Detail string
+}
+
+func (v *PolicyViolation) Error() string {
+	return fmt.Sprintf("violates %s policy: %s", v.Rule, v.Detail)
+}
+
+// WithPolicy is an [Option] that makes the client enforce a [Policy].
+//
+// The Allow and Deny rules apply to every request.
+// The other rules apply to module zip files,
+// which, as with [WithScanner],
+// are downloaded to temporary files and checked before being returned by [Client.Zip].
+func WithPolicy(p *Policy) Option {
+	return func(c *config) {
+		c.policy = p
+	}
+}
+
+// LoadPolicy reads a [Policy] from a JSON file
+// with fields "allow", "deny", "max_age", "licenses", and "require_verified".
+// For example:
+//
+//	{
+//	  "allow": "github.com,golang.org/x",
+//	  "deny": "github.com/evil",
+//	  "max_age": "43800h",
+//	  "licenses": ["MIT", "Apache-2.0", "BSD-3-Clause"],
+//	  "require_verified": false
+//	}
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", path)
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+	return &p, nil
+}
+
+type policyJSON struct {
+	Allow           PathPatterns `json:"allow,omitempty"`
+	Deny            PathPatterns `json:"deny,omitempty"`
+	MaxAge          string       `json:"max_age,omitempty"`
+	Licenses        []string     `json:"licenses,omitempty"`
+	RequireVerified bool         `json:"require_verified,omitempty"`
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (p Policy) MarshalJSON() ([]byte, error) {
+	pj := policyJSON{
+		Allow:           p.Allow,
+		Deny:            p.Deny,
+		Licenses:        p.Licenses,
+		RequireVerified: p.RequireVerified,
+	}
+	if p.MaxAge > 0 {
+		pj.MaxAge = p.MaxAge.String()
+	}
+	return json.Marshal(pj)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (p *Policy) UnmarshalJSON(data []byte) error {
+	var pj policyJSON
+	if err := json.Unmarshal(data, &pj); err != nil {
+		return err
+	}
+	var maxAge time.Duration
+	if pj.MaxAge != "" {
+		var err error
+		maxAge, err = time.ParseDuration(pj.MaxAge)
+		if err != nil {
+			return errors.Wrap(err, "parsing max_age")
+		}
+	}
+	*p = Policy{
+		Allow:           pj.Allow,
+		Deny:            pj.Deny,
+		MaxAge:          maxAge,
+		Licenses:        pj.Licenses,
+		RequireVerified: pj.RequireVerified,
+	}
+	return nil
+}
+
+// CheckModule checks mod against the Allow and Deny rules of the policy.
+// The result is nil or a [*PolicyViolation].
+func (p *Policy) CheckModule(mod string) error {
+	if p == nil {
+		return nil
+	}
+	if p.Deny.Match(mod) {
+		return &PolicyViolation{Rule: "deny", Detail: fmt.Sprintf("%s matches %s", mod, p.Deny)}
+	}
+	if p.Allow.String() != "" && !p.Allow.Match(mod) {
+		return &PolicyViolation{Rule: "allow", Detail: fmt.Sprintf("%s does not match %s", mod, p.Allow)}
+	}
+	return nil
+}
+
+// CheckLicenses checks the licenses detected in a module
+// (see [DetectLicenses])
+// against the Licenses rule of the policy.
+// The result is nil or a [*PolicyViolation].
+func (p *Policy) CheckLicenses(licenses []string) error {
+	if p == nil || len(p.Licenses) == 0 {
+		return nil
+	}
+	if len(licenses) == 0 {
+		return &PolicyViolation{Rule: "licenses", Detail: "no license found"}
+	}
+	for _, l := range licenses {
+		if !slices.Contains(p.Licenses, l) {
+			return &PolicyViolation{Rule: "licenses", Detail: fmt.Sprintf("license %s is not one of %s", l, strings.Join(p.Licenses, ", "))}
+		}
+	}
+	return nil
+}
+
+// CheckTime checks the publication time of a module version against the MaxAge rule of the policy,
+// as of now.
+// The result is nil or a [*PolicyViolation].
+func (p *Policy) CheckTime(tm, now time.Time) error {
+	if p == nil || p.MaxAge <= 0 {
+		return nil
+	}
+	if age := now.Sub(tm); age > p.MaxAge {
+		return &PolicyViolation{Rule: "max_age", Detail: fmt.Sprintf("version is %s old, more than %s", age.Round(time.Hour), p.MaxAge)}
+	}
+	return nil
+}
+
+// checksZips tells whether the policy has rules that require inspecting module zip files.
+func (p *Policy) checksZips() bool {
+	return p != nil && (p.MaxAge > 0 || len(p.Licenses) > 0 || p.RequireVerified)
+}
+
+// CheckModulePolicy checks mod against the Allow and Deny rules of the client's policy, if any.
+// The kind and ver describe the request being made,
+// as in [Artifact].
+func (cl Client) checkModulePolicy(mod, ver, kind string) error {
+	err := cl.conf.policy.CheckModule(mod)
+	if err == nil {
+		return nil
+	}
+	a := Artifact{Module: mod, Version: ver, Kind: kind, Path: mod}
+	if escPath, escErr := module.EscapePath(mod); escErr == nil {
+		a.Path = escPath
+	}
+	return &PolicyError{Artifact: a, Err: err}
+}
+
+// EnforceZipPolicy checks a downloaded zip file against the rules of the client's policy, if any,
+// other than Allow and Deny.
+// The verified argument tells whether the file has been checked against the client's [VerifiedDB].
+func (cl Client) enforceZipPolicy(ctx context.Context, d *downloadedZip, mod, ver string, verified bool) error {
+	p := cl.conf.policy
+	if !p.checksZips() {
+		return nil
+	}
+
+	err := func() error {
+		if p.RequireVerified && !verified {
+			return &PolicyViolation{Rule: "require_verified", Detail: "no verified hash"}
+		}
+		if p.MaxAge > 0 {
+			_, tm, _, err := cl.Info(ctx, mod, ver)
+			if err != nil {
+				return errors.Wrap(err, "getting version time")
+			}
+			if err := p.CheckTime(tm, time.Now()); err != nil {
+				return err
+			}
+		}
+		if len(p.Licenses) > 0 {
+			zr, err := zip.NewReader(d, d.size)
+			if err != nil {
+				return errors.Wrap(err, "parsing zip")
+			}
+			if err := p.CheckLicenses(DetectLicenses(zr, mod+"@"+ver+"/")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if err == nil {
+		return nil
+	}
+
+	a, aErr := newArtifact(mod, ver, "zip")
+	if aErr != nil {
+		return errors.Join(err, aErr)
+	}
+	return &PolicyError{Artifact: a, Err: err}
+}
@@ -0,0 +1,128 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	db, err := OpenVerifiedDB(filepath.Join(t.TempDir(), "verified"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mustParse := func(list string) PathPatterns {
+		p, err := ParsePathPatterns(list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	cases := []struct {
+		name   string
+		policy Policy
+		rule   string // "" means no violation
+	}{
+		{name: "empty"},
+		{name: "allowed", policy: Policy{Allow: mustParse("example.com")}},
+		{name: "not_allowed", policy: Policy{Allow: mustParse("golang.org")}, rule: "allow"},
+		{name: "denied", policy: Policy{Allow: mustParse("example.com"), Deny: mustParse("example.com/multi")}, rule: "deny"},
+		{name: "license_ok", policy: Policy{Licenses: []string{"MIT", "BSD-3-Clause"}}},
+		{name: "license_bad", policy: Policy{Licenses: []string{"Apache-2.0"}}, rule: "licenses"},
+		{name: "too_old", policy: Policy{MaxAge: 24 * time.Hour}, rule: "max_age"},
+		{name: "not_too_old", policy: Policy{MaxAge: 100 * 365 * 24 * time.Hour}},
+		{name: "unverified", policy: Policy{RequireVerified: true}, rule: "require_verified"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cl := New(s.URL, nil, WithPolicy(&tc.policy), WithVerifiedDB(db))
+
+			rc, err := cl.Zip(ctx, "example.com/multi", "v1.0.0")
+			if err == nil {
+				rc.Close()
+			}
+			checkViolation(t, err, tc.rule)
+
+			err = cl.Extract(ctx, "example.com/multi", "v1.0.0", t.TempDir())
+			checkViolation(t, err, tc.rule)
+		})
+	}
+
+	// Allow and Deny apply to other requests too.
+	cl := New(s.URL, nil, WithPolicy(&Policy{Deny: mustParse("example.com")}))
+	_, err = cl.List(ctx, "example.com/multi")
+	checkViolation(t, err, "deny")
+	_, _, _, err = cl.Info(ctx, "example.com/multi", "v1.0.0")
+	checkViolation(t, err, "deny")
+	if _, err := cl.List(ctx, "github.com/bobg/errors"); err != nil {
+		t.Errorf("listing a module that is not denied: %s", err)
+	}
+}
+
+func checkViolation(t *testing.T, err error, rule string) {
+	t.Helper()
+
+	if rule == "" {
+		if err != nil {
+			t.Errorf("got error %v, want none", err)
+		}
+		return
+	}
+	var (
+		policyErr *PolicyError
+		violation *PolicyViolation
+	)
+	if !errors.As(err, &policyErr) || !errors.As(err, &violation) {
+		t.Fatalf("got error %v, want policy violation", err)
+	}
+	if violation.Rule != rule {
+		t.Errorf("got rule %s, want %s", violation.Rule, rule)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	const text = `{
+  "allow": "github.com,golang.org/x",
+  "deny": "github.com/evil",
+  "max_age": "48h",
+  "licenses": ["MIT"],
+  "require_verified": true
+}`
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Allow.String() != "github.com,golang.org/x" || p.Deny.String() != "github.com/evil" || p.MaxAge != 48*time.Hour || len(p.Licenses) != 1 || !p.RequireVerified {
+		t.Errorf("got %+v", p)
+	}
+	if err := p.CheckModule("github.com/evil/x"); err == nil {
+		t.Error("denied module passed")
+	}
+	if err := p.CheckModule("golang.org/x/mod"); err != nil {
+		t.Errorf("allowed module failed: %s", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"max_age": "forever"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicy(path); err == nil {
+		t.Error("got no error for bad max_age")
+	}
+}
@@ -0,0 +1,56 @@
+package goproxyclient
+
+import (
+	"context"
+	"sync"
+)
+
+// Provenance records where the result of a [Client] operation came from,
+// which is otherwise unknown when the client has several proxies.
+// Attach one to a context with [WithProvenance]
+// and call [Provenance.Proxy] after the operation completes.
+//
+// A Provenance is safe for concurrent use,
+// but if several operations use its context at once,
+// it reports whichever of them finished last.
+type Provenance struct {
+	mu    sync.Mutex
+	proxy string
+}
+
+// Proxy returns the base URL of the proxy that produced the result
+// of the last successful operation using the Provenance's context,
+// or "direct" if it came from a version-control repository,
+// or "cache" if it came from the client's cache (see [WithCache]).
+// It returns the empty string if no operation has succeeded.
+func (p *Provenance) Proxy() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.proxy
+}
+
+type provenanceKey struct{}
+
+// WithProvenance returns a copy of ctx carrying p.
+// Operations on a [Client] using the resulting context
+// record in p where their results came from.
+func WithProvenance(ctx context.Context, p *Provenance) context.Context {
+	return context.WithValue(ctx, provenanceKey{}, p)
+}
+
+// ProvenanceFrom returns the [Provenance] carried by ctx,
+// or nil if there isn't one.
+// The methods of a nil *Provenance (other than Proxy) do nothing.
+func provenanceFrom(ctx context.Context) *Provenance {
+	p, _ := ctx.Value(provenanceKey{}).(*Provenance)
+	return p
+}
+
+func (p *Provenance) set(proxy string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.proxy = proxy
+	p.mu.Unlock()
+}
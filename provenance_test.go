@@ -0,0 +1,60 @@
+package goproxyclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProvenance(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	good := httptest.NewServer(testHandler(nil))
+	defer good.Close()
+
+	cl := New(strings.Join([]string{notFound.URL, good.URL}, ","), nil, WithCache(t.TempDir(), 0))
+
+	var p Provenance
+	ctx := WithProvenance(context.Background(), &p)
+
+	if got := p.Proxy(); got != "" {
+		t.Errorf("before any operation, got proxy %q, want none", got)
+	}
+
+	if _, _, _, err := cl.Info(ctx, "example.com/multi", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Proxy(); got != good.URL {
+		t.Errorf("got proxy %q, want %q", got, good.URL)
+	}
+
+	// The same request is now answered from the cache.
+	if _, _, _, err := cl.Info(ctx, "example.com/multi", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Proxy(); got != "cache" {
+		t.Errorf("got proxy %q, want %q", got, "cache")
+	}
+
+	rc, err := cl.Zip(ctx, "example.com/multi", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, rc)
+	rc.Close()
+	if got := p.Proxy(); got != good.URL {
+		t.Errorf("got proxy %q, want %q", got, good.URL)
+	}
+
+	// A failed operation leaves the Provenance alone.
+	if _, _, _, err := cl.Info(ctx, "example.com/nonexistent", "v1.0.0"); err == nil {
+		t.Fatal("got no error for nonexistent module")
+	}
+	if got := p.Proxy(); got != good.URL {
+		t.Errorf("after failure, got proxy %q, want %q", got, good.URL)
+	}
+}
@@ -0,0 +1,227 @@
+package goproxyclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ProxyInfo describes one of the entries in a [Client]'s list of proxies,
+// as reported by [Client.Proxies].
+type ProxyInfo struct {
+	// URL is the base URL of the proxy,
+	// with any password redacted,
+	// or "direct" or "off."
+	URL string
+
+	// Fallback says when the client falls back from this entry to the next one.
+	Fallback FallbackMode
+
+	// Health is the proxy's state according to its recent operations.
+	Health ProxyHealth
+
+	// Stats are counts of the proxy's operations.
+	Stats ProxyStats
+}
+
+// FallbackMode says when a [Client] falls back from one of its proxies to the next
+// (see [Parse]).
+type FallbackMode int
+
+const (
+	// FallbackNone means the client never falls back from the entry,
+	// because it is the last one or is "off."
+	FallbackNone FallbackMode = iota
+
+	// FallbackNotFound means the client falls back to the next entry
+	// only after a 404 (Not Found) or 410 (Gone) error
+	// (the entries are separated by a comma).
+	FallbackNotFound
+
+	// FallbackAnyError means the client falls back to the next entry after any error
+	// (the entries are separated by a pipe).
+	FallbackAnyError
+)
+
+var fallbackModeNames = map[FallbackMode]string{
+	FallbackNone:     "none",
+	FallbackNotFound: "not-found",
+	FallbackAnyError: "any-error",
+}
+
+func (m FallbackMode) String() string {
+	if s, ok := fallbackModeNames[m]; ok {
+		return s
+	}
+	return "unknown fallback mode"
+}
+
+// ProxyHealth is the state of one of a [Client]'s proxies,
+// as reported in [ProxyInfo].
+type ProxyHealth int
+
+const (
+	// Healthy means the last operation sent to the proxy succeeded,
+	// or that none has been sent.
+	Healthy ProxyHealth = iota
+
+	// Failing means the last operation sent to the proxy failed
+	// (in the sense of [CircuitBreakerPolicy]),
+	// but the proxy is still being tried.
+	Failing
+
+	// CircuitOpen means the proxy is being skipped
+	// because its circuit breaker is open
+	// (see [WithCircuitBreaker]).
+	CircuitOpen
+)
+
+var proxyHealthNames = map[ProxyHealth]string{
+	Healthy:     "healthy",
+	Failing:     "failing",
+	CircuitOpen: "circuit open",
+}
+
+func (h ProxyHealth) String() string {
+	if s, ok := proxyHealthNames[h]; ok {
+		return s
+	}
+	return "unknown health"
+}
+
+// ProxyStats are counts of the operations a [Client] has sent to one of its proxies,
+// over the lifetime of the client and all its copies.
+//
+// An operation is one attempt to get an info, @latest, list, go.mod, or zip response
+// (or the like)
+// from the proxy,
+// including any retries.
+// Coalesced operations (see [Client]) count once.
+// As for [CircuitBreakerPolicy],
+// an operation counts as a failure
+// if it fails with a 5xx status or a network error,
+// and as a success otherwise
+// (even with 404 Not Found),
+// unless it is canceled by its caller,
+// in which case it counts as neither.
+type ProxyStats struct {
+	// Requests is the number of operations sent to the proxy,
+	// not counting those canceled by their callers.
+	Requests int64
+
+	// Failures is the number of those operations that failed.
+	Failures int64
+
+	// Skipped is the number of operations that skipped the proxy
+	// because its circuit breaker was open.
+	Skipped int64
+
+	// LastSuccess and LastFailure are the times of the most recent successful and failed operations,
+	// or zero if there have been none.
+	LastSuccess, LastFailure time.Time
+
+	// LastError is the error from the most recent failed operation,
+	// or nil if there has been none.
+	LastError error
+}
+
+// Proxies describes the client's proxies,
+// in order,
+// so that programs using the client can show its configuration and the state of its proxies
+// (for example, on a status page).
+// Entries after an "off" entry are not included,
+// since they are never tried.
+func (cl Client) Proxies() []ProxyInfo {
+	ch := cl.chain()
+	result := make([]ProxyInfo, 0, 1+len(ch.rest))
+
+	s := ch.first
+	for i := 0; ; i++ {
+		info := s.describe()
+		if i == len(ch.rest) {
+			return append(result, info)
+		}
+		if !s.off {
+			info.Fallback = FallbackNotFound
+			if ch.rest[i].afterAnyErr {
+				info.Fallback = FallbackAnyError
+			}
+		}
+		result = append(result, info)
+		s = ch.rest[i].client
+	}
+}
+
+// Describe describes s,
+// except for its fallback mode,
+// which depends on the entry after it.
+func (s single) describe() ProxyInfo {
+	info := ProxyInfo{URL: s.baseURL}
+	if s.baseURL != "direct" && !s.off {
+		info.URL = redactURL(s.baseURL)
+	}
+
+	if s.stats != nil {
+		info.Stats = s.stats.snapshot()
+	}
+
+	switch {
+	case s.breaker.isOpen():
+		info.Health = CircuitOpen
+	case info.Stats.LastFailure.After(info.Stats.LastSuccess):
+		info.Health = Failing
+	}
+
+	return info
+}
+
+// proxyStats accumulates the [ProxyStats] for one proxy.
+// It is shared by the copies of a [single].
+type proxyStats struct {
+	mu    sync.Mutex
+	stats ProxyStats
+}
+
+// Record records the outcome of an operation sent to the proxy.
+func (p *proxyStats) record(ctx context.Context, err error) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// Canceled by the caller.
+		return
+
+	case err == nil || !retryable(ctx, err):
+		p.stats.LastSuccess = time.Now()
+
+	default:
+		p.stats.Failures++
+		p.stats.LastFailure = time.Now()
+		p.stats.LastError = err
+	}
+	p.stats.Requests++
+}
+
+// Skip records an operation that skipped the proxy.
+func (p *proxyStats) skip() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stats.Skipped++
+}
+
+func (p *proxyStats) snapshot() ProxyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.stats
+}
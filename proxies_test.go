@@ -0,0 +1,75 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxies(t *testing.T) {
+	ctx := context.Background()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	good := httptest.NewServer(testHandler(nil))
+	defer good.Close()
+
+	brokenURL := strings.Replace(broken.URL, "http://", "http://user:secret@", 1)
+
+	cl := New(brokenURL+"|"+good.URL+",off", nil, WithCircuitBreaker(CircuitBreakerPolicy{Failures: 2, Cooldown: time.Hour}))
+
+	if _, err := cl.List(ctx, "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.List(ctx, "example.com/nonexistent"); err == nil {
+		t.Fatal("got no error for nonexistent module")
+	}
+
+	got := cl.Proxies()
+	if len(got) != 3 {
+		t.Fatalf("got %d proxies, want 3", len(got))
+	}
+
+	want := []struct {
+		url                         string
+		fallback                    FallbackMode
+		health                      ProxyHealth
+		requests, failures, skipped int64
+	}{
+		{url: strings.Replace(broken.URL, "http://", "http://user:xxxxx@", 1), fallback: FallbackAnyError, health: CircuitOpen, requests: 2, failures: 2},
+		{url: good.URL, fallback: FallbackNotFound, health: Healthy, requests: 2},
+		{url: "off", fallback: FallbackNone, health: Healthy},
+	}
+	for i, w := range want {
+		p := got[i]
+		if p.URL != w.url {
+			t.Errorf("proxy %d: got URL %s, want %s", i, p.URL, w.url)
+		}
+		if p.Fallback != w.fallback {
+			t.Errorf("proxy %d: got fallback %s, want %s", i, p.Fallback, w.fallback)
+		}
+		if p.Health != w.health {
+			t.Errorf("proxy %d: got health %s, want %s", i, p.Health, w.health)
+		}
+		if p.Stats.Requests != w.requests || p.Stats.Failures != w.failures || p.Stats.Skipped != w.skipped {
+			t.Errorf("proxy %d: got %d requests, %d failures, %d skipped; want %d, %d, %d", i, p.Stats.Requests, p.Stats.Failures, p.Stats.Skipped, w.requests, w.failures, w.skipped)
+		}
+	}
+	if got[0].Stats.LastError == nil {
+		t.Error("got no last error for the broken proxy")
+	}
+
+	// Now the broken proxy is skipped.
+	if _, err := cl.List(ctx, "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := cl.Proxies()[0].Stats.Skipped; got != 1 {
+		t.Errorf("got %d skipped, want 1", got)
+	}
+}
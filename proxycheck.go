@@ -0,0 +1,118 @@
+package goproxyclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"golang.org/x/mod/module"
+
+	"github.com/bobg/goproxyclient/internal/protocol"
+)
+
+// DefaultProbe is the module version that [CheckProxy] requests
+// when no other is given.
+var DefaultProbe = protocol.DefaultProbe
+
+// ProxyReport is the result of [CheckProxy].
+type ProxyReport struct {
+	// Proxy is the base URL of the proxy that was checked.
+	Proxy string
+
+	// Probe is the module version that was requested.
+	Probe module.Version
+
+	// Checks are the individual checks,
+	// in the order they were made.
+	Checks []ProxyCheck
+}
+
+// ProxyCheck is one of the checks in a [ProxyReport].
+type ProxyCheck struct {
+	// Name is a short description of the check,
+	// such as "list" or "missing module".
+	Name string
+
+	// URL is the URL that was requested.
+	URL string
+
+	// Optional tells whether the check is for behavior that the GOPROXY protocol does not require,
+	// such as the @latest endpoint.
+	// A failed optional check does not make the proxy nonconforming.
+	Optional bool
+
+	// Elapsed is how long the request took.
+	Elapsed time.Duration
+
+	// Err is the reason the check failed,
+	// or nil if it passed.
+	Err error
+}
+
+// Ensure ProxyCheck and protocol.Result stay convertible.
+var _ = ProxyCheck(protocol.Result{})
+
+// OK tells whether the proxy passed all of its non-optional checks.
+func (r *ProxyReport) OK() bool {
+	return !slices.ContainsFunc(r.Checks, func(c ProxyCheck) bool {
+		return c.Err != nil && !c.Optional
+	})
+}
+
+// CheckProxy checks that the Go module proxy at proxyURL
+// implements the [GOPROXY protocol] correctly.
+// It requests the version list, info, go.mod file, and zip file for the probe module version
+// (or [DefaultProbe] if probe.Path is empty),
+// and validates each response.
+// If probe.Version is empty,
+// the highest release version in the list is used.
+// It also requests a version and a module that do not exist,
+// which must produce 404 (Not Found) or 410 (Gone) responses
+// so that the go command can fall back to the next proxy in GOPROXY.
+//
+// The hc and opts arguments are as for [New],
+// though compatibility mode (see [WithCompat]) is ignored,
+// since its purpose is to tolerate nonconforming responses.
+//
+// The error result is for problems with the arguments.
+// Problems with the proxy are reported in the [ProxyReport].
+//
+// [GOPROXY protocol]: https://go.dev/ref/mod#goproxy-protocol
+func CheckProxy(ctx context.Context, proxyURL string, hc *http.Client, probe module.Version, opts ...Option) (*ProxyReport, error) {
+	proxyURL, err := normalizeProxyEntry(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	switch proxyURL {
+	case "", "direct", "off":
+		return nil, fmt.Errorf("cannot check %q, which is not a proxy URL", proxyURL)
+	}
+
+	conf := &config{requestIDHeader: DefaultRequestIDHeader}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	s := newSingle(proxyURL, hc, conf)
+	s.compat = false
+
+	get := func(ctx context.Context, q string) (io.ReadCloser, error) {
+		resp, err := s.get(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	probe, results, err := protocol.Check(ctx, s.baseURL, probe, get)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ProxyReport{Proxy: s.baseURL, Probe: probe}
+	for _, r := range results {
+		report.Checks = append(report.Checks, ProxyCheck(r))
+	}
+	return report, nil
+}
@@ -0,0 +1,98 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/mod/module"
+)
+
+func TestCheckProxy(t *testing.T) {
+	ctx := context.Background()
+
+	checkErrs := func(t *testing.T, report *ProxyReport) map[string]bool {
+		t.Helper()
+
+		failed := make(map[string]bool)
+		for _, c := range report.Checks {
+			if c.Err != nil {
+				failed[c.Name] = true
+				t.Logf("%s: %s", c.Name, c.Err)
+			}
+		}
+		return failed
+	}
+
+	t.Run("good", func(t *testing.T) {
+		s := httptest.NewServer(testHandler(nil))
+		defer s.Close()
+
+		report, err := CheckProxy(ctx, s.URL, nil, module.Version{Path: "example.com/multi"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed := checkErrs(t, report); len(failed) > 0 {
+			t.Errorf("got failures %v, want none", failed)
+		}
+		if !report.OK() {
+			t.Error("got !OK")
+		}
+		if report.Probe.Version != "v1.0.0" {
+			t.Errorf("got probe version %s, want v1.0.0", report.Probe.Version)
+		}
+		if len(report.Checks) != 7 {
+			t.Errorf("got %d checks, want 7", len(report.Checks))
+		}
+	})
+
+	t.Run("bad", func(t *testing.T) {
+		s := httptest.NewServer(testHandler(map[string]int{
+			"example.com/multi/@latest":                     http.StatusNotFound,
+			"example.com/multi/@v/v1.0.0.zip":               http.StatusInternalServerError,
+			"example.com/multi/goproxyclient-check-missing": http.StatusUnauthorized,
+		}))
+		defer s.Close()
+
+		report, err := CheckProxy(ctx, s.URL, nil, module.Version{Path: "example.com/multi", Version: "v1.0.0"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		failed := checkErrs(t, report)
+		for _, name := range []string{"latest", "zip", "missing module"} {
+			if !failed[name] {
+				t.Errorf("%s check passed, want failure", name)
+			}
+		}
+		for _, name := range []string{"list", "info", "mod", "missing version"} {
+			if failed[name] {
+				t.Errorf("%s check failed, want success", name)
+			}
+		}
+		if report.OK() {
+			t.Error("got OK")
+		}
+	})
+
+	t.Run("optional", func(t *testing.T) {
+		s := httptest.NewServer(testHandler(map[string]int{
+			"example.com/multi/@latest": http.StatusNotFound,
+		}))
+		defer s.Close()
+
+		report, err := CheckProxy(ctx, s.URL, nil, module.Version{Path: "example.com/multi", Version: "v1.0.0"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !report.OK() {
+			t.Error("got !OK for failed optional check")
+		}
+	})
+
+	t.Run("direct", func(t *testing.T) {
+		if _, err := CheckProxy(ctx, "direct", nil, module.Version{}); err == nil {
+			t.Error("got no error for direct")
+		}
+	})
+}
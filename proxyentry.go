@@ -0,0 +1,80 @@
+package goproxyclient
+
+import "github.com/bobg/errors"
+
+// ProxyEntry is one entry in a GOPROXY string,
+// as produced by [ParseConfig].
+type ProxyEntry struct {
+	// URL is the base URL of the proxy,
+	// or the special string "direct" or "off."
+	// A URL given without a scheme has had "https://" prepended,
+	// as the go command does.
+	URL string
+
+	// AfterAnyErr tells whether this entry is tried after any error from the preceding one
+	// (it was preceded by a pipe),
+	// rather than only after a 404 (Not Found) or 410 (Gone) error
+	// (it was preceded by a comma).
+	// It is false for the first entry.
+	AfterAnyErr bool
+}
+
+// IsDirect tells whether the entry is "direct,"
+// meaning to fetch modules directly from their version control repositories.
+func (e ProxyEntry) IsDirect() bool {
+	return e.URL == "direct"
+}
+
+// IsOff tells whether the entry is "off,"
+// meaning to disallow fetching modules.
+func (e ProxyEntry) IsOff() bool {
+	return e.URL == "off"
+}
+
+// ErrProxyOff is the error returned by a [Client] operation
+// that reaches an "off" entry in the client's list of proxies,
+// as the go command refuses to fetch modules when GOPROXY is off.
+var ErrProxyOff = errors.New("module lookup disabled by GOPROXY=off")
+
+// String returns the entry as it would appear in a GOPROXY string,
+// including its leading separator if AfterAnyErr is true.
+func (e ProxyEntry) String() string {
+	if e.AfterAnyErr {
+		return "|" + e.URL
+	}
+	return e.URL
+}
+
+// ParseConfig parses and validates a GOPROXY string,
+// returning its non-empty entries.
+// It returns an error for the first invalid entry,
+// by the rules described at [ValidateGOPROXY].
+func ParseConfig(goproxy string) ([]ProxyEntry, error) {
+	return parseConfig(goproxy, true)
+}
+
+// ParseConfig parses a GOPROXY string.
+// If strict is true,
+// it stops at the first invalid entry and returns an error.
+// Otherwise,
+// invalid entries are included unchanged.
+func parseConfig(goproxy string, strict bool) ([]ProxyEntry, error) {
+	var entries []ProxyEntry
+	for val, afterAnyErr := range Parse(goproxy) {
+		if val == "" {
+			continue
+		}
+		if len(entries) == 0 {
+			afterAnyErr = false
+		}
+		normalized, err := normalizeProxyEntry(val)
+		if err != nil {
+			if strict {
+				return nil, err
+			}
+			normalized = val
+		}
+		entries = append(entries, ProxyEntry{URL: normalized, AfterAnyErr: afterAnyErr})
+	}
+	return entries, nil
+}
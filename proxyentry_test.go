@@ -0,0 +1,72 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/bobg/goproxyclient/goproxytest"
+)
+
+func TestParseConfig(t *testing.T) {
+	got, err := ParseConfig("proxy.corp.example.com,,https://proxy.golang.org|direct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ProxyEntry{
+		{URL: "https://proxy.corp.example.com"},
+		{URL: "https://proxy.golang.org"},
+		{URL: "direct", AfterAnyErr: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if !got[2].IsDirect() || got[2].IsOff() {
+		t.Errorf("got IsDirect %v, IsOff %v for %s", got[2].IsDirect(), got[2].IsOff(), got[2])
+	}
+
+	if _, err := ParseConfig("https://proxy.golang.org,bogus"); err == nil {
+		t.Error("got nil error for invalid entry")
+	}
+
+	ch := NewFromConfig(got, nil).chain()
+	if ch.first.baseURL != "https://proxy.corp.example.com" || len(ch.rest) != 2 || ch.rest[0].client.baseURL != "https://proxy.golang.org" || ch.rest[1].client.direct == nil || !ch.rest[1].afterAnyErr {
+		t.Errorf("unexpected client from config %v", got)
+	}
+}
+
+func TestOff(t *testing.T) {
+	s := httptest.NewServer(goproxytest.New(fstest.MapFS{
+		"example.com/a/@v/list": {Data: []byte("v1.0.0\n")},
+	}))
+	defer s.Close()
+
+	ctx := context.Background()
+
+	cl := New(s.URL+",off,https://proxy.golang.org", nil)
+	if ch := cl.chain(); len(ch.rest) != 1 || !ch.rest[0].client.off {
+		t.Fatalf("got %d fallback entries, want only off", len(ch.rest))
+	}
+
+	versions, err := cl.List(ctx, "example.com/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 {
+		t.Errorf("got versions %v, want [v1.0.0]", versions)
+	}
+
+	// A 404 from the first proxy reaches the off entry.
+	if _, err := cl.List(ctx, "example.com/b"); !errors.Is(err, ErrProxyOff) {
+		t.Errorf("got %v, want ErrProxyOff", err)
+	}
+
+	cl = New("off", nil)
+	if _, _, _, err := cl.Latest(ctx, "example.com/a"); !errors.Is(err, ErrProxyOff) {
+		t.Errorf("got %v, want ErrProxyOff", err)
+	}
+}
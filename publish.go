@@ -0,0 +1,119 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// Publish makes version ver of the Go module in the local directory dir
+// available from st,
+// without a version-control server:
+// it writes the version's .info, .mod, and .zip files into st
+// in the layout of the Go module proxy protocol
+// and adds ver to the module's version list.
+// A [DirStorage] can then be served by any static file server
+// (or used with a file:// URL)
+// as a proxy for the module.
+//
+// The module path is the one declared in dir's go.mod file,
+// and is returned.
+// The version must be canonical
+// and consistent with any major-version suffix of the module path.
+// The zip file is created as by [Rezip].
+// The .info file gives tm as the version's time,
+// or the current time if tm is zero.
+//
+// Published versions must never change,
+// so if st already has any of the version's files,
+// Publish fails with an error satisfying errors.Is(err, fs.ErrExist).
+// Concurrent calls publishing versions of the same module
+// may lose updates to its version list.
+func Publish(ctx context.Context, st Storage, dir, ver string, tm time.Time) (string, error) {
+	modData, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", errors.Wrap(err, "reading go.mod")
+	}
+	mod := modfile.ModulePath(modData)
+	if mod == "" {
+		return "", errors.Newf("no module path in %s", filepath.Join(dir, "go.mod"))
+	}
+	if err := module.Check(mod, ver); err != nil {
+		return "", err
+	}
+	if tm.IsZero() {
+		tm = time.Now()
+	}
+
+	escPath, err := module.EscapePath(mod)
+	if err != nil {
+		return "", errors.Wrapf(err, "escaping module path %s", mod)
+	}
+	escVer, err := module.EscapeVersion(ver)
+	if err != nil {
+		return "", errors.Wrapf(err, "escaping version %s", ver)
+	}
+	prefix := escPath + "/@v/" + escVer
+
+	for _, suffix := range []string{".info", ".mod", ".zip"} {
+		ok, err := st.Exists(ctx, prefix+suffix)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return "", errors.Wrapf(fs.ErrExist, "%s@%s is already published", mod, ver)
+		}
+	}
+
+	f, err := os.CreateTemp("", "goproxyclient-publish-*.zip")
+	if err != nil {
+		return "", errors.Wrap(err, "creating temp file")
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := Rezip(f, mod, ver, dir); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", errors.Wrap(err, "rewinding zip file")
+	}
+
+	info, err := json.Marshal(struct {
+		Version string
+		Time    time.Time
+	}{
+		Version: ver,
+		Time:    tm.UTC(),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "encoding info")
+	}
+
+	// Write the zip file first and the info file last,
+	// so that anyone who can see the info file can get the others.
+	puts := []struct {
+		suffix string
+		r      io.Reader
+	}{
+		{".zip", f},
+		{".mod", bytes.NewReader(modData)},
+		{".info", bytes.NewReader(info)},
+	}
+	for _, p := range puts {
+		if err := st.Put(ctx, prefix+p.suffix, p.r); err != nil {
+			return "", errors.Wrapf(err, "writing %s", prefix+p.suffix)
+		}
+	}
+
+	return mod, addToStorageList(ctx, st, escPath+"/@v/list", ver)
+}
@@ -0,0 +1,110 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+
+	src := t.TempDir()
+	files := map[string]string{
+		"go.mod":      "module example.com/Private\n\ngo 1.23\n",
+		"private.go":  "package private\n",
+		"sub/sub.go":  "package sub\n",
+		".git/config": "not part of the module\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dest := t.TempDir()
+	st := DirStorage(dest)
+	tm := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, ver := range []string{"v1.1.0", "v1.0.0"} {
+		mod, err := Publish(ctx, st, src, ver, tm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mod != "example.com/Private" {
+			t.Errorf("got module path %s, want example.com/Private", mod)
+		}
+	}
+
+	// The destination can be served as a proxy.
+	s := httptest.NewServer(http.FileServer(http.Dir(dest)))
+	defer s.Close()
+	cl := New(s.URL, nil)
+
+	versions, err := cl.List(ctx, "example.com/Private")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v1.0.0", "v1.1.0"}; !slices.Equal(versions, want) {
+		t.Errorf("got versions %v, want %v", versions, want)
+	}
+
+	ver, gotTime, _, err := cl.Info(ctx, "example.com/Private", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver != "v1.1.0" || !gotTime.Equal(tm) {
+		t.Errorf("got info %s %s, want v1.1.0 %s", ver, gotTime, tm)
+	}
+
+	rc, err := cl.Mod(ctx, "example.com/Private", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modData, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(modData) != files["go.mod"] {
+		t.Errorf("got go.mod %q, want %q", modData, files["go.mod"])
+	}
+
+	out := t.TempDir()
+	if err := cl.Extract(ctx, "example.com/Private", "v1.1.0", out); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "sub", "sub.go")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(filepath.Join(out, ".git")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v for .git in the published module, want not-exist", err)
+	}
+
+	t.Run("republish", func(t *testing.T) {
+		_, err := Publish(ctx, st, src, "v1.1.0", time.Time{})
+		if !errors.Is(err, fs.ErrExist) {
+			t.Errorf("got %v, want ErrExist", err)
+		}
+	})
+
+	t.Run("bad_version", func(t *testing.T) {
+		for _, ver := range []string{"1.2.0", "v1.2", "v2.0.0"} {
+			if _, err := Publish(ctx, st, src, ver, time.Time{}); err == nil {
+				t.Errorf("got no error for version %s", ver)
+			}
+		}
+	})
+}
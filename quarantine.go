@@ -0,0 +1,184 @@
+package goproxyclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+)
+
+// HashMismatchError is the error returned when a downloaded module
+// does not have the hash it was expected to have.
+// This may indicate tampering,
+// by the proxy or by something between it and the client.
+type HashMismatchError struct {
+	Module, Version string
+
+	// Want is the expected hash,
+	// and Got is the hash of what was downloaded.
+	Want, Got string
+
+	// Quarantined is the path of the quarantined download,
+	// or the empty string if it was not quarantined
+	// (see [WithQuarantine]).
+	Quarantined string
+}
+
+func (e *HashMismatchError) Error() string {
+	msg := fmt.Sprintf("hash mismatch for %s@%s: got %s, want %s", e.Module, e.Version, e.Got, e.Want)
+	if e.Quarantined != "" {
+		msg += " (quarantined in " + e.Quarantined + ")"
+	}
+	return msg
+}
+
+// WithVerifiedDB is an [Option] that makes the client check downloaded module zip files
+// (e.g. in [Client.Extract])
+// against the hashes recorded in db.
+// A download whose module version has a recorded hash that differs
+// fails with a [*HashMismatchError].
+func WithVerifiedDB(db *VerifiedDB) Option {
+	return func(c *config) {
+		c.verified = db
+	}
+}
+
+// WithQuarantine is an [Option] that makes the client keep downloads that fail verification
+// (see [WithVerifiedDB])
+// instead of deleting them,
+// so they can be investigated.
+// Each such download is moved to dir,
+// which is created if necessary,
+// along with a JSON report file describing the failure.
+func WithQuarantine(dir string) Option {
+	return func(c *config) {
+		c.quarantineDir = dir
+	}
+}
+
+// VerifyZipFile checks the module zip file at path
+// against the known hash for mod@ver, if any
+// (from the client's [VerifiedDB] or [ChecksumDB]; see [Client.expectedHash]),
+// using the [Hasher] named by the hash's prefix.
+// It reports whether there was a hash to check against.
+// On a mismatch,
+// the file is quarantined if the client has a quarantine directory,
+// and the result is a [*HashMismatchError].
+func (cl Client) verifyZipFile(path, mod, ver string) (bool, error) {
+	want, ok, record, err := cl.expectedHash(mod, ver)
+	if err != nil || !ok {
+		return false, err
+	}
+	alg, _, _ := strings.Cut(want, ":")
+	h := cl.conf.hasher(alg)
+	if h == nil {
+		return false, fmt.Errorf("no hasher for %s hash of %s@%s", alg, mod, ver)
+	}
+	got, err := h.HashZip(path)
+	if err != nil {
+		return false, errors.Wrapf(err, "hashing zip for %s@%s", mod, ver)
+	}
+	if got == want {
+		if record {
+			return true, cl.recordHash(mod, ver, got)
+		}
+		return true, nil
+	}
+
+	mismatch := &HashMismatchError{Module: mod, Version: ver, Want: want, Got: got}
+	if cl.conf.quarantineDir != "" {
+		dest, err := quarantine(cl.conf.quarantineDir, path, mismatch)
+		if err != nil {
+			return false, errors.Join(mismatch, errors.Wrap(err, "quarantining"))
+		}
+		mismatch.Quarantined = dest
+	}
+	return false, mismatch
+}
+
+// quarantineReport is the content of the JSON report file written alongside a quarantined file.
+type quarantineReport struct {
+	Module, Version string
+	Want, Got       string
+	Time            time.Time
+	File            string
+}
+
+// Quarantine moves the file at path into dir,
+// with a name based on the module version in mismatch,
+// and writes a report file next to it.
+// The report is written first,
+// and removed again if the file cannot be moved,
+// so dir never holds a quarantined file without its report
+// or a report without its file.
+// It returns the file's new path.
+func quarantine(dir, path string, mismatch *HashMismatchError) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Wrapf(err, "creating %s", dir)
+	}
+
+	escPath, err := module.EscapePath(mismatch.Module)
+	if err != nil {
+		return "", errors.Wrapf(err, "escaping module path %s", mismatch.Module)
+	}
+	var (
+		now        = time.Now()
+		base       = fmt.Sprintf("%s@%s-%s", strings.ReplaceAll(escPath, "/", "_"), mismatch.Version, now.UTC().Format("20060102T150405.000000000Z"))
+		dest       = filepath.Join(dir, base+".zip")
+		reportPath = filepath.Join(dir, base+".json")
+	)
+
+	report := quarantineReport{
+		Module:  mismatch.Module,
+		Version: mismatch.Version,
+		Want:    mismatch.Want,
+		Got:     mismatch.Got,
+		Time:    now,
+		File:    filepath.Base(dest),
+	}
+	err = writeFileAtomic(reportPath, 0644, false, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "writing quarantine report")
+	}
+
+	if err := moveFile(path, dest); err != nil {
+		os.Remove(reportPath)
+		return "", err
+	}
+
+	return dest, nil
+}
+
+// MoveFile renames src to dest,
+// falling back to copying and removing src
+// when they are on different filesystems.
+func moveFile(src, dest string) error {
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "opening %s", src)
+	}
+	defer in.Close()
+
+	err = writeFileAtomic(dest, 0644, false, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "copying %s to %s", src, dest)
+	}
+	return os.Remove(src)
+}
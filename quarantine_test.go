@@ -0,0 +1,97 @@
+package goproxyclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQuarantine(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	db, err := OpenVerifiedDB(filepath.Join(t.TempDir(), "verified"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const bogus = "h1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+	if err := db.Add("example.com/multi", "v1.0.0", bogus); err != nil {
+		t.Fatal(err)
+	}
+
+	qdir := t.TempDir()
+	cl := New(s.URL, nil, WithVerifiedDB(db), WithQuarantine(qdir))
+
+	dir := t.TempDir()
+	err = cl.Extract(ctx, "example.com/multi", "v1.0.0", dir)
+
+	var mismatch *HashMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("got error %v, want HashMismatchError", err)
+	}
+	if mismatch.Want != bogus || !strings.HasPrefix(mismatch.Got, "h1:") {
+		t.Errorf("got %+v", mismatch)
+	}
+	if _, err := os.Stat(mismatch.Quarantined); err != nil {
+		t.Errorf("quarantined file: %s", err)
+	}
+
+	reportFile := strings.TrimSuffix(mismatch.Quarantined, ".zip") + ".json"
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report quarantineReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Module != "example.com/multi" || report.Got != mismatch.Got {
+		t.Errorf("got report %+v", report)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("extracted %d entries despite hash mismatch", len(entries))
+	}
+
+	// The quarantine directory holds only the file and its report.
+	if entries, err := os.ReadDir(qdir); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 2 {
+		t.Errorf("got %d entries in quarantine directory, want 2", len(entries))
+	}
+
+	// If the file cannot be moved, no report is left behind.
+	qdir2 := t.TempDir()
+	if _, err := quarantine(qdir2, filepath.Join(dir, "nonexistent.zip"), mismatch); err == nil {
+		t.Error("quarantined a nonexistent file")
+	}
+	if entries, err := os.ReadDir(qdir2); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 0 {
+		t.Errorf("got %d entries in quarantine directory after failure, want 0", len(entries))
+	}
+
+	// With the right hash, extraction succeeds.
+	db2, err := OpenVerifiedDB(filepath.Join(t.TempDir(), "verified"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db2.Add("example.com/multi", "v1.0.0", mismatch.Got); err != nil {
+		t.Fatal(err)
+	}
+	cl = New(s.URL, nil, WithVerifiedDB(db2))
+	if err := cl.Extract(ctx, "example.com/multi", "v1.0.0", dir); err != nil {
+		t.Fatal(err)
+	}
+}
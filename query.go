@@ -0,0 +1,199 @@
+package goproxyclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/mid"
+	"golang.org/x/mod/semver"
+)
+
+// Query resolves a version query for a module
+// the way "go get MODULE@QUERY" does,
+// returning a canonical version.
+// See https://go.dev/ref/mod#version-queries.
+// The query may be:
+//
+//   - "latest", for the highest release version,
+//     or the highest prerelease version if there are no releases,
+//     or the version the proxy reports as latest
+//     (typically a pseudo-version) if there are neither;
+//   - "upgrade" or "patch",
+//     which here are the same as "latest"
+//     (see [Client.QueryFrom] for their full meanings);
+//   - a complete semantic version such as "v1.2.3",
+//     which must exist;
+//   - a version prefix such as "v1" or "v1.2",
+//     for the highest release
+//     (or, failing that, prerelease)
+//     version with that prefix;
+//   - a comparison such as "<v1.5.0" or ">=v1.2.0",
+//     for the highest matching version
+//     (or, with > and >=, the lowest),
+//     again preferring releases to prereleases;
+//   - anything else,
+//     such as a branch name or a commit hash prefix,
+//     which is resolved by the proxy
+//     (see [Client.Info]).
+//
+// Retracted versions
+// (according to the go.mod file of the module's latest version; see [Client.Status])
+// are not chosen by queries that select from the list of versions,
+// unless all candidates are retracted.
+//
+// If no version matches,
+// the result is an error for which [IsNotFound] is true.
+func (cl Client) Query(ctx context.Context, mod, query string) (string, error) {
+	return cl.QueryFrom(ctx, mod, query, "")
+}
+
+// QueryFrom is like [Client.Query],
+// for a module whose current version
+// (in some build list)
+// is current.
+// This affects the "upgrade" and "patch" queries:
+// "upgrade" is like "latest"
+// but never chooses a lower version than current;
+// "patch" chooses the highest version with the same major and minor version numbers as current,
+// again never lower than current.
+// If current is the empty string,
+// both are the same as "latest".
+func (cl Client) QueryFrom(ctx context.Context, mod, query, current string) (string, error) {
+	if current != "" && !semver.IsValid(current) {
+		return "", fmt.Errorf("invalid current version %q", current)
+	}
+
+	var (
+		match  func(string) bool
+		lowest bool // choose the lowest matching version instead of the highest
+	)
+
+	switch {
+	case query == "latest":
+		match = func(string) bool { return true }
+
+	case query == "upgrade", query == "patch":
+		if query == "patch" && current != "" {
+			mm := semver.MajorMinor(current)
+			match = func(v string) bool { return semver.MajorMinor(v) == mm }
+		} else {
+			match = func(string) bool { return true }
+		}
+		ver, err := cl.queryList(ctx, mod, query, match, false, current)
+		if err != nil {
+			return "", err
+		}
+		if current != "" && semver.Compare(current, ver) > 0 {
+			return current, nil
+		}
+		return ver, nil
+
+	case strings.HasPrefix(query, "<="), strings.HasPrefix(query, "<"), strings.HasPrefix(query, ">="), strings.HasPrefix(query, ">"):
+		op := query[:1]
+		if len(query) > 1 && query[1] == '=' {
+			op = query[:2]
+		}
+		operand := query[len(op):]
+		if !semver.IsValid(operand) {
+			return "", fmt.Errorf("invalid version %q in query %q", operand, query)
+		}
+		match = func(v string) bool {
+			c := semver.Compare(v, operand)
+			switch op {
+			case "<":
+				return c < 0
+			case "<=":
+				return c <= 0
+			case ">":
+				return c > 0
+			default:
+				return c >= 0
+			}
+		}
+		lowest = op[0] == '>'
+
+	case semver.IsValid(query) && semver.Canonical(query) == query:
+		// A complete version.
+		ver, _, _, err := cl.Info(ctx, mod, query)
+		return ver, errors.Wrapf(err, "getting info for %s@%s", mod, query)
+
+	case semver.IsValid(query) && isVersionPrefix(query):
+		match = func(v string) bool {
+			return v == query || strings.HasPrefix(v, query+".")
+		}
+
+	default:
+		// A branch name, commit hash, or noncanonical version.
+		ver, _, _, err := cl.Info(ctx, mod, query)
+		return ver, errors.Wrapf(err, "resolving %s@%s", mod, query)
+	}
+
+	return cl.queryList(ctx, mod, query, match, lowest, "")
+}
+
+// IsVersionPrefix tells whether a valid semantic version
+// has only a major, or only a major and minor, version number,
+// as in "v1" or "v1.2".
+func isVersionPrefix(v string) bool {
+	return strings.Count(v, ".") < 2 && !strings.ContainsAny(v, "-+")
+}
+
+// QueryList chooses the highest
+// (or, if lowest is true, the lowest)
+// of a module's listed versions that match,
+// preferring releases to prereleases,
+// and non-retracted versions (other than allowed) to retracted ones.
+//
+// If the module has no listed versions at all and the query is latest, upgrade, or patch,
+// it asks the proxy for the module's latest version.
+func (cl Client) queryList(ctx context.Context, mod, query string, match func(string) bool, lowest bool, allowed string) (string, error) {
+	versions, err := cl.List(ctx, mod)
+	if err != nil {
+		return "", errors.Wrapf(err, "listing versions of %s", mod)
+	}
+
+	if len(versions) == 0 && (query == "latest" || query == "upgrade" || query == "patch") {
+		ver, _, _, err := cl.Latest(ctx, mod)
+		return ver, errors.Wrapf(err, "getting latest version of %s", mod)
+	}
+
+	versions = slices.DeleteFunc(versions, func(v string) bool { return !match(v) })
+	if len(versions) == 0 {
+		return "", mid.CodeErr{C: http.StatusNotFound, Err: fmt.Errorf("no versions of %s match query %q", mod, query)}
+	}
+	if lowest {
+		slices.Reverse(versions)
+	}
+
+	status, err := cl.Status(ctx, mod)
+	if err != nil && !IsNotFound(err) {
+		return "", errors.Wrapf(err, "getting status of %s", mod)
+	}
+	notRetracted := func(v string) bool {
+		if v == allowed {
+			return true
+		}
+		retracted, _ := status.Retracted(v)
+		return !retracted
+	}
+
+	// Candidates in order of preference.
+	for _, ok := range []func(string) bool{
+		func(v string) bool { return semver.Prerelease(v) == "" && notRetracted(v) },
+		notRetracted,
+		func(v string) bool { return semver.Prerelease(v) == "" },
+	} {
+		for _, v := range slices.Backward(versions) {
+			if ok(v) {
+				return v, nil
+			}
+		}
+	}
+
+	// All candidates are retracted prereleases.
+	return versions[len(versions)-1], nil
+}
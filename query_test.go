@@ -0,0 +1,80 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bobg/goproxyclient/goproxytest"
+)
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"example.com/q/@v/list":              {Data: []byte("v1.0.0\nv1.1.0\nv1.1.1\nv1.2.0-pre\nv1.3.0\nv1.4.0\nv1.4.1\nv1.5.0-rc.1\n")},
+		"example.com/q/@latest":              {Data: []byte(`{"Version":"v1.4.1"}`)},
+		"example.com/q/@v/v1.4.1.mod":        {Data: []byte("module example.com/q\n\nretract v1.4.1 // oops\n")},
+		"example.com/q/@v/v1.1.0.info":       {Data: []byte(`{"Version":"v1.1.0"}`)},
+		"example.com/q/@v/main.info":         {Data: []byte(`{"Version":"v1.5.0-rc.1.0.20240101000000-abcdefabcdef"}`)},
+		"example.com/pre/@v/list":            {Data: []byte("v0.1.0-alpha\nv0.1.0-beta\n")},
+		"example.com/pre/@latest":            {Data: []byte(`{"Version":"v0.1.0-beta"}`)},
+		"example.com/pre/@v/v0.1.0-beta.mod": {Data: []byte("module example.com/pre\n")},
+		"example.com/none/@v/list":           {Data: []byte("")},
+		"example.com/none/@latest":           {Data: []byte(`{"Version":"v0.0.0-20240101000000-abcdefabcdef"}`)},
+	}
+	s := httptest.NewServer(goproxytest.New(fsys))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+
+	cases := []struct {
+		mod, query, current string
+		want                string // "" means not found
+	}{
+		{"example.com/q", "latest", "", "v1.4.0"}, // v1.4.1 is retracted
+		{"example.com/q", "upgrade", "", "v1.4.0"},
+		{"example.com/q", "upgrade", "v1.4.1", "v1.4.1"},
+		{"example.com/q", "upgrade", "v1.5.0-rc.1", "v1.5.0-rc.1"},
+		{"example.com/q", "patch", "v1.1.0", "v1.1.1"},
+		{"example.com/q", "patch", "v1.2.0-pre", "v1.2.0-pre"},
+		{"example.com/q", "v1", "", "v1.4.0"},
+		{"example.com/q", "v1.1", "", "v1.1.1"},
+		{"example.com/q", "v1.2", "", "v1.2.0-pre"},
+		{"example.com/q", "v2", "", ""},
+		{"example.com/q", "v1.1.0", "", "v1.1.0"},
+		{"example.com/q", "v1.1.5", "", ""},
+		{"example.com/q", "<v1.3.0", "", "v1.1.1"},
+		{"example.com/q", "<=v1.3.0", "", "v1.3.0"},
+		{"example.com/q", ">v1.1.0", "", "v1.1.1"},
+		{"example.com/q", ">=v1.4.0", "", "v1.4.0"},
+		{"example.com/q", ">=v1.4.1", "", "v1.5.0-rc.1"}, // v1.4.1 is retracted
+		{"example.com/q", ">v1.4.1", "", "v1.5.0-rc.1"},
+		{"example.com/q", "<v1.0.0", "", ""},
+		{"example.com/q", "main", "", "v1.5.0-rc.1.0.20240101000000-abcdefabcdef"},
+		{"example.com/pre", "latest", "", "v0.1.0-beta"},
+		{"example.com/none", "latest", "", "v0.0.0-20240101000000-abcdefabcdef"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.mod+"@"+tc.query+"_"+tc.current, func(t *testing.T) {
+			got, err := cl.QueryFrom(ctx, tc.mod, tc.query, tc.current)
+			if tc.want == "" {
+				if !IsNotFound(err) {
+					t.Errorf("got %s, %v; want not-found error", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := cl.Query(ctx, "example.com/q", "<banana"); err == nil {
+		t.Error("got no error for invalid comparison query")
+	}
+}
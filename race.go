@@ -0,0 +1,157 @@
+package goproxyclient
+
+import (
+	"context"
+	"io"
+
+	"github.com/bobg/errors"
+)
+
+// WithRace is an [Option] that makes the client query all of its proxies at once
+// rather than one after another,
+// using the first successful response
+// and canceling the requests still in progress.
+// This reduces the latency of an operation
+// to that of the fastest proxy able to answer it,
+// at the cost of extra requests.
+//
+// In race mode,
+// the separators between proxies
+// (which, as described at [Parse],
+// determine which errors cause a fallback to the next one)
+// are ignored,
+// except that proxies after an "off" entry are never queried.
+// An operation fails only if all of the proxies fail,
+// with a [FallbackError] if there was more than one.
+func WithRace() Option {
+	return func(c *config) {
+		c.race = true
+	}
+}
+
+type raceResult[T any] struct {
+	i   int
+	val T
+	err error
+}
+
+// Race is the race-mode version of [loop].
+// It calls f concurrently on each of the proxies in ch
+// (up to and including the first "off" entry),
+// each with its own cancelable copy of ctx.
+// When one succeeds,
+// it cancels the others
+// and waits for them to finish,
+// closing any results they produce anyway.
+func race[T any](ctx context.Context, ch *chain, f func(context.Context, single) (T, error)) (T, error) {
+	entries := ch.reachable()
+	t := traceFrom(ctx)
+
+	var (
+		results = make(chan raceResult[T], len(entries))
+		cancels = make([]context.CancelFunc, len(entries))
+	)
+	for i, s := range entries {
+		sctx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+
+		t.proxyStart(s.baseURL)
+		go func() {
+			val, err := call(sctx, s, f)
+			results <- raceResult[T]{i: i, val: val, err: err}
+		}()
+	}
+
+	var (
+		winner = -1
+		val    T
+		errs   = make([]error, len(entries))
+	)
+	for range entries {
+		r := <-results
+		s := entries[r.i]
+		switch {
+		case winner >= 0:
+			// Too late.
+			err := r.err
+			if err == nil {
+				closeResult(r.val)
+				err = context.Canceled
+			}
+			t.proxyDone(s.baseURL, err, "")
+
+		case r.err == nil:
+			winner, val = r.i, r.val
+			for i, cancel := range cancels {
+				if i != winner {
+					cancel()
+				}
+			}
+			t.proxyDone(s.baseURL, nil, "")
+
+		default:
+			cancels[r.i]()
+			errs[r.i] = r.err
+			t.proxyDone(s.baseURL, r.err, "")
+		}
+	}
+
+	if winner >= 0 {
+		provenanceFrom(ctx).set(entries[winner].baseURL)
+		return releaseWith(val, cancels[winner]), nil
+	}
+
+	var zero T
+	if len(errs) == 1 {
+		return zero, errs[0]
+	}
+	for i, err := range errs {
+		errs[i] = errors.Wrapf(err, "proxy %s", entries[i].baseURL)
+	}
+	return zero, FallbackError{Errs: errs}
+}
+
+// Reachable returns the proxies in ch
+// up to and including the first "off" entry,
+// after which none is ever reached.
+func (ch *chain) reachable() []single {
+	entries := []single{ch.first}
+	for _, next := range ch.rest {
+		if entries[len(entries)-1].off {
+			break
+		}
+		entries = append(entries, next.client)
+	}
+	return entries
+}
+
+// CloseResult closes val if it is an [io.Closer].
+func closeResult(val any) {
+	if c, ok := val.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// ReleaseWith arranges for cancel to be called when val is no longer needed,
+// which for a response body (a [sizedBody])
+// is when it is closed,
+// since reading it depends on the context that cancel cancels.
+// For any other value it is right away.
+func releaseWith[T any](val T, cancel context.CancelFunc) T {
+	if b, ok := any(val).(sizedBody); ok {
+		b.ReadCloser = cancelOnClose{ReadCloser: b.ReadCloser, cancel: cancel}
+		return any(b).(T)
+	}
+	cancel()
+	return val
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
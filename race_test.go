@@ -0,0 +1,122 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRace(t *testing.T) {
+	ctx := context.Background()
+
+	// The slow server answers only when its request is canceled.
+	var canceled atomic.Int64
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+			canceled.Add(1)
+		case <-time.After(10 * time.Second):
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer slow.Close()
+
+	fast := httptest.NewServer(testHandler(nil))
+	defer fast.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	t.Run("fastest_wins", func(t *testing.T) {
+		canceled.Store(0)
+
+		// Without race mode, the slow proxy's error would prevent fallback.
+		cl := New(strings.Join([]string{slow.URL, broken.URL, fast.URL}, ","), nil, WithRace())
+
+		var prov Provenance
+		start := time.Now()
+		versions, err := cl.List(WithProvenance(ctx, &prov), "example.com/multi")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("took %s, want the fast proxy's latency", elapsed)
+		}
+		if len(versions) == 0 {
+			t.Error("got no versions")
+		}
+		if got := prov.Proxy(); got != fast.URL {
+			t.Errorf("got provenance %q, want %q", got, fast.URL)
+		}
+
+		// The server notices the cancellation asynchronously.
+		deadline := time.Now().Add(5 * time.Second)
+		for canceled.Load() == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n := canceled.Load(); n != 1 {
+			t.Errorf("slow proxy saw %d canceled requests, want 1", n)
+		}
+	})
+
+	t.Run("body_outlives_race", func(t *testing.T) {
+		want, err := fs.ReadFile(testdata, "testdata/example.com/multi/@v/v1.0.0.zip")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cl := New(strings.Join([]string{slow.URL, fast.URL}, "|"), nil, WithRace())
+		rc, err := cl.Zip(ctx, "example.com/multi", "v1.0.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+
+		got, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Errorf("got %d bytes, want %d", len(got), len(want))
+		}
+	})
+
+	t.Run("all_fail", func(t *testing.T) {
+		notFound := httptest.NewServer(http.NotFoundHandler())
+		defer notFound.Close()
+
+		cl := New(strings.Join([]string{broken.URL, notFound.URL}, ","), nil, WithRace())
+		_, err := cl.List(ctx, "example.com/multi")
+
+		var fbErr FallbackError
+		if !errors.As(err, &fbErr) {
+			t.Fatalf("got %v, want a FallbackError", err)
+		}
+		if len(fbErr.Errs) != 2 {
+			t.Fatalf("got %d errors, want 2", len(fbErr.Errs))
+		}
+
+		// The errors are in the order of the proxies,
+		// and the last one determines the classification.
+		if IsNotFound(fbErr.Errs[0]) || !IsNotFound(fbErr.Errs[1]) || !IsNotFound(err) {
+			t.Errorf("errors are out of order: %v", err)
+		}
+	})
+
+	t.Run("off", func(t *testing.T) {
+		cl := New(strings.Join([]string{broken.URL, "off", fast.URL}, ","), nil, WithRace())
+		_, err := cl.List(ctx, "example.com/multi")
+		if !errors.Is(err, ErrProxyOff) {
+			t.Errorf("got %v, want ErrProxyOff", err)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package goproxyclient
+
+import (
+	"net/url"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimit is an [Option] that limits the rate of requests to each proxy host
+// to reqsPerSec requests per second,
+// with bursts of up to burst requests.
+// Upstream proxies on the same host share a limit.
+// Requests wait for their turn
+// (or until their context is canceled).
+//
+// This keeps bulk tooling under the request limits published by proxy operators,
+// such as proxy.golang.org.
+func WithRateLimit(reqsPerSec float64, burst int) Option {
+	return func(c *config) {
+		c.rateLimit = rate.Limit(reqsPerSec)
+		c.rateBurst = max(burst, 1)
+	}
+}
+
+// WithHostRateLimiter is an [Option] that limits the rate of requests to proxies on host
+// (a host name, with a port if the proxy URLs have one,
+// such as "proxy.golang.org" or "localhost:8080")
+// using l.
+// Requests wait for their turn
+// (or until their context is canceled).
+//
+// It takes precedence over [WithRateLimit] for that host.
+// The caller keeps control of l,
+// so it may share l among several clients
+// (to bound the total rate at which a bulk tool sends requests to a proxy, for example)
+// or adjust its limit while they are in use.
+// Giving a nil l removes a limiter given earlier for the same host.
+func WithHostRateLimiter(host string, l *rate.Limiter) Option {
+	return func(c *config) {
+		if l == nil {
+			delete(c.hostRateLimiters, host)
+			return
+		}
+		if c.hostRateLimiters == nil {
+			c.hostRateLimiters = make(map[string]*rate.Limiter)
+		}
+		c.hostRateLimiters[host] = l
+	}
+}
+
+// HostLimiter returns the request-rate limiter for the host in baseURL:
+// the one given with [WithHostRateLimiter],
+// if any,
+// or else the one for the client's rate limit,
+// creating it if necessary.
+// It returns nil if there is no rate limit.
+func (c *config) hostLimiter(baseURL string) *rate.Limiter {
+	host := baseURL
+	if u, err := url.Parse(baseURL); err == nil {
+		host = u.Host
+	}
+
+	if l, ok := c.hostRateLimiters[host]; ok {
+		return l
+	}
+	if c.rateLimit == 0 {
+		return nil
+	}
+
+	if l, ok := c.hostLimiters[host]; ok {
+		return l
+	}
+	if c.hostLimiters == nil {
+		c.hostLimiters = make(map[string]*rate.Limiter)
+	}
+	l := rate.NewLimiter(c.rateLimit, c.rateBurst)
+	c.hostLimiters[host] = l
+	return l
+}
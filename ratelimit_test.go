@@ -0,0 +1,80 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	// Two upstreams on the same host share one limit.
+	cl := New(s.URL+"/a|"+s.URL, nil, WithRateLimit(20, 1))
+	if cl.chain().first.reqLimiter != cl.chain().rest[0].client.reqLimiter {
+		t.Error("upstreams on the same host have different limiters")
+	}
+
+	start := time.Now()
+	for range 5 {
+		if _, err := cl.List(ctx, "github.com/bobg/errors"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Each List makes two requests (the first fails at /a),
+	// so ten requests at 20 per second
+	// with an initial burst of one
+	// take at least 450ms.
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("10 requests took %s, want at least 400ms", elapsed)
+	}
+}
+
+func TestHostRateLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two clients share one limiter,
+	// which takes precedence over WithRateLimit.
+	l := rate.NewLimiter(20, 1)
+	cl1 := New(s.URL, nil, WithRateLimit(1000, 1000), WithHostRateLimiter(u.Host, l))
+	cl2 := New(s.URL, nil, WithHostRateLimiter(u.Host, l))
+	if cl1.chain().first.reqLimiter != l || cl2.chain().first.reqLimiter != l {
+		t.Fatal("clients are not using the given limiter")
+	}
+
+	// Other hosts are unaffected.
+	cl3 := New(s.URL, nil, WithHostRateLimiter("proxy.golang.org", l))
+	if cl3.chain().first.reqLimiter != nil {
+		t.Error("limiter applies to the wrong host")
+	}
+
+	start := time.Now()
+	for range 5 {
+		for _, cl := range []Client{cl1, cl2} {
+			if _, err := cl.List(ctx, "github.com/bobg/errors"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Ten requests at 20 per second
+	// with an initial burst of one
+	// take at least 450ms.
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("10 requests took %s, want at least 400ms", elapsed)
+	}
+}
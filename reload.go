@@ -0,0 +1,114 @@
+package goproxyclient
+
+import (
+	"maps"
+	"net/http"
+)
+
+// Reload replaces the client's list of proxies with entries
+// (as for [NewFromConfig])
+// and its options affecting individual requests with opts,
+// for all copies of the client at once.
+// This lets a long-running program react to a change in its GOPROXY setting,
+// rotate credentials,
+// or reroute requests
+// without creating a new client
+// (and losing its cache, circuit breakers, and other state)
+// or interrupting operations in progress,
+// which finish with the proxies and options they started with.
+//
+// The options that Reload replaces are the ones listed at [WithUpstreamConfig],
+// together with WithUpstreamConfig itself and [WithUpstreamOutboundProxy].
+// If hc is non-nil,
+// it replaces the HTTP client passed to [New] or [NewFromConfig]
+// (or to an earlier Reload);
+// otherwise that one is kept.
+// Other options apply to the client as a whole,
+// keep the values they were given when the client was created,
+// and are ignored in opts.
+//
+// A proxy whose base URL is in the client's list both before and after the reload
+// keeps its [ProxyStats],
+// and its circuit breaker state if the [CircuitBreakerPolicy] is unchanged.
+func (cl Client) Reload(entries []ProxyEntry, hc *http.Client, opts ...Option) {
+	cl.conf.reloadMu.Lock()
+	defer cl.conf.reloadMu.Unlock()
+
+	old := cl.chain()
+
+	if hc == nil {
+		hc = cl.conf.hc
+	} else {
+		cl.conf.hc = hc
+	}
+
+	conf := &config{
+		requestIDHeader: DefaultRequestIDHeader,
+		vcsPolicy:       cl.conf.vcsPolicy,
+		insecure:        cl.conf.insecure,
+		vcsCacheDir:     cl.conf.vcsCacheDir,
+		journal:         cl.conf.journal,
+		scheduler:       cl.conf.scheduler,
+	}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	if conf.rateLimit == old.conf.rateLimit && conf.rateBurst == old.conf.rateBurst {
+		// Keep the state of the per-host rate limiters.
+		conf.hostLimiters = maps.Clone(old.conf.hostLimiters)
+	}
+	if conf.tenantLimiters.sameRate(old.conf.tenantLimiters) {
+		// Keep the state of the per-tenant rate limiters.
+		conf.tenantLimiters = old.conf.tenantLimiters
+	}
+
+	ch := newChain(entries, hc, conf)
+
+	ch.first.inherit(old)
+	for i := range ch.rest {
+		ch.rest[i].client.inherit(old)
+	}
+	if od, d := old.conf.direct, conf.direct; od != nil && d != nil {
+		// Keep the repositories already fetched.
+		od.mu.Lock()
+		d.repos = maps.Clone(od.repos)
+		od.mu.Unlock()
+	}
+
+	cl.conf.chain.Store(ch)
+
+	// Connections in use by operations in progress are unaffected.
+	// HTTP clients belonging to the caller are left alone,
+	// since they may be shared.
+	old.closeIdleConnections()
+}
+
+// Inherit gives s the per-proxy state of the proxy in old with the same base URL,
+// if there is one.
+func (s *single) inherit(old *chain) {
+	for _, o := range old.reachable() {
+		if o.baseURL != s.baseURL {
+			continue
+		}
+		s.head, s.stats = o.head, o.stats
+		if s.breaker != nil && o.breaker != nil && s.breaker.policy == o.breaker.policy {
+			s.breaker = o.breaker
+		}
+		return
+	}
+}
+
+func (ch *chain) closeIdleConnections() {
+	ch.first.closeIdleConnections()
+	for _, next := range ch.rest {
+		next.client.closeIdleConnections()
+	}
+}
+
+// CloseIdleConnections closes the idle connections of s's HTTP client,
+// if its transport was created for s.
+func (s single) closeIdleConnections() {
+	if s.ownTransport {
+		s.client.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,174 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+
+	// The first server requires a password
+	// and doesn't answer until release is closed.
+	var (
+		password atomic.Value
+		requests atomic.Int64
+		release  = make(chan struct{})
+		h        = testHandler(nil)
+	)
+	password.Store("old")
+	first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		<-release
+		if _, pw, _ := req.BasicAuth(); pw != password.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, req)
+	}))
+	defer first.Close()
+
+	second := httptest.NewServer(testHandler(nil))
+	defer second.Close()
+
+	auth := func(pw string) Option {
+		return WithAuth(AuthFunc(func(_ context.Context, req *http.Request) error {
+			req.SetBasicAuth("user", pw)
+			return nil
+		}))
+	}
+
+	cl := New(first.URL, nil, auth("old"))
+
+	// Start an operation,
+	// and rotate the password while it's in progress.
+	errch := make(chan error, 1)
+	go func() {
+		_, err := cl.List(ctx, "example.com/multi")
+		errch <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("request not received")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	entries, err := ParseConfig(first.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl.Reload(entries, nil, auth("new"))
+	password.Store("new")
+	close(release)
+
+	// The operation in progress finishes with the old password
+	// (which the server no longer accepts).
+	if err := <-errch; Classify(err) != AuthFailed {
+		t.Errorf("got %v, want an authentication failure", err)
+	}
+
+	// A new one uses the new one,
+	// and so does a copy of the client.
+	cl2 := cl
+	if _, err := cl2.List(ctx, "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The proxy kept its stats.
+	if got := cl.Proxies()[0].Stats.Requests; got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+
+	// Change the proxy list.
+	entries, err = ParseConfig(second.URL + "," + first.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl.Reload(entries, nil, auth("new"))
+
+	requests.Store(0)
+	var prov Provenance
+	if _, err := cl2.List(WithProvenance(ctx, &prov), "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := prov.Proxy(); got != second.URL {
+		t.Errorf("got provenance %s, want %s", got, second.URL)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("got %d requests to the first proxy, want 0", got)
+	}
+
+	infos := cl.Proxies()
+	if len(infos) != 2 || infos[0].URL != second.URL || infos[1].URL != first.URL || infos[1].Stats.Requests != 2 {
+		t.Errorf("got proxies %+v", infos)
+	}
+}
+
+// countingTransport is an [http.RoundTripper]
+// that counts its requests and calls to CloseIdleConnections.
+type countingTransport struct {
+	requests, closes atomic.Int64
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (t *countingTransport) CloseIdleConnections() {
+	t.closes.Add(1)
+}
+
+func TestReloadHTTPClient(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	entries, err := ParseConfig(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := new(countingTransport)
+	cl := New(s.URL, &http.Client{Transport: transport})
+
+	// Reloading without an HTTP client keeps the caller's,
+	// and leaves its connections alone.
+	for range 2 {
+		cl.Reload(entries, nil)
+	}
+	if _, err := cl.List(ctx, "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := transport.requests.Load(); got != 1 {
+		t.Errorf("got %d requests through the caller's transport, want 1", got)
+	}
+	if got := transport.closes.Load(); got != 0 {
+		t.Errorf("got %d calls to CloseIdleConnections on the caller's transport, want 0", got)
+	}
+
+	// A new one replaces it,
+	// including for later reloads without one.
+	transport2 := new(countingTransport)
+	cl.Reload(entries, &http.Client{Transport: transport2})
+	cl.Reload(entries, nil)
+	if _, err := cl.List(ctx, "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := transport2.requests.Load(); got != 1 {
+		t.Errorf("got %d requests through the new transport, want 1", got)
+	}
+	if got := transport.requests.Load(); got != 1 {
+		t.Errorf("got %d requests through the old transport, want 1", got)
+	}
+	if got := transport.closes.Load() + transport2.closes.Load(); got != 0 {
+		t.Errorf("got %d calls to CloseIdleConnections on the caller's transports, want 0", got)
+	}
+}
@@ -0,0 +1,209 @@
+package goproxyclient
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bobg/errors"
+)
+
+// Repo describes the source repository of a module.
+type Repo struct {
+	// VCS is the kind of version control system, e.g. "git".
+	VCS string
+
+	// URL is the URL of the repository.
+	URL string
+
+	// Subdir is the subdirectory of the repository containing the module,
+	// if it is not at the root.
+	Subdir string
+}
+
+// Repo resolves a module path to its source repository.
+//
+// It first looks for Origin data in the proxy's response for the latest version of the module.
+// Failing that,
+// it fetches https://MODPATH?go-get=1 and looks for a go-import meta tag,
+// as described at https://go.dev/ref/mod#vcs-find.
+func (cl Client) Repo(ctx context.Context, mod string) (Repo, error) {
+	info, err := cl.LatestInfo(ctx, mod)
+	if err == nil {
+		if repo, ok := repoFromOrigin(info.Origin); ok {
+			return repo, nil
+		}
+	}
+
+	repo, err2 := cl.repoFromGoGet(ctx, mod)
+	if err2 != nil {
+		return Repo{}, errors.Join(err, err2)
+	}
+	return repo, nil
+}
+
+func repoFromOrigin(o *Origin) (Repo, bool) {
+	if o == nil || o.VCS == "" || o.URL == "" {
+		return Repo{}, false
+	}
+	return Repo{VCS: o.VCS, URL: o.URL, Subdir: o.Subdir}, true
+}
+
+func (cl Client) repoFromGoGet(ctx context.Context, mod string) (Repo, error) {
+	imp, err := goImport(ctx, cl.chain().first.client, mod)
+	if err != nil {
+		return Repo{}, err
+	}
+	if err := checkRepoURL(imp.repo, cl.conf.insecure.Match(mod)); err != nil {
+		return Repo{}, err
+	}
+	return Repo{VCS: imp.vcs, URL: imp.repo, Subdir: imp.subdir}, nil
+}
+
+// GoImport fetches https://MODPATH?go-get=1
+// and returns the go-import meta tag for the repository containing mod.
+func goImport(ctx context.Context, hc *http.Client, mod string) (metaImport, error) {
+	q := fmt.Sprintf("https://%s?go-get=1", mod)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", q, nil)
+	if err != nil {
+		return metaImport{}, errors.Wrapf(err, "creating GET %s request", q)
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return metaImport{}, errors.Wrapf(err, "in GET %s", q)
+	}
+	defer resp.Body.Close()
+
+	// Note: the go command accepts go-import tags even from non-200 responses,
+	// so this does too.
+
+	imports, err := parseMetaGoImports(resp.Body)
+	if err != nil {
+		return metaImport{}, errors.Wrapf(err, "parsing response from GET %s", q)
+	}
+
+	var (
+		best  metaImport
+		found bool
+	)
+	for _, imp := range imports {
+		if imp.vcs == "mod" {
+			// This names a module proxy, not a repository.
+			continue
+		}
+		if mod != imp.prefix && !strings.HasPrefix(mod, imp.prefix+"/") {
+			continue
+		}
+		if found && best.prefix != imp.prefix {
+			return metaImport{}, fmt.Errorf("multiple go-import meta tags match %s in response from GET %s", mod, q)
+		}
+		best, found = imp, true
+	}
+	if !found {
+		return metaImport{}, fmt.Errorf("no go-import meta tag for %s in response from GET %s", mod, q)
+	}
+
+	return best, nil
+}
+
+// allowFileRepos permits file: repository URLs,
+// for tests.
+var allowFileRepos = false
+
+// CheckRepoURL tells whether repo,
+// a repository URL from a go-import meta tag,
+// may be passed to a version control command.
+// As in the go command,
+// it must be an https, ssh, or git+ssh URL,
+// or a git or http URL if insecure is true.
+// Anything else,
+// including a local path
+// or something git might take for an option,
+// is an error.
+func checkRepoURL(repo string, insecure bool) error {
+	if strings.HasPrefix(repo, "-") {
+		return fmt.Errorf("invalid repository URL %q", repo)
+	}
+	u, err := url.Parse(repo)
+	if err != nil {
+		return errors.Wrapf(err, "parsing repository URL %q", repo)
+	}
+	if u.Scheme == "file" && allowFileRepos {
+		return nil
+	}
+	if u.Host == "" || strings.HasPrefix(u.Host, "-") {
+		return fmt.Errorf("invalid repository URL %q", repo)
+	}
+	switch u.Scheme {
+	case "https", "ssh", "git+ssh":
+		return nil
+	case "http", "git":
+		if insecure {
+			return nil
+		}
+		return fmt.Errorf("insecure repository URL %q (see GOINSECURE)", repo)
+	default:
+		return fmt.Errorf("unsupported scheme in repository URL %q", repo)
+	}
+}
+
+type metaImport struct {
+	prefix, vcs, repo, subdir string
+}
+
+// ParseMetaGoImports finds the go-import meta tags in an HTML document.
+// It is modeled on the function of the same name in cmd/go.
+func parseMetaGoImports(r io.Reader) ([]metaImport, error) {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+
+	var result []metaImport
+	for {
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) || (err != nil && len(result) > 0) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if e, ok := tok.(xml.StartElement); ok && strings.EqualFold(e.Name.Local, "body") {
+			return result, nil
+		}
+		if e, ok := tok.(xml.EndElement); ok && strings.EqualFold(e.Name.Local, "head") {
+			return result, nil
+		}
+
+		e, ok := tok.(xml.StartElement)
+		if !ok || !strings.EqualFold(e.Name.Local, "meta") {
+			continue
+		}
+		if attrValue(e.Attr, "name") != "go-import" {
+			continue
+		}
+		switch f := strings.Fields(attrValue(e.Attr, "content")); len(f) {
+		case 3:
+			result = append(result, metaImport{prefix: f[0], vcs: f[1], repo: f[2]})
+		case 4:
+			// The optional fourth field is a subdirectory of the repository.
+			result = append(result, metaImport{prefix: f[0], vcs: f[1], repo: f[2], subdir: f[3]})
+		}
+	}
+}
+
+func attrValue(attrs []xml.Attr, name string) string {
+	for _, a := range attrs {
+		if strings.EqualFold(a.Name.Local, name) {
+			return a.Value
+		}
+	}
+	return ""
+}
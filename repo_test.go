@@ -0,0 +1,78 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepoFromOrigin(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+	repo, err := cl.Repo(context.Background(), "github.com/bobg/subcmd/v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Repo{VCS: "git", URL: "https://github.com/bobg/subcmd"}
+	if repo != want {
+		t.Errorf("got %+v, want %+v", repo, want)
+	}
+}
+
+func TestParseMetaGoImports(t *testing.T) {
+	const doc = `<!DOCTYPE html>
+<html>
+<head>
+<meta http-equiv="Content-Type" content="text/html; charset=utf-8">
+<meta name="go-import" content="example.com/foo git https://github.com/example/foo">
+<meta name="go-import" content="example.com/foo mod https://proxy.example.com">
+<meta name="go-import" content="example.com/bar git https://github.com/example/monorepo bar">
+<meta name="go-source" content="example.com/foo https://github.com/example/foo _ _">
+</head>
+<body>
+<meta name="go-import" content="example.com/ignored git https://github.com/example/ignored">
+</body>
+</html>`
+
+	got, err := parseMetaGoImports(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []metaImport{
+		{prefix: "example.com/foo", vcs: "git", repo: "https://github.com/example/foo"},
+		{prefix: "example.com/foo", vcs: "mod", repo: "https://proxy.example.com"},
+		{prefix: "example.com/bar", vcs: "git", repo: "https://github.com/example/monorepo", subdir: "bar"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestBranchHead(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+	head, err := cl.BranchHead(context.Background(), "github.com/bobg/errors", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Head{
+		Version: "v1.1.1-0.20240601120000-0123456789ab",
+		Time:    time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		Hash:    "0123456789abcdef0123456789abcdef01234567",
+		Ref:     "refs/heads/main",
+	}
+	if !head.Time.Equal(want.Time) {
+		t.Errorf("got time %s, want %s", head.Time, want.Time)
+	}
+	head.Time = want.Time
+	if head != want {
+		t.Errorf("got %+v, want %+v", head, want)
+	}
+}
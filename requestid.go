@@ -0,0 +1,69 @@
+package goproxyclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+)
+
+// DefaultRequestIDHeader is the name of the HTTP header
+// in which the client sends request IDs to proxies,
+// unless changed with [WithRequestIDHeader].
+const DefaultRequestIDHeader = "X-Request-Id"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the given request ID.
+// Operations on a [Client] using the resulting context
+// send the ID to each proxy they contact
+// and include it in log events (see [WithLogger]),
+// so that all of the upstream requests for one logical operation
+// (such as a single call to [Client.Info] that tries several proxies)
+// can be correlated.
+//
+// Operations whose context has no request ID get a randomly generated one.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx,
+// or the empty string if there isn't one.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// EnsureRequestID returns ctx if it has a request ID,
+// otherwise a copy of it with a newly generated one.
+func ensureRequestID(ctx context.Context) context.Context {
+	if RequestID(ctx) != "" {
+		return ctx
+	}
+	var buf [8]byte
+	rand.Read(buf[:])
+	return WithRequestID(ctx, hex.EncodeToString(buf[:]))
+}
+
+// WithRequestIDHeader is an [Option] that sets the name of the HTTP header
+// in which request IDs are sent to proxies.
+// The default is [DefaultRequestIDHeader].
+// An empty name means not to send request IDs.
+func WithRequestIDHeader(name string) Option {
+	return func(c *config) {
+		c.requestIDHeader = name
+	}
+}
+
+// WithLogger is an [Option] that makes the client log each request to a proxy
+// (at level [slog.LevelDebug])
+// to the given logger.
+// Each log event includes the operation's request ID
+// (see [WithRequestID]),
+// and its tenant ID and priority if it has them
+// (see [WithTenant] and [WithPriority]).
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = l
+	}
+}
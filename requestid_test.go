@@ -0,0 +1,82 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		ids []string
+	)
+
+	h := testHandler(nil)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		ids = append(ids, req.Header.Get(DefaultRequestIDHeader))
+		mu.Unlock()
+		h.ServeHTTP(w, req)
+	}))
+	defer s.Close()
+
+	var logbuf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logbuf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	// The first proxy has nothing, so each operation makes two requests.
+	cl := New(s.URL+"/empty,"+s.URL, nil, WithLogger(logger))
+
+	t.Run("generated", func(t *testing.T) {
+		ids = nil
+		logbuf.Reset()
+
+		if _, _, _, err := cl.Info(context.Background(), "github.com/bobg/errors", "v1.1.0"); err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) != 2 {
+			t.Fatalf("got %d requests, want 2", len(ids))
+		}
+		if ids[0] == "" || ids[0] != ids[1] {
+			t.Errorf("got request IDs %q, want two identical non-empty IDs", ids)
+		}
+
+		dec := json.NewDecoder(&logbuf)
+		var n int
+		for dec.More() {
+			var event struct {
+				RequestID string `json:"request_id"`
+				Status    int    `json:"status"`
+			}
+			if err := dec.Decode(&event); err != nil {
+				t.Fatal(err)
+			}
+			if event.RequestID != ids[0] {
+				t.Errorf("log event has request ID %q, want %q", event.RequestID, ids[0])
+			}
+			n++
+		}
+		if n != 2 {
+			t.Errorf("got %d log events, want 2", n)
+		}
+	})
+
+	t.Run("supplied", func(t *testing.T) {
+		ids = nil
+
+		ctx := WithRequestID(context.Background(), "xyzzy")
+		if _, err := cl.List(ctx, "github.com/bobg/errors"); err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range ids {
+			if id != "xyzzy" {
+				t.Errorf("got request ID %q, want xyzzy", id)
+			}
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package goproxyclient
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobg/errors"
+)
+
+// RetryPolicy says how a client retries requests that fail transiently:
+// with a 5xx status
+// (other than 501 Not Implemented),
+// or with a network error.
+// Retries happen for each proxy
+// before falling back to the next one in the client's list.
+//
+// The delay before each retry is BaseDelay,
+// doubled for each retry after the first,
+// up to MaxDelay.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts for each request,
+	// including the first.
+	// Values less than 2 mean no retries.
+	Attempts int
+
+	// BaseDelay is the delay before the first retry.
+	BaseDelay time.Duration
+
+	// MaxDelay is the maximum delay before a retry.
+	// Zero means no limit.
+	MaxDelay time.Duration
+
+	// Jitter is the fraction of each delay
+	// (from 0 to 1)
+	// that is random,
+	// so that many clients failing at once don't retry in lockstep.
+	// For example,
+	// with a Jitter of 0.25,
+	// a 1-second delay is randomly chosen from between 0.75 and 1 seconds.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is a reasonable [RetryPolicy] for use with [WithRetry].
+// Without WithRetry,
+// a client does not retry at all.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:  3,
+	BaseDelay: 500 * time.Millisecond,
+	MaxDelay:  10 * time.Second,
+	Jitter:    0.5,
+}
+
+// WithRetry is an [Option] that makes the client retry requests that fail transiently,
+// according to p.
+func WithRetry(p RetryPolicy) Option {
+	return func(c *config) {
+		c.retry = p
+	}
+}
+
+// WithRetryAfter is an [Option] that makes the client wait and retry
+// when a proxy responds with 429 (Too Many Requests),
+// or with 503 (Service Unavailable) and a Retry-After header,
+// as rate-limiting proxies do.
+// The client waits as long as the Retry-After header says,
+// or, for a 429 response without one,
+// one second,
+// doubling with each further 429 response.
+//
+// The total time spent waiting in this way for a single request
+// is limited to maxWait.
+// If the proxy asks for a longer wait,
+// or the wait would outlast the request context's deadline,
+// the request fails immediately with the proxy's status code,
+// as it does without this option.
+//
+// These retries are separate from,
+// and do not count against,
+// those of a [RetryPolicy] (see [WithRetry]).
+func WithRetryAfter(maxWait time.Duration) Option {
+	return func(c *config) {
+		c.retryAfterMax = maxWait
+	}
+}
+
+// DefaultRateLimitDelay is the delay before retrying after a 429 response
+// with no Retry-After header.
+// It doubles for each further such response.
+const defaultRateLimitDelay = time.Second
+
+// Delay returns the delay before the given retry
+// (1 for the first retry).
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < retry; i++ {
+		if d > math.MaxInt64/2 || (p.MaxDelay > 0 && d >= p.MaxDelay) {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * min(p.Jitter, 1) * float64(d))
+	}
+	return d
+}
+
+// Retryable tells whether a failed request should be retried.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var codeErr CodeErr
+	if errors.As(err, &codeErr) {
+		code := codeErr.Code()
+		return code >= 500 && code != http.StatusNotImplemented
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// Sleep waits for d or until ctx is canceled.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RetryAfterError is the error inside the [mid.CodeErr]
+// for a 429 or 503 response with a Retry-After header.
+type retryAfterError struct {
+	error
+	after time.Duration
+}
+
+func (e retryAfterError) Unwrap() error {
+	return e.error
+}
+
+// RetryAfter tells whether the single should wait and retry a request that failed with err,
+// as described for [WithRetryAfter],
+// and if so for how long.
+// The number of rate-limited responses to the request so far is n.
+func (s single) retryAfter(ctx context.Context, err error, n int) (time.Duration, bool) {
+	if s.retryAfterMax <= 0 || ctx.Err() != nil {
+		return 0, false
+	}
+
+	var d time.Duration
+
+	var (
+		codeErr CodeErr
+		raErr   retryAfterError
+	)
+	switch {
+	case errors.As(err, &raErr):
+		d = raErr.after
+	case errors.As(err, &codeErr) && codeErr.Code() == http.StatusTooManyRequests:
+		d = defaultRateLimitDelay << min(n, 30)
+	default:
+		return 0, false
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return 0, false
+	}
+	return d, true
+}
+
+// ParseRetryAfter parses the value of a Retry-After header,
+// which is either a number of seconds or an HTTP date.
+func parseRetryAfter(val string, now time.Time) (time.Duration, bool) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseInt(val, 10, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(min(secs, math.MaxInt64/int64(time.Second))) * time.Second, true
+	}
+	t, err := http.ParseTime(val)
+	if err != nil {
+		return 0, false
+	}
+	return max(t.Sub(now), 0), true
+}
@@ -0,0 +1,176 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		fails   atomic.Int64 // how many more requests fail
+		code    atomic.Int64 // with what status
+		handler = testHandler(nil)
+		count   atomic.Int64
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		count.Add(1)
+		if fails.Add(-1) >= 0 {
+			w.WriteHeader(int(code.Load()))
+			return
+		}
+		handler.ServeHTTP(w, req)
+	}))
+	defer s.Close()
+
+	policy := RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond, Jitter: 0.5}
+
+	cases := []struct {
+		name      string
+		opts      []Option
+		fails     int64
+		code      int
+		wantErr   bool
+		wantCount int64
+	}{
+		{name: "no_retry", fails: 1, code: http.StatusServiceUnavailable, wantErr: true, wantCount: 1},
+		{name: "recovers", opts: []Option{WithRetry(policy)}, fails: 2, code: http.StatusServiceUnavailable, wantCount: 3},
+		{name: "exhausted", opts: []Option{WithRetry(policy)}, fails: 3, code: http.StatusBadGateway, wantErr: true, wantCount: 3},
+		{name: "not_found", opts: []Option{WithRetry(policy)}, fails: 1, code: http.StatusNotFound, wantErr: true, wantCount: 1},
+		{name: "not_implemented", opts: []Option{WithRetry(policy)}, fails: 1, code: http.StatusNotImplemented, wantErr: true, wantCount: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fails.Store(tc.fails)
+			code.Store(int64(tc.code))
+			count.Store(0)
+
+			cl := New(s.URL, nil, tc.opts...)
+			_, err := cl.List(ctx, "example.com/multi")
+			if (err != nil) != tc.wantErr {
+				t.Errorf("got error %v, want error: %v", err, tc.wantErr)
+			}
+			if n := count.Load(); n != tc.wantCount {
+				t.Errorf("got %d requests, want %d", n, tc.wantCount)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for retry, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 100: 5 * time.Second} {
+		if got := p.delay(retry); got != want {
+			t.Errorf("retry %d: got %s, want %s", retry, got, want)
+		}
+	}
+
+	p.Jitter = 0.25
+	for range 100 {
+		if got := p.delay(1); got < 750*time.Millisecond || got > time.Second {
+			t.Fatalf("got %s, want between 750ms and 1s", got)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		fails      atomic.Int64 // how many more requests fail
+		code       atomic.Int64 // with what status
+		retryAfter atomic.Value // and what Retry-After header
+		handler    = testHandler(nil)
+		count      atomic.Int64
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		count.Add(1)
+		if fails.Add(-1) >= 0 {
+			if ra := retryAfter.Load().(string); ra != "" {
+				w.Header().Set("Retry-After", ra)
+			}
+			w.WriteHeader(int(code.Load()))
+			return
+		}
+		handler.ServeHTTP(w, req)
+	}))
+	defer s.Close()
+
+	cases := []struct {
+		name       string
+		opts       []Option
+		fails      int64
+		code       int
+		retryAfter string
+		wantCode   int // 0 for success
+		wantCount  int64
+	}{
+		{name: "no_option", fails: 1, code: http.StatusTooManyRequests, retryAfter: "0", wantCode: http.StatusTooManyRequests, wantCount: 1},
+		{name: "429", opts: []Option{WithRetryAfter(time.Second)}, fails: 2, code: http.StatusTooManyRequests, retryAfter: "0", wantCount: 3},
+		{name: "503", opts: []Option{WithRetryAfter(time.Second)}, fails: 1, code: http.StatusServiceUnavailable, retryAfter: "0", wantCount: 2},
+		{name: "503_no_header", opts: []Option{WithRetryAfter(time.Second)}, fails: 1, code: http.StatusServiceUnavailable, wantCode: http.StatusServiceUnavailable, wantCount: 1},
+		{name: "too_long", opts: []Option{WithRetryAfter(time.Second)}, fails: 1, code: http.StatusTooManyRequests, retryAfter: "3600", wantCode: http.StatusTooManyRequests, wantCount: 1},
+		{name: "429_no_header_too_long", opts: []Option{WithRetryAfter(time.Millisecond)}, fails: 1, code: http.StatusTooManyRequests, wantCode: http.StatusTooManyRequests, wantCount: 1},
+		{
+			// Responses with Retry-After don't count against the retry policy's attempts.
+			name:       "with_retry_policy",
+			opts:       []Option{WithRetryAfter(time.Second), WithRetry(RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond})},
+			fails:      3,
+			code:       http.StatusServiceUnavailable,
+			retryAfter: "0",
+			wantCount:  4,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fails.Store(tc.fails)
+			code.Store(int64(tc.code))
+			retryAfter.Store(tc.retryAfter)
+			count.Store(0)
+
+			cl := New(s.URL, nil, tc.opts...)
+			_, err := cl.List(ctx, "example.com/multi")
+			if tc.wantCode == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				var codeErr CodeErr
+				if !errors.As(err, &codeErr) || codeErr.Code() != tc.wantCode {
+					t.Errorf("got error %v, want status %d", err, tc.wantCode)
+				}
+			}
+			if n := count.Load(); n != tc.wantCount {
+				t.Errorf("got %d requests, want %d", n, tc.wantCount)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	cases := []struct {
+		val    string
+		want   time.Duration
+		wantOK bool
+	}{
+		{val: ""},
+		{val: "5", want: 5 * time.Second, wantOK: true},
+		{val: " 0 ", wantOK: true},
+		{val: "-1"},
+		{val: "soon"},
+		{val: "Thu, 02 Jan 2025 03:05:05 GMT", want: time.Minute, wantOK: true},
+		{val: "Thu, 02 Jan 2025 03:00:00 GMT", wantOK: true},
+	}
+	for _, tc := range cases {
+		got, ok := parseRetryAfter(tc.val, now)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("parseRetryAfter(%q): got %s, %v; want %s, %v", tc.val, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package goproxyclient
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+	modzip "golang.org/x/mod/zip"
+)
+
+// Rezip writes to w a module zip file for mod@ver
+// containing the files in dir,
+// such as a directory populated by [Client.Extract]
+// (perhaps with changes, for a patched version of the module).
+// The zip file conforms to the module zip format that the go command requires,
+// as created by [modzip.CreateFromDir]:
+// files that don't belong in a module zip,
+// such as those in version-control directories
+// and in nested modules,
+// are left out,
+// and it is an error if the files exceed the format's size limits.
+func Rezip(w io.Writer, mod, ver, dir string) error {
+	err := modzip.CreateFromDir(w, module.Version{Path: mod, Version: ver}, dir)
+	return errors.Wrapf(err, "creating zip for %s@%s from %s", mod, ver, dir)
+}
+
+// RezipFile is like [Rezip]
+// but writes the zip file to the named file,
+// creating or replacing it.
+// The zip goes first to a temporary file in the same directory,
+// which is renamed into place only if [Rezip] succeeds,
+// so on failure nothing is left at path
+// (and any file already there is unchanged).
+func RezipFile(path, mod, ver, dir string) error {
+	return writeFileAtomic(path, 0644, false, func(w io.Writer) error {
+		return Rezip(w, mod, ver, dir)
+	})
+}
+
+// VerifyRezip checks that dir holds exactly the contents of mod@ver,
+// as extracted by [Client.Extract],
+// by re-creating its zip file (see [Rezip])
+// and comparing the zip's h1 hash
+// (see [H1])
+// with that of the original.
+// The original's hash is the known one
+// from the client's [VerifiedDB] or [ChecksumDB],
+// if it has one,
+// and otherwise is computed from the zip file fetched from the client's proxies.
+//
+// It returns the h1 hash of the re-created zip,
+// and a [*HashMismatchError] if it does not match.
+// Note that the hash cannot match
+// if only some of the module's files were extracted
+// (see [Client.ExtractFiltered]).
+func (cl Client) VerifyRezip(ctx context.Context, mod, ver, dir string) (string, error) {
+	got, err := rezipHash(mod, ver, dir)
+	if err != nil {
+		return "", err
+	}
+
+	want, ok, _, err := cl.expectedHash(mod, ver)
+	if err != nil {
+		return got, err
+	}
+	if !ok || !strings.HasPrefix(want, H1.Name()+":") {
+		d, err := cl.zipFile(ctx, mod, ver)
+		if err != nil {
+			return got, err
+		}
+		defer d.remove()
+
+		want, err = H1.HashZip(d.Name())
+		if err != nil {
+			return got, errors.Wrapf(err, "hashing original zip for %s@%s", mod, ver)
+		}
+	}
+
+	if got != want {
+		return got, &HashMismatchError{Module: mod, Version: ver, Want: want, Got: got}
+	}
+	return got, nil
+}
+
+// RezipHash re-creates the zip file for mod@ver from dir
+// in a temporary file
+// and returns its h1 hash.
+func rezipHash(mod, ver, dir string) (string, error) {
+	f, err := os.CreateTemp("", "goproxyclient-rezip-*.zip")
+	if err != nil {
+		return "", errors.Wrap(err, "creating temp file")
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := Rezip(f, mod, ver, dir); err != nil {
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", errors.Wrapf(err, "writing zip for %s@%s", mod, ver)
+	}
+
+	h, err := H1.HashZip(f.Name())
+	return h, errors.Wrapf(err, "hashing zip for %s@%s", mod, ver)
+}
@@ -0,0 +1,132 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRezip(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+
+	const (
+		mod = "example.com/multi"
+		ver = "v1.0.0"
+	)
+
+	dir := t.TempDir()
+	if err := cl.Extract(ctx, mod, ver, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := cl.VerifyRezip(ctx, mod, ver, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Rezip(&buf, mod, ver, dir); err != nil {
+		t.Fatal(err)
+	}
+	zipfile := filepath.Join(t.TempDir(), "rezip.zip")
+	if err := os.WriteFile(zipfile, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := H1.HashZip(zipfile); err != nil {
+		t.Fatal(err)
+	} else if got != hash {
+		t.Errorf("got hash %s from Rezip, want %s from VerifyRezip", got, hash)
+	}
+
+	t.Run("known_hash", func(t *testing.T) {
+		db, err := OpenVerifiedDB(filepath.Join(t.TempDir(), "verified"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Add(mod, ver, hash); err != nil {
+			t.Fatal(err)
+		}
+
+		// The proxy is unreachable,
+		// so the known hash must be used.
+		cl := New("off", nil, WithVerifiedDB(db))
+		if _, err := cl.VerifyRezip(ctx, mod, ver, dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("patched", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(dir, "multi.go"), []byte("package multi\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := cl.VerifyRezip(ctx, mod, ver, dir)
+
+		var mismatch *HashMismatchError
+		if !errors.As(err, &mismatch) {
+			t.Fatalf("got %v, want HashMismatchError", err)
+		}
+		if mismatch.Want != hash {
+			t.Errorf("got want-hash %s, want %s", mismatch.Want, hash)
+		}
+	})
+}
+
+func TestRezipFile(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+
+	const (
+		mod = "example.com/multi"
+		ver = "v1.0.0"
+	)
+
+	dir := t.TempDir()
+	if err := cl.Extract(ctx, mod, ver, dir); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := cl.VerifyRezip(ctx, mod, ver, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outdir := t.TempDir()
+	zipfile := filepath.Join(outdir, "rezip.zip")
+	if err := RezipFile(zipfile, mod, ver, dir); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := H1.HashZip(zipfile); err != nil {
+		t.Fatal(err)
+	} else if got != hash {
+		t.Errorf("got hash %s from RezipFile, want %s from VerifyRezip", got, hash)
+	}
+
+	// A failed rezip leaves nothing at its output path.
+	failed := filepath.Join(outdir, "failed.zip")
+	if err := RezipFile(failed, mod, ver, filepath.Join(dir, "nonexistent")); err == nil {
+		t.Fatal("got no error rezipping a nonexistent directory")
+	}
+	entries, err := os.ReadDir(outdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "rezip.zip" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("got files %q after failed rezip, want only rezip.zip", names)
+	}
+}
@@ -0,0 +1,287 @@
+package goproxyclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/mid"
+)
+
+// S3Storage is a [Storage] in an Amazon S3 bucket,
+// or in a bucket of another service with an S3-compatible API
+// (such as MinIO or Google Cloud Storage).
+// Requests are signed with AWS Signature Version 4.
+type S3Storage struct {
+	Bucket string
+
+	// Prefix, if not empty, is prepended (with a slash) to the name of each file
+	// to produce its key in the bucket.
+	Prefix string
+
+	Region      string
+	Credentials AWSCredentials
+
+	// Endpoint is the base URL of an S3-compatible service,
+	// such as http://localhost:9000.
+	// Buckets are addressed as a path under the endpoint
+	// (e.g. http://localhost:9000/BUCKET/KEY).
+	// If Endpoint is empty,
+	// Amazon S3 is used,
+	// with buckets addressed by hostname
+	// (e.g. https://BUCKET.s3.REGION.amazonaws.com/KEY).
+	Endpoint string
+
+	// Client is the HTTP client to use.
+	// If it is nil, [http.DefaultClient] is used.
+	Client *http.Client
+}
+
+var _ Storage = S3Storage{}
+
+// AWSCredentials are credentials for signing requests to AWS services.
+type AWSCredentials struct {
+	AccessKeyID, SecretAccessKey string
+
+	// SessionToken is needed only with temporary credentials.
+	SessionToken string
+}
+
+// AWSCredentialsFromEnv gets [AWSCredentials] from the environment variables
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN.
+func AWSCredentialsFromEnv() AWSCredentials {
+	return AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// Put implements [Storage.Put].
+// S3 needs to know the size of an object before it is uploaded,
+// so unless r is an [io.ReadSeeker],
+// its contents are first copied to a temporary file.
+func (s S3Storage) Put(ctx context.Context, name string, r io.Reader) error {
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		f, err := os.CreateTemp("", "goproxyclient-s3-*")
+		if err != nil {
+			return errors.Wrap(err, "creating temp file")
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		if _, err := io.Copy(f, r); err != nil {
+			return errors.Wrapf(err, "buffering content for %s", name)
+		}
+		rs = f
+	}
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return errors.Wrapf(err, "finding size of content for %s", name)
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrapf(err, "rewinding content for %s", name)
+	}
+
+	resp, err := s.do(ctx, "PUT", name, io.NopCloser(rs), size)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get implements [Storage.Get].
+func (s S3Storage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, "GET", name, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Exists implements [Storage.Exists].
+func (s S3Storage) Exists(ctx context.Context, name string) (bool, error) {
+	resp, err := s.do(ctx, "HEAD", name, nil, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// Do sends a signed request for the object with the given name.
+// A 404 (Not Found) response produces an error wrapping [fs.ErrNotExist],
+// and any other non-2xx response produces a [mid.CodeErr].
+func (s S3Storage) do(ctx context.Context, method, name string, body io.ReadCloser, size int64) (*http.Response, error) {
+	if err := checkStorageName(name); err != nil {
+		return nil, err
+	}
+	key := storageJoin(s.Prefix, name)
+
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating %s request for %s", method, key)
+	}
+	req.URL = u // preserve RawPath
+	if body != nil {
+		req.ContentLength = size
+	}
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	signV4(req, s.Credentials, s.Region, "s3", unsignedPayload, time.Now())
+
+	hc := s.Client
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "in %s %s", method, key)
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, &fs.PathError{Op: strings.ToLower(method), Path: key, Err: fs.ErrNotExist}
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, mid.CodeErr{C: resp.StatusCode, Err: fmt.Errorf("%s %s: %s: %s", method, key, resp.Status, strings.TrimSpace(string(msg)))}
+}
+
+func (s S3Storage) objectURL(key string) (*url.URL, error) {
+	var (
+		base    string
+		objPath = "/" + key
+	)
+	if s.Endpoint == "" {
+		base = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", s.Bucket, s.Region)
+	} else {
+		base = strings.TrimRight(s.Endpoint, "/")
+		objPath = "/" + s.Bucket + objPath
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing S3 endpoint %s", base)
+	}
+	u.Path += objPath
+	u.RawPath = uriEncode(u.Path, false)
+	return u, nil
+}
+
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// SignV4 signs req with AWS Signature Version 4,
+// setting its X-Amz-Date, X-Amz-Security-Token (if needed), and Authorization headers.
+// All other headers already in req are signed too, as is the Host.
+// See https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv-create-signed-request.html.
+func signV4(req *http.Request, creds AWSCredentials, region, service, payloadHash string, now time.Time) {
+	now = now.UTC()
+	var (
+		amzDate = now.Format("20060102T150405Z")
+		date    = now.Format("20060102")
+		scope   = date + "/" + region + "/" + service + "/aws4_request"
+	)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, vals := range req.Header {
+		trimmed := make([]string, 0, len(vals))
+		for _, v := range vals {
+			trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	crHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(crHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.AccessKeyID, scope, signedHeaders, sig))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func canonicalQuery(q url.Values) string {
+	var pairs []string
+	for k, vs := range q {
+		for _, v := range vs {
+			pairs = append(pairs, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// UriEncode encodes s as AWS Signature Version 4 requires:
+// everything but unreserved characters is percent-encoded,
+// except that slashes are left alone unless encodeSlash is true.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
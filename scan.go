@@ -0,0 +1,174 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+)
+
+// Scanner inspects content fetched from a Go module proxy,
+// e.g. for malware, leaked secrets, or unacceptable licenses.
+// Add one to a client with [WithScanner].
+type Scanner interface {
+	// Scan inspects the content in r,
+	// which is described by a.
+	// A non-nil error rejects the content:
+	// the operation that fetched it fails with a [*PolicyError] wrapping the error.
+	Scan(ctx context.Context, a Artifact, r io.Reader) error
+}
+
+// ScanFunc is an adapter allowing the use of an ordinary function as a [Scanner].
+type ScanFunc func(ctx context.Context, a Artifact, r io.Reader) error
+
+func (f ScanFunc) Scan(ctx context.Context, a Artifact, r io.Reader) error {
+	return f(ctx, a, r)
+}
+
+// Artifact describes content passed to a [Scanner].
+type Artifact struct {
+	Module, Version string
+
+	// Kind is "mod" for a go.mod file
+	// or "zip" for a module zip file.
+	// (In a [PolicyError] from a [Policy]'s Allow or Deny rule,
+	// it may also be "info", "latest", or "list",
+	// and Path is the escaped module path.)
+	Kind string
+
+	// Path is the location of the artifact relative to a proxy's base URL,
+	// such as "golang.org/x/mod/@v/v0.20.0.zip"
+	// (with the module path and version escaped; see [module.EscapePath]).
+	Path string
+}
+
+// PolicyError is the error returned when a [Scanner] or [Policy] rejects content.
+type PolicyError struct {
+	Artifact Artifact
+	Err      error
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s rejected: %s", e.Artifact.Path, e.Err)
+}
+
+func (e *PolicyError) Unwrap() error {
+	return e.Err
+}
+
+// WithScanner is an [Option] that adds a [Scanner] to the client.
+// Each go.mod file and module zip file the client fetches
+// (in [Client.Mod], [Client.Zip], [Client.Extract], and the methods that use them)
+// is passed to every scanner in the order they were added,
+// and is rejected if any of them reports an error.
+//
+// Scanning requires the client to hold the complete content before returning it:
+// go.mod files in memory,
+// and zip files in temporary files.
+func WithScanner(s Scanner) Option {
+	return func(c *config) {
+		c.scanners = append(c.scanners, s)
+	}
+}
+
+// NewArtifact produces the [Artifact] describing the given kind of file for mod@ver.
+func newArtifact(mod, ver, kind string) (Artifact, error) {
+	escPath, err := module.EscapePath(mod)
+	if err != nil {
+		return Artifact{}, errors.Wrap(err, "escaping module path")
+	}
+	escVer, err := module.EscapeVersion(ver)
+	if err != nil {
+		return Artifact{}, errors.Wrap(err, "escaping module version")
+	}
+	return Artifact{Module: mod, Version: ver, Kind: kind, Path: escPath + "/@v/" + escVer + "." + kind}, nil
+}
+
+// Scan passes the content produced by newReader to each of the client's scanners.
+// The newReader function is called once for each scanner.
+func (cl Client) scan(ctx context.Context, a Artifact, newReader func() io.Reader) error {
+	for _, s := range cl.conf.scanners {
+		if err := s.Scan(ctx, a, newReader()); err != nil {
+			return &PolicyError{Artifact: a, Err: err}
+		}
+	}
+	return nil
+}
+
+// maxModSize is the largest go.mod file that will be read into memory for scanning.
+// This is the go command's limit.
+const maxModSize = 16 << 20
+
+func (cl Client) scannedMod(ctx context.Context, mod, ver string) (io.ReadCloser, error) {
+	a, err := newArtifact(mod, ver, "mod")
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := cl.fetchMod(ctx, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxModSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading go.mod for %s@%s", mod, ver)
+	}
+	if len(data) > maxModSize {
+		return nil, fmt.Errorf("go.mod for %s@%s exceeds %d bytes", mod, ver, maxModSize)
+	}
+
+	verified, err := cl.verifyModFile(mod, ver, data)
+	if cl.hasHashes() {
+		traceFrom(ctx).verified(mod, ver, "mod", verified, err)
+	}
+	if err != nil {
+		cl.uncache(ctx, mod, ver, "mod")
+		return nil, err
+	}
+	if err := cl.scan(ctx, a, func() io.Reader { return bytes.NewReader(data) }); err != nil {
+		cl.uncache(ctx, mod, ver, "mod")
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (cl Client) scannedZip(ctx context.Context, mod, ver string) (io.ReadCloser, error) {
+	d, err := cl.zipFile(ctx, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cl.inspectZip(ctx, d, mod, ver); err != nil {
+		d.remove()
+		return nil, err
+	}
+	return tempZip{SectionReader: io.NewSectionReader(d, 0, d.size), d: d}, nil
+}
+
+// ScanZipFile passes a downloaded zip file to each of the client's scanners.
+func (cl Client) scanZipFile(ctx context.Context, d *downloadedZip, mod, ver string) error {
+	if len(cl.conf.scanners) == 0 {
+		return nil
+	}
+	a, err := newArtifact(mod, ver, "zip")
+	if err != nil {
+		return err
+	}
+	return cl.scan(ctx, a, func() io.Reader { return io.NewSectionReader(d, 0, d.size) })
+}
+
+// tempZip is the [io.ReadCloser] returned by [Client.Zip] for a zip file that has been scanned.
+// Closing it removes the temporary file.
+type tempZip struct {
+	*io.SectionReader
+	d *downloadedZip
+}
+
+func (t tempZip) Close() error {
+	t.d.remove()
+	return nil
+}
@@ -0,0 +1,95 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestScanner(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	var (
+		scanned     []Artifact
+		errRejected = errors.New("rejected")
+	)
+	scanner := ScanFunc(func(ctx context.Context, a Artifact, r io.Reader) error {
+		scanned = append(scanned, a)
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if a.Kind == "zip" {
+			if _, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	cl := New(s.URL, nil, WithScanner(scanner))
+
+	rc, err := cl.Mod(ctx, "example.com/multi", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "module example.com/multi") {
+		t.Errorf("got go.mod %q", data)
+	}
+
+	rc, err = cl.Zip(ctx, "example.com/multi", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Errorf("reading scanned zip: %s", err)
+	}
+
+	if err := cl.Extract(ctx, "example.com/multi", "v1.0.0", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Artifact{
+		{Module: "example.com/multi", Version: "v1.0.0", Kind: "mod", Path: "example.com/multi/@v/v1.0.0.mod"},
+		{Module: "example.com/multi", Version: "v1.0.0", Kind: "zip", Path: "example.com/multi/@v/v1.0.0.zip"},
+		{Module: "example.com/multi", Version: "v1.0.0", Kind: "zip", Path: "example.com/multi/@v/v1.0.0.zip"},
+	}
+	if len(scanned) != len(want) {
+		t.Fatalf("scanned %v, want %v", scanned, want)
+	}
+	for i := range want {
+		if scanned[i] != want[i] {
+			t.Errorf("scan %d: got %+v, want %+v", i, scanned[i], want[i])
+		}
+	}
+
+	reject := ScanFunc(func(context.Context, Artifact, io.Reader) error { return errRejected })
+	cl = New(s.URL, nil, WithScanner(scanner), WithScanner(reject))
+
+	err = cl.Extract(ctx, "example.com/multi", "v1.0.0", t.TempDir())
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("got error %v, want PolicyError", err)
+	}
+	if !errors.Is(err, errRejected) {
+		t.Errorf("got error %v, want one wrapping %v", err, errRejected)
+	}
+}
@@ -0,0 +1,277 @@
+package goproxyclient
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/bobg/errors"
+)
+
+// ErrQueueFull is the error for a request that a [Client] does not send
+// because too many requests of its priority are already waiting
+// (see [WithScheduler]).
+var ErrQueueFull = errors.New("too many requests waiting")
+
+// SchedulerPolicy says how many requests a client sends to its proxies at once
+// and which waiting requests go next
+// (see [WithScheduler]).
+type SchedulerPolicy struct {
+	// MaxConcurrent is the number of requests that may be in progress at once,
+	// across all of the client's proxies.
+	// A request is in progress from when it is sent
+	// until its response body is closed.
+	// Values less than 1 mean 1.
+	MaxConcurrent int
+
+	// InteractiveReserve is the number of those
+	// that only requests with [PriorityInteractive] may use,
+	// so that they need not wait for background work to finish.
+	// It is at most MaxConcurrent-1.
+	InteractiveReserve int
+
+	// MaxInteractiveQueue, MaxNormalQueue, and MaxBackgroundQueue
+	// are the numbers of requests that may wait at each priority.
+	// A request beyond that fails at once with [ErrQueueFull].
+	// Values less than 1 mean no limit.
+	MaxInteractiveQueue, MaxNormalQueue, MaxBackgroundQueue int
+
+	// MaxWait protects lower-priority requests from starvation:
+	// a request that has waited at least this long
+	// goes ahead of those that have waited less,
+	// whatever their priority
+	// (but still may not use the InteractiveReserve).
+	// Values less than 1 mean requests go in priority order however long they wait.
+	MaxWait time.Duration
+}
+
+// DefaultSchedulerPolicy is a reasonable [SchedulerPolicy] for use with [WithScheduler].
+var DefaultSchedulerPolicy = SchedulerPolicy{
+	MaxConcurrent:      16,
+	InteractiveReserve: 4,
+	MaxWait:            10 * time.Second,
+}
+
+// WithScheduler is an [Option] that schedules the client's requests to its proxies according to p,
+// so that interactive operations
+// (such as a command-line tool's or an editor's)
+// go ahead of bulk work
+// (such as mirroring or prefetching)
+// sharing the same client.
+// Each request has the priority of its context
+// (see [WithPriority]).
+// When MaxConcurrent requests are in progress,
+// others wait for a turn:
+// those with higher priority first,
+// and those with the same priority in the order they arrived,
+// except as described at [SchedulerPolicy].MaxWait.
+// Requests in progress are not interrupted.
+// Identical operations in progress at the same time
+// share their requests to the proxies
+// (see [Client.Info] and the others)
+// only if their priorities are treated alike,
+// so an interactive operation never waits for a background one's turn.
+//
+// Priorities above [PriorityInteractive] are treated as PriorityInteractive,
+// and those below [PriorityBackground] as PriorityBackground.
+// Requests wait for any rate limits
+// (see [WithRateLimit] and [WithTenantRateLimit])
+// before waiting for a turn.
+// Operations on "direct" entries are not scheduled.
+//
+// The scheduler is shared by all copies of the client.
+// By default there is none,
+// and requests are sent as soon as they are made.
+func WithScheduler(p SchedulerPolicy) Option {
+	return func(c *config) {
+		c.scheduler = newScheduler(p)
+	}
+}
+
+const (
+	schedBackground = iota
+	schedNormal
+	schedInteractive
+	numSchedLevels
+)
+
+func schedLevel(p Priority) int {
+	switch {
+	case p >= PriorityInteractive:
+		return schedInteractive
+	case p <= PriorityBackground:
+		return schedBackground
+	default:
+		return schedNormal
+	}
+}
+
+// scheduler decides when requests may proceed,
+// as described at [WithScheduler].
+// A nil *scheduler lets all requests proceed at once.
+type scheduler struct {
+	policy   SchedulerPolicy
+	maxQueue [numSchedLevels]int
+
+	mu      sync.Mutex
+	running int
+	queues  [numSchedLevels]list.List // values are *schedWaiter, oldest first
+}
+
+type schedWaiter struct {
+	level   int
+	arrived time.Time
+	ready   chan struct{} // closed when granted is set
+	granted bool
+}
+
+func newScheduler(p SchedulerPolicy) *scheduler {
+	p.MaxConcurrent = max(p.MaxConcurrent, 1)
+	p.InteractiveReserve = min(max(p.InteractiveReserve, 0), p.MaxConcurrent-1)
+	return &scheduler{
+		policy: p,
+		maxQueue: [numSchedLevels]int{
+			schedBackground:  p.MaxBackgroundQueue,
+			schedNormal:      p.MaxNormalQueue,
+			schedInteractive: p.MaxInteractiveQueue,
+		},
+	}
+}
+
+// Acquire waits for a turn for a request with the priority in ctx,
+// or until ctx is canceled.
+// On success,
+// the caller must call the returned function when the request is done.
+// It may be called more than once.
+func (s *scheduler) acquire(ctx context.Context) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+
+	w := &schedWaiter{
+		level:   schedLevel(RequestPriority(ctx)),
+		arrived: time.Now(),
+		ready:   make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	q := &s.queues[w.level]
+	el := q.PushBack(w)
+	s.dispatch(w.arrived)
+	if !w.granted && s.maxQueue[w.level] > 0 && q.Len() > s.maxQueue[w.level] {
+		q.Remove(el)
+		s.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return s.releaser(), nil
+
+	case <-ctx.Done():
+		s.mu.Lock()
+		granted := w.granted
+		if !granted {
+			q.Remove(el)
+		}
+		s.mu.Unlock()
+
+		if granted {
+			// The turn came at the same time as the cancellation.
+			s.release()
+		}
+		return nil, context.Cause(ctx)
+	}
+}
+
+func (s *scheduler) releaser() func() {
+	var once sync.Once
+	return func() { once.Do(s.release) }
+}
+
+func (s *scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running--
+	s.dispatch(time.Now())
+}
+
+// Dispatch grants turns to waiting requests
+// while there are turns to give.
+// The caller must hold s.mu.
+func (s *scheduler) dispatch(now time.Time) {
+	for {
+		el := s.next(now)
+		if el == nil {
+			return
+		}
+		w := el.Value.(*schedWaiter)
+		s.queues[w.level].Remove(el)
+		s.running++
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+// Next returns the waiting request that should go next,
+// or nil if there is none that may go now.
+// The caller must hold s.mu.
+func (s *scheduler) next(now time.Time) *list.Element {
+	// The oldest request in each queue is at its front.
+	// First choose the oldest of those that has waited too long,
+	// then the oldest with the highest priority.
+
+	var result *list.Element
+	if s.policy.MaxWait > 0 {
+		for level := range s.queues {
+			el := s.queues[level].Front()
+			if el == nil || !s.fits(level) {
+				continue
+			}
+			w := el.Value.(*schedWaiter)
+			if now.Sub(w.arrived) < s.policy.MaxWait {
+				continue
+			}
+			if result == nil || w.arrived.Before(result.Value.(*schedWaiter).arrived) {
+				result = el
+			}
+		}
+		if result != nil {
+			return result
+		}
+	}
+
+	for level := numSchedLevels - 1; level >= 0; level-- {
+		if el := s.queues[level].Front(); el != nil && s.fits(level) {
+			return el
+		}
+	}
+	return nil
+}
+
+// Fits tells whether a request with the given level may start now.
+// The caller must hold s.mu.
+func (s *scheduler) fits(level int) bool {
+	limit := s.policy.MaxConcurrent
+	if level != schedInteractive {
+		limit -= s.policy.InteractiveReserve
+	}
+	return s.running < limit
+}
+
+// scheduledBody is a response body
+// that ends its request's turn from the [scheduler] when closed.
+type scheduledBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b scheduledBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,239 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	// start calls s.acquire in a goroutine with the given priority
+	// and waits for it to be queued.
+	// The returned channel yields the result.
+	start := func(t *testing.T, s *scheduler, ctx context.Context, p Priority, got chan<- Priority) <-chan error {
+		t.Helper()
+
+		level := schedLevel(p)
+		s.mu.Lock()
+		n := s.queues[level].Len()
+		s.mu.Unlock()
+
+		errch := make(chan error, 1)
+		go func() {
+			release, err := s.acquire(WithPriority(ctx, p))
+			if err == nil {
+				got <- p
+				release()
+			}
+			errch <- err
+		}()
+
+		for {
+			s.mu.Lock()
+			queued := s.queues[level].Len() > n
+			s.mu.Unlock()
+			if queued {
+				return errch
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	t.Run("priority", func(t *testing.T) {
+		s := newScheduler(SchedulerPolicy{MaxConcurrent: 1})
+		release, err := s.acquire(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := make(chan Priority, 4)
+		start(t, s, ctx, PriorityBackground, got)
+		start(t, s, ctx, PriorityNormal, got)
+		start(t, s, ctx, PriorityInteractive, got)
+		start(t, s, ctx, Priority(5), got)
+		release()
+
+		want := []Priority{PriorityInteractive, Priority(5), PriorityNormal, PriorityBackground}
+		for i, w := range want {
+			if p := <-got; p != w {
+				t.Errorf("request %d: got %v, want %v", i, p, w)
+			}
+		}
+	})
+
+	t.Run("reserve", func(t *testing.T) {
+		s := newScheduler(SchedulerPolicy{MaxConcurrent: 2, InteractiveReserve: 1})
+		release, err := s.acquire(WithPriority(ctx, PriorityBackground))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer release()
+
+		got := make(chan Priority, 1)
+		start(t, s, ctx, PriorityBackground, got)
+
+		// An interactive request still gets the reserved turn.
+		release2, err := s.acquire(WithPriority(ctx, PriorityInteractive))
+		if err != nil {
+			t.Fatal(err)
+		}
+		release2()
+		release2() // no effect
+
+		select {
+		case p := <-got:
+			t.Errorf("got unexpected turn for %v request", p)
+		default:
+		}
+		release()
+		if p := <-got; p != PriorityBackground {
+			t.Errorf("got %v, want %v", p, PriorityBackground)
+		}
+	})
+
+	t.Run("queue_full", func(t *testing.T) {
+		s := newScheduler(SchedulerPolicy{MaxConcurrent: 1, MaxBackgroundQueue: 1})
+		release, err := s.acquire(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := make(chan Priority, 2)
+		start(t, s, ctx, PriorityBackground, got)
+		if _, err := s.acquire(WithPriority(ctx, PriorityBackground)); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("got %v, want %v", err, ErrQueueFull)
+		}
+		start(t, s, ctx, PriorityNormal, got)
+		release()
+
+		<-got
+		<-got
+	})
+
+	t.Run("starvation", func(t *testing.T) {
+		s := newScheduler(SchedulerPolicy{MaxConcurrent: 1, MaxWait: 10 * time.Millisecond})
+		release, err := s.acquire(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := make(chan Priority, 2)
+		start(t, s, ctx, PriorityBackground, got)
+		time.Sleep(20 * time.Millisecond)
+		start(t, s, ctx, PriorityInteractive, got)
+		release()
+
+		if p := <-got; p != PriorityBackground {
+			t.Errorf("got %v first, want %v", p, PriorityBackground)
+		}
+		<-got
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		s := newScheduler(SchedulerPolicy{MaxConcurrent: 1})
+		release, err := s.acquire(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cctx, cancel := context.WithCancel(ctx)
+		errch := start(t, s, cctx, PriorityNormal, make(chan Priority, 1))
+		cancel()
+		if err := <-errch; !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+		if n := s.queues[schedNormal].Len(); n != 0 {
+			t.Errorf("got %d requests still queued, want 0", n)
+		}
+
+		release()
+		release, err = s.acquire(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	})
+}
+
+func TestWithScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil, WithScheduler(SchedulerPolicy{MaxConcurrent: 1}))
+
+	info := func() error {
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_, _, _, err := cl.Info(ctx, "example.com/multi", "v1.0.0")
+		return err
+	}
+
+	if err := info(); err != nil {
+		t.Fatal(err)
+	}
+
+	// An open response body holds the client's only turn.
+	rc, err := cl.Zip(ctx, "example.com/multi", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := info(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v with zip file open, want %v", err, context.DeadlineExceeded)
+	}
+	rc.Close()
+	if err := info(); err != nil {
+		t.Errorf("got %v after closing zip file", err)
+	}
+}
+
+func TestSchedulerFlight(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil, WithScheduler(SchedulerPolicy{MaxConcurrent: 2, InteractiveReserve: 1}))
+	background := WithPriority(ctx, PriorityBackground)
+
+	// Saturate the turns available to background requests.
+	rc, err := cl.Zip(background, "example.com/multi", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A background operation waits for a turn...
+	bgctx, cancel := context.WithCancel(background)
+	defer cancel()
+	bgerr := make(chan error, 1)
+	go func() {
+		_, _, _, err := cl.Info(bgctx, "example.com/multi", "v1.0.0")
+		bgerr <- err
+	}()
+	for {
+		cl.conf.scheduler.mu.Lock()
+		n := cl.conf.scheduler.queues[schedBackground].Len()
+		cl.conf.scheduler.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// ...but an identical interactive one does not wait with it.
+	ictx, icancel := context.WithTimeout(WithPriority(ctx, PriorityInteractive), time.Second)
+	defer icancel()
+	if _, _, _, err := cl.Info(ictx, "example.com/multi", "v1.0.0"); err != nil {
+		t.Errorf("interactive operation: %v", err)
+	}
+
+	rc.Close()
+	if err := <-bgerr; err != nil {
+		t.Errorf("background operation: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package goproxyclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bobg/errors"
+)
+
+// Shutdowner is implemented by components that can be shut down gracefully,
+// such as [Client].
+// Shutdown stops the component from accepting new work
+// and waits for work in progress to finish,
+// or for ctx to be done,
+// whichever comes first.
+// In the latter case it returns the context's error.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+var _ Shutdowner = Client{}
+
+// ErrShutdown is the error returned by operations attempted after [Client.Shutdown].
+var ErrShutdown = errors.New("client is shut down")
+
+// Shutdown shuts down the client gracefully.
+// New operations fail with [ErrShutdown].
+// Operations already in progress are allowed to finish,
+// unless ctx is done first,
+// in which case the result is the context's error.
+// (Readers already returned by [Client.Mod] and [Client.Zip] are unaffected.)
+// Once all operations are finished,
+// idle connections are closed.
+//
+// Shutdown applies to all copies of the client.
+// It may be called more than once.
+func (cl Client) Shutdown(ctx context.Context) error {
+	if err := cl.work().shutdown(ctx); err != nil {
+		return err
+	}
+	cl.chain().closeIdleConnections()
+	return nil
+}
+
+func (cl Client) work() *workTracker {
+	if cl.conf == nil {
+		return nil
+	}
+	return &cl.conf.work
+}
+
+// workTracker counts operations in progress,
+// so that shutdown can wait for them.
+// Its zero value is ready to use.
+type workTracker struct {
+	mu       sync.Mutex
+	n        int
+	shutDown bool
+	idle     chan struct{} // closed when n drops to zero after shutdown
+}
+
+// Begin records the start of an operation.
+// The caller must call the returned function when the operation is finished.
+// After shutdown, begin fails with [ErrShutdown].
+// A nil *workTracker tracks nothing.
+func (w *workTracker) begin() (func(), error) {
+	if w == nil {
+		return func() {}, nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shutDown {
+		return nil, ErrShutdown
+	}
+	w.n++
+
+	var once sync.Once
+	return func() {
+		once.Do(w.end)
+	}, nil
+}
+
+func (w *workTracker) end() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.n--
+	if w.n == 0 && w.idle != nil {
+		close(w.idle)
+		w.idle = nil
+	}
+}
+
+func (w *workTracker) shutdown(ctx context.Context) error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	w.shutDown = true
+	if w.n == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	if w.idle == nil {
+		w.idle = make(chan struct{})
+	}
+	idle := w.idle
+	w.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
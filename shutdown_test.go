@@ -0,0 +1,59 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		started = make(chan struct{})
+		release = make(chan struct{})
+		h       = testHandler(nil)
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		h.ServeHTTP(w, req)
+	}))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+
+	errch := make(chan error, 1)
+	go func() {
+		_, err := cl.List(ctx, "example.com/multi")
+		errch <- err
+	}()
+	<-started
+
+	// Shutdown can't finish while the List call is in progress.
+	shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := cl.Shutdown(shortCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// New operations are rejected.
+	if _, err := cl.List(ctx, "example.com/multi"); !errors.Is(err, ErrShutdown) {
+		t.Errorf("got %v, want %v", err, ErrShutdown)
+	}
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- cl.Shutdown(ctx)
+	}()
+
+	close(release)
+	if err := <-errch; err != nil {
+		t.Errorf("in-progress List: %s", err)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("Shutdown: %s", err)
+	}
+}
@@ -0,0 +1,108 @@
+package goproxyclient
+
+import (
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bobg/errors"
+)
+
+// SignatureSuffix is the suffix added to a file's name
+// to get the name of its detached signature file
+// (see [SignFile]).
+const SignatureSuffix = ".sig"
+
+// DetachedSignature is the content of a detached signature file
+// produced by [SignFile].
+//
+// As in minisign's prehashed mode,
+// the signature is over the SHA-512 hash of the file,
+// so that large files (such as exported module bundles)
+// need not be held in memory.
+type DetachedSignature struct {
+	// Algorithm is always "ed25519-sha512".
+	Algorithm string `json:"algorithm"`
+
+	// KeyID is the hex-encoded SHA-256 hash of the signer's public key.
+	KeyID string `json:"keyid"`
+
+	// Sig is the signature.
+	Sig []byte `json:"sig"` // base64-encoded in JSON
+}
+
+const signatureAlgorithm = "ed25519-sha512"
+
+// SignFile signs the file at path with key,
+// writing a detached signature to path+[SignatureSuffix].
+// The signature file is replaced atomically,
+// so a failure never leaves a truncated one.
+// This lets artifacts such as mirror bundles carried across an air gap
+// be checked with [VerifyFile] on the other side,
+// without consulting a checksum database.
+func SignFile(path string, key ed25519.PrivateKey) error {
+	digest, err := fileSHA512(path)
+	if err != nil {
+		return err
+	}
+	sig := DetachedSignature{
+		Algorithm: signatureAlgorithm,
+		KeyID:     keyID(key.Public().(ed25519.PublicKey)),
+		Sig:       ed25519.Sign(key, digest),
+	}
+	j, err := json.MarshalIndent(sig, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encoding signature")
+	}
+	err = writeFileAtomic(path+SignatureSuffix, 0644, false, func(w io.Writer) error {
+		_, err := w.Write(append(j, '\n'))
+		return err
+	})
+	return errors.Wrapf(err, "writing signature for %s", path)
+}
+
+// VerifyFile checks the file at path against its detached signature
+// in path+[SignatureSuffix],
+// which must have been made by [SignFile] with the private key corresponding to pub.
+func VerifyFile(path string, pub ed25519.PublicKey) error {
+	data, err := os.ReadFile(path + SignatureSuffix)
+	if err != nil {
+		return errors.Wrapf(err, "reading signature for %s", path)
+	}
+	var sig DetachedSignature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return errors.Wrapf(err, "decoding signature for %s", path)
+	}
+	if sig.Algorithm != signatureAlgorithm {
+		return fmt.Errorf("signature for %s: unknown algorithm %q", path, sig.Algorithm)
+	}
+	if sig.KeyID != keyID(pub) {
+		return fmt.Errorf("signature for %s is from a different key (%s)", path, sig.KeyID)
+	}
+
+	digest, err := fileSHA512(path)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, digest, sig.Sig) {
+		return fmt.Errorf("invalid signature for %s", path)
+	}
+	return nil
+}
+
+func fileSHA512(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %s", path)
+	}
+	defer f.Close()
+
+	h := sha512.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, errors.Wrapf(err, "reading %s", path)
+	}
+	return h.Sum(nil), nil
+}
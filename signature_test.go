@@ -0,0 +1,54 @@
+package goproxyclient
+
+import (
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSignFile(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub2, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bundle.tar")
+	if err := os.WriteFile(path, []byte("bundle contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SignFile(path, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyFile(path, pub); err != nil {
+		t.Errorf("verifying: %s", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"bundle.tar", "bundle.tar" + SignatureSuffix}; !slices.Equal(names, want) {
+		t.Errorf("got files %q after signing, want %q", names, want)
+	}
+	if err := VerifyFile(path, pub2); err == nil {
+		t.Error("verified with the wrong key")
+	}
+
+	if err := os.WriteFile(path, []byte("tampered contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyFile(path, pub); err == nil {
+		t.Error("verified a tampered file")
+	}
+}
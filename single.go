@@ -1,143 +1,354 @@
 package goproxyclient
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/bobg/errors"
 	"github.com/bobg/mid"
-	"golang.org/x/mod/semver"
+	"golang.org/x/time/rate"
+
+	"github.com/bobg/goproxyclient/internal/protocol"
 )
 
 type single struct {
 	baseURL string
 	client  *http.Client
+	auth    []Authenticator
+	compat  bool
+
+	// OwnTransport tells whether client's transport was made for this proxy,
+	// rather than belonging to the caller (who may share it).
+	ownTransport bool
+
+	bandwidth         *rate.Limiter // shared by all downloads
+	downloadBandwidth int           // bytes per second for each download
+
+	idleTimeout time.Duration
+
+	reqLimiter     *rate.Limiter   // request rate for this proxy's host
+	tenantLimiters *tenantLimiters // nil if there is no per-tenant rate limit
+	scheduler      *scheduler      // shared by all proxies; nil if there is none
+
+	retry         RetryPolicy
+	retryAfterMax time.Duration
+
+	head    *headSupport
+	breaker *breaker    // nil if there is no circuit breaker
+	stats   *proxyStats // nil for an "off" entry
+
+	requestIDHeader string
+	tenantHeader    string
+	logger          *slog.Logger
+	journal         *Journal
+
+	direct *direct // non-nil for a "direct" entry
+	off    bool    // true for an "off" entry
 }
 
-func newSingle(url string, hc *http.Client) single {
+func newSingle(url string, hc *http.Client, conf *config) single {
 	url = strings.TrimRight(url, "/")
+	if conf != nil {
+		conf, hc = conf.forUpstream(url, hc)
+	}
 	if hc == nil {
 		hc = &http.Client{}
 	}
-	return single{baseURL: url, client: hc}
+	callerHC := hc
+	if conf != nil {
+		hc = conf.clientFor(url, hc)
+	}
+	s := single{baseURL: url, client: hc, ownTransport: hc != callerHC, off: url == "off", head: new(headSupport)}
+	if !s.off {
+		s.stats = new(proxyStats)
+	}
+	if conf != nil {
+		s.auth = conf.auth
+		s.compat = conf.compat
+		s.bandwidth = conf.bandwidth
+		s.downloadBandwidth = conf.downloadBandwidth
+		s.idleTimeout = conf.idleTimeout
+		s.reqLimiter = conf.hostLimiter(url)
+		s.tenantLimiters = conf.tenantLimiters
+		s.scheduler = conf.scheduler
+		s.retry = conf.retry
+		s.retryAfterMax = conf.retryAfterMax
+		s.requestIDHeader = conf.requestIDHeader
+		s.tenantHeader = conf.tenantHeader
+		s.logger = conf.logger
+		s.journal = conf.journal
+		if url == "direct" {
+			s.direct = conf.direct
+		}
+		if conf.breaker != nil && url != "direct" && url != "off" {
+			s.breaker = newBreaker(*conf.breaker)
+		}
+	}
+	return s
 }
 
-// Note, modpath is already escaped.
-func (s single) list(ctx context.Context, modpath string) ([]string, error) {
-	q := fmt.Sprintf("%s/%s/@v/list", s.baseURL, modpath)
+// Get issues a GET request for the given URL,
+// retrying according to the single's [RetryPolicy]
+// and, for rate-limited requests, its Retry-After limit (see [WithRetryAfter]).
+// It returns an error (a [mid.CodeErr]) if the response status is not 200 (OK).
+// On success, the caller is responsible for closing the response body.
+func (s single) get(ctx context.Context, q string) (*http.Response, error) {
+	return s.do(ctx, http.MethodGet, q)
+}
+
+// Do issues a request with the given method for the given URL,
+// as described for [single.get].
+func (s single) do(ctx context.Context, method, q string) (*http.Response, error) {
+	var (
+		attempt     = 1
+		rateLimited int           // number of rate-limited responses so far
+		waited      time.Duration // total time waited after them
+	)
+	for {
+		resp, err := s.doOnce(ctx, method, q)
+		if err == nil {
+			return resp, nil
+		}
+
+		if d, ok := s.retryAfter(ctx, err, rateLimited); ok && waited+d <= s.retryAfterMax {
+			rateLimited++
+			waited += d
+			s.log(ctx, "rate limited, retrying "+method, slog.String("url", q), slog.String("request_id", RequestID(ctx)), slog.Duration("delay", d), slog.Any("error", err))
+			traceFrom(ctx).retry(q, d, err)
+			if err := sleep(ctx, d); err != nil {
+				return nil, errors.Wrapf(err, "waiting to retry %s %s", method, q)
+			}
+			continue
+		}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", q, nil)
+		if attempt >= s.retry.Attempts || !retryable(ctx, err) {
+			return nil, err
+		}
+
+		d := s.retry.delay(attempt)
+		s.log(ctx, "retrying "+method, slog.String("url", q), slog.String("request_id", RequestID(ctx)), slog.Int("attempt", attempt), slog.Duration("delay", d), slog.Any("error", err))
+		traceFrom(ctx).retry(q, d, err)
+		if err := sleep(ctx, d); err != nil {
+			return nil, errors.Wrapf(err, "waiting to retry %s %s", method, q)
+		}
+		attempt++
+	}
+}
+
+// DoOnce issues a request for the given URL,
+// as described for [single.do] but without retries.
+func (s single) doOnce(ctx context.Context, method, q string) (resp *http.Response, err error) {
+	req, err := http.NewRequestWithContext(ctx, method, q, nil)
 	if err != nil {
-		return nil, errors.Wrapf(err, "creating GET %s request", q)
+		return nil, errors.Wrapf(err, "creating %s %s request", method, q)
+	}
+
+	for _, a := range s.auth {
+		if err := a.Authenticate(ctx, req); err != nil {
+			return nil, errors.Wrapf(err, "authenticating %s %s", method, q)
+		}
+	}
+
+	reqID := RequestID(ctx)
+	if reqID != "" && s.requestIDHeader != "" {
+		req.Header.Set(s.requestIDHeader, reqID)
+	}
+	if tenant := Tenant(ctx); tenant != "" && s.tenantHeader != "" {
+		req.Header.Set(s.tenantHeader, tenant)
 	}
 
-	resp, err := s.client.Do(req)
+	if err := s.tenantLimiters.wait(ctx); err != nil {
+		return nil, errors.Wrapf(err, "waiting to %s %s", method, q)
+	}
+	if s.reqLimiter != nil {
+		if err := s.reqLimiter.Wait(ctx); err != nil {
+			return nil, errors.Wrapf(err, "waiting to %s %s", method, q)
+		}
+	}
+
+	release, err := s.scheduler.acquire(ctx)
 	if err != nil {
-		return nil, errors.Wrapf(err, "in GET %s", q)
+		return nil, errors.Wrapf(err, "waiting to %s %s", method, q)
+	}
+	defer func() {
+		if err != nil {
+			release()
+		}
+	}()
+
+	start := time.Now()
+	defer func() { s.journalRequest(ctx, method, q, start, resp, err) }()
+
+	resp, err = s.client.Do(req)
+	if err != nil {
+		s.log(ctx, method+" failed", slog.String("url", q), slog.String("request_id", reqID), slog.Any("error", err))
+		traceFrom(ctx).gotResponse(method, q, 0, time.Since(start), err)
+		return nil, errors.Wrapf(err, "in %s %s", method, q)
+	}
+	elapsed := time.Since(start)
+	s.log(ctx, method, slog.String("url", q), slog.String("request_id", reqID), slog.Int("status", resp.StatusCode), slog.Duration("elapsed", elapsed))
+	traceFrom(ctx).gotResponse(method, q, resp.StatusCode, elapsed, nil)
+	resp.Body = scheduledBody{ReadCloser: resp.Body, release: release}
+
+	if s.compat {
+		if code, reason := compatStatus(resp); code != resp.StatusCode {
+			resp.Body.Close()
+			return nil, mid.CodeErr{C: code, Err: fmt.Errorf("%s %s: %s (%s, treated as %d in compatibility mode)", method, q, resp.Status, reason, code)}
+		}
 	}
-	defer resp.Body.Close()
 
 	if code := resp.StatusCode; code != http.StatusOK {
-		return nil, mid.CodeErr{C: code, Err: fmt.Errorf("GET %s: %s", q, resp.Status)}
+		resp.Body.Close()
+		if code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable {
+			if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				err := retryAfterError{error: fmt.Errorf("%s %s: %s (Retry-After %s)", method, q, resp.Status, d), after: d}
+				return nil, mid.CodeErr{C: code, Err: err}
+			}
+		}
+		return nil, mid.CodeErr{C: code, Err: fmt.Errorf("%s %s: %s", method, q, resp.Status)}
 	}
 
-	var (
-		sc       = bufio.NewScanner(resp.Body)
-		versions []string
-	)
-	for sc.Scan() {
-		versions = append(versions, sc.Text())
+	return resp, nil
+}
+
+// ByTenant tells whether requests to s depend on the tenant of the operation.
+func (s single) byTenant() bool {
+	return s.tenantHeader != "" || s.tenantLimiters != nil
+}
+
+func (s single) log(ctx context.Context, msg string, attrs ...slog.Attr) {
+	if s.logger == nil {
+		return
 	}
-	semver.Sort(versions)
-	return versions, errors.Wrapf(sc.Err(), "scanning response from GET %s", q)
+	s.logger.LogAttrs(ctx, slog.LevelDebug, msg, append(attrs, metadataAttrs(ctx)...)...)
+}
+
+// Note, modpath is already escaped.
+func (s single) list(ctx context.Context, modpath string) ([]string, error) {
+	if s.off {
+		return nil, ErrProxyOff
+	}
+	if s.direct != nil {
+		return s.direct.list(ctx, modpath)
+	}
+	q := fmt.Sprintf("%s/%s/@v/list", s.baseURL, modpath)
+
+	resp, err := s.get(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	versions, err := protocol.ParseList(resp.Body)
+	return versions, errors.Wrapf(err, "scanning response from GET %s", q)
 }
 
 // Note, modpath and version are already escaped.
 func (s single) info(ctx context.Context, modpath, version string) (string, time.Time, map[string]json.RawMessage, error) {
+	if s.off {
+		return "", time.Time{}, nil, ErrProxyOff
+	}
+	if s.direct != nil {
+		return s.direct.info(ctx, modpath, version)
+	}
 	q := fmt.Sprintf("%s/%s/@v/%s.info", s.baseURL, modpath, version)
 	return s.handleInfoRequest(ctx, q)
 }
 
 // Note, modpath and version are already escaped.
 func (s single) mod(ctx context.Context, modpath, version string) (io.ReadCloser, error) {
+	if s.off {
+		return nil, ErrProxyOff
+	}
+	if s.direct != nil {
+		return s.direct.mod(ctx, modpath, version)
+	}
 	return s.getContent(ctx, modpath, version, "mod")
 }
 
 // Note, modpath and version are already escaped.
 func (s single) zip(ctx context.Context, modpath, version string) (io.ReadCloser, error) {
+	if s.off {
+		return nil, ErrProxyOff
+	}
+	if s.direct != nil {
+		return s.direct.zip(ctx, modpath, version)
+	}
 	return s.getContent(ctx, modpath, version, "zip")
 }
 
+// ZipSize reports the size of a zip file from the Content-Length of its response,
+// or -1 if the proxy does not say,
+// using a HEAD request if the proxy supports them
+// (see [single.probe]).
 // Note, modpath and version are already escaped.
-func (s single) getContent(ctx context.Context, modpath, version, suffix string) (io.ReadCloser, error) {
-	q := fmt.Sprintf("%s/%s/@v/%s.%s", s.baseURL, modpath, version, suffix)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", q, nil)
-	if err != nil {
-		return nil, errors.Wrapf(err, "creating GET %s request", q)
+func (s single) zipSize(ctx context.Context, modpath, version string) (int64, error) {
+	if s.off {
+		return 0, ErrProxyOff
+	}
+	if s.direct != nil {
+		return s.direct.zipSize(ctx, modpath, version)
 	}
+	q := fmt.Sprintf("%s/%s/@v/%s.zip", s.baseURL, modpath, version)
 
-	resp, err := s.client.Do(req)
+	resp, err := s.probe(ctx, q, true)
 	if err != nil {
-		return nil, errors.Wrapf(err, "in GET %s", q)
+		return 0, err
 	}
+	return resp.ContentLength, nil
+}
 
-	if code := resp.StatusCode; code != http.StatusOK {
-		resp.Body.Close()
-		return nil, mid.CodeErr{C: code, Err: fmt.Errorf("GET %s: %s", q, resp.Status)}
-	}
+// Note, modpath and version are already escaped.
+func (s single) getContent(ctx context.Context, modpath, version, suffix string) (io.ReadCloser, error) {
+	q := fmt.Sprintf("%s/%s/@v/%s.%s", s.baseURL, modpath, version, suffix)
 
-	return resp.Body, nil
+	resp, err := s.get(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	body := s.limitBandwidth(ctx, newCtxBody(ctx, resp.Body, s.idleTimeout))
+	return sizedBody{ReadCloser: body, size: resp.ContentLength, proxy: s.baseURL, url: q}, nil
 }
 
 // Latest gets info about the latest version of a Go module.
 // Its return values are the same as for [Info].
 // Note, modpath is already escaped.
 func (s single) latest(ctx context.Context, modpath string) (string, time.Time, map[string]json.RawMessage, error) {
+	if s.off {
+		return "", time.Time{}, nil, ErrProxyOff
+	}
+	if s.direct != nil {
+		return s.direct.latest(ctx, modpath)
+	}
 	q := fmt.Sprintf("%s/%s/@latest", s.baseURL, modpath)
-	return s.handleInfoRequest(ctx, q)
+	ver, tm, m, err := s.handleInfoRequest(ctx, q)
+	if err != nil && s.compat {
+		return s.compatLatest(ctx, modpath, err)
+	}
+	return ver, tm, m, err
 }
 
 func (s single) handleInfoRequest(ctx context.Context, q string) (string, time.Time, map[string]json.RawMessage, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", q, nil)
+	resp, err := s.get(ctx, q)
 	if err != nil {
-		return "", time.Time{}, nil, errors.Wrapf(err, "creating GET %s request", q)
-	}
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", time.Time{}, nil, errors.Wrapf(err, "in GET %s", q)
+		return "", time.Time{}, nil, err
 	}
 	defer resp.Body.Close()
 
-	if code := resp.StatusCode; code != http.StatusOK {
-		return "", time.Time{}, nil, mid.CodeErr{C: code, Err: fmt.Errorf("GET %s: %s", q, resp.Status)}
-	}
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, protocol.MaxInfoSize+1))
 	if err != nil {
 		return "", time.Time{}, nil, errors.Wrapf(err, "reading response body from GET %s", q)
 	}
-
-	var info struct {
-		Version string
-		Time    time.Time
-	}
-
-	if err := json.Unmarshal(body, &info); err != nil {
-		return "", time.Time{}, nil, errors.Wrapf(err, "unmarshaling response body from GET %s", q)
-	}
-
-	var m map[string]json.RawMessage
-	if err := json.Unmarshal(body, &m); err != nil {
-		return "", time.Time{}, nil, errors.Wrapf(err, "unmarshaling response body from GET %s", q)
+	if len(body) > protocol.MaxInfoSize {
+		return "", time.Time{}, nil, fmt.Errorf("response body from GET %s exceeds %d bytes", q, protocol.MaxInfoSize)
 	}
 
-	return info.Version, info.Time, m, nil
+	ver, tm, m, err := protocol.ParseInfo(body)
+	return ver, tm, m, errors.Wrapf(err, "parsing response body from GET %s", q)
 }
@@ -0,0 +1,140 @@
+package goproxyclient
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/bobg/errors"
+)
+
+// StateDir is a directory holding persistent state
+// for this package and the goproxyclient command,
+// such as a [VerifiedDB] and quarantined downloads.
+// Keeping it all in one place makes it easy to find and to relocate.
+//
+// Use [OpenStateDir] to make sure the directory exists and its layout is current,
+// then [StateDir.Path] to locate each component within it.
+type StateDir string
+
+// Components of a [StateDir],
+// for use with [StateDir.Path].
+const (
+	StateVerified   = "verified.sum" // a VerifiedDB file
+	StateQuarantine = "quarantine"   // a directory for WithQuarantine
+	StateAttest     = "attestations" // a directory for WithAttestations
+	StateCache      = "cache"        // a directory for cached proxy responses
+	StateSumDB      = "sumdb"        // a directory for checksum database tiles
+	StateIndex      = "index"        // a directory for index crawler checkpoints
+)
+
+// DefaultStateDir returns the default location for a [StateDir].
+// This is $XDG_STATE_HOME/goproxyclient if XDG_STATE_HOME is set.
+// Otherwise it is $HOME/.local/state/goproxyclient on Unix-like systems
+// (following the XDG Base Directory Specification),
+// and goproxyclient in the directory given by [os.UserConfigDir] on Windows and macOS.
+func DefaultStateDir() (StateDir, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" && filepath.IsAbs(dir) {
+		return StateDir(filepath.Join(dir, "goproxyclient")), nil
+	}
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "plan9":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", errors.Wrap(err, "finding user config directory")
+		}
+		return StateDir(filepath.Join(dir, "goproxyclient")), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "finding home directory")
+	}
+	return StateDir(filepath.Join(home, ".local", "state", "goproxyclient")), nil
+}
+
+// stateLayoutFile is the name of the file in a [StateDir] recording the version of its layout.
+const stateLayoutFile = "layout"
+
+// stateMigrations upgrade a [StateDir] from one layout version to the next:
+// stateMigrations[i] upgrades version i to version i+1.
+// Version 0 is a directory with no layout file
+// (e.g. a new, empty one).
+// The current layout version is len(stateMigrations).
+var stateMigrations = []func(StateDir) error{
+	// 0 -> 1: create the component directories.
+	func(d StateDir) error {
+		for _, sub := range []string{StateQuarantine, StateAttest, StateCache, StateSumDB, StateIndex} {
+			if err := os.MkdirAll(d.Path(sub), 0755); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// OpenStateDir creates the [StateDir] dir if necessary
+// and brings its layout up to date,
+// migrating state written by earlier versions of this package.
+// It is an error if dir was written by a later version with a different layout.
+func OpenStateDir(dir string) (StateDir, error) {
+	d := StateDir(dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Wrapf(err, "creating %s", dir)
+	}
+
+	layout, err := d.layout()
+	if err != nil {
+		return "", err
+	}
+	if layout > len(stateMigrations) {
+		return "", fmt.Errorf("state directory %s has layout version %d, newer than this version of goproxyclient supports (%d)", dir, layout, len(stateMigrations))
+	}
+
+	for ; layout < len(stateMigrations); layout++ {
+		if err := stateMigrations[layout](d); err != nil {
+			return "", errors.Wrapf(err, "migrating %s from layout version %d", dir, layout)
+		}
+		// Record progress after each step,
+		// so an interrupted migration resumes where it left off.
+		err := writeFileAtomic(d.Path(stateLayoutFile), 0644, true, func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, layout+1)
+			return err
+		})
+		if err != nil {
+			return "", errors.Wrapf(err, "updating layout version in %s", dir)
+		}
+	}
+
+	return d, nil
+}
+
+func (d StateDir) layout() (int, error) {
+	data, err := os.ReadFile(d.Path(stateLayoutFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "reading layout version in %s", d)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, errors.Wrapf(err, "parsing layout version in %s", d)
+	}
+	return n, nil
+}
+
+// Path returns the location of the given component
+// (such as [StateVerified])
+// in the state directory.
+func (d StateDir) Path(component string) string {
+	return filepath.Join(string(d), component)
+}
+
+// VerifiedDB opens the [VerifiedDB] in the state directory.
+func (d StateDir) VerifiedDB() (*VerifiedDB, error) {
+	return OpenVerifiedDB(d.Path(StateVerified))
+}
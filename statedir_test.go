@@ -0,0 +1,69 @@
+package goproxyclient
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenStateDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+
+	d, err := OpenStateDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sub := range []string{StateQuarantine, StateAttest, StateCache, StateSumDB, StateIndex} {
+		if info, err := os.Stat(d.Path(sub)); err != nil || !info.IsDir() {
+			t.Errorf("%s: not a directory (%v)", sub, err)
+		}
+	}
+	layout, err := d.layout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout != len(stateMigrations) {
+		t.Errorf("got layout %d, want %d", layout, len(stateMigrations))
+	}
+
+	db, err := d.VerifiedDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add("example.com/foo", "v1.0.0", "h1:xyz"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopening is a no-op.
+	d, err = OpenStateDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err = d.VerifiedDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, ok := db.Lookup("example.com/foo", "v1.0.0"); !ok || h != "h1:xyz" {
+		t.Errorf("got %q, %v", h, ok)
+	}
+
+	// A layout from the future is rejected.
+	if err := os.WriteFile(d.Path(stateLayoutFile), []byte("999\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenStateDir(dir); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("got %v, want layout version error", err)
+	}
+}
+
+func TestDefaultStateDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/xdg/state")
+	d, err := DefaultStateDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("/xdg/state", "goproxyclient"); string(d) != want {
+		t.Errorf("got %s, want %s", d, want)
+	}
+}
@@ -0,0 +1,110 @@
+package goproxyclient
+
+import (
+	"context"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// ModuleStatus describes the deprecation and retraction status of a module,
+// as declared in the go.mod file of its latest version.
+// Get one with [Client.Status].
+type ModuleStatus struct {
+	// Latest is the latest version of the module.
+	Latest string
+
+	// Deprecated is the module's deprecation message,
+	// or the empty string if the module is not deprecated.
+	Deprecated string
+
+	// Retract lists the module's retracted versions.
+	Retract []*modfile.Retract
+}
+
+// Status gets the deprecation and retraction status of a module.
+func (cl Client) Status(ctx context.Context, mod string) (ModuleStatus, error) {
+	latest, _, _, err := cl.Latest(ctx, mod)
+	if err != nil {
+		return ModuleStatus{}, errors.Wrapf(err, "getting latest version of %s", mod)
+	}
+	f, err := cl.modFile(ctx, mod, latest)
+	if err != nil {
+		return ModuleStatus{}, err
+	}
+
+	status := ModuleStatus{Latest: latest, Retract: f.Retract}
+	if f.Module != nil {
+		status.Deprecated = f.Module.Deprecated
+	}
+	return status, nil
+}
+
+// Retracted tells whether the given version is retracted,
+// and if so, the rationale given for the retraction (which may be empty).
+func (s ModuleStatus) Retracted(ver string) (bool, string) {
+	for _, r := range s.Retract {
+		if semver.Compare(r.Low, ver) <= 0 && semver.Compare(ver, r.High) <= 0 {
+			return true, r.Rationale
+		}
+	}
+	return false, ""
+}
+
+// VersionStatus is a module version and its retraction status.
+// See [Client.VersionsBetween].
+type VersionStatus struct {
+	Version string
+
+	// Retracted tells whether the version is retracted,
+	// and RetractRationale is the reason given, if any.
+	Retracted        bool
+	RetractRationale string
+}
+
+// VersionsBetween returns the versions of a module after from,
+// up to and including to,
+// in semver order:
+// the ones a consumer passes over or lands on when upgrading from one to the other.
+// Each is annotated with its retraction status
+// according to the go.mod file of the module's latest version
+// (see [Client.Status]).
+// This is useful for changelog and risk-assessment tools.
+//
+// Only versions reported by [Client.List] are included.
+func (cl Client) VersionsBetween(ctx context.Context, mod, from, to string) ([]VersionStatus, error) {
+	versions, err := cl.List(ctx, mod)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing versions of %s", mod)
+	}
+	versions = versionsInRange(versions, from, to)
+	if len(versions) == 0 {
+		return nil, nil
+	}
+
+	status, err := cl.Status(ctx, mod)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]VersionStatus, 0, len(versions))
+	for _, v := range versions {
+		retracted, rationale := status.Retracted(v)
+		result = append(result, VersionStatus{Version: v, Retracted: retracted, RetractRationale: rationale})
+	}
+	return result, nil
+}
+
+// VersionsInRange returns the members of versions
+// (which must be in semver order)
+// after from, up to and including to.
+func versionsInRange(versions []string, from, to string) []string {
+	var result []string
+	for _, v := range versions {
+		if semver.Compare(v, from) > 0 && semver.Compare(v, to) <= 0 {
+			result = append(result, v)
+		}
+	}
+	return result
+}
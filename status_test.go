@@ -0,0 +1,66 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+	status, err := cl.Status(context.Background(), "github.com/bobg/mid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Latest != "v1.9.0" {
+		t.Errorf("got latest %s, want v1.9.0", status.Latest)
+	}
+	if status.Deprecated != "" {
+		t.Errorf("got deprecation %q, want none", status.Deprecated)
+	}
+
+	cases := []struct {
+		ver  string
+		want bool
+	}{
+		{"v1.4.2", false},
+		{"v1.5.0", true},
+		{"v1.9.0", false},
+	}
+	for _, tc := range cases {
+		if got, _ := status.Retracted(tc.ver); got != tc.want {
+			t.Errorf("%s: got retracted %v, want %v", tc.ver, got, tc.want)
+		}
+	}
+}
+
+func TestVersionsBetween(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+	got, err := cl.VersionsBetween(context.Background(), "github.com/bobg/mid", "v1.4.1", "v1.6.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []VersionStatus{
+		{Version: "v1.4.2"},
+		{Version: "v1.5.0", Retracted: true},
+		{Version: "v1.6.0"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got, err = cl.VersionsBetween(context.Background(), "github.com/bobg/mid", "v1.9.0", "v2.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %v, want none", got)
+	}
+}
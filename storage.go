@@ -0,0 +1,173 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/bobg/errors"
+)
+
+// Storage is a place to write the files of a Go module proxy,
+// such as the output of a [Mirror].
+// Files are named by slash-separated paths
+// in the layout of the Go module proxy protocol,
+// such as "golang.org/x/mod/@v/v0.20.0.zip".
+//
+// Implementations must be safe for concurrent use by multiple goroutines.
+type Storage interface {
+	// Put creates or replaces the named file with the contents of r.
+	Put(ctx context.Context, name string, r io.Reader) error
+
+	// Get opens the named file for reading.
+	// The caller must close the result.
+	// If the file does not exist,
+	// the error satisfies errors.Is(err, fs.ErrNotExist).
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+
+	// Exists tells whether the named file exists.
+	Exists(ctx context.Context, name string) (bool, error)
+}
+
+// checkStorageName reports an error if name is not a valid name for a file in a [Storage].
+func checkStorageName(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "storage", Path: name, Err: fs.ErrInvalid}
+	}
+	return nil
+}
+
+// DirStorage is a [Storage] in a local directory.
+// The directory's contents can be served by any static file server
+// as a Go module proxy,
+// or used directly in GOPROXY with a file:// URL.
+type DirStorage string
+
+var _ Storage = DirStorage("")
+
+func (d DirStorage) path(name string) (string, error) {
+	if err := checkStorageName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(string(d), filepath.FromSlash(name)), nil
+}
+
+// Put implements [Storage.Put].
+// The file is written atomically.
+func (d DirStorage) Put(_ context.Context, name string, r io.Reader) error {
+	p, err := d.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return errors.Wrapf(err, "creating directory for %s", p)
+	}
+	return writeFileAtomic(p, 0644, false, func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
+}
+
+// Get implements [Storage.Get].
+func (d DirStorage) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	p, err := d.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+// Exists implements [Storage.Exists].
+func (d DirStorage) Exists(_ context.Context, name string) (bool, error) {
+	p, err := d.path(name)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// MemStorage is an in-memory [Storage],
+// mainly for testing.
+// Its zero value is ready to use.
+type MemStorage struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+var _ Storage = (*MemStorage)(nil)
+
+// Put implements [Storage.Put].
+func (m *MemStorage) Put(_ context.Context, name string, r io.Reader) error {
+	if err := checkStorageName(name); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return errors.Wrapf(err, "reading content for %s", name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.files == nil {
+		m.files = make(map[string][]byte)
+	}
+	m.files[name] = data
+	return nil
+}
+
+// Get implements [Storage.Get].
+func (m *MemStorage) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	if err := checkStorageName(name); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Exists implements [Storage.Exists].
+func (m *MemStorage) Exists(_ context.Context, name string) (bool, error) {
+	if err := checkStorageName(name); err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.files[name]
+	return ok, nil
+}
+
+// Names returns the names of the files in m, sorted.
+func (m *MemStorage) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Sorted(maps.Keys(m.files))
+}
+
+// storageJoin joins a prefix and a name in a [Storage].
+func storageJoin(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return path.Join(prefix, name)
+}
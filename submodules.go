@@ -0,0 +1,159 @@
+package goproxyclient
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/module"
+)
+
+// submoduleConcurrency is the number of candidate paths [Client.Submodules] probes at once.
+const submoduleConcurrency = 8
+
+// Submodules finds modules nested under the given module path prefix,
+// such as the many modules under cloud.google.com/go in a monorepo,
+// and returns each one's path and latest version,
+// sorted by path.
+// The prefix itself is not included.
+//
+// A module proxy cannot list the modules under a path,
+// so candidates are gathered from:
+//
+//   - the requirements and replacements in the go.mod file of the latest version of the module at prefix,
+//     if there is one;
+//   - the imports of that module's packages
+//     (see [Client.Imports])
+//     under prefix but outside the module itself,
+//     which must belong to nested modules;
+//   - the module paths under prefix in corpus,
+//     if it is not nil.
+//
+// Each candidate,
+// and each of its ancestors below prefix,
+// is then probed with [Client.Latest],
+// and counts as a module if the proxy has it
+// and its go.mod file declares the same path
+// (see [Client.CheckModulePath]).
+// Nested modules that nothing refers to are not found.
+func (cl Client) Submodules(ctx context.Context, prefix string, corpus *Corpus) ([]module.Version, error) {
+	candidates := make(map[string]bool)
+	add := func(p string) {
+		for p != prefix && strings.HasPrefix(p, prefix+"/") {
+			candidates[p] = true
+			p = path.Dir(p)
+		}
+	}
+
+	if corpus != nil {
+		for _, mv := range corpus.versions {
+			add(mv.Path)
+		}
+	}
+
+	ver, _, _, err := cl.Latest(ctx, prefix)
+	switch {
+	case IsNotFound(err):
+		// Not a module; rely on the corpus.
+
+	case err != nil:
+		return nil, errors.Wrapf(err, "getting latest version of %s", prefix)
+
+	default:
+		f, err := cl.modFile(ctx, prefix, ver)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range f.Require {
+			add(r.Mod.Path)
+		}
+		for _, r := range f.Replace {
+			add(r.Old.Path)
+		}
+
+		g, err := cl.Imports(ctx, prefix, ver, true)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting imports of %s@%s", prefix, ver)
+		}
+		for _, imports := range g.Packages {
+			for _, p := range imports {
+				if _, ok := g.Packages[p]; !ok {
+					add(p)
+				}
+			}
+		}
+	}
+
+	var (
+		wg     sync.WaitGroup
+		sem    = make(chan struct{}, submoduleConcurrency)
+		mu     sync.Mutex
+		result []module.Version
+		errs   []error
+	)
+	for p := range candidates {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			mv, ok, err := cl.probeModule(ctx, p)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			if ok {
+				result = append(result, mv)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	slices.SortFunc(result, func(a, b module.Version) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return result, nil
+}
+
+// ProbeModule tells whether mod is a module,
+// and if so its latest version.
+func (cl Client) probeModule(ctx context.Context, mod string) (module.Version, bool, error) {
+	ver, _, _, err := cl.Latest(ctx, mod)
+	if IsNotFound(err) {
+		return module.Version{}, false, nil
+	}
+	if err != nil {
+		return module.Version{}, false, errors.Wrapf(err, "getting latest version of %s", mod)
+	}
+
+	mismatch, err := cl.CheckModulePath(ctx, mod, ver)
+	if IsNotFound(err) {
+		return module.Version{}, false, nil
+	}
+	if err != nil {
+		return module.Version{}, false, errors.Wrapf(err, "checking module path of %s@%s", mod, ver)
+	}
+	if mismatch != nil {
+		return module.Version{}, false, nil
+	}
+
+	return module.Version{Path: mod, Version: ver}, true, nil
+}
@@ -0,0 +1,91 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/module"
+
+	"github.com/bobg/goproxyclient/goproxytest"
+)
+
+func TestSubmodules(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		zw  = zip.NewWriter(&buf)
+	)
+	files := map[string]string{
+		"go.mod":          "module example.com/mono\n",
+		"mono.go":         "package mono\n\nimport (\n\t\"example.com/mono/internal/x\"\n\t\"example.com/mono/sub/pkg\"\n)\n",
+		"internal/x/x.go": "package x\n",
+	}
+	for name, content := range files {
+		w, err := zw.Create("example.com/mono@v1.0.0/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := fstest.MapFS{
+		"example.com/mono/@latest":       {Data: []byte(`{"Version":"v1.0.0"}`)},
+		"example.com/mono/@v/v1.0.0.mod": {Data: []byte("module example.com/mono\n\nrequire example.com/mono/other v0.1.0\n")},
+		"example.com/mono/@v/v1.0.0.zip": {Data: buf.Bytes()},
+
+		"example.com/mono/sub/@latest":       {Data: []byte(`{"Version":"v0.2.0"}`)},
+		"example.com/mono/sub/@v/v0.2.0.mod": {Data: []byte("module example.com/mono/sub\n")},
+
+		"example.com/mono/other/@latest":       {Data: []byte(`{"Version":"v0.1.0"}`)},
+		"example.com/mono/other/@v/v0.1.0.mod": {Data: []byte("module example.com/mono/other\n")},
+
+		"example.com/mono/fromcorpus/@latest":       {Data: []byte(`{"Version":"v1.1.0"}`)},
+		"example.com/mono/fromcorpus/@v/v1.1.0.mod": {Data: []byte("module example.com/mono/fromcorpus\n")},
+
+		// Served at a nested path but declaring another.
+		"example.com/mono/fork/@latest":       {Data: []byte(`{"Version":"v1.0.0"}`)},
+		"example.com/mono/fork/@v/v1.0.0.mod": {Data: []byte("module example.com/elsewhere\n")},
+	}
+	s := httptest.NewServer(goproxytest.New(fsys))
+	defer s.Close()
+
+	var (
+		ctx    = context.Background()
+		cl     = New(s.URL, nil)
+		corpus = NewCorpus(cl, []module.Version{
+			{Path: "example.com/mono/fromcorpus", Version: "v1.0.0"},
+			{Path: "example.com/mono/fork", Version: "v1.0.0"},
+			{Path: "example.com/unrelated", Version: "v1.0.0"},
+		})
+	)
+
+	got, err := cl.Submodules(ctx, "example.com/mono", corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []module.Version{
+		{Path: "example.com/mono/fromcorpus", Version: "v1.1.0"},
+		{Path: "example.com/mono/other", Version: "v0.1.0"},
+		{Path: "example.com/mono/sub", Version: "v0.2.0"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = cl.Submodules(ctx, "example.com/mono", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want[1:], got); diff != "" {
+		t.Errorf("mismatch without corpus (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,380 @@
+// Package sumdb verifies module hashes against a Go checksum database,
+// such as sum.golang.org,
+// as described at https://go.dev/ref/mod#checksum-database.
+//
+// It wraps the client in [golang.org/x/mod/sumdb],
+// supplying the network access and storage that it needs.
+// A [*DB] can be passed to [github.com/bobg/goproxyclient.WithChecksumDB]
+// so that go.mod and zip files fetched by a [github.com/bobg/goproxyclient.Client] are verified transparently.
+package sumdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/mid"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// DefaultGOSUMDB is the checksum database used when none is specified,
+// as it is by the go command.
+const DefaultGOSUMDB = "sum.golang.org"
+
+// knownKeys maps the names of well-known checksum databases to their verifier keys.
+// Copied from cmd/go.
+var knownKeys = map[string]string{
+	"sum.golang.org": "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ux18htTTAD8OuAn8",
+}
+
+// ErrOff is the error returned by [New] for a GOSUMDB of "off".
+var ErrOff = errors.New("checksum database is off")
+
+// These are errors from [DB.Lookup].
+var (
+	// ErrGONOSUMDB means the module matches the patterns given with [WithGONOSUMDB].
+	ErrGONOSUMDB = sumdb.ErrGONOSUMDB
+
+	// ErrSecurity means the checksum database has misbehaved.
+	ErrSecurity = sumdb.ErrSecurity
+)
+
+// DB is a client for a checksum database.
+// Create one with [New].
+// It is safe for concurrent use by multiple goroutines.
+type DB struct {
+	name string
+	c    *sumdb.Client
+}
+
+// Option is the type of an option that can be passed to [New].
+type Option func(*ops)
+
+// WithHTTPClient sets the HTTP client used to reach the checksum database.
+// The default is a distinct client from [http.DefaultClient].
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *ops) {
+		o.hc = hc
+	}
+}
+
+// WithDir sets a directory in which to keep the database's state between runs:
+// the latest signed tree head that has been seen,
+// which protects against a database that rewrites its history,
+// and a cache of lookups and tiles.
+// The directory is created if necessary.
+// Without it,
+// state is kept only in memory.
+func WithDir(dir string) Option {
+	return func(o *ops) {
+		o.dir = dir
+	}
+}
+
+// WithGONOSUMDB sets a list of module path patterns,
+// in the format of the GONOSUMDB environment variable
+// (a comma-separated list of glob patterns matching path prefixes),
+// for modules that are not to be looked up.
+// Lookups for them fail with [ErrGONOSUMDB],
+// which [github.com/bobg/goproxyclient.Client] treats as meaning "not verified."
+func WithGONOSUMDB(patterns string) Option {
+	return func(o *ops) {
+		o.nosumdb = patterns
+	}
+}
+
+// WithLogger sets a logger for the database client's messages,
+// including security errors
+// (which are also reported as errors from lookups).
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *ops) {
+		o.logger = logger
+	}
+}
+
+// WithTimeout sets a time limit for each request to the checksum database.
+// The default is one minute.
+func WithTimeout(d time.Duration) Option {
+	return func(o *ops) {
+		o.timeout = d
+	}
+}
+
+// New creates a [DB] for the checksum database described by gosumdb,
+// in the format of the GOSUMDB environment variable:
+//
+//   - the name of a well-known database,
+//     such as sum.golang.org;
+//   - a verifier key,
+//     in which case the database's URL is https:// followed by the key's name;
+//   - a verifier key or well-known name followed by a space and a URL.
+//
+// The empty string means [DefaultGOSUMDB].
+// The value "off" yields [ErrOff].
+func New(gosumdb string, opts ...Option) (*DB, error) {
+	key, u, err := parseGOSUMDB(gosumdb)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := note.NewVerifier(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing verifier key %s", key)
+	}
+
+	o := &ops{
+		key:     key,
+		url:     strings.TrimRight(u, "/"),
+		timeout: time.Minute,
+		latest:  make(map[string][]byte),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.hc == nil {
+		o.hc = &http.Client{}
+	}
+
+	c := sumdb.NewClient(o)
+	if o.nosumdb != "" {
+		c.SetGONOSUMDB(o.nosumdb)
+	}
+	return &DB{name: verifier.Name(), c: c}, nil
+}
+
+// ParseGOSUMDB parses a GOSUMDB value into a verifier key and a URL.
+func parseGOSUMDB(gosumdb string) (key, u string, err error) {
+	if gosumdb == "" {
+		gosumdb = DefaultGOSUMDB
+	}
+	if gosumdb == "off" {
+		return "", "", ErrOff
+	}
+
+	fields := strings.Fields(gosumdb)
+	if len(fields) > 2 {
+		return "", "", fmt.Errorf("invalid GOSUMDB: too many fields in %q", gosumdb)
+	}
+
+	key = fields[0]
+	if k, ok := knownKeys[key]; ok {
+		key = k
+	} else if key == "sum.golang.google.cn" {
+		// A mirror of sum.golang.org, as in cmd/go.
+		key, u = knownKeys["sum.golang.org"], "https://sum.golang.google.cn"
+	}
+
+	name, _, _ := strings.Cut(key, "+")
+	if name == key {
+		return "", "", fmt.Errorf("invalid GOSUMDB: unknown database %q (a verifier key is needed)", key)
+	}
+
+	switch {
+	case len(fields) == 2:
+		u = fields[1]
+		if _, err := url.Parse(u); err != nil {
+			return "", "", errors.Wrapf(err, "invalid GOSUMDB URL %s", u)
+		}
+	case u == "":
+		u = "https://" + name
+	}
+
+	return key, u, nil
+}
+
+// Name returns the name of the checksum database,
+// e.g. sum.golang.org.
+func (db *DB) Name() string {
+	return db.name
+}
+
+// Lookup returns the go.sum lines for the given module path and version,
+// or for its go.mod file if ver has the suffix /go.mod.
+// Each line is "MODPATH VERSION HASH".
+//
+// The result has been verified against the database's signed tree,
+// which is checked for consistency with the trees it has reported before.
+// If the database has misbehaved,
+// the error is [ErrSecurity].
+func (db *DB) Lookup(mod, ver string) ([]string, error) {
+	lines, err := db.c.Lookup(mod, ver)
+	if err != nil && !errors.Is(err, ErrSecurity) && strings.Contains(err.Error(), ErrSecurity.Error()) {
+		// The underlying client formats some errors without wrapping them.
+		err = lookupError{msg: err.Error(), err: ErrSecurity}
+	}
+	return lines, err
+}
+
+// lookupError is an error from [DB.Lookup]
+// whose message is from the underlying client
+// and whose cause is err.
+type lookupError struct {
+	msg string
+	err error
+}
+
+func (e lookupError) Error() string { return e.msg }
+func (e lookupError) Unwrap() error { return e.err }
+
+// ops implements [sumdb.ClientOps].
+type ops struct {
+	key, url string
+	hc       *http.Client
+	dir      string
+	nosumdb  string
+	logger   *slog.Logger
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	latest map[string][]byte // config files, when there is no dir
+}
+
+var _ sumdb.ClientOps = (*ops)(nil)
+
+func (o *ops) ReadRemote(path string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+	defer cancel()
+
+	u := o.url + path
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating GET %s request", u)
+	}
+	resp, err := o.hc.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "in GET %s", u)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		msg := strings.TrimSpace(string(body))
+		if msg == "" {
+			msg = resp.Status
+		}
+		return nil, mid.CodeErr{C: resp.StatusCode, Err: fmt.Errorf("GET %s: %s", u, msg)}
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	return data, errors.Wrapf(err, "reading response from GET %s", u)
+}
+
+func (o *ops) ReadConfig(file string) ([]byte, error) {
+	if file == "key" {
+		return []byte(o.key), nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.readConfig(file)
+}
+
+// ReadConfig reads a configuration file other than the key.
+// A missing file reads as empty,
+// meaning no tree has been seen yet.
+// The caller must hold o.mu.
+func (o *ops) readConfig(file string) ([]byte, error) {
+	if o.dir == "" {
+		return o.latest[file], nil
+	}
+	data, err := os.ReadFile(o.path("config", file))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (o *ops) WriteConfig(file string, old, new []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	cur, err := o.readConfig(file)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(cur, old) {
+		return sumdb.ErrWriteConflict
+	}
+
+	if o.dir == "" {
+		o.latest[file] = bytes.Clone(new)
+		return nil
+	}
+	return writeFile(o.path("config", file), new)
+}
+
+func (o *ops) ReadCache(file string) ([]byte, error) {
+	if o.dir == "" {
+		return nil, fs.ErrNotExist
+	}
+	return os.ReadFile(o.path("cache", file))
+}
+
+func (o *ops) WriteCache(file string, data []byte) {
+	if o.dir == "" {
+		return
+	}
+	if err := writeFile(o.path("cache", file), data); err != nil {
+		o.Log(fmt.Sprintf("writing cache file %s: %s", file, err))
+	}
+}
+
+func (o *ops) Log(msg string) {
+	if o.logger != nil {
+		o.logger.Debug(msg)
+	}
+}
+
+func (o *ops) SecurityError(msg string) {
+	if o.logger != nil {
+		o.logger.Error(msg)
+	}
+}
+
+// Path returns the path in o.dir of a configuration or cache file.
+// The file names come from [sumdb.Client]
+// and consist of the database name and escaped module paths and versions,
+// or tile coordinates.
+func (o *ops) path(kind, file string) string {
+	return filepath.Join(o.dir, kind, filepath.FromSlash(file))
+}
+
+// WriteFile writes a file atomically,
+// by writing a temporary file and renaming it,
+// creating its directory if necessary.
+func writeFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.Wrapf(err, "creating %s", dir)
+	}
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return errors.Wrapf(err, "creating temp file in %s", dir)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return errors.Wrapf(err, "writing %s", f.Name())
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return errors.Wrapf(err, "closing %s", f.Name())
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return errors.Wrapf(err, "renaming %s to %s", f.Name(), path)
+	}
+	return nil
+}
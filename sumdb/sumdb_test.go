@@ -0,0 +1,126 @@
+package sumdb
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// newTestServer starts a checksum database server
+// that knows about example.com/a v1.0.0
+// and returns a GOSUMDB value for it.
+func newTestServer(t *testing.T) string {
+	t.Helper()
+
+	skey, vkey, err := note.GenerateKey(rand.Reader, "sumdb.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gosum := func(path, vers string) ([]byte, error) {
+		if path != "example.com/a" || vers != "v1.0.0" {
+			return nil, fmt.Errorf("unknown module %s@%s", path, vers)
+		}
+		return []byte("example.com/a v1.0.0 h1:zip=\nexample.com/a v1.0.0/go.mod h1:mod=\n"), nil
+	}
+	s := httptest.NewServer(sumdb.NewServer(sumdb.NewTestServer(skey, gosum)))
+	t.Cleanup(s.Close)
+
+	return vkey + " " + s.URL
+}
+
+func TestLookup(t *testing.T) {
+	gosumdb := newTestServer(t)
+	dir := t.TempDir()
+
+	db, err := New(gosumdb, WithDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Name() != "sumdb.example.com" {
+		t.Errorf("got name %s, want sumdb.example.com", db.Name())
+	}
+
+	lines, err := db.Lookup("example.com/a", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/a v1.0.0 h1:zip="}; !slices.Equal(lines, want) {
+		t.Errorf("got %v, want %v", lines, want)
+	}
+
+	lines, err = db.Lookup("example.com/a", "v1.0.0/go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/a v1.0.0/go.mod h1:mod="}; !slices.Equal(lines, want) {
+		t.Errorf("got %v, want %v", lines, want)
+	}
+
+	if _, err := db.Lookup("example.com/b", "v1.0.0"); err == nil {
+		t.Error("got no error for unknown module")
+	}
+
+	// A new DB with the same directory uses the cached lookup
+	// and the tree head that has been seen.
+	db2, err := New(gosumdb, WithDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db2.Lookup("example.com/a", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	db3, err := New(gosumdb, WithGONOSUMDB("example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db3.Lookup("example.com/a", "v1.0.0"); !errors.Is(err, ErrGONOSUMDB) {
+		t.Errorf("got %v, want ErrGONOSUMDB", err)
+	}
+}
+
+func TestParseGOSUMDB(t *testing.T) {
+	const testKey = "sumdb.example.com+01234567+AUmTEsLORa9dp/3rKcmgZsh0uX4tM5cEaR5ueJQz8nSp"
+
+	cases := []struct {
+		in, wantKey, wantURL string
+		wantErr              bool
+	}{
+		{"", knownKeys["sum.golang.org"], "https://sum.golang.org", false},
+		{"sum.golang.org", knownKeys["sum.golang.org"], "https://sum.golang.org", false},
+		{"sum.golang.org https://mirror.example.com", knownKeys["sum.golang.org"], "https://mirror.example.com", false},
+		{"sum.golang.google.cn", knownKeys["sum.golang.org"], "https://sum.golang.google.cn", false},
+		{testKey, testKey, "https://sumdb.example.com", false},
+		{testKey + " https://other.example.com/sumdb", testKey, "https://other.example.com/sumdb", false},
+		{"unknown.example.com", "", "", true},
+		{"a b c", "", "", true},
+		{"off", "", "", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			key, u, err := parseGOSUMDB(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("got %s, %s; want error", key, u)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key != tc.wantKey || u != tc.wantURL {
+				t.Errorf("got %s, %s; want %s, %s", key, u, tc.wantKey, tc.wantURL)
+			}
+		})
+	}
+
+	if _, err := New("off"); !errors.Is(err, ErrOff) {
+		t.Errorf("got %v, want ErrOff", err)
+	}
+}
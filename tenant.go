@@ -0,0 +1,194 @@
+package goproxyclient
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the given tenant ID,
+// identifying the customer or other party on whose behalf an operation is performed
+// by a service that shares a [Client] among many
+// (or that uses a [Manager] to keep one for each).
+// Operations on a Client using the resulting context
+// include the tenant ID in log events (see [WithLogger])
+// and [Journal] entries,
+// send it to proxies if the client is configured to (see [WithTenantHeader]),
+// and are subject to the tenant's rate limit, if there is one
+// (see [WithTenantRateLimit]).
+//
+// Identical operations in progress at the same time share their requests to the proxies
+// (see [Client.Info] and the others),
+// but if the client sends tenant IDs to proxies
+// or limits the rate of each tenant's requests,
+// only operations for the same tenant do,
+// and each tenant has its own part of the client's cache (see [WithCache]).
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// Tenant returns the tenant ID carried by ctx,
+// or the empty string if there isn't one.
+func Tenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// Priority is the priority of an operation on a [Client]
+// (see [WithPriority]).
+type Priority int
+
+const (
+	// PriorityBackground is for bulk work that no one is waiting for,
+	// such as mirroring or prefetching.
+	PriorityBackground Priority = -1
+
+	// PriorityNormal is the default.
+	PriorityNormal Priority = 0
+
+	// PriorityInteractive is for operations that a person is waiting for,
+	// such as a command-line tool's or an editor's.
+	PriorityInteractive Priority = 1
+)
+
+var priorityNames = map[Priority]string{
+	PriorityBackground:  "background",
+	PriorityNormal:      "normal",
+	PriorityInteractive: "interactive",
+}
+
+func (p Priority) String() string {
+	if s, ok := priorityNames[p]; ok {
+		return s
+	}
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx carrying the given priority.
+// Operations on a [Client] using the resulting context
+// include the priority in log events (see [WithLogger])
+// and [Journal] entries,
+// and interactive operations do not wait for their tenant's rate limit
+// (see [WithTenantRateLimit]).
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// RequestPriority returns the priority carried by ctx,
+// or [PriorityNormal] if there isn't one.
+func RequestPriority(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// WithTenantHeader is an [Option] that sends the tenant ID of each operation
+// (see [WithTenant])
+// to proxies in the HTTP header with the given name.
+// By default,
+// and if name is empty,
+// tenant IDs are not sent.
+// Use [WithUpstreamConfig] to send them only to proxies that use them,
+// such as a corporate proxy that accounts for usage by tenant.
+func WithTenantHeader(name string) Option {
+	return func(c *config) {
+		c.tenantHeader = name
+	}
+}
+
+// WithTenantRateLimit is an [Option] that limits the rate of requests for each tenant
+// (see [WithTenant])
+// to reqsPerSec requests per second,
+// with bursts of up to burst requests,
+// across all of the client's proxies,
+// so that no tenant of a service sharing the client
+// can use up the proxies' request limits (see [WithRateLimit]) by itself.
+// Requests wait for their turn
+// (or until their context is canceled),
+// except for those with [PriorityInteractive]
+// (see [WithPriority]),
+// which count against the tenant's limit but proceed at once,
+// leaving the tenant's other requests to wait longer instead.
+// Requests with no tenant ID are not limited.
+func WithTenantRateLimit(reqsPerSec float64, burst int) Option {
+	return func(c *config) {
+		c.tenantLimiters = &tenantLimiters{limit: rate.Limit(reqsPerSec), burst: max(burst, 1)}
+	}
+}
+
+// tenantLimiters holds the request-rate limiter for each tenant
+// (see [WithTenantRateLimit]),
+// created as needed.
+type tenantLimiters struct {
+	limit rate.Limit
+	burst int
+
+	mu      sync.Mutex
+	m       map[string]*rate.Limiter
+	pruneAt int // size of m at which to remove unneeded limiters
+}
+
+func (t *tenantLimiters) sameRate(other *tenantLimiters) bool {
+	return t != nil && other != nil && t.limit == other.limit && t.burst == other.burst
+}
+
+// Wait waits for the rate limit of the tenant in ctx, if any,
+// as described at [WithTenantRateLimit].
+func (t *tenantLimiters) wait(ctx context.Context) error {
+	tenant := Tenant(ctx)
+	if t == nil || tenant == "" {
+		return nil
+	}
+	l := t.get(tenant)
+	if RequestPriority(ctx) >= PriorityInteractive {
+		l.Reserve()
+		return nil
+	}
+	return l.Wait(ctx)
+}
+
+func (t *tenantLimiters) get(tenant string) *rate.Limiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if l, ok := t.m[tenant]; ok {
+		return l
+	}
+	if t.m == nil {
+		t.m = make(map[string]*rate.Limiter)
+	}
+	if len(t.m) >= t.pruneAt {
+		// A limiter with a full bucket is the same as a new one,
+		// so it need not be kept.
+		now := time.Now()
+		for k, l := range t.m {
+			if l.TokensAt(now) >= float64(t.burst) {
+				delete(t.m, k)
+			}
+		}
+		t.pruneAt = max(2*len(t.m), 64)
+	}
+	l := rate.NewLimiter(t.limit, t.burst)
+	t.m[tenant] = l
+	return l
+}
+
+// MetadataAttrs returns log attributes for the tenant and priority in ctx,
+// if they are set.
+func metadataAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	if tenant := Tenant(ctx); tenant != "" {
+		attrs = append(attrs, slog.String("tenant", tenant))
+	}
+	if p := RequestPriority(ctx); p != PriorityNormal {
+		attrs = append(attrs, slog.String("priority", p.String()))
+	}
+	return attrs
+}
@@ -0,0 +1,274 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTenant(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		tenants []string
+	)
+
+	h := testHandler(nil)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		tenants = append(tenants, req.Header.Get("X-Tenant"))
+		mu.Unlock()
+		h.ServeHTTP(w, req)
+	}))
+	defer s.Close()
+
+	var logbuf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logbuf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	path := filepath.Join(t.TempDir(), "journal")
+	j, err := OpenJournal(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	cl := New(s.URL, nil, WithLogger(logger), WithJournal(j), WithTenantHeader("X-Tenant"))
+
+	ctx := WithPriority(WithTenant(context.Background(), "acme"), PriorityBackground)
+	if _, _, _, err := cl.Info(ctx, "example.com/multi", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := cl.Info(context.Background(), "example.com/multi", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tenants) != 2 || tenants[0] != "acme" || tenants[1] != "" {
+		t.Errorf("got tenant headers %q, want [acme, (none)]", tenants)
+	}
+
+	type logEvent struct {
+		Tenant   string `json:"tenant"`
+		Priority string `json:"priority"`
+	}
+	var events []logEvent
+	dec := json.NewDecoder(&logbuf)
+	for dec.More() {
+		var event logEvent
+		if err := dec.Decode(&event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d log events, want 2", len(events))
+	}
+	if events[0].Tenant != "acme" || events[0].Priority != "background" {
+		t.Errorf("got tenant %q, priority %q in first log event, want acme, background", events[0].Tenant, events[0].Priority)
+	}
+	if events[1].Tenant != "" || events[1].Priority != "" {
+		t.Errorf("got tenant %q, priority %q in second log event, want none", events[1].Tenant, events[1].Priority)
+	}
+
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+	entries := readJournal(t, path)
+	if len(entries) != 2 {
+		t.Fatalf("got %d journal entries, want 2", len(entries))
+	}
+	if entries[0].Tenant != "acme" || entries[0].Priority != "background" {
+		t.Errorf("got tenant %q, priority %q in first journal entry, want acme, background", entries[0].Tenant, entries[0].Priority)
+	}
+	if entries[1].Tenant != "" || entries[1].Priority != "normal" {
+		t.Errorf("got tenant %q, priority %q in second journal entry, want none, normal", entries[1].Tenant, entries[1].Priority)
+	}
+}
+
+func TestTenantCoalesce(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		tenants []string
+		arrived = make(chan struct{}, 2)
+		release = make(chan struct{})
+	)
+	h := testHandler(nil)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		tenants = append(tenants, req.Header.Get("X-Tenant"))
+		mu.Unlock()
+		arrived <- struct{}{}
+		<-release
+		h.ServeHTTP(w, req)
+	}))
+	defer s.Close()
+
+	var once sync.Once
+	unblock := func() { once.Do(func() { close(release) }) }
+	defer unblock()
+
+	cl := New(s.URL, nil, WithTenantHeader("X-Tenant"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	info := func(tenant string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, _, err := cl.Info(WithTenant(ctx, tenant), "example.com/multi", "v1.0.0"); err != nil {
+				t.Errorf("tenant %s: %v", tenant, err)
+			}
+		}()
+	}
+
+	// The second tenant's operation does not join the first's request,
+	// which was sent with the first tenant's ID.
+	info("acme")
+	<-arrived
+	info("globex")
+	select {
+	case <-arrived:
+	case <-ctx.Done():
+		t.Fatal("second tenant's operation shared the first's request")
+	}
+	unblock()
+	wg.Wait()
+
+	slices.Sort(tenants)
+	if want := []string{"acme", "globex"}; !slices.Equal(tenants, want) {
+		t.Errorf("got tenant headers %q, want %q", tenants, want)
+	}
+}
+
+func TestTenantRateLimit(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	// One request per tenant, then one an hour.
+	cl := New(s.URL, nil, WithTenantRateLimit(1.0/3600, 1))
+
+	info := func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_, _, _, err := cl.Info(ctx, "example.com/multi", "v1.0.0")
+		return err
+	}
+
+	acme := WithTenant(context.Background(), "acme")
+	if err := info(acme); err != nil {
+		t.Fatal(err)
+	}
+	if err := info(acme); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v for second request, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Interactive requests don't wait.
+	if err := info(WithPriority(acme, PriorityInteractive)); err != nil {
+		t.Errorf("got %v for interactive request", err)
+	}
+
+	// Other tenants have their own limits,
+	// and requests without a tenant are not limited.
+	if err := info(WithTenant(context.Background(), "globex")); err != nil {
+		t.Errorf("got %v for other tenant", err)
+	}
+	for range 2 {
+		if err := info(context.Background()); err != nil {
+			t.Errorf("got %v for request without tenant", err)
+		}
+	}
+}
+
+func TestPriorityString(t *testing.T) {
+	cases := map[Priority]string{
+		PriorityBackground:  "background",
+		PriorityNormal:      "normal",
+		PriorityInteractive: "interactive",
+		Priority(7):         "Priority(7)",
+	}
+	for p, want := range cases {
+		if got := p.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	if got := RequestPriority(context.Background()); got != PriorityNormal {
+		t.Errorf("got %v for context without priority, want %v", got, PriorityNormal)
+	}
+}
+
+func TestTenantCache(t *testing.T) {
+	// Each tenant sees a different go.mod file for the same module version.
+	var (
+		mu       sync.Mutex
+		requests = make(map[string]int)
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		tenant := req.Header.Get("X-Tenant")
+		mu.Lock()
+		requests[tenant]++
+		mu.Unlock()
+		if req.URL.Path != "/example.com/multi/@v/v1.0.0.mod" {
+			http.NotFound(w, req)
+			return
+		}
+		fmt.Fprintf(w, "module example.com/multi // %s\n", tenant)
+	}))
+	defer s.Close()
+
+	dir := t.TempDir()
+	cl := New(s.URL, nil, WithTenantHeader("X-Tenant"), WithCache(dir, 0))
+
+	mod := func(t *testing.T, tenant string) string {
+		t.Helper()
+
+		rc, err := cl.Mod(WithTenant(context.Background(), tenant), "example.com/multi", "v1.0.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// Each tenant's second request is served from the cache,
+	// and neither is served the other's file.
+	for range 2 {
+		for _, tenant := range []string{"acme", "globex"} {
+			if got, want := mod(t, tenant), "module example.com/multi // "+tenant+"\n"; got != want {
+				t.Errorf("got %q for tenant %s, want %q", got, tenant, want)
+			}
+		}
+	}
+	if requests["acme"] != 1 || requests["globex"] != 1 {
+		t.Errorf("got requests %v, want one for each tenant", requests)
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("got cached files %q, want one for each tenant", files)
+	}
+}
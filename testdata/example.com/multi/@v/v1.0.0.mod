@@ -0,0 +1,5 @@
+module example.com/multi
+
+go 1.22
+
+require github.com/bobg/errors v1.1.0
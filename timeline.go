@@ -0,0 +1,86 @@
+package goproxyclient
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/semver"
+)
+
+// VersionTime is a module version and its publication time.
+// See [Client.Timeline].
+type VersionTime struct {
+	Version string
+	Time    time.Time
+}
+
+// timelineConcurrency is the number of .info files [Client.Timeline] fetches at once.
+const timelineConcurrency = 8
+
+// Timeline returns the versions of a module reported by [Client.List],
+// with the time of each from its .info file,
+// in order of publication
+// (with ties broken by semver order).
+// Versions whose .info files cannot be found are omitted.
+//
+// The .info files are fetched concurrently,
+// and their times are cached in the client
+// (as for [Client.VersionAt]).
+func (cl Client) Timeline(ctx context.Context, mod string) ([]VersionTime, error) {
+	versions, err := cl.List(ctx, mod)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing versions of %s", mod)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		sem    = make(chan struct{}, timelineConcurrency)
+		mu     sync.Mutex
+		result []VersionTime
+		errs   []error
+	)
+	for _, v := range versions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			tm, err := cl.versionTime(ctx, mod, v)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if IsNotFound(err) {
+				return
+			}
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			result = append(result, VersionTime{Version: v, Time: tm})
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	slices.SortFunc(result, func(a, b VersionTime) int {
+		if c := a.Time.Compare(b.Time); c != 0 {
+			return c
+		}
+		return semver.Compare(a.Version, b.Version)
+	})
+	return result, nil
+}
@@ -0,0 +1,99 @@
+package goproxyclient
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts are the limits on how long a [Client] waits at each stage of an operation.
+// From the innermost stage to the outermost:
+// establishing a connection to a proxy,
+// the TLS handshake on it,
+// waiting for the response to a request,
+// and (for metadata requests) the whole operation,
+// including any fallback from one proxy to the next and any retries.
+// Downloads are limited instead by how long they may go without receiving data,
+// so that large files on slow connections can still succeed.
+//
+// A zero field means the default described for it.
+// An overall timeout on the [http.Client] passed to [New]
+// (its Timeout field)
+// applies as well,
+// to each request.
+type Timeouts struct {
+	// Connect is the maximum time to wait for a connection to a proxy host
+	// (or outbound proxy)
+	// to be established,
+	// as set by [WithDialTimeout].
+	// The default is 30 seconds.
+	Connect time.Duration
+
+	// TLS is the maximum time to wait for a TLS handshake
+	// (see [http.Transport.TLSHandshakeTimeout]).
+	// The default is the setting of the [http.Client]'s transport,
+	// which for the default transport is 10 seconds.
+	TLS time.Duration
+
+	// ResponseHeader is the maximum time to wait for a proxy's response headers
+	// after sending a request
+	// (see [http.Transport.ResponseHeaderTimeout]).
+	// The default is the setting of the [http.Client]'s transport,
+	// which for the default transport is no limit.
+	ResponseHeader time.Duration
+
+	// MetadataTotal is the maximum time for a whole info, @latest, list, go.mod, or zip-size operation
+	// (see [Client.Info], [Client.Latest], [Client.List], [Client.Mod], and [Client.ZipSize]),
+	// including all proxies tried and all retries.
+	// When it is exceeded,
+	// the operation fails with an error satisfying errors.Is(err, context.DeadlineExceeded).
+	// The default is no limit.
+	MetadataTotal time.Duration
+
+	// DownloadIdle is the maximum time a read from the result of [Client.Mod] or [Client.Zip]
+	// may wait for data,
+	// as set by [WithIdleTimeout].
+	// The default is no limit.
+	DownloadIdle time.Duration
+}
+
+// WithTimeouts is an [Option] that sets all of the client's timeouts at once
+// (see [Timeouts]),
+// replacing any set with [WithDialTimeout] or [WithIdleTimeout].
+//
+// The Connect, TLS, and ResponseHeader timeouts take effect only when the [http.Client] passed to [New]
+// is nil or has a Transport that is nil or an [*http.Transport].
+func WithTimeouts(t Timeouts) Option {
+	return func(c *config) {
+		c.dial.timeout = t.Connect
+		c.tlsTimeout = t.TLS
+		c.responseHeaderTimeout = t.ResponseHeader
+		c.metadataTimeout = t.MetadataTotal
+		c.idleTimeout = t.DownloadIdle
+	}
+}
+
+// Timeouts returns the client's timeouts,
+// as set with [WithTimeouts]
+// (or [WithDialTimeout] and [WithIdleTimeout])
+// and possibly changed by [Client.Reload].
+// Zero fields mean the defaults described at [Timeouts].
+func (cl Client) Timeouts() Timeouts {
+	conf := cl.chain().conf
+	return Timeouts{
+		Connect:        conf.dial.timeout,
+		TLS:            conf.tlsTimeout,
+		ResponseHeader: conf.responseHeaderTimeout,
+		MetadataTotal:  cl.conf.metadataTimeout,
+		DownloadIdle:   conf.idleTimeout,
+	}
+}
+
+// MetadataContext returns a copy of ctx limited by the client's MetadataTotal timeout,
+// if it has one.
+// The caller must call the returned function when the operation is finished.
+func (cl Client) metadataContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if cl.conf.metadataTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, cl.conf.metadataTimeout)
+}
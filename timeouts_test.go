@@ -0,0 +1,101 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeouts(t *testing.T) {
+	ctx := context.Background()
+
+	// The slow server answers after a second,
+	// or when its request is canceled.
+	h := testHandler(nil)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-time.After(time.Second):
+		}
+		h.ServeHTTP(w, req)
+	}))
+	defer slow.Close()
+
+	t.Run("settings", func(t *testing.T) {
+		want := Timeouts{
+			Connect:        time.Second,
+			TLS:            2 * time.Second,
+			ResponseHeader: 3 * time.Second,
+			MetadataTotal:  4 * time.Second,
+			DownloadIdle:   5 * time.Second,
+		}
+		cl := New(slow.URL, nil, WithTimeouts(want))
+		if got := cl.Timeouts(); got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+
+		transport, ok := cl.chain().first.client.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("got transport of type %T, want *http.Transport", cl.chain().first.client.Transport)
+		}
+		if transport.TLSHandshakeTimeout != want.TLS {
+			t.Errorf("got TLS handshake timeout %s, want %s", transport.TLSHandshakeTimeout, want.TLS)
+		}
+		if transport.ResponseHeaderTimeout != want.ResponseHeader {
+			t.Errorf("got response header timeout %s, want %s", transport.ResponseHeaderTimeout, want.ResponseHeader)
+		}
+		if cl.chain().first.idleTimeout != want.DownloadIdle {
+			t.Errorf("got idle timeout %s, want %s", cl.chain().first.idleTimeout, want.DownloadIdle)
+		}
+
+		// The individual options are reflected too,
+		// and later options win.
+		cl = New(slow.URL, nil, WithTimeouts(want), WithDialTimeout(time.Minute), WithIdleTimeout(time.Hour))
+		want.Connect, want.DownloadIdle = time.Minute, time.Hour
+		if got := cl.Timeouts(); got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+
+		// Without timeouts, the HTTP client is used as is.
+		hc := &http.Client{}
+		cl = New(slow.URL, hc)
+		if cl.chain().first.client != hc {
+			t.Error("HTTP client replaced with no timeouts set")
+		}
+	})
+
+	t.Run("response_header", func(t *testing.T) {
+		cl := New(slow.URL, nil, WithTimeouts(Timeouts{ResponseHeader: 50 * time.Millisecond}))
+		start := time.Now()
+		if _, err := cl.List(ctx, "example.com/multi"); err == nil {
+			t.Error("got no error, want a timeout")
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("took %s, want about 50ms", elapsed)
+		}
+	})
+
+	t.Run("metadata_total", func(t *testing.T) {
+		// The total applies across fallback from one proxy to the next.
+		cl := New(slow.URL+"|"+slow.URL, nil, WithTimeouts(Timeouts{MetadataTotal: 50 * time.Millisecond}))
+		start := time.Now()
+		_, _, _, err := cl.Info(ctx, "example.com/multi", "v1.0.0")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("took %s, want about 50ms", elapsed)
+		}
+
+		// Downloads are not limited.
+		rc, err := cl.Zip(ctx, "example.com/multi", "v1.0.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc.Close()
+	})
+}
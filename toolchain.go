@@ -0,0 +1,94 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"go/version"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bobg/errors"
+)
+
+// ToolchainModule is the path of the module through which Go toolchains are distributed.
+// See https://go.dev/doc/toolchain#download.
+const ToolchainModule = "golang.org/toolchain"
+
+// ToolchainVersion gives the version of [ToolchainModule]
+// containing the given Go release (e.g. "go1.21.0") for the given platform,
+// such as "v0.0.1-go1.21.0.linux-amd64".
+func ToolchainVersion(gover, goos, goarch string) string {
+	return fmt.Sprintf("v0.0.1-%s.%s-%s", gover, goos, goarch)
+}
+
+// Toolchains lists the Go releases (e.g. "go1.21.0") available as toolchains for the given platform,
+// sorted from oldest to newest.
+func (cl Client) Toolchains(ctx context.Context, goos, goarch string) ([]string, error) {
+	versions, err := cl.List(ctx, ToolchainModule)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing toolchain versions")
+	}
+
+	suffix := "." + goos + "-" + goarch
+	var result []string
+	for _, v := range versions {
+		rest, ok := strings.CutPrefix(v, "v0.0.1-")
+		if !ok {
+			continue
+		}
+		gover, ok := strings.CutSuffix(rest, suffix)
+		if !ok || !version.IsValid(gover) {
+			continue
+		}
+		result = append(result, gover)
+	}
+	slices.SortFunc(result, version.Compare)
+
+	return result, nil
+}
+
+// ToolchainZip gets the zip file for the given Go release (e.g. "go1.21.0") and platform.
+func (cl Client) ToolchainZip(ctx context.Context, gover, goos, goarch string) (io.ReadCloser, error) {
+	return cl.Zip(ctx, ToolchainModule, ToolchainVersion(gover, goos, goarch))
+}
+
+// DownloadToolchain downloads the given Go release (e.g. "go1.21.0") for the given platform
+// and extracts it into dir,
+// which is created if necessary.
+// The result is a Go installation (a GOROOT) with the go command at dir/bin/go.
+//
+// Like [Client.Extract],
+// this checks the download against the client's [VerifiedDB], if any.
+//
+// Module zip files do not record file modes,
+// so as the go command does,
+// this makes the files in bin and pkg/tool executable.
+func (cl Client) DownloadToolchain(ctx context.Context, gover, goos, goarch, dir string) error {
+	ver := ToolchainVersion(gover, goos, goarch)
+
+	d, err := cl.zipFile(ctx, ToolchainModule, ver)
+	if err != nil {
+		return err
+	}
+	defer d.remove()
+
+	if err := cl.checkDownload(ctx, d, ToolchainModule, ver); err != nil {
+		return err
+	}
+
+	zr, err := zip.NewReader(d, d.size)
+	if err != nil {
+		return errors.Wrapf(err, "parsing zip for %s@%s", ToolchainModule, ver)
+	}
+	return cl.extractZip(zr, ToolchainModule+"@"+ver+"/", dir, ExtractFilter{}, toolchainFileMode)
+}
+
+func toolchainFileMode(name string) os.FileMode {
+	if strings.HasPrefix(name, "bin/") || strings.HasPrefix(name, "pkg/tool/") {
+		return 0755
+	}
+	return 0644
+}
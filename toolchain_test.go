@@ -0,0 +1,214 @@
+package goproxyclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestToolchains(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	var (
+		ctx = context.Background()
+		cl  = New(s.URL, nil)
+	)
+
+	got, err := cl.Toolchains(ctx, "linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"go1.21.0", "go1.21.10", "go1.22rc1", "go1.22.0"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	dir := t.TempDir()
+	if err := cl.DownloadToolchain(ctx, "go1.22.0", "linux", "amd64", dir); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "bin", "go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("bin/go has mode %v, want executable", info.Mode())
+	}
+	info, err = os.Stat(filepath.Join(dir, "src", "fmt", "print.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0100 != 0 {
+		t.Errorf("src/fmt/print.go has mode %v, want non-executable", info.Mode())
+	}
+}
+
+func TestDownloadToolchainFiles(t *testing.T) {
+	const (
+		gover  = "go1.99.0"
+		prefix = ToolchainModule + "@v0.0.1-" + gover + ".linux-amd64/"
+	)
+
+	// fakeToolchain makes a toolchain zip file with the given entries.
+	fakeToolchain := func(t *testing.T, names ...string) []byte {
+		t.Helper()
+
+		buf := new(bytes.Buffer)
+		zw := zip.NewWriter(buf)
+		for _, name := range names {
+			w, err := zw.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(name, "/") {
+				if _, err := w.Write([]byte(name)); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	download := func(t *testing.T, data []byte) (string, error) {
+		t.Helper()
+
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/golang.org/toolchain/@v/v0.0.1-"+gover+".linux-amd64.zip" {
+				http.NotFound(w, req)
+				return
+			}
+			w.Write(data)
+		}))
+		defer s.Close()
+
+		dir := filepath.Join(t.TempDir(), "go")
+		err := New(s.URL, nil).DownloadToolchain(context.Background(), gover, "linux", "amd64", dir)
+		return dir, err
+	}
+
+	t.Run("modes", func(t *testing.T) {
+		want := map[string]os.FileMode{
+			"bin/go":                       0755,
+			"bin/gofmt":                    0755,
+			"pkg/tool/linux_amd64/compile": 0755,
+			"pkg/include/asm_ppc64x.h":     0644,
+			"src/cmd/go/main.go":           0644,
+			"src/bin/notes.txt":            0644,
+			"binary.txt":                   0644,
+			"lib/time/zoneinfo.zip":        0644,
+		}
+		names := []string{prefix + "pkg/tool/"}
+		for name := range want {
+			names = append(names, prefix+name)
+		}
+
+		dir, err := download(t, fakeToolchain(t, names...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for name, mode := range want {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			if info.Mode() != mode {
+				t.Errorf("%s has mode %v, want %v", name, info.Mode(), mode)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != prefix+name {
+				t.Errorf("%s contains %q, want %q", name, got, prefix+name)
+			}
+		}
+		if info, err := os.Stat(filepath.Join(dir, "pkg", "tool")); err != nil || !info.IsDir() {
+			t.Errorf("got %v, %v for pkg/tool, want a directory", info, err)
+		}
+	})
+
+	// Entries that would land outside the destination directory,
+	// or that are not in the module,
+	// stop the extraction before anything is written.
+	bad := []struct{ name, entry string }{
+		{"parent", prefix + "../escape"},
+		{"unclean", prefix + "bin/../../escape"},
+		{"absolute", prefix + "/etc/passwd"},
+		{"backslash", prefix + `bin\go.exe`},
+		{"other_version", "golang.org/toolchain@v0.0.1-go1.98.0.linux-amd64/bin/go"},
+		{"no_prefix", "bin/go"},
+	}
+	for _, tc := range bad {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := download(t, fakeToolchain(t, prefix+"bin/go", tc.entry))
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+				entries, _ := os.ReadDir(dir)
+				if len(entries) > 0 {
+					t.Errorf("got %d entries in destination, want none", len(entries))
+				}
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape")); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("got %v for escaped file, want %v", err, os.ErrNotExist)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	var (
+		ctx = context.Background()
+		cl  = New(s.URL, nil)
+		dir = t.TempDir()
+	)
+
+	if err := cl.Extract(ctx, "example.com/multi", "v1.0.0", dir); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "sub", "sub.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 {
+		t.Error("sub/sub.go is empty")
+	}
+}
+
+func TestZipSize(t *testing.T) {
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+	size, err := cl.ZipSize(context.Background(), "example.com/multi", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat("testdata/example.com/multi/@v/v1.0.0.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != info.Size() {
+		t.Errorf("got %d, want %d", size, info.Size())
+	}
+}
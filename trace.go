@@ -0,0 +1,131 @@
+package goproxyclient
+
+import (
+	"context"
+	"time"
+)
+
+// Trace is a set of hooks for following the steps of a [Client] operation,
+// such as for explaining why a request succeeded or failed
+// (e.g. which proxies were tried and what they said).
+// Attach one to a context with [WithTrace].
+// Any of the hooks may be nil.
+//
+// Hooks may be called concurrently
+// if the context is used for concurrent operations.
+type Trace struct {
+	// CacheLookup is called when the client looks for a file in its cache
+	// (see [WithCache]).
+	// The name is the file's path in the cache
+	// (which has the same layout as a proxy),
+	// and hit tells whether it was found
+	// (and, for a version list or @latest response, was fresh enough to use).
+	CacheLookup func(name string, hit bool)
+
+	// ProxyStart is called when the client starts trying an entry in its list of proxies.
+	// The proxy is the entry's base URL,
+	// or "direct" or "off."
+	ProxyStart func(proxy string)
+
+	// ProxyDone is called when the client is done with an entry in its list of proxies,
+	// with the error (if any) it produced.
+	// If the client is going on to try another entry,
+	// next is that entry;
+	// otherwise it is empty,
+	// either because there was no error,
+	// because the error does not allow falling back
+	// (under the rules described at [Parse]),
+	// or because there are no more entries.
+	//
+	// In race mode (see [WithRace]),
+	// ProxyStart is called for all of the proxies at once,
+	// ProxyDone is called as each one finishes,
+	// and next is always empty.
+	ProxyDone func(proxy string, err error, next string)
+
+	// GotResponse is called after each HTTP request to a proxy,
+	// including each retry.
+	// The method is GET or HEAD.
+	// The status is 0 if there was no response,
+	// in which case err is the error from the HTTP client.
+	GotResponse func(method, url string, status int, elapsed time.Duration, err error)
+
+	// Retry is called before the client waits to retry a failed HTTP request
+	// (see [WithRetry] and [WithRetryAfter]).
+	Retry func(url string, delay time.Duration, err error)
+
+	// Verified is called after the client checks a go.mod or zip file
+	// (kind is "mod" or "zip")
+	// against its known hash
+	// from the client's [VerifiedDB] or [ChecksumDB].
+	// It is not called if the client has neither.
+	// The ok argument tells whether there was a known hash and the file matched it.
+	// If there was no known hash,
+	// ok is false and err is nil.
+	Verified func(mod, ver, kind string, ok bool, err error)
+
+	// CircuitBreaker is called when the circuit breaker for a proxy
+	// (see [WithCircuitBreaker])
+	// opens (and the proxy starts being skipped)
+	// or closes (and the proxy is restored).
+	CircuitBreaker func(proxy string, open bool)
+}
+
+type traceKey struct{}
+
+// WithTrace returns a copy of ctx carrying t.
+// Operations on a [Client] using the resulting context
+// call t's hooks as they proceed.
+func WithTrace(ctx context.Context, t *Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+// TraceFrom returns the [Trace] carried by ctx,
+// or nil if there isn't one.
+// The methods of a nil *Trace do nothing.
+func traceFrom(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceKey{}).(*Trace)
+	return t
+}
+
+func (t *Trace) cacheLookup(name string, hit bool) {
+	if t != nil && t.CacheLookup != nil {
+		t.CacheLookup(name, hit)
+	}
+}
+
+func (t *Trace) proxyStart(proxy string) {
+	if t != nil && t.ProxyStart != nil {
+		t.ProxyStart(proxy)
+	}
+}
+
+func (t *Trace) proxyDone(proxy string, err error, next string) {
+	if t != nil && t.ProxyDone != nil {
+		t.ProxyDone(proxy, err, next)
+	}
+}
+
+func (t *Trace) gotResponse(method, url string, status int, elapsed time.Duration, err error) {
+	if t != nil && t.GotResponse != nil {
+		t.GotResponse(method, url, status, elapsed, err)
+	}
+}
+
+func (t *Trace) retry(url string, delay time.Duration, err error) {
+	if t != nil && t.Retry != nil {
+		t.Retry(url, delay, err)
+	}
+}
+
+func (t *Trace) verified(mod, ver, kind string, ok bool, err error) {
+	if t != nil && t.Verified != nil {
+		t.Verified(mod, ver, kind, ok, err)
+	}
+}
+
+func (t *Trace) circuitBreaker(proxy string, open bool) {
+	if t != nil && t.CircuitBreaker != nil {
+		t.CircuitBreaker(proxy, open)
+	}
+}
@@ -0,0 +1,119 @@
+package goproxyclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTrace(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	good := httptest.NewServer(testHandler(nil))
+	defer good.Close()
+
+	var events []string
+	trace := &Trace{
+		CacheLookup: func(name string, hit bool) {
+			events = append(events, fmt.Sprintf("cache %s %v", name, hit))
+		},
+		ProxyStart: func(proxy string) {
+			events = append(events, "start "+proxy)
+		},
+		ProxyDone: func(proxy string, err error, next string) {
+			events = append(events, fmt.Sprintf("done %s %v next=%s", proxy, err != nil, next))
+		},
+		GotResponse: func(method, url string, status int, _ time.Duration, _ error) {
+			events = append(events, fmt.Sprintf("response %s %s %d", method, url, status))
+		},
+	}
+	ctx := WithTrace(context.Background(), trace)
+
+	// The test servers' URLs are all alike but for the port,
+	// so make them easier to tell apart.
+	names := strings.NewReplacer(notFound.URL, "notfound", broken.URL, "broken", good.URL, "good")
+	name := func(events []string) []string {
+		for i, e := range events {
+			events[i] = names.Replace(e)
+		}
+		return events
+	}
+
+	cases := []struct {
+		name    string
+		goproxy string
+		want    []string
+	}{{
+		name:    "fallback",
+		goproxy: notFound.URL + "," + good.URL,
+		want: []string{
+			"cache example.com/multi/@v/v1.0.0.info false",
+			"start notfound",
+			"response GET notfound/example.com/multi/@v/v1.0.0.info 404",
+			"done notfound true next=good",
+			"start good",
+			"response GET good/example.com/multi/@v/v1.0.0.info 200",
+			"done good false next=",
+		},
+	}, {
+		name:    "no_fallback",
+		goproxy: broken.URL + "," + good.URL,
+		want: []string{
+			"cache example.com/multi/@v/v1.0.0.info false",
+			"start broken",
+			"response GET broken/example.com/multi/@v/v1.0.0.info 500",
+			"done broken true next=",
+		},
+	}, {
+		name:    "fallback_after_any_error",
+		goproxy: broken.URL + "|" + good.URL,
+		want: []string{
+			"cache example.com/multi/@v/v1.0.0.info false",
+			"start broken",
+			"response GET broken/example.com/multi/@v/v1.0.0.info 500",
+			"done broken true next=good",
+			"start good",
+			"response GET good/example.com/multi/@v/v1.0.0.info 200",
+			"done good false next=",
+		},
+	}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cl := New(tc.goproxy, nil, WithCache(t.TempDir(), 0))
+
+			events = nil
+			cl.Info(ctx, "example.com/multi", "v1.0.0")
+			if diff := cmp.Diff(tc.want, name(events)); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("cache_hit", func(t *testing.T) {
+		cl := New(good.URL, nil, WithCache(t.TempDir(), 0))
+		if _, _, _, err := cl.Info(ctx, "example.com/multi", "v1.0.0"); err != nil {
+			t.Fatal(err)
+		}
+
+		events = nil
+		if _, _, _, err := cl.Info(ctx, "example.com/multi", "v1.0.0"); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"cache example.com/multi/@v/v1.0.0.info true"}
+		if diff := cmp.Diff(want, events); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,231 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/mid"
+	"golang.org/x/mod/module"
+)
+
+// Transport returns an [http.RoundTripper] that answers requests in the [GOPROXY protocol]
+// using the client,
+// with all its features
+// (such as fallback from one proxy to the next, caching, and verification).
+// Code written to talk to a proxy with raw HTTP requests
+// can use it in an [http.Client]
+// to talk to this client instead.
+//
+// The request's host is ignored,
+// and its path,
+// which must not have a prefix before the module path,
+// selects the operation:
+// e.g. /golang.org/x/mod/@v/list
+// or /golang.org/x/mod/@v/v0.20.0.zip.
+// Only GET and HEAD requests are supported.
+// A request ID in the request's header
+// (see [WithRequestIDHeader])
+// is used for the client operation.
+//
+// An error from the client with an HTTP status code
+// (a [CodeErr])
+// produces a response with that status;
+// a [*PolicyError] or [ErrProxyOff] produces 403 (Forbidden);
+// and other errors produce 502 (Bad Gateway).
+// The response body is the error message.
+// If the request's context is canceled,
+// the RoundTrip method returns the context's error.
+//
+// [GOPROXY protocol]: https://go.dev/ref/mod#goproxy-protocol
+func (cl Client) Transport() http.RoundTripper {
+	return transport{cl: cl}
+}
+
+type transport struct {
+	cl Client
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	ctx := req.Context()
+	if h := t.cl.chain().conf.requestIDHeader; h != "" {
+		if id := req.Header.Get(h); id != "" {
+			ctx = WithRequestID(ctx, id)
+		}
+	}
+
+	var (
+		code   int
+		header = make(http.Header)
+		body   io.ReadCloser
+		err    error
+	)
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		body, err = t.serve(ctx, req, header)
+		code = http.StatusOK
+	default:
+		header.Set("Allow", "GET, HEAD")
+		code, err = http.StatusMethodNotAllowed, errors.New("method not allowed")
+	}
+
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		var codeErr CodeErr
+		switch {
+		case code != http.StatusOK:
+			// Already set.
+		case errors.As(err, &codeErr):
+			code = codeErr.Code()
+		case errors.Is(err, ErrProxyOff), errors.As(err, new(*PolicyError)):
+			code = http.StatusForbidden
+		default:
+			code = http.StatusBadGateway
+		}
+		msg := err.Error() + "\n"
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+		header.Set("Content-Length", strconv.Itoa(len(msg)))
+		body = io.NopCloser(strings.NewReader(msg))
+	}
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body,
+		ContentLength: -1,
+		Request:       req,
+	}
+	if n, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+		resp.ContentLength = n
+	}
+	if req.Method == http.MethodHead {
+		resp.Body.Close()
+		resp.Body = http.NoBody
+	}
+	return resp, nil
+}
+
+// Serve performs the client operation for a GOPROXY protocol request,
+// setting response headers and returning the response body.
+func (t transport) serve(ctx context.Context, req *http.Request, header http.Header) (io.ReadCloser, error) {
+	escPath, rest, ok := strings.Cut(strings.TrimPrefix(req.URL.Path, "/"), "/@")
+	if !ok {
+		return nil, notFound("unrecognized path %s", req.URL.Path)
+	}
+	mod, err := module.UnescapePath(escPath)
+	if err != nil {
+		return nil, notFound("invalid module path %s", escPath)
+	}
+
+	if rest == "latest" {
+		_, _, m, err := t.cl.Latest(ctx, mod)
+		if err != nil {
+			return nil, err
+		}
+		return jsonBody(header, m)
+	}
+
+	rest, ok = strings.CutPrefix(rest, "v/")
+	if !ok {
+		return nil, notFound("unrecognized path %s", req.URL.Path)
+	}
+	if rest == "list" {
+		versions, err := t.cl.List(ctx, mod)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		for _, v := range versions {
+			fmt.Fprintln(&buf, v)
+		}
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+		header.Set("Content-Length", strconv.Itoa(buf.Len()))
+		return io.NopCloser(&buf), nil
+	}
+
+	idx := strings.LastIndex(rest, ".")
+	if idx < 0 {
+		return nil, notFound("unrecognized path %s", req.URL.Path)
+	}
+	ver, err := module.UnescapeVersion(rest[:idx])
+	if err != nil {
+		return nil, notFound("invalid version %s", rest[:idx])
+	}
+
+	switch rest[idx+1:] {
+	case "info":
+		_, _, m, err := t.cl.Info(ctx, mod, ver)
+		if err != nil {
+			return nil, err
+		}
+		return jsonBody(header, m)
+
+	case "mod":
+		rc, err := t.cl.Mod(ctx, mod, ver)
+		if err != nil {
+			return nil, err
+		}
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+		if n := contentLength(rc); n >= 0 {
+			header.Set("Content-Length", strconv.FormatInt(n, 10))
+		}
+		return rc, nil
+
+	case "zip":
+		header.Set("Content-Type", "application/zip")
+		if req.Method == http.MethodHead {
+			size, err := t.cl.ZipSize(ctx, mod, ver)
+			if err != nil {
+				return nil, err
+			}
+			if size >= 0 {
+				header.Set("Content-Length", strconv.FormatInt(size, 10))
+			}
+			return http.NoBody, nil
+		}
+		rc, err := t.cl.Zip(ctx, mod, ver)
+		if err != nil {
+			return nil, err
+		}
+		if n := contentLength(rc); n >= 0 {
+			header.Set("Content-Length", strconv.FormatInt(n, 10))
+		}
+		return rc, nil
+	}
+
+	return nil, notFound("unrecognized path %s", req.URL.Path)
+}
+
+// JSONBody produces the body of an info or @latest response
+// from the fields parsed from the proxy's response
+// (which include Version).
+func jsonBody(header http.Header, m map[string]json.RawMessage) (io.ReadCloser, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding info")
+	}
+	header.Set("Content-Type", "application/json")
+	header.Set("Content-Length", strconv.Itoa(len(data)))
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func notFound(format string, args ...any) error {
+	return mid.CodeErr{C: http.StatusNotFound, Err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,105 @@
+package goproxyclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/module"
+)
+
+func TestTransport(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+	hc := &http.Client{Transport: cl.Transport()}
+
+	get := func(t *testing.T, method, path string) (*http.Response, string) {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(ctx, method, "http://goproxy.invalid/"+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := hc.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(body)
+	}
+
+	cases := []struct {
+		name, method, path string
+		wantCode           int
+		wantBody           string // substring
+	}{
+		{name: "list", path: "example.com/multi/@v/list", wantCode: http.StatusOK, wantBody: "v1.0.0\n"},
+		{name: "info", path: "example.com/multi/@v/v1.0.0.info", wantCode: http.StatusOK, wantBody: `"Version":"v1.0.0"`},
+		{name: "latest", path: "example.com/multi/@latest", wantCode: http.StatusOK, wantBody: `"Version":"v1.0.0"`},
+		{name: "mod", path: "example.com/multi/@v/v1.0.0.mod", wantCode: http.StatusOK, wantBody: "module example.com/multi"},
+		{name: "zip", path: "example.com/multi/@v/v1.0.0.zip", wantCode: http.StatusOK, wantBody: "PK"},
+		{name: "missing_module", path: "example.com/nonexistent/@v/list", wantCode: http.StatusNotFound},
+		{name: "missing_version", path: "example.com/multi/@v/v9.9.9.info", wantCode: http.StatusNotFound},
+		{name: "bad_path", path: "example.com/multi/foo", wantCode: http.StatusNotFound},
+		{name: "bad_suffix", path: "example.com/multi/@v/v1.0.0.txt", wantCode: http.StatusNotFound},
+		{name: "post", method: http.MethodPost, path: "example.com/multi/@v/list", wantCode: http.StatusMethodNotAllowed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			resp, body := get(t, method, tc.path)
+			if resp.StatusCode != tc.wantCode {
+				t.Fatalf("got status %d, want %d (body %q)", resp.StatusCode, tc.wantCode, body)
+			}
+			if !strings.Contains(body, tc.wantBody) {
+				t.Errorf("body %q does not contain %q", body, tc.wantBody)
+			}
+			if resp.StatusCode == http.StatusOK && resp.ContentLength != int64(len(body)) {
+				t.Errorf("got content length %d, want %d", resp.ContentLength, len(body))
+			}
+		})
+	}
+
+	t.Run("head_zip", func(t *testing.T) {
+		_, zipBody := get(t, http.MethodGet, "example.com/multi/@v/v1.0.0.zip")
+
+		resp, body := get(t, http.MethodHead, "example.com/multi/@v/v1.0.0.zip")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		if body != "" {
+			t.Errorf("got body %q for HEAD request", body)
+		}
+		if resp.ContentLength != int64(len(zipBody)) {
+			t.Errorf("got content length %d, want %d", resp.ContentLength, len(zipBody))
+		}
+	})
+
+	t.Run("conformance", func(t *testing.T) {
+		probe := module.Version{Path: "example.com/multi", Version: "v1.0.0"}
+		report, err := CheckProxy(ctx, "http://goproxy.invalid", hc, probe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range report.Checks {
+			if c.Err != nil {
+				t.Errorf("%s (%s): %s", c.Name, c.URL, c.Err)
+			}
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package goproxyclient
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/semver"
+)
+
+// WithListUnion is an [Option] that makes [Client.List] query all of the client's proxies
+// (up to any "off" entry)
+// and combine their results,
+// rather than using the first one able to answer.
+// This finds versions that only some of the proxies have,
+// such as those on a secondary mirror.
+//
+// The proxies are queried concurrently.
+// Errors from some of them are ignored
+// (though reported to any [Trace])
+// as long as at least one succeeds;
+// if none does,
+// the error is as in race mode
+// (see [WithRace]).
+// A [Provenance] records the first proxy in the list that succeeded.
+func WithListUnion() Option {
+	return func(c *config) {
+		c.listUnion = true
+	}
+}
+
+// WithHighestLatest is an [Option] that makes [Client.Latest] query all of the client's proxies
+// (up to any "off" entry)
+// and return the highest of their answers in semver order,
+// rather than the answer of the first one able to respond.
+// This is useful when some of the proxies,
+// such as private mirrors,
+// may lag behind others.
+//
+// Errors are handled as for [WithListUnion].
+// A [Provenance] records the proxy that gave the chosen answer
+// (the first in the list, if several gave it).
+func WithHighestLatest() Option {
+	return func(c *config) {
+		c.highestLatest = true
+	}
+}
+
+// ListUnion lists the versions of a module (whose path is already escaped)
+// from all of the client's reachable proxies
+// and returns the sorted union.
+func (cl Client) listUnion(ctx context.Context, mod string) ([]string, error) {
+	results, proxies, err := queryAll(ctx, cl, func(ctx context.Context, s single) ([]string, error) {
+		return s.list(ctx, mod)
+	})
+	if err != nil {
+		return nil, err
+	}
+	provenanceFrom(ctx).set(proxies[0])
+
+	var versions []string
+	for _, r := range results {
+		versions = append(versions, r...)
+	}
+	semver.Sort(versions)
+	return slices.Compact(versions), nil
+}
+
+// HighestLatest gets the @latest answer for a module (whose path is already escaped)
+// from all of the client's reachable proxies
+// and returns the highest.
+func (cl Client) highestLatest(ctx context.Context, mod string) (infoResult, error) {
+	results, proxies, err := queryAll(ctx, cl, func(ctx context.Context, s single) (infoResult, error) {
+		return newInfoResult(s.latest(ctx, mod))
+	})
+	if err != nil {
+		return infoResult{}, err
+	}
+
+	best := 0
+	for i, r := range results {
+		if semver.Compare(r.ver, results[best].ver) > 0 {
+			best = i
+		}
+	}
+	provenanceFrom(ctx).set(proxies[best])
+	return results[best], nil
+}
+
+// QueryAll calls f concurrently on each of the client's reachable proxies
+// (see [Client.reachable]).
+// It returns the results of the calls that succeeded,
+// in the order of the proxies,
+// and the base URLs of those proxies.
+// If none succeeded,
+// the error is the one from the only proxy,
+// or a [FallbackError] if there were several.
+func queryAll[T any](ctx context.Context, cl Client, f func(context.Context, single) (T, error)) ([]T, []string, error) {
+	done, err := cl.work().begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer done()
+
+	var (
+		entries = cl.chain().reachable()
+		results = make([]T, len(entries))
+		errs    = make([]error, len(entries))
+		t       = traceFrom(ctx)
+		wg      sync.WaitGroup
+	)
+	for i, s := range entries {
+		t.proxyStart(s.baseURL)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = f(ctx, s)
+			t.proxyDone(s.baseURL, errs[i], "")
+		}()
+	}
+	wg.Wait()
+
+	var (
+		good    []T
+		proxies []string
+	)
+	for i, s := range entries {
+		if errs[i] == nil {
+			good = append(good, results[i])
+			proxies = append(proxies, s.baseURL)
+		}
+	}
+	if len(good) > 0 {
+		return good, proxies, nil
+	}
+
+	if len(errs) == 1 {
+		return nil, nil, errs[0]
+	}
+	for i, err := range errs {
+		errs[i] = errors.Wrapf(err, "proxy %s", entries[i].baseURL)
+	}
+	return nil, nil, FallbackError{Errs: errs}
+}
@@ -0,0 +1,139 @@
+package goproxyclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestListUnion(t *testing.T) {
+	ctx := context.Background()
+
+	lister := func(versions ...string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/example.com/m/@v/list" {
+				http.NotFound(w, req)
+				return
+			}
+			for _, v := range versions {
+				fmt.Fprintln(w, v)
+			}
+		}))
+	}
+
+	primary := lister("v1.0.0", "v1.2.0")
+	defer primary.Close()
+
+	mirror := lister("v1.1.0", "v1.2.0", "v1.10.0")
+	defer mirror.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	cases := []struct {
+		name    string
+		proxies []string
+		want    []string
+		wantErr bool
+	}{{
+		name:    "union",
+		proxies: []string{primary.URL, mirror.URL},
+		want:    []string{"v1.0.0", "v1.1.0", "v1.2.0", "v1.10.0"},
+	}, {
+		name:    "partial_failure",
+		proxies: []string{broken.URL, mirror.URL},
+		want:    []string{"v1.1.0", "v1.2.0", "v1.10.0"},
+	}, {
+		name:    "off",
+		proxies: []string{primary.URL, "off", mirror.URL},
+		want:    []string{"v1.0.0", "v1.2.0"},
+	}, {
+		name:    "all_fail",
+		proxies: []string{broken.URL, broken.URL},
+		wantErr: true,
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cl := New(strings.Join(tc.proxies, ","), nil, WithListUnion())
+			got, err := cl.List(ctx, "example.com/m")
+			if tc.wantErr {
+				if !errors.As(err, new(FallbackError)) {
+					t.Errorf("got %v, want a FallbackError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// Without the option, only the first proxy is consulted.
+	cl := New(primary.URL+","+mirror.URL, nil)
+	got, err := cl.List(ctx, "example.com/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"v1.0.0", "v1.2.0"}, got); diff != "" {
+		t.Errorf("without union, mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHighestLatest(t *testing.T) {
+	ctx := context.Background()
+
+	latest := func(ver string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/example.com/m/@latest" {
+				http.NotFound(w, req)
+				return
+			}
+			fmt.Fprintf(w, `{"Version":%q,"Time":"2025-01-01T00:00:00Z"}`, ver)
+		}))
+	}
+
+	mirror := latest("v1.9.0")
+	defer mirror.Close()
+
+	public := latest("v1.10.0")
+	defer public.Close()
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	cl := New(strings.Join([]string{mirror.URL, notFound.URL, public.URL}, ","), nil, WithHighestLatest())
+
+	var prov Provenance
+	ver, _, _, err := cl.Latest(WithProvenance(ctx, &prov), "example.com/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver != "v1.10.0" {
+		t.Errorf("got %s, want v1.10.0", ver)
+	}
+	if got := prov.Proxy(); got != public.URL {
+		t.Errorf("got provenance %s, want %s", got, public.URL)
+	}
+
+	// Without the option, the first proxy's answer is used.
+	cl = New(strings.Join([]string{mirror.URL, public.URL}, ","), nil)
+	ver, _, _, err = cl.Latest(ctx, "example.com/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver != "v1.9.0" {
+		t.Errorf("without the option, got %s, want v1.9.0", ver)
+	}
+}
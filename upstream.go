@@ -0,0 +1,117 @@
+package goproxyclient
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+// WithUpstreamConfig is an [Option] that customizes requests to one of the Go module proxies in the client's list:
+// the one whose base URL is upstream
+// (as with [WithUpstreamOutboundProxy]).
+// This allows, for example,
+// sending credentials to a corporate proxy but not to the public one after it
+// in a GOPROXY string like "https://corp.example|https://proxy.golang.org".
+//
+// If hc is non-nil,
+// it is used for requests to that proxy
+// instead of the HTTP client passed to [New] or [NewFromConfig]
+// (for a custom TLS configuration, for example).
+//
+// The opts apply only to requests to that proxy,
+// in addition to the client's other options.
+// Only options affecting individual requests have an effect here:
+// [WithAuth] and the other authentication options,
+// [WithCompat],
+// [WithBandwidthLimit] and [WithDownloadBandwidthLimit],
+// [WithIdleTimeout] and [WithTimeouts] (except for its MetadataTotal timeout),
+// [WithOutboundProxy] and the dialing options (such as [WithResolver]),
+// [WithRateLimit], [WithHostRateLimiter], and [WithTenantRateLimit],
+// [WithRetry] and [WithRetryAfter],
+// [WithCircuitBreaker],
+// [WithRequestIDHeader] and [WithTenantHeader],
+// and [WithLogger].
+// Other options,
+// such as [WithCache] and [WithChecksumDB],
+// apply to the client as a whole
+// and are ignored here.
+//
+// The upstream may not be "direct" or "off."
+// If WithUpstreamConfig is given more than once for the same upstream,
+// the last one wins.
+func WithUpstreamConfig(upstream string, hc *http.Client, opts ...Option) Option {
+	return func(c *config) {
+		if c.upstreams == nil {
+			c.upstreams = make(map[string]upstreamConfig)
+		}
+		c.upstreams[strings.TrimRight(upstream, "/")] = upstreamConfig{hc: hc, opts: opts}
+	}
+}
+
+type upstreamConfig struct {
+	hc   *http.Client
+	opts []Option
+}
+
+// ForUpstream returns the configuration and HTTP client to use for the upstream proxy at baseURL:
+// c and hc,
+// unless changed with [WithUpstreamConfig].
+//
+// A changed configuration has only the fields of c used by a [single],
+// with the upstream's options applied.
+// It shares c's per-host and per-tenant rate limiters
+// unless the upstream has its own rate limits.
+func (c *config) forUpstream(baseURL string, hc *http.Client) (*config, *http.Client) {
+	u, ok := c.upstreams[baseURL]
+	if !ok || baseURL == "direct" || baseURL == "off" {
+		return c, hc
+	}
+	if u.hc != nil {
+		hc = u.hc
+	}
+	if len(u.opts) == 0 {
+		return c, hc
+	}
+
+	if c.hostLimiters == nil {
+		c.hostLimiters = make(map[string]*rate.Limiter)
+	}
+
+	sub := &config{
+		auth:                  slices.Clip(c.auth),
+		compat:                c.compat,
+		bandwidth:             c.bandwidth,
+		downloadBandwidth:     c.downloadBandwidth,
+		idleTimeout:           c.idleTimeout,
+		tlsTimeout:            c.tlsTimeout,
+		responseHeaderTimeout: c.responseHeaderTimeout,
+		outboundProxy:         c.outboundProxy,
+		outboundProxySet:      c.outboundProxySet,
+		upstreamProxies:       maps.Clone(c.upstreamProxies),
+		dial:                  c.dial,
+		rateLimit:             c.rateLimit,
+		rateBurst:             c.rateBurst,
+		hostLimiters:          c.hostLimiters,
+		hostRateLimiters:      maps.Clone(c.hostRateLimiters),
+		retry:                 c.retry,
+		retryAfterMax:         c.retryAfterMax,
+		breaker:               c.breaker,
+		requestIDHeader:       c.requestIDHeader,
+		tenantHeader:          c.tenantHeader,
+		tenantLimiters:        c.tenantLimiters,
+		scheduler:             c.scheduler,
+		logger:                c.logger,
+		journal:               c.journal,
+	}
+	sub.dial.hostMap = maps.Clone(c.dial.hostMap)
+	for _, opt := range u.opts {
+		opt(sub)
+	}
+	if sub.rateLimit != c.rateLimit || sub.rateBurst != c.rateBurst {
+		sub.hostLimiters = nil
+	}
+	return sub, hc
+}
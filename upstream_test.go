@@ -0,0 +1,65 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUpstreamConfig(t *testing.T) {
+	ctx := context.Background()
+
+	// Each server records the Authorization header of the last request it got.
+	var corpAuth, publicAuth atomic.Value
+	corp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		corpAuth.Store(req.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer corp.Close()
+
+	handler := testHandler(nil)
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		publicAuth.Store(req.Header.Get("Authorization"))
+		handler.ServeHTTP(w, req)
+	}))
+	defer public.Close()
+
+	var corpRequests atomic.Int64
+	corpClient := &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			corpRequests.Add(1)
+			return http.DefaultTransport.RoundTrip(req)
+		}),
+	}
+
+	auth := AuthFunc(func(_ context.Context, req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer secret")
+		return nil
+	})
+	cl := New(corp.URL+"|"+public.URL, nil, WithUpstreamConfig(corp.URL+"/", corpClient,
+		WithAuth(auth),
+		WithRetry(RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond}),
+	))
+
+	if _, err := cl.List(ctx, "example.com/multi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := corpAuth.Load(); got != "Bearer secret" {
+		t.Errorf("corporate proxy got Authorization %q, want %q", got, "Bearer secret")
+	}
+	if got := publicAuth.Load(); got != "" {
+		t.Errorf("public proxy got Authorization %q, want none", got)
+	}
+	if n := corpRequests.Load(); n != 2 {
+		t.Errorf("got %d requests through the corporate proxy's HTTP client, want 2 (with one retry)", n)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
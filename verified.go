@@ -0,0 +1,108 @@
+package goproxyclient
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/bobg/errors"
+)
+
+// VerifiedDB is a persistent record of module hashes that have already been verified
+// (e.g. against a checksum database),
+// so that verifying them again on a later run is a local lookup.
+// This is analogous to the go command's use of go.sum files and its module cache.
+//
+// The file is in go.sum format:
+// one "MODPATH VERSION HASH" line per entry,
+// where VERSION has the suffix /go.mod for the hash of a go.mod file.
+// New entries are appended to the file as they are added.
+//
+// A VerifiedDB is safe for concurrent use by multiple goroutines.
+// Create one with [OpenVerifiedDB].
+type VerifiedDB struct {
+	path string
+
+	mu sync.Mutex
+	m  map[string]string // "modpath version" -> hash
+}
+
+// OpenVerifiedDB opens the [VerifiedDB] in the given file,
+// creating it if it does not exist.
+func OpenVerifiedDB(path string) (*VerifiedDB, error) {
+	db := &VerifiedDB{path: path, m: make(map[string]string)}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %s", path)
+	}
+	defer f.Close()
+
+	var (
+		sc     = bufio.NewScanner(f)
+		lineno int
+	)
+	for sc.Scan() {
+		lineno++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: malformed line", path, lineno)
+		}
+		db.m[fields[0]+" "+fields[1]] = fields[2]
+	}
+	return db, errors.Wrapf(sc.Err(), "reading %s", path)
+}
+
+// Lookup returns the recorded hash for the given module path and version
+// (which should have the suffix /go.mod to look up the hash of a go.mod file).
+// The boolean result is false if there is no such record.
+func (db *VerifiedDB) Lookup(mod, ver string) (string, bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	h, ok := db.m[mod+" "+ver]
+	return h, ok
+}
+
+// Add records a verified hash for the given module path and version,
+// both in memory and in the underlying file.
+//
+// It is an error to add a hash for a module and version
+// that already has a different hash recorded:
+// that indicates that something has been tampered with.
+func (db *VerifiedDB) Add(mod, ver, hash string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	key := mod + " " + ver
+	if old, ok := db.m[key]; ok {
+		if old != hash {
+			return fmt.Errorf("hash %s for %s@%s does not match previously verified hash %s", hash, mod, ver, old)
+		}
+		return nil
+	}
+
+	f, err := os.OpenFile(db.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "opening %s", db.path)
+	}
+	if _, err := fmt.Fprintf(f, "%s %s\n", key, hash); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "writing to %s", db.path)
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "closing %s", db.path)
+	}
+
+	db.m[key] = hash
+	return nil
+}
@@ -0,0 +1,41 @@
+package goproxyclient
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifiedDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "verified")
+
+	db, err := OpenVerifiedDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.Lookup("example.com/foo", "v1.0.0"); ok {
+		t.Error("found entry in empty db")
+	}
+	if err := db.Add("example.com/foo", "v1.0.0", "h1:abc="); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add("example.com/foo", "v1.0.0/go.mod", "h1:def="); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add("example.com/foo", "v1.0.0", "h1:abc="); err != nil {
+		t.Errorf("re-adding the same hash: %s", err)
+	}
+	if err := db.Add("example.com/foo", "v1.0.0", "h1:xyz="); err == nil {
+		t.Error("adding a conflicting hash: got nil, want error")
+	}
+
+	db, err = OpenVerifiedDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, ok := db.Lookup("example.com/foo", "v1.0.0"); !ok || h != "h1:abc=" {
+		t.Errorf("got %q, %v; want h1:abc=, true", h, ok)
+	}
+	if h, ok := db.Lookup("example.com/foo", "v1.0.0/go.mod"); !ok || h != "h1:def=" {
+		t.Errorf("got %q, %v; want h1:def=, true", h, ok)
+	}
+}
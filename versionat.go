@@ -0,0 +1,108 @@
+package goproxyclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/mid"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// VersionAt returns the version of a module that was latest as of time t:
+// the highest release version whose .info Time is not after t,
+// or, if there is none,
+// the highest such prerelease version.
+// (This is how the go command chooses the latest version,
+// except that retractions are not considered.)
+// This allows reproducing "as of" dependency snapshots
+// and historical analyses.
+//
+// Only the versions reported by [Client.List] are considered.
+// If none qualifies,
+// the result is an error for which [IsNotFound] is true.
+//
+// Version times are cached in the client,
+// so repeated queries about the same module are cheap.
+func (cl Client) VersionAt(ctx context.Context, mod string, t time.Time) (string, error) {
+	versions, err := cl.List(ctx, mod)
+	if err != nil {
+		return "", errors.Wrapf(err, "listing versions of %s", mod)
+	}
+
+	// The list is in ascending semver order.
+	// Try releases from highest to lowest, then prereleases.
+	// A higher version may have been published earlier than a lower one
+	// (e.g. v1.3.0 before the backported v1.2.5),
+	// so this finds the highest version published by t,
+	// not the most recently published one.
+	for _, prerelease := range []bool{false, true} {
+		for _, v := range slices.Backward(versions) {
+			if (semver.Prerelease(v) != "") != prerelease {
+				continue
+			}
+			tm, err := cl.versionTime(ctx, mod, v)
+			if IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			if !tm.After(t) {
+				return v, nil
+			}
+		}
+	}
+
+	return "", mid.CodeErr{C: http.StatusNotFound, Err: fmt.Errorf("no version of %s as of %s", mod, t.Format(time.RFC3339))}
+}
+
+// VersionTime returns the time of a module version from its .info file,
+// caching the result for canonical versions.
+func (cl Client) versionTime(ctx context.Context, mod, ver string) (time.Time, error) {
+	mv := module.Version{Path: mod, Version: ver}
+	if tm, ok := cl.conf.versionTimes.get(mv); ok {
+		return tm, nil
+	}
+
+	canonical, tm, _, err := cl.Info(ctx, mod, ver)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "getting info for %s@%s", mod, ver)
+	}
+	if canonical == ver {
+		cl.conf.versionTimes.put(mv, tm)
+	}
+	return tm, nil
+}
+
+// versionTimeCache holds the times of module versions.
+// These never change for a given canonical version,
+// so they need no expiration.
+// Its zero value is ready to use.
+type versionTimeCache struct {
+	mu sync.Mutex
+	m  map[module.Version]time.Time
+}
+
+func (c *versionTimeCache) get(mv module.Version) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tm, ok := c.m[mv]
+	return tm, ok
+}
+
+func (c *versionTimeCache) put(mv module.Version, tm time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.m == nil {
+		c.m = make(map[module.Version]time.Time)
+	}
+	c.m[mv] = tm
+}
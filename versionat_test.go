@@ -0,0 +1,138 @@
+package goproxyclient
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/bobg/goproxyclient/goproxytest"
+)
+
+// versionsFS is a module proxy file tree for example.com/ver,
+// in which v1.2.5 was published after v1.3.0.
+func versionsFS() fstest.MapFS {
+	fsys := fstest.MapFS{
+		"example.com/ver/@v/list": {Data: []byte("v1.0.0\nv1.1.0-pre\nv1.2.5\nv1.3.0\nv2.0.0-beta\n")},
+	}
+	times := map[string]string{
+		"v1.0.0":      "2024-01-01T00:00:00Z",
+		"v1.1.0-pre":  "2024-02-01T00:00:00Z",
+		"v1.3.0":      "2024-03-01T00:00:00Z",
+		"v1.2.5":      "2024-04-01T00:00:00Z",
+		"v2.0.0-beta": "2024-05-01T00:00:00Z",
+	}
+	for v, tm := range times {
+		fsys["example.com/ver/@v/"+v+".info"] = &fstest.MapFile{Data: []byte(`{"Version":"` + v + `","Time":"` + tm + `"}`)}
+	}
+	return fsys
+}
+
+func TestVersionAt(t *testing.T) {
+	ctx := context.Background()
+
+	h := goproxytest.New(versionsFS())
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+
+	cases := []struct {
+		at   string
+		want string // "" means not found
+	}{
+		{"2023-12-31T00:00:00Z", ""},
+		{"2024-01-01T00:00:00Z", "v1.0.0"},
+		{"2024-02-15T00:00:00Z", "v1.0.0"},
+		{"2024-03-15T00:00:00Z", "v1.3.0"},
+		{"2024-04-15T00:00:00Z", "v1.3.0"},
+		{"2024-06-01T00:00:00Z", "v1.3.0"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.at, func(t *testing.T) {
+			at, err := time.Parse(time.RFC3339, tc.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := cl.VersionAt(ctx, "example.com/ver", at)
+			if tc.want == "" {
+				if !IsNotFound(err) {
+					t.Errorf("got %q, %v; want not-found error", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+
+	// Version times are cached, so asking again needs only the list.
+	before := h.Requests()
+	if _, err := cl.VersionAt(ctx, "example.com/ver", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if n := h.Requests() - before; n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestVersionAtPrerelease(t *testing.T) {
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"example.com/pre/@v/list":          {Data: []byte("v0.1.0-a\nv0.1.0-b\n")},
+		"example.com/pre/@v/v0.1.0-a.info": {Data: []byte(`{"Version":"v0.1.0-a","Time":"2024-01-01T00:00:00Z"}`)},
+		"example.com/pre/@v/v0.1.0-b.info": {Data: []byte(`{"Version":"v0.1.0-b","Time":"2024-02-01T00:00:00Z"}`)},
+	}
+	s := goproxytest.NewServer(fsys)
+	defer s.Close()
+
+	got, err := New(s.URL, nil).VersionAt(ctx, "example.com/pre", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "v0.1.0-a" {
+		t.Errorf("got %s, want v0.1.0-a", got)
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	ctx := context.Background()
+
+	h := goproxytest.New(versionsFS())
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+
+	got, err := cl.Timeline(ctx, "example.com/ver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []string
+	for _, vt := range got {
+		versions = append(versions, vt.Version)
+	}
+	want := []string{"v1.0.0", "v1.1.0-pre", "v1.3.0", "v1.2.5", "v2.0.0-beta"}
+	if !slices.Equal(versions, want) {
+		t.Errorf("got %v, want %v", versions, want)
+	}
+	if !got[0].Time.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got time %s for %s", got[0].Time, got[0].Version)
+	}
+
+	// The times are now cached.
+	before := h.Requests()
+	if _, err := cl.VersionAt(ctx, "example.com/ver", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if n := h.Requests() - before; n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
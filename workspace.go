@@ -0,0 +1,214 @@
+package goproxyclient
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bobg/errors"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Workspace describes a Go workspace,
+// as defined by a go.work file.
+// Load one with [LoadWorkspace].
+type Workspace struct {
+	// Dir is the directory containing the go.work file.
+	Dir string
+
+	// Modules are the paths of the workspace's modules
+	// (those named in its use directives),
+	// in the order they appear.
+	Modules []string
+
+	// Requires is the union of the requirements of the workspace's modules,
+	// sorted by path,
+	// with the highest required version of each path.
+	// Requirements on the workspace's own modules are omitted.
+	// The replace directives of the go.work file and of the modules' go.mod files,
+	// and the exclude directives of the go.mod files,
+	// have been applied
+	// (see [Requirement]).
+	Requires []Requirement
+}
+
+// Requirement is a module requirement from a go.mod file,
+// with the file's replace and exclude directives applied.
+type Requirement struct {
+	// Mod is the required module version.
+	Mod module.Version
+
+	// Replace is the replacement for the required module version,
+	// or nil if there is none.
+	// If the replacement is a local directory,
+	// its Path is the directory
+	// (relative to the go.mod or go.work file containing the replace directive)
+	// and its Version is empty.
+	Replace *module.Version
+
+	// Excluded tells whether the required version is excluded by an exclude directive.
+	// The go command would use the next higher version not excluded instead.
+	Excluded bool
+}
+
+// Fetch tells which module version to fetch from a proxy for r:
+// its replacement if it has one,
+// otherwise the required version itself.
+// It returns false if there is nothing to fetch,
+// because r is excluded or is replaced by a local directory.
+func (r Requirement) Fetch() (module.Version, bool) {
+	switch {
+	case r.Excluded:
+		return module.Version{}, false
+	case r.Replace == nil:
+		return r.Mod, true
+	case r.Replace.Version == "":
+		return module.Version{}, false
+	default:
+		return *r.Replace, true
+	}
+}
+
+// LoadRequirements reads the go.mod file at the given path
+// and returns its requirements,
+// sorted by path,
+// with its replace and exclude directives applied.
+func LoadRequirements(path string) ([]Requirement, error) {
+	mf, err := readModFile(path)
+	if err != nil {
+		return nil, err
+	}
+	reqs := applyDirectives(mf.Require, replacements(mf.Replace), excluded(mf.Exclude))
+	sortRequirements(reqs)
+	return reqs, nil
+}
+
+// LoadWorkspace reads the go.work file at the given path,
+// and the go.mod file of each module it uses,
+// so that tools operating on dependencies can handle
+// all of the workspace's modules in one pass.
+func LoadWorkspace(path string) (*Workspace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", path)
+	}
+	wf, err := modfile.ParseWork(path, data, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+
+	ws := &Workspace{Dir: filepath.Dir(path)}
+
+	var (
+		mfs     []*modfile.File
+		replace = make(map[module.Version]module.Version)
+		exclude = make(map[module.Version]bool)
+	)
+	for _, use := range wf.Use {
+		dir := filepath.FromSlash(use.Path)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(ws.Dir, dir)
+		}
+		mf, err := readModFile(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+
+		ws.Modules = append(ws.Modules, mf.Module.Mod.Path)
+		mfs = append(mfs, mf)
+
+		for k, v := range replacements(mf.Replace) {
+			replace[k] = v
+		}
+		for k := range excluded(mf.Exclude) {
+			exclude[k] = true
+		}
+	}
+
+	// Replacements in go.work override those in go.mod files.
+	for k, v := range replacements(wf.Replace) {
+		replace[k] = v
+	}
+
+	highest := make(map[string]*modfile.Require)
+	for _, mf := range mfs {
+		for _, req := range mf.Require {
+			if slices.Contains(ws.Modules, req.Mod.Path) {
+				continue
+			}
+			if prev, ok := highest[req.Mod.Path]; !ok || semver.Compare(req.Mod.Version, prev.Mod.Version) > 0 {
+				highest[req.Mod.Path] = req
+			}
+		}
+	}
+	var reqs []*modfile.Require
+	for _, req := range highest {
+		reqs = append(reqs, req)
+	}
+
+	ws.Requires = applyDirectives(reqs, replace, exclude)
+	sortRequirements(ws.Requires)
+
+	return ws, nil
+}
+
+func readModFile(path string) (*modfile.File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", path)
+	}
+	mf, err := modfile.Parse(path, data, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+	if mf.Module == nil {
+		return nil, errors.Newf("%s has no module directive", path)
+	}
+	return mf, nil
+}
+
+// Replacements maps the old module versions in replace directives to their new ones.
+// A key with an empty Version applies to all versions of its path.
+func replacements(rs []*modfile.Replace) map[module.Version]module.Version {
+	m := make(map[module.Version]module.Version)
+	for _, r := range rs {
+		m[r.Old] = r.New
+	}
+	return m
+}
+
+func excluded(es []*modfile.Exclude) map[module.Version]bool {
+	m := make(map[module.Version]bool)
+	for _, e := range es {
+		m[e.Mod] = true
+	}
+	return m
+}
+
+func applyDirectives(reqs []*modfile.Require, replace map[module.Version]module.Version, exclude map[module.Version]bool) []Requirement {
+	var result []Requirement
+	for _, req := range reqs {
+		r := Requirement{Mod: req.Mod, Excluded: exclude[req.Mod]}
+
+		// A replacement for a specific version takes precedence over one for all versions.
+		repl, ok := replace[req.Mod]
+		if !ok {
+			repl, ok = replace[module.Version{Path: req.Mod.Path}]
+		}
+		if ok {
+			r.Replace = &repl
+		}
+
+		result = append(result, r)
+	}
+	return result
+}
+
+func sortRequirements(reqs []Requirement) {
+	slices.SortFunc(reqs, func(a, b Requirement) int {
+		return strings.Compare(a.Mod.Path, b.Mod.Path)
+	})
+}
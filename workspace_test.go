@@ -0,0 +1,132 @@
+package goproxyclient
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/module"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.work": `go 1.23
+
+use (
+	./a
+	./b
+)
+
+replace github.com/bobg/mid => github.com/bobg/mid v1.8.0
+`,
+		"a/go.mod": `module example.com/a
+
+go 1.23
+
+require (
+	example.com/b v0.0.0
+	github.com/bobg/errors v1.0.0
+	github.com/bobg/mid v1.9.0
+	example.com/local v1.0.0
+)
+
+replace example.com/local => ../local
+
+replace github.com/bobg/mid => github.com/bobg/mid v1.7.0
+`,
+		"b/go.mod": `module example.com/b
+
+go 1.23
+
+require github.com/bobg/errors v1.1.0
+
+exclude github.com/bobg/errors v1.1.0
+`,
+	})
+
+	ws, err := LoadWorkspace(filepath.Join(dir, "go.work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/a", "example.com/b"}; !slices.Equal(ws.Modules, want) {
+		t.Errorf("got modules %v, want %v", ws.Modules, want)
+	}
+	want := []Requirement{
+		{
+			Mod:     module.Version{Path: "example.com/local", Version: "v1.0.0"},
+			Replace: &module.Version{Path: "../local"},
+		},
+		{
+			Mod:      module.Version{Path: "github.com/bobg/errors", Version: "v1.1.0"},
+			Excluded: true,
+		},
+		{
+			Mod:     module.Version{Path: "github.com/bobg/mid", Version: "v1.9.0"},
+			Replace: &module.Version{Path: "github.com/bobg/mid", Version: "v1.8.0"},
+		},
+	}
+	if diff := cmp.Diff(want, ws.Requires); diff != "" {
+		t.Errorf("requirements mismatch (-want +got):\n%s", diff)
+	}
+
+	var fetch []module.Version
+	for _, r := range ws.Requires {
+		if mv, ok := r.Fetch(); ok {
+			fetch = append(fetch, mv)
+		}
+	}
+	if want := []module.Version{{Path: "github.com/bobg/mid", Version: "v1.8.0"}}; !slices.Equal(fetch, want) {
+		t.Errorf("got fetches %v, want %v", fetch, want)
+	}
+}
+
+func TestLoadRequirements(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": `module example.com/a
+
+go 1.23
+
+require (
+	github.com/bobg/errors v1.0.0
+	github.com/bobg/mid v1.9.0
+)
+
+replace github.com/bobg/errors v1.0.0 => github.com/bobg/errors v1.1.0
+`,
+	})
+
+	reqs, err := LoadRequirements(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Requirement{
+		{
+			Mod:     module.Version{Path: "github.com/bobg/errors", Version: "v1.0.0"},
+			Replace: &module.Version{Path: "github.com/bobg/errors", Version: "v1.1.0"},
+		},
+		{
+			Mod: module.Version{Path: "github.com/bobg/mid", Version: "v1.9.0"},
+		},
+	}
+	if diff := cmp.Diff(want, reqs); diff != "" {
+		t.Errorf("requirements mismatch (-want +got):\n%s", diff)
+	}
+}
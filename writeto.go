@@ -0,0 +1,51 @@
+package goproxyclient
+
+import (
+	"context"
+	"io"
+
+	"github.com/bobg/errors"
+)
+
+// ModTo writes the go.mod file for a specific version of a Go module to w,
+// returning the number of bytes written.
+// It is like [Client.Mod],
+// but takes care of closing the response body.
+func (cl Client) ModTo(ctx context.Context, mod, ver string, w io.Writer) (int64, error) {
+	rc, err := cl.Mod(ctx, mod, ver)
+	if err != nil {
+		return 0, err
+	}
+	n, err := copyBody(w, rc)
+	return n, errors.Wrapf(err, "copying go.mod file for %s %s", mod, ver)
+}
+
+// ZipTo writes the zip file for a specific version of a Go module to w,
+// returning the number of bytes written.
+// It is like [Client.Zip],
+// but takes care of closing the response body.
+//
+// If the proxy reported the size of the zip file
+// and fewer bytes arrive,
+// the error is [io.ErrUnexpectedEOF].
+func (cl Client) ZipTo(ctx context.Context, mod, ver string, w io.Writer) (int64, error) {
+	rc, err := cl.Zip(ctx, mod, ver)
+	if err != nil {
+		return 0, err
+	}
+	n, err := copyBody(w, rc)
+	return n, errors.Wrapf(err, "copying zip file for %s %s", mod, ver)
+}
+
+// CopyBody copies rc to w and closes it.
+// If rc's size is known (see [contentLength]),
+// it is an error for fewer bytes to arrive.
+func copyBody(w io.Writer, rc io.ReadCloser) (int64, error) {
+	n, err := io.Copy(w, rc)
+	if err == nil {
+		if size := contentLength(rc); size >= 0 && n < size {
+			err = io.ErrUnexpectedEOF
+		}
+	}
+	return n, errors.Join(err, rc.Close())
+}
@@ -0,0 +1,72 @@
+package goproxyclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(testHandler(nil))
+	defer s.Close()
+
+	cl := New(s.URL, nil)
+
+	cases := []struct {
+		name string
+		fn   func(context.Context, string, string, io.Writer) (int64, error)
+		file string
+	}{
+		{name: "mod", fn: cl.ModTo, file: "v1.0.0.mod"},
+		{name: "zip", fn: cl.ZipTo, file: "v1.0.0.zip"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			want, err := fs.ReadFile(testdata, "testdata/example.com/multi/@v/"+tc.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buf := new(bytes.Buffer)
+			n, err := tc.fn(ctx, "example.com/multi", "v1.0.0", buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(len(want)) {
+				t.Errorf("got %d bytes written, want %d", n, len(want))
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Error("got wrong content")
+			}
+
+			if _, err := tc.fn(ctx, "example.com/nonexistent", "v1.0.0", buf); !IsNotFound(err) {
+				t.Errorf("got %v, want a not-found error", err)
+			}
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Length", strconv.Itoa(1000))
+			w.Write([]byte("PK"))
+		}))
+		defer s.Close()
+
+		cl := New(s.URL, nil)
+		n, err := cl.ZipTo(ctx, "example.com/multi", "v1.0.0", io.Discard)
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("got %v, want %v", err, io.ErrUnexpectedEOF)
+		}
+		if n != 2 {
+			t.Errorf("got %d bytes written, want 2", n)
+		}
+	})
+}